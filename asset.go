@@ -0,0 +1,86 @@
+package gosect
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// asset= attribute: images and other files referenced by sections
+// /////////////////////////////////////////////////////////////////////////////
+
+// AssetChecksum returns the checksum recorded in the asset-sha= attribute for
+// the asset file at path, in the format of BodyChecksum
+func AssetChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:12], nil
+}
+
+// checkAsset verifies that the asset= file of s, such as a screenshot, exists
+// and, when s records an asset-sha= checksum, that it did not change since
+func (opts Options) checkAsset(s Section) error {
+	asset, ok := s.Attrs["asset"]
+	if !ok {
+		return nil
+	}
+	path, err := opts.SourcePath(Section{Name: s.Name, SrcFile: asset})
+	if err != nil {
+		return err
+	}
+
+	recorded, ok := s.Attrs["asset-sha"]
+	if !ok {
+		_, err = os.Stat(path)
+	} else {
+		var sum string
+		sum, err = AssetChecksum(path)
+		if err == nil && sum != recorded {
+			return fmt.Errorf("section %s: asset %s changed since asset-sha=%s was recorded (now %s)", s.Name, asset, recorded, sum)
+		}
+	}
+	// a missing asset is not a missing source that -on-missing tolerates
+	if errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("section %s: asset %s is missing", s.Name, asset)
+	}
+	if err != nil {
+		return fmt.Errorf("section %s: asset %w", s.Name, err)
+	}
+
+	return nil
+}
+
+// assetImage returns the Markdown image of the asset= file of s, for
+// sections without another source, with the alt= attribute as text
+func assetImage(s Section) []byte {
+	return []byte("![" + s.Attrs["alt"] + "](" + s.Attrs["asset"] + ")\n")
+}
+
+// CheckAssets reports the sections whose asset= file is missing, or changed
+// since its asset-sha= checksum was recorded, located at their asset=
+// attribute
+func (opts Options) CheckAssets(sections []Section) []MarkerIssue {
+	var issues []MarkerIssue
+	for _, s := range sections {
+		if err := opts.checkAsset(s); err != nil {
+			at := s.Pos.Attrs["asset"].Value.Start
+			issues = append(issues, MarkerIssue{Line: at.Line, Column: at.Column, Message: err.Error()})
+		}
+	}
+
+	return issues
+}
@@ -0,0 +1,82 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test asset= files referenced by sections
+// /////////////////////////////////////////////////////////////////////////////
+func TestAssets(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "ui.png"), []byte("PNG"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum, err := AssetChecksum(filepath.Join(tmpDir, "ui.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "Image of the asset",
+			content: "<!-- BEGIN SECTION ui asset=ui.png alt=\"Main window\" -->\n<!-- END SECTION ui -->\n",
+			want:    "<!-- BEGIN SECTION ui asset=ui.png alt=\"Main window\" -->\n\n![Main window](ui.png)\n\n<!-- END SECTION ui -->\n",
+		},
+		{
+			name:    "Recorded checksum",
+			content: "<!-- BEGIN SECTION ui asset=ui.png asset-sha=" + sum + " -->\n<!-- END SECTION ui -->\n",
+			want:    "<!-- BEGIN SECTION ui asset=ui.png asset-sha=" + sum + " -->\n\n![](ui.png)\n\n<!-- END SECTION ui -->\n",
+		},
+		{
+			name:    "Stale asset",
+			content: "<!-- BEGIN SECTION ui asset=ui.png asset-sha=000000000000 -->\n<!-- END SECTION ui -->\n",
+			wantErr: "1:6: section ui: asset ui.png changed since asset-sha=000000000000 was recorded (now " + sum + ")",
+		},
+		{
+			name:    "Missing asset of a file= section",
+			content: "<!-- BEGIN SECTION ui file=ui.png asset=missing.png -->\n<!-- END SECTION ui -->\n",
+			wantErr: "1:6: section ui: asset missing.png is missing",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := FindSections(tt.content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := Options{BaseDir: tmpDir, OnMissing: MissingSkip}
+			got, err := Replace([]byte(tt.content), sections, opts)
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				issues := opts.CheckAssets(sections)
+				if len(issues) != 1 || !strings.Contains(tt.wantErr, issues[0].Message) {
+					t.Errorf("Expected asset issue %q, got %v", tt.wantErr, issues)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+			if issues := opts.CheckAssets(sections); len(issues) != 0 {
+				t.Errorf("Expected no asset issue, got %v", issues)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package gosect
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// benchDocument builds a document of n sections all sourced from srcFile,
+// separated by filler paragraphs
+func benchDocument(n int, srcFile string) []byte {
+	var b strings.Builder
+	filler := strings.Repeat("Lorem ipsum dolor sit amet, consectetur adipiscing elit.\n", 20)
+	for i := 0; i < n; i++ {
+		b.WriteString(filler)
+		fmt.Fprintf(&b, "<!-- BEGIN SECTION s%d file=%s -->\nold content\n<!-- END SECTION s%d -->\n", i, srcFile, i)
+	}
+
+	return []byte(b.String())
+}
+
+// benchSource writes a source file used by the benchmarks
+func benchSource(b *testing.B) string {
+	srcFile := filepath.Join(b.TempDir(), "source.txt")
+	err := os.WriteFile(srcFile, []byte(strings.Repeat("replacement line\n", 10)), 0644)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	return srcFile
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Benchmark FindSectionsBytes
+// /////////////////////////////////////////////////////////////////////////////
+func BenchmarkFindSectionsBytes(b *testing.B) {
+	content := benchDocument(500, "source.txt")
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(content)))
+	for b.Loop() {
+		if _, err := FindSectionsBytes(content, reBegin, reEnd); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Benchmark ReplaceSectionsBytes
+// /////////////////////////////////////////////////////////////////////////////
+func BenchmarkReplaceSectionsBytes(b *testing.B) {
+	content := benchDocument(500, benchSource(b))
+	sections, err := FindSectionsBytes(content, reBegin, reEnd)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(content)))
+	for b.Loop() {
+		if _, err := ReplaceSectionsBytes(content, sections, false, reBegin, reEnd); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Benchmark ReplaceSections (string API)
+// /////////////////////////////////////////////////////////////////////////////
+func BenchmarkReplaceSections(b *testing.B) {
+	content := string(benchDocument(500, benchSource(b)))
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(content)))
+	for b.Loop() {
+		if _, err := ReplaceSections(content, sections, false, reBegin, reEnd); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Benchmark FindSectionsBytes on a document without markers
+// /////////////////////////////////////////////////////////////////////////////
+func BenchmarkFindSectionsBytesNoMarkers(b *testing.B) {
+	content := []byte(strings.Repeat("Lorem ipsum dolor sit amet, consectetur adipiscing elit.\n", 10000))
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(content)))
+	for b.Loop() {
+		if _, err := FindSectionsBytes(content, reBegin, reEnd); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
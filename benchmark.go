@@ -0,0 +1,204 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// bench= sources: go test -bench and benchstat results
+// /////////////////////////////////////////////////////////////////////////////
+
+// reBenchResult matches a result line of go test -bench, capturing the name
+// of the benchmark, without its Benchmark prefix, and its measurements
+var reBenchResult = regexp.MustCompile(`^Benchmark(\S+)\s+\d+\s+(.+)$`)
+
+// reBenchPackage matches the line naming the package of the next results
+var reBenchPackage = regexp.MustCompile(`^pkg: (\S+)`)
+
+// reBenchstatGap separates the columns of a benchstat table
+var reBenchstatGap = regexp.MustCompile(`\s{2,}`)
+
+// benchmark is a benchmark of go test -bench output, with the values of
+// each run by unit, such as ns/op
+type benchmark struct {
+	pkg, name string
+	values    map[string][]float64
+}
+
+// extractBench renders the bench= source of s, the output of go test -bench
+// or of benchstat, as Markdown tables. Repeated runs of a benchmark (-count)
+// are summarized by their median, as benchstat does. The match= attribute
+// selects benchmarks by comma separated glob patterns.
+func extractBench(s Section, data []byte) ([]byte, error) {
+	data = bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+
+	var patterns []string
+	if match, ok := s.Attrs["match"]; ok {
+		patterns = strings.Split(match, ",")
+	}
+
+	var out []byte
+	if bytes.ContainsRune(data, '│') {
+		out = benchstatTables(data, patterns)
+	} else {
+		out = benchTable(s, data, patterns)
+	}
+	if out == nil {
+		return nil, fmt.Errorf("section %s: no benchmark result in %s", s.Name, s.Attrs["bench"])
+	}
+
+	return out, nil
+}
+
+// benchTable renders the results of go test -bench output as a table, with
+// a column per unit in order of appearance, and a Package column when they
+// come from several packages
+func benchTable(s Section, data []byte, patterns []string) []byte {
+	var benchmarks []*benchmark
+	var units, packages []string
+	pkg := ""
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimSpace(line)
+		if m := reBenchPackage.FindStringSubmatch(line); m != nil {
+			pkg = m[1]
+			continue
+		}
+		m := reBenchResult.FindStringSubmatch(line)
+		if m == nil || (patterns != nil && !matchAny(patterns, m[1])) {
+			continue
+		}
+
+		i := slices.IndexFunc(benchmarks, func(b *benchmark) bool { return b.pkg == pkg && b.name == m[1] })
+		if i == -1 {
+			i = len(benchmarks)
+			benchmarks = append(benchmarks, &benchmark{pkg: pkg, name: m[1], values: map[string][]float64{}})
+		}
+		if !slices.Contains(packages, pkg) {
+			packages = append(packages, pkg)
+		}
+
+		// measurements are value unit pairs
+		fields := strings.Fields(m[2])
+		for j := 0; j+1 < len(fields); j += 2 {
+			value, err := strconv.ParseFloat(fields[j], 64)
+			if err != nil {
+				continue
+			}
+			unit := fields[j+1]
+			if !slices.Contains(units, unit) {
+				units = append(units, unit)
+			}
+			benchmarks[i].values[unit] = append(benchmarks[i].values[unit], value)
+		}
+	}
+	if len(benchmarks) == 0 {
+		return nil
+	}
+	if s.Attrs["sort"] == "name" {
+		slices.SortStableFunc(benchmarks, func(a, b *benchmark) int { return strings.Compare(a.name, b.name) })
+	}
+
+	header := []string{"Benchmark"}
+	if len(packages) > 1 {
+		header = append([]string{"Package"}, header...)
+	}
+	header = append(header, units...)
+	var rows [][]string
+	for _, b := range benchmarks {
+		row := []string{tableCell(b.name)}
+		if len(packages) > 1 {
+			row = append([]string{tableCell(b.pkg)}, row...)
+		}
+		for _, unit := range units {
+			cell := ""
+			if values := b.values[unit]; len(values) > 0 {
+				cell = strconv.FormatFloat(median(values), 'f', -1, 64)
+			}
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
+	}
+
+	return formatTable(header, rows)
+}
+
+// median returns the median of values
+func median(values []float64) float64 {
+	sorted := slices.Sorted(slices.Values(values))
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// benchstatTables renders the tables of benchstat text output, one per
+// unit. The │ separated header lines name the compared files and the
+// columns of each, and the rows below are aligned by blanks.
+func benchstatTables(data []byte, patterns []string) []byte {
+	var tables [][]byte
+	var header []string
+	var rows [][]string
+	flush := func() {
+		if header != nil && rows != nil {
+			tables = append(tables, formatTable(header, rows))
+		}
+		header, rows = nil, nil
+	}
+
+	var files []string
+	for line := range strings.Lines(string(data)) {
+		line = strings.TrimRight(line, " \n")
+		switch {
+		case strings.Contains(line, "│"):
+			// the first header line names the files, the second one the
+			// columns of each file
+			groups := strings.Split(line, "│")[1:]
+			if files == nil {
+				flush()
+				files = make([]string, len(groups))
+				for i, group := range groups {
+					files[i] = strings.TrimSpace(group)
+				}
+				continue
+			}
+			header = []string{"Benchmark"}
+			for i, group := range groups {
+				for j, column := range reBenchstatGap.Split(strings.TrimSpace(group), -1) {
+					if column == "" {
+						continue
+					}
+					if j == 0 && i < len(files) && files[i] != "" {
+						column = files[i] + " " + column
+					}
+					header = append(header, tableCell(column))
+				}
+			}
+			files = nil
+		case strings.TrimSpace(line) == "":
+			flush()
+		case header != nil:
+			cells := reBenchstatGap.Split(strings.TrimSpace(line), -1)
+			if patterns != nil && !matchAny(patterns, cells[0]) {
+				continue
+			}
+			row := make([]string, len(header))
+			for i, cell := range cells[:min(len(cells), len(header))] {
+				row[i] = tableCell(cell)
+			}
+			rows = append(rows, row)
+		}
+	}
+	flush()
+	if len(tables) == 0 {
+		return nil
+	}
+
+	return bytes.Join(tables, []byte("\n"))
+}
@@ -0,0 +1,105 @@
+package gosect
+
+import (
+	"strings"
+	"testing"
+)
+
+// benchOutput is the go test -bench output of the bench= tests
+const benchOutput = `goos: linux
+goarch: amd64
+pkg: example.com/tool
+cpu: AMD EPYC 7763 64-Core Processor
+BenchmarkReplace-4     	  100000	     10200 ns/op	    2048 B/op	      12 allocs/op
+BenchmarkReplace-4     	  100000	     10600 ns/op	    2048 B/op	      12 allocs/op
+BenchmarkFind-4        	 1000000	      1050 ns/op	     128 B/op	       2 allocs/op
+BenchmarkReplace-4     	  100000	     10400 ns/op	    2048 B/op	      12 allocs/op
+PASS
+ok  	example.com/tool	4.210s
+`
+
+// benchstatOutput is the benchstat output of the bench= tests
+const benchstatOutput = `goos: linux
+goarch: amd64
+pkg: example.com/tool
+          │   old.txt   │               new.txt               │
+          │   sec/op    │   sec/op     vs base                │
+Replace-4   10.40µ ± 2%   8.10µ ± 1%  -22.12% (p=0.000 n=10)
+Find-4      1.050µ ± 1%   1.040µ ± 3%        ~ (p=0.481 n=10)
+geomean     3.304µ        2.903µ      -12.15%
+
+          │   old.txt    │              new.txt               │
+          │     B/op     │    B/op     vs base                │
+Replace-4   2.000Ki ± 0%   1.500Ki ± 0%  -25.00% (p=0.000 n=10)
+`
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test rendering benchmark results of bench= sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestExtractBench(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		attrs   map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "Median of go test -bench runs",
+			data:  benchOutput,
+			attrs: map[string]string{"bench": "bench.txt"},
+			want: "| Benchmark | ns/op | B/op | allocs/op |\n" +
+				"| --------- | ----: | ---: | --------: |\n" +
+				"| Replace-4 | 10400 | 2048 |        12 |\n" +
+				"| Find-4    |  1050 |  128 |         2 |\n",
+		},
+		{
+			name:  "Selected benchmarks sorted by name",
+			data:  benchOutput + "pkg: example.com/tool/cli\nBenchmarkFlags-4 \t 5000 \t 250000 ns/op\n",
+			attrs: map[string]string{"bench": "bench.txt", "match": "Find*,Flags*", "sort": "name"},
+			want: "| Package              | Benchmark |  ns/op | B/op | allocs/op |\n" +
+				"| -------------------- | --------- | -----: | ---: | --------: |\n" +
+				"| example.com/tool     | Find-4    |   1050 |  128 |         2 |\n" +
+				"| example.com/tool/cli | Flags-4   | 250000 |      |           |\n",
+		},
+		{
+			name:  "Benchstat tables",
+			data:  benchstatOutput,
+			attrs: map[string]string{"bench": "bench.txt"},
+			want: "| Benchmark | old.txt sec/op | new.txt sec/op | vs base                |\n" +
+				"| --------- | -------------- | -------------- | ---------------------- |\n" +
+				"| Replace-4 | 10.40µ ± 2%    | 8.10µ ± 1%     | -22.12% (p=0.000 n=10) |\n" +
+				"| Find-4    | 1.050µ ± 1%    | 1.040µ ± 3%    | ~ (p=0.481 n=10)       |\n" +
+				"| geomean   | 3.304µ         | 2.903µ         | -12.15%                |\n" +
+				"\n" +
+				"| Benchmark | old.txt B/op | new.txt B/op | vs base                |\n" +
+				"| --------- | ------------ | ------------ | ---------------------- |\n" +
+				"| Replace-4 | 2.000Ki ± 0% | 1.500Ki ± 0% | -25.00% (p=0.000 n=10) |\n",
+		},
+		{
+			name:    "No benchmark",
+			data:    "PASS\n",
+			attrs:   map[string]string{"bench": "bench.txt"},
+			wantErr: "no benchmark result in bench.txt",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractBench(Section{Name: "perf", Attrs: tt.attrs}, []byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package gosect
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// BodyChecksum returns the checksum recorded in the sha= attribute for a
+// generated section body
+func BodyChecksum(body []byte) string {
+	sum := sha256.Sum256(body)
+
+	return hex.EncodeToString(sum[:])[:12]
+}
+
+// checksumBegin verifies that the current body of s was not edited by hand
+// since the recorded checksum and returns the BEGIN line with the checksum
+// of the new body, and the body to write. With a snapshot directory, hand
+// edits are merged with the changes of the new body instead of failing.
+func (opts Options) checksumBegin(s Section, beginLine, oldBody, newBody []byte) ([]byte, []byte, error) {
+	recorded, has := s.Attrs["sha"]
+	if !has && !opts.Checksum {
+		return beginLine, newBody, nil
+	}
+
+	body := newBody
+	edited := has && recorded != BodyChecksum(oldBody)
+	if edited && opts.Force {
+		opts.logger().Warn("overwriting hand-edited section", "section", s.Name)
+	} else if edited {
+		switch merged, err := opts.mergeEdits(recorded, oldBody, newBody); {
+		case errors.Is(err, errMergeConflict):
+			return nil, nil, fmt.Errorf("section %s was edited by hand and conflicts with its source changes (use -force to overwrite)", s.Name)
+		case err != nil:
+			return nil, nil, fmt.Errorf("section %s: %w", s.Name, err)
+		case merged != nil:
+			opts.logger().Info("merged hand edits of section", "section", s.Name)
+			body = merged
+		default:
+			return nil, nil, fmt.Errorf("section %s was edited by hand since it was generated (use -force to overwrite)", s.Name)
+		}
+	}
+
+	if opts.SnapshotDir != "" && !opts.ReadOnlySnapshots {
+		if err := opts.saveSnapshot(newBody); err != nil {
+			return nil, nil, fmt.Errorf("section %s: %w", s.Name, err)
+		}
+	}
+
+	line, err := opts.setMarkerAttr(s, beginLine, "sha", BodyChecksum(newBody))
+
+	return line, body, err
+}
+
+// mergeEdits merges the hand edits of oldBody with the changes of newBody,
+// from the body recorded in the snapshot directory with the checksum
+// recorded. It returns nil when no snapshot is available.
+func (opts Options) mergeEdits(recorded string, oldBody, newBody []byte) ([]byte, error) {
+	if opts.SnapshotDir == "" {
+		return nil, nil
+	}
+
+	base, ok, err := opts.loadSnapshot(recorded)
+	if !ok {
+		return nil, err
+	}
+
+	merged, err := merge3(string(base), string(oldBody), string(newBody))
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(merged), nil
+}
+
+// setMarkerAttr sets the key attribute of the BEGIN marker found at the start
+// of line, replacing its value if present or appending it to the attributes
+func (opts Options) setMarkerAttr(s Section, line []byte, key, value string) ([]byte, error) {
+	attrsStart, attrsEnd, err := opts.markerAttrs(s, line)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	out.Write(line[:attrsStart])
+	out.Write(setAttr(line[attrsStart:attrsEnd], key, value))
+	out.Write(line[attrsEnd:])
+
+	return out.Bytes(), nil
+}
+
+// setAttr sets the key attribute of the attribute list attrs, replacing its
+// value if present or appending it
+func setAttr(attrs []byte, key, value string) []byte {
+	reKey := regexp.MustCompile(`[ \t]` + regexp.QuoteMeta(key) + `=(?:` + attrValuePattern + `)`)
+
+	var out bytes.Buffer
+	if loc := reKey.FindIndex(attrs); loc != nil {
+		out.Write(attrs[:loc[0]])
+		out.WriteString(" " + key + "=" + quoteAttr(value))
+		out.Write(attrs[loc[1]:])
+	} else {
+		out.Write(attrs)
+		out.WriteString(" " + key + "=" + quoteAttr(value))
+	}
+
+	return out.Bytes()
+}
+
+// markerAttrs returns the span of the attribute list of the BEGIN marker at
+// the start of line
+func (opts Options) markerAttrs(s Section, line []byte) (int, int, error) {
+	start, end := s.attrsStart-s.StartIdx, s.attrsEnd-s.StartIdx
+	if s.attrsEnd > 0 && start >= 0 && end <= len(line) {
+		return start, end, nil
+	}
+
+	// section built by the caller, find the marker again
+	reBegin, _ := opts.markers()
+	loc := markerLoc(reBegin, reBegin.FindSubmatchIndex(line))
+	if loc == nil {
+		return 0, 0, fmt.Errorf("malformed BEGIN line for section %s", s.Name)
+	}
+	if loc[4] == -1 {
+		return 0, 0, fmt.Errorf("section %s: the BEGIN marker has no attribute list to record attributes in (the marker regex needs a (?P<attrs>...) group)", s.Name)
+	}
+
+	return loc[4], loc[5], nil
+}
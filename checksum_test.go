@@ -0,0 +1,116 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test checksum recording and manual edit detection
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceChecksum(t *testing.T) {
+	tmpDir := t.TempDir()
+	sourceFile := filepath.Join(tmpDir, "source.txt")
+	err := os.WriteFile(sourceFile, []byte("GENERATED"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := "<!-- BEGIN SECTION gen file=" + sourceFile + " -->\nold\n<!-- END SECTION gen -->\n"
+	reSha := regexp.MustCompile(`file=\S+ sha=([0-9a-f]{12}) -->`)
+
+	// first run records the checksum
+	result := replaceAll(t, content, Options{Checksum: true})
+	if !reSha.MatchString(result) {
+		t.Fatalf("Expected sha= attribute on BEGIN marker, got:\n%s", result)
+	}
+
+	// second run without -checksum keeps it up to date
+	again := replaceAll(t, result, Options{})
+	if again != result {
+		t.Errorf("Expected stable output, got:\n%s", again)
+	}
+
+	// manual edit is refused
+	edited := strings.Replace(result, "GENERATED", "HAND EDITED", 1)
+	sections, err := FindSections(edited, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Replace([]byte(edited), sections, Options{})
+	if err == nil || !strings.Contains(err.Error(), "edited by hand") {
+		t.Fatalf("Expected manual edit error, got %v", err)
+	}
+
+	// unless forced
+	forced := replaceAll(t, edited, Options{Force: true})
+	if forced != result {
+		t.Errorf("Expected forced output to match generated output, got:\n%s", forced)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test setMarkerAttr on sections built by the caller
+// /////////////////////////////////////////////////////////////////////////////
+func TestSetMarkerAttr(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{
+			name: "Append attribute",
+			line: "<!-- BEGIN SECTION a file=a.txt -->\n",
+			want: "<!-- BEGIN SECTION a file=a.txt sha=123 -->\n",
+		},
+		{
+			name: "Replace attribute",
+			line: "<!-- BEGIN SECTION a sha=old file=a.txt -->\n",
+			want: "<!-- BEGIN SECTION a sha=123 file=a.txt -->\n",
+		},
+		{
+			name: "Replace quoted attribute",
+			line: "<!-- BEGIN SECTION a sha=\"not yet\" file=a.txt -->\n",
+			want: "<!-- BEGIN SECTION a sha=123 file=a.txt -->\n",
+		},
+		{
+			name: "No attribute",
+			line: "# BEGIN SECTION a\n",
+			want: "# BEGIN SECTION a sha=123\n",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := strings.Index(tt.line, "BEGIN")
+			got, err := Options{}.setMarkerAttr(Section{Name: "a", StartIdx: start}, []byte(tt.line[start:]), "sha", "123")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.line[:start]+string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, tt.line[:start]+string(got))
+			}
+		})
+	}
+}
+
+// replaceAll finds and replaces all sections of content
+func replaceAll(t *testing.T, content string, opts Options) string {
+	t.Helper()
+
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Replace([]byte(content), sections, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(result)
+}
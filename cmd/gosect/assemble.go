@@ -0,0 +1,57 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/badele/gosect"
+)
+
+// runAssemble generates the document described by a manifest:
+// gosect assemble [flags] manifest.yaml
+func runAssemble(args []string) error {
+	fs := flag.NewFlagSet("assemble", flag.ExitOnError)
+	stdout := fs.Bool("stdout", false, "print to stdout instead of writing the target file")
+	verbose := fs.Bool("verbose", false, "log details about processed sections")
+	fsync := fs.Bool("fsync", false, "fsync written files and their directory")
+	var backup backupFlag
+	fs.Var(&backup, "backup", "save the original target with the .bak suffix, or -backup=suffix, before overwriting it")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect assemble [flags] manifest.yaml")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("assemble: one manifest file required")
+	}
+
+	m, err := gosect.LoadManifest(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	result, err := gosect.Assemble(m, gosect.Options{Verbose: *verbose})
+	if err != nil {
+		return err
+	}
+
+	if *stdout {
+		writeStdout(result)
+		return nil
+	}
+
+	if m.Target == "" {
+		return fmt.Errorf("%s: no target document", fs.Arg(0))
+	}
+
+	if backup.suffix != "" {
+		if err := backupTarget(m.Target, backup.suffix, *fsync); err != nil {
+			return err
+		}
+	}
+
+	return writeTarget(m.Target, result, *fsync)
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"html"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// badge is a shields.io style status badge
+type badge struct {
+	Label   string
+	Message string
+	Color   string
+}
+
+// badgeColors maps badge color names to their SVG colors
+var badgeColors = map[string]string{
+	"brightgreen": "#4c1",
+	"green":       "#97ca00",
+	"yellow":      "#dfb317",
+	"orange":      "#fe7d37",
+	"red":         "#e05d44",
+}
+
+// freshnessBadge returns the badge of fresh sections out of total
+func freshnessBadge(label string, fresh, total int) badge {
+	percent := 100
+	if total > 0 {
+		percent = fresh * 100 / total
+	}
+
+	color := "red"
+	switch {
+	case percent == 100:
+		color = "brightgreen"
+	case percent >= 90:
+		color = "green"
+	case percent >= 75:
+		color = "yellow"
+	case percent >= 50:
+		color = "orange"
+	}
+
+	return badge{Label: label, Message: fmt.Sprintf("%d%% fresh", percent), Color: color}
+}
+
+// json returns the badge in the shields.io endpoint format
+func (b badge) json() ([]byte, error) {
+	out, err := json.MarshalIndent(map[string]any{
+		"schemaVersion": 1,
+		"label":         b.Label,
+		"message":       b.Message,
+		"color":         b.Color,
+	}, "", "  ")
+
+	return append(out, '\n'), err
+}
+
+// svg renders the badge as a flat SVG image
+func (b badge) svg() []byte {
+	labelWidth := 7*utf8.RuneCountInString(b.Label) + 10
+	messageWidth := 7*utf8.RuneCountInString(b.Message) + 10
+	title := html.EscapeString(b.Label + ": " + b.Message)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s">`+"\n", labelWidth+messageWidth, title)
+	fmt.Fprintf(&out, "  <title>%s</title>\n", title)
+	fmt.Fprintf(&out, `  <rect width="%d" height="20" fill="#555"/>`+"\n", labelWidth)
+	fmt.Fprintf(&out, `  <rect x="%d" width="%d" height="20" fill="%s"/>`+"\n", labelWidth, messageWidth, badgeColors[b.Color])
+	out.WriteString(`  <g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">` + "\n")
+	fmt.Fprintf(&out, `    <text x="%d" y="14">%s</text>`+"\n", labelWidth/2, html.EscapeString(b.Label))
+	fmt.Fprintf(&out, `    <text x="%d" y="14">%s</text>`+"\n", labelWidth+messageWidth/2, html.EscapeString(b.Message))
+	out.WriteString("  </g>\n</svg>\n")
+
+	return out.Bytes()
+}
+
+// freshness counts the sections of the target files at paths, and the fresh
+// ones whose body matches their generated content. Sections which cannot be
+// generated are stale.
+func (c updateConfig) freshness(paths []string) (fresh, total int, err error) {
+	for _, path := range paths {
+		status, err := c.documentStatus(path)
+		if err != nil {
+			return 0, 0, err
+		}
+		fresh += status.Fresh
+		total += status.Total
+	}
+
+	return fresh, total, nil
+}
+
+// runBadge writes a badge showing the share of fresh sections:
+// gosect badge [flags] file...
+func runBadge(args []string) error {
+	fs := flag.NewFlagSet("badge", flag.ExitOnError)
+	f := addUpdateFlags(fs)
+	output := fs.String("output", "", "badge file (default: stdout)")
+	format := fs.String("format", "", "badge format, svg or json (default: from the -output extension, else svg)")
+	label := fs.String("label", "docs", "badge label")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect badge [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	files := append(f.files, fs.Args()...)
+	if len(files) == 0 {
+		fs.Usage()
+		return errors.New("badge: at least one file required")
+	}
+
+	c, err := f.settings()
+	if err != nil {
+		return err
+	}
+	c.opts.ReadOnlySnapshots = true
+
+	fresh, total, err := c.freshness(files)
+	if err != nil {
+		return err
+	}
+	b := freshnessBadge(*label, fresh, total)
+
+	if *format == "" {
+		*format = "svg"
+		if filepath.Ext(*output) == ".json" {
+			*format = "json"
+		}
+	}
+
+	var data []byte
+	switch *format {
+	case "svg":
+		data = b.svg()
+	case "json":
+		data, err = b.json()
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("badge: unknown format %q", *format)
+	}
+
+	fmt.Fprintf(os.Stderr, "%d/%d sections fresh\n", fresh, total)
+	if *output == "" {
+		writeStdout(data)
+		return nil
+	}
+
+	return writeTarget(*output, data, *f.fsync)
+}
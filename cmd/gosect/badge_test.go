@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test freshness badges
+// /////////////////////////////////////////////////////////////////////////////
+func TestFreshnessBadge(t *testing.T) {
+	tests := []struct {
+		fresh, total int
+		message      string
+		color        string
+	}{
+		{0, 0, "100% fresh", "brightgreen"},
+		{4, 4, "100% fresh", "brightgreen"},
+		{99, 100, "99% fresh", "green"},
+		{199, 200, "99% fresh", "green"},
+		{3, 4, "75% fresh", "yellow"},
+		{1, 2, "50% fresh", "orange"},
+		{1, 4, "25% fresh", "red"},
+	}
+
+	// Run tests
+	for _, test := range tests {
+		b := freshnessBadge("docs", test.fresh, test.total)
+		if b.Message != test.message || b.Color != test.color {
+			t.Errorf("Expected %s/%s for %d/%d, got %s/%s", test.message, test.color, test.fresh, test.total, b.Message, b.Color)
+		}
+	}
+
+	b := freshnessBadge("docs", 1, 2)
+	svg := string(b.svg())
+	if !strings.Contains(svg, "<title>docs: 50% fresh</title>") || !strings.Contains(svg, badgeColors["orange"]) {
+		t.Errorf("Unexpected SVG badge %q", svg)
+	}
+
+	data, err := b.json()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"message": "50% fresh"`) || !strings.Contains(string(data), `"schemaVersion": 1`) {
+		t.Errorf("Unexpected JSON badge %q", data)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test counting fresh sections
+// /////////////////////////////////////////////////////////////////////////////
+func TestFreshness(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "source.txt"), []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := filepath.Join(tmpDir, "doc.md")
+	content := "<!-- BEGIN SECTION fresh file=source.txt -->\n\ngenerated\n\n<!-- END SECTION fresh -->\n" +
+		"<!-- BEGIN SECTION stale file=source.txt -->\nold\n<!-- END SECTION stale -->\n" +
+		"<!-- BEGIN SECTION broken file=missing.txt -->\n<!-- END SECTION broken -->\n"
+	if err := os.WriteFile(doc, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	c := updateConfig{opts: gosect.Options{ReBegin: reBegin, ReEnd: reEnd}}
+
+	fresh, total, err := c.freshness([]string{doc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh != 1 || total != 3 {
+		t.Errorf("Expected 1/3 fresh sections, got %d/%d", fresh, total)
+	}
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// completionScripts are the completion scripts of each shell. Flags are read
+// from the -h output of the subcommand being completed, and section names
+// from the files given on the command line.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for gosect
+# source <(gosect completion bash)
+
+_gosect_flags() {
+	gosect "$1" -h 2>&1 | sed -n 's/^  \(-[A-Za-z0-9-]*\).*/\1/p'
+}
+
+_gosect() {
+	local cur=${COMP_WORDS[COMP_CWORD]}
+	local prev=${COMP_WORDS[COMP_CWORD-1]}
+	local subcommands="{{.}}"
+	local sub=update
+	if [[ $COMP_CWORD -gt 1 && " $subcommands " == *" ${COMP_WORDS[1]} "* ]]; then
+		sub=${COMP_WORDS[1]}
+	fi
+
+	if [[ $prev == -section ]]; then
+		COMPREPLY=($(compgen -W "$(gosect completion sections "${COMP_WORDS[@]:1}" 2>/dev/null)" -- "$cur"))
+		return
+	fi
+	if [[ $cur == -* ]]; then
+		COMPREPLY=($(compgen -W "$(_gosect_flags "$sub")" -- "$cur"))
+		return
+	fi
+
+	COMPREPLY=($(compgen -f -- "$cur"))
+	if [[ $COMP_CWORD -eq 1 ]]; then
+		COMPREPLY+=($(compgen -W "$subcommands" -- "$cur"))
+	fi
+}
+
+complete -o filenames -F _gosect gosect
+`,
+	"zsh": `#compdef gosect
+# source <(gosect completion zsh)
+
+_gosect() {
+	local -a subcommands flags sections
+	subcommands=({{.}})
+	local sub=update
+	if (( CURRENT > 2 && ${subcommands[(Ie)$words[2]]} )); then
+		sub=$words[2]
+	fi
+
+	if [[ $words[CURRENT-1] == -section ]]; then
+		sections=(${(f)"$(gosect completion sections ${words[2,-1]} 2>/dev/null)"})
+		compadd -a sections
+		return
+	fi
+	if [[ $PREFIX == -* ]]; then
+		flags=(${(f)"$(gosect $sub -h 2>&1 | sed -n 's/^  \(-[A-Za-z0-9-]*\).*/\1/p')"})
+		compadd -a flags
+		return
+	fi
+
+	if (( CURRENT == 2 )); then
+		compadd -a subcommands
+	fi
+	_files
+}
+
+compdef _gosect gosect
+`,
+	"fish": `# fish completion for gosect
+# gosect completion fish | source
+
+function __gosect_subcommand
+	set -l words (commandline -opc)
+	if set -q words[2]; and contains -- $words[2] {{.}}
+		echo $words[2]
+	else
+		echo update
+	end
+end
+
+function __gosect_flags
+	gosect (__gosect_subcommand) -h 2>&1 | sed -n 's/^  \(-[A-Za-z0-9-]*\).*/\1/p'
+end
+
+function __gosect_sections
+	set -l words (commandline -opc)
+	gosect completion sections $words[2..-1] 2>/dev/null
+end
+
+complete -c gosect -n '__fish_use_subcommand' -a '{{.}}'
+complete -c gosect -n 'string match -q -- "-*" (commandline -ct)' -a '(__gosect_flags)'
+complete -c gosect -n 'test (commandline -opc)[-1] = -section' -f -a '(__gosect_sections)'
+`,
+}
+
+// completionSections returns the names of the sections of the target files
+// among args, or of the Markdown files of the current directory when args
+// name no file. Markers are read from the configuration file.
+func completionSections(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		if info, err := os.Stat(arg); err == nil && info.Mode().IsRegular() && !strings.HasPrefix(arg, "-") {
+			files = append(files, arg)
+		}
+	}
+	if len(files) == 0 {
+		files, _ = filepath.Glob("*.md")
+	}
+
+	markers := addMarkerFlags(flag.NewFlagSet("completion", flag.ContinueOnError))
+	if _, err := markers.loadConfig(); err != nil {
+		return nil, err
+	}
+
+	// skip the files whose markers are damaged
+	var names []string
+	for _, file := range files {
+		entries, _ := listSections([]string{file}, markers.regex)
+		for _, e := range entries {
+			if !slices.Contains(names, e.Name) {
+				names = append(names, e.Name)
+			}
+		}
+	}
+
+	return names, nil
+}
+
+// runCompletion prints the completion script of a shell:
+// gosect completion bash|zsh|fish
+func runCompletion(args []string) error {
+	usage := "usage: gosect completion bash|zsh|fish"
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	// section names, for the completion scripts
+	if args[0] == "sections" {
+		names, err := completionSections(args[1:])
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	}
+
+	script, ok := completionScripts[args[0]]
+	if !ok {
+		return fmt.Errorf("completion: unknown shell %q (%s)", args[0], usage)
+	}
+
+	subcommands := strings.Join(slices.Sorted(maps.Keys(commands)), " ")
+	return template.Must(template.New(args[0]).Parse(script)).Execute(os.Stdout, subcommands)
+}
+
+// register completion apart from the commands it lists, which would
+// otherwise form an initialization cycle
+func init() {
+	commands["completion"] = runCompletion
+}
@@ -0,0 +1,33 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test section names completion
+// /////////////////////////////////////////////////////////////////////////////
+func TestCompletionSections(t *testing.T) {
+	tmpDir := t.TempDir()
+	doc := filepath.Join(tmpDir, "doc.md")
+	content := "<!-- BEGIN SECTION intro file=a.md -->\n<!-- END SECTION intro -->\n" +
+		"<!-- BEGIN SECTION usage file=b.md -->\n<!-- END SECTION usage -->\n"
+	if err := os.WriteFile(doc, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(tmpDir, "broken.md")
+	if err := os.WriteFile(broken, []byte("<!-- BEGIN SECTION intro file=a.md -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := completionSections([]string{"update", "-section", doc, broken, doc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"intro", "usage"}; !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+// ANSI escape sequences coloring the -diff output
+const (
+	ansiReset = "\x1b[0m"
+	ansiBold  = "\x1b[1m"
+	ansiRed   = "\x1b[31m"
+	ansiGreen = "\x1b[32m"
+	ansiCyan  = "\x1b[36m"
+)
+
+// useColor reports whether output written to f is colored with the -color
+// mode: always, never, or auto to color terminals unless NO_COLOR is set or
+// TERM is dumb. Windows consoles are switched to ANSI escape sequences
+// processing, and auto disables colors on consoles which do not support them.
+func useColor(mode string, f *os.File) (bool, error) {
+	switch mode {
+	case "always":
+		enableVirtualTerminal(f)
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" || !isTerminal(f) {
+			return false, nil
+		}
+		return enableVirtualTerminal(f), nil
+	}
+
+	return false, fmt.Errorf("unknown -color mode %q (expected auto, always or never)", mode)
+}
+
+// colorize wraps text in the ANSI escape sequence code when color is set
+func colorize(text, code string, color bool) string {
+	if !color {
+		return text
+	}
+
+	return code + text + ansiReset
+}
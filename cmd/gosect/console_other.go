@@ -0,0 +1,17 @@
+//go:build !windows
+
+package main
+
+import "os"
+
+// isTerminal reports whether f is a terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// enableVirtualTerminal reports whether the terminal f processes ANSI escape
+// sequences, which terminals of other platforms always do
+func enableVirtualTerminal(f *os.File) bool {
+	return true
+}
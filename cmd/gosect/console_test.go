@@ -0,0 +1,35 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the -color modes
+// /////////////////////////////////////////////////////////////////////////////
+func TestUseColor(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+
+	tests := []struct {
+		mode    string
+		want    bool
+		wantErr bool
+	}{
+		{"always", true, false},
+		{"never", false, false},
+		{"auto", false, false},
+		{"sometimes", false, true},
+	}
+
+	// Run tests
+	for _, test := range tests {
+		got, err := useColor(test.mode, os.Stdout)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: expected error %v, got %v", test.mode, test.wantErr, err)
+		}
+		if got != test.want {
+			t.Errorf("%s: expected %v, got %v", test.mode, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the next run of cron schedules
+// /////////////////////////////////////////////////////////////////////////////
+func TestCronNext(t *testing.T) {
+	// a Wednesday
+	from := time.Date(2025, 6, 4, 10, 17, 30, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		expr     string
+		expected time.Time
+		wantErr  bool
+	}{
+		{"Every minute", "* * * * *", time.Date(2025, 6, 4, 10, 18, 0, 0, time.UTC), false},
+		{"Step", "*/15 * * * *", time.Date(2025, 6, 4, 10, 30, 0, 0, time.UTC), false},
+		{"Nightly", "30 2 * * *", time.Date(2025, 6, 5, 2, 30, 0, 0, time.UTC), false},
+		{"Alias", "@daily", time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC), false},
+		{"Weekdays range", "0 9 * * 1-5", time.Date(2025, 6, 5, 9, 0, 0, 0, time.UTC), false},
+		{"Sunday as 7", "0 0 * * 7", time.Date(2025, 6, 8, 0, 0, 0, 0, time.UTC), false},
+		{"Day of month or weekday", "0 0 10 * 6", time.Date(2025, 6, 7, 0, 0, 0, 0, time.UTC), false},
+		{"List of months", "0 0 1 1,7 *", time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), false},
+		{"Never", "0 0 30 2 *", time.Time{}, false},
+		{"Missing field", "0 0 * *", time.Time{}, true},
+		{"Out of range", "60 * * * *", time.Time{}, true},
+		{"Invalid step", "*/0 * * * *", time.Time{}, true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseCron(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if err != nil {
+				return
+			}
+
+			if got := schedule.next(from); !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
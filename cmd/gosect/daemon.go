@@ -0,0 +1,247 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/badele/gosect"
+)
+
+// daemonProfile is a configuration profile run by gosect daemon, and its
+// scheduling state
+type daemonProfile struct {
+	name     string
+	profile  gosect.ConfigProfile
+	schedule *cronSchedule        // nil without schedule
+	next     time.Time            // time of the next scheduled run
+	mtimes   map[string]time.Time // watched files at the last run
+}
+
+// newDaemonProfile prepares the profile name, scheduled from now
+func newDaemonProfile(name string, p gosect.ConfigProfile, now time.Time) (*daemonProfile, error) {
+	if len(p.Files) == 0 {
+		return nil, fmt.Errorf("profile %s: no files", name)
+	}
+	if p.Schedule == "" && !p.Watch {
+		return nil, fmt.Errorf("profile %s: schedule or watch required", name)
+	}
+
+	d := &daemonProfile{name: name, profile: p}
+	if p.Schedule != "" {
+		schedule, err := parseCron(p.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", name, err)
+		}
+		d.schedule = schedule
+		d.next = schedule.next(now)
+	}
+
+	return d, nil
+}
+
+// targets returns the target files matching the file patterns of the profile
+func (d *daemonProfile) targets() ([]string, error) {
+	var paths []string
+	for _, pattern := range d.profile.Files {
+		matches, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("profile %s: %w", d.name, err)
+		}
+		paths = append(paths, matches...)
+	}
+	slices.Sort(paths)
+
+	return slices.Compact(paths), nil
+}
+
+// due returns why the profile must run at now: "schedule" when its schedule
+// is due, "change" when a watched file changed since its last run, or ""
+func (d *daemonProfile) due(now time.Time, mtimes map[string]time.Time) string {
+	switch {
+	case d.schedule != nil && !d.next.IsZero() && !now.Before(d.next):
+		return "schedule"
+	case d.profile.Watch && (d.mtimes == nil || !maps.Equal(mtimes, d.mtimes)):
+		return "change"
+	default:
+		return ""
+	}
+}
+
+// watchedFiles returns the modification times of the targets and of their
+// local file= and extractor sources, zero for missing files. git: and resolved
+// sources are not watched.
+func (c updateConfig) watchedFiles(targets []string) map[string]time.Time {
+	mtimes := map[string]time.Time{}
+	stat := func(path string) {
+		if info, err := os.Stat(path); err == nil {
+			mtimes[path] = info.ModTime()
+		} else {
+			mtimes[path] = time.Time{}
+		}
+	}
+
+	for _, path := range targets {
+		stat(path)
+
+		content, err := readText(path)
+		if err != nil {
+			continue
+		}
+		reBegin, reEnd := c.targetMarkers(path)
+		sections, err := gosect.FindSectionsBytes(content, reBegin, reEnd)
+		if err != nil {
+			continue
+		}
+
+		opts, err := c.targetOptions(path)
+		if err != nil {
+			continue
+		}
+		for _, s := range sections {
+			s = gosect.ExpandLanguage(s, opts.Language)
+			if _, spec, ok := gosect.ExtractorSource(s); ok && s.SrcFile == "" && !gosect.DatabaseURL(spec) {
+				s.SrcFile = spec
+			}
+			if path, _, ok := gosect.RefSource(s); ok && s.SrcFile == "" {
+				s.SrcFile = path
+			}
+			if asset, ok := s.Attrs["asset"]; ok && s.SrcFile == "" {
+				s.SrcFile = asset
+			}
+			if s.SrcFile == "" || opts.SchemeSource(s) {
+				continue
+			}
+			if src, err := opts.SourcePath(s); err == nil {
+				stat(src)
+			}
+		}
+	}
+
+	return mtimes
+}
+
+// runProfile updates the targets of a profile, running the hooks around the
+// update
+func (c updateConfig) runProfile(d *daemonProfile, targets []string, jobs int) error {
+	if len(d.profile.Sections) > 0 {
+		c.only = d.profile.Sections
+	}
+
+	if err := c.runHooks("pre", c.preCmds, os.Stderr); err != nil {
+		return err
+	}
+	if err := c.updateFiles(os.Stdout, targets, jobs); err != nil {
+		return err
+	}
+
+	return c.runHooks("post", c.postCmds, os.Stderr)
+}
+
+// tick runs the profiles that are due at now. Failures are logged and the
+// profile runs again at its next schedule or change.
+func (c updateConfig) tick(profiles []*daemonProfile, now time.Time, jobs int) {
+	for _, d := range profiles {
+		targets, err := d.targets()
+		if err != nil {
+			c.logger().Error("profile failed", "profile", d.name, "err", err)
+			continue
+		}
+
+		var mtimes map[string]time.Time
+		if d.profile.Watch {
+			mtimes = c.watchedFiles(targets)
+		}
+
+		reason := d.due(now, mtimes)
+		if reason == "" {
+			continue
+		}
+
+		c.logger().Info("running profile", "profile", d.name, "reason", reason, "files", len(targets))
+		if err := c.runProfile(d, targets, jobs); err != nil {
+			c.logger().Error("profile failed", "profile", d.name, "err", err)
+		}
+
+		// Targets written by the run must not trigger it again
+		if d.profile.Watch {
+			d.mtimes = c.watchedFiles(targets)
+		}
+		if d.schedule != nil && reason == "schedule" {
+			d.next = d.schedule.next(now)
+		}
+	}
+}
+
+// runDaemon keeps the files of the configuration profiles up to date, on
+// their cron schedule or when they change: gosect daemon [flags]
+func runDaemon(args []string) error {
+	fs := flag.NewFlagSet("daemon", flag.ExitOnError)
+	f := addUpdateFlags(fs)
+	var names stringList
+	fs.Var(&names, "profile", "only run this configuration profile (repeatable, default: every profile)")
+	poll := fs.Duration("poll", 2*time.Second, "interval between checks of schedules and watched files")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect daemon [flags]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() > 0 || len(f.files) > 0 {
+		return errors.New("daemon: target files are set by the profiles of the configuration file")
+	}
+	if *poll <= 0 {
+		return fmt.Errorf("daemon: invalid -poll %s", *poll)
+	}
+
+	c, err := f.settings()
+	if err != nil {
+		return err
+	}
+
+	if len(names) == 0 {
+		names = slices.Sorted(maps.Keys(c.profiles))
+	}
+	if len(names) == 0 {
+		return errors.New("daemon: no profile in the configuration file")
+	}
+
+	now := time.Now()
+	var profiles []*daemonProfile
+	for _, name := range names {
+		p, ok := c.profiles[name]
+		if !ok {
+			return fmt.Errorf("daemon: unknown profile %s", name)
+		}
+		d, err := newDaemonProfile(name, p, now)
+		if err != nil {
+			return err
+		}
+		profiles = append(profiles, d)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	c.logger().Info("daemon started", "profiles", len(profiles), "poll", *poll)
+	ticker := time.NewTicker(*poll)
+	defer ticker.Stop()
+	for {
+		c.tick(profiles, time.Now(), *f.jobs)
+
+		select {
+		case <-ctx.Done():
+			c.logger().Info("daemon stopped")
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test when daemon profiles run
+// /////////////////////////////////////////////////////////////////////////////
+func TestDaemonProfileDue(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "source.txt")
+	doc := filepath.Join(tmpDir, "doc.md")
+	if err := os.WriteFile(source, []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content := "<!-- BEGIN SECTION local file=source.txt -->\n<!-- END SECTION local -->\n"
+	if err := os.WriteFile(doc, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	c := updateConfig{opts: gosect.Options{ReBegin: reBegin, ReEnd: reEnd}}
+	now := time.Date(2025, 6, 4, 10, 0, 0, 0, time.UTC)
+
+	watch, err := newDaemonProfile("local", gosect.ConfigProfile{Files: []string{filepath.Join(tmpDir, "*.md")}, Watch: true}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nightly, err := newDaemonProfile("remote", gosect.ConfigProfile{Files: []string{doc}, Schedule: "0 2 * * *"}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run the profiles once, at startup for the watch profile
+	c.tick([]*daemonProfile{watch, nightly}, now, 1)
+	if got, _ := os.ReadFile(doc); string(got) == content {
+		t.Fatalf("Expected the watch profile to update %s at startup", doc)
+	}
+
+	targets, _ := watch.targets()
+	tests := []struct {
+		name     string
+		profile  *daemonProfile
+		at       time.Time
+		touch    string
+		expected string
+	}{
+		{"Watch unchanged", watch, now, "", ""},
+		{"Source changed", watch, now, source, "change"},
+		{"Schedule not due", nightly, now.Add(time.Hour), "", ""},
+		{"Schedule due", nightly, now.Add(16 * time.Hour), "", "schedule"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.touch != "" {
+				later := time.Now().Add(time.Minute)
+				if err := os.Chtimes(tt.touch, later, later); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if got := tt.profile.due(tt.at, c.watchedFiles(targets)); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+
+	if _, err := newDaemonProfile("idle", gosect.ConfigProfile{Files: []string{doc}}, now); err == nil {
+		t.Error("Expected an error for a profile without schedule or watch")
+	}
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around changes
+const diffContext = 3
+
+// diffOp is a line of a diff: kept (' '), removed ('-') or added ('+'). a and
+// b are the indexes of the line in the old and new content.
+type diffOp struct {
+	kind byte
+	line string
+	a, b int
+}
+
+// splitLines splits content into lines without their trailing newline
+func splitLines(content string) []string {
+	if content == "" {
+		return nil
+	}
+
+	return strings.Split(strings.TrimSuffix(content, "\n"), "\n")
+}
+
+// diffLines returns the line operations turning a into b, computed from the
+// longest common subsequence of the lines that differ
+func diffLines(a, b []string) []diffOp {
+	// skip the common prefix and suffix
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var ops []diffOp
+	for i := range prefix {
+		ops = append(ops, diffOp{kind: ' ', line: a[i], a: i, b: i})
+	}
+
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	lcs := make([][]int, len(ma)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(mb)+1)
+	}
+	for i := len(ma) - 1; i >= 0; i-- {
+		for j := len(mb) - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	i, j := 0, 0
+	for i < len(ma) || j < len(mb) {
+		switch {
+		case i < len(ma) && j < len(mb) && ma[i] == mb[j]:
+			ops = append(ops, diffOp{kind: ' ', line: ma[i], a: prefix + i, b: prefix + j})
+			i++
+			j++
+		case i < len(ma) && (j == len(mb) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{kind: '-', line: ma[i], a: prefix + i, b: prefix + j})
+			i++
+		default:
+			ops = append(ops, diffOp{kind: '+', line: mb[j], a: prefix + i, b: prefix + j})
+			j++
+		}
+	}
+
+	for k := range suffix {
+		ops = append(ops, diffOp{kind: ' ', line: a[len(a)-suffix+k], a: len(a) - suffix + k, b: len(b) - suffix + k})
+	}
+
+	return ops
+}
+
+// visibleWhitespace renders tabs (→), trailing spaces (·) and carriage
+// returns (␍) of line visibly
+func visibleWhitespace(line string) string {
+	body := strings.TrimRight(line, " \t\r")
+	trailing := line[len(body):]
+
+	body = strings.ReplaceAll(body, "\t", "→")
+	body = strings.ReplaceAll(body, "\r", "␍")
+	trailing = strings.NewReplacer(" ", "·", "\t", "→", "\r", "␍").Replace(trailing)
+
+	return body + trailing
+}
+
+// hunkRange formats the start,count range of a hunk header
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// diffColors maps the diff line kinds to their ANSI colors
+var diffColors = map[byte]string{'-': ansiRed, '+': ansiGreen}
+
+// writeDiff writes a unified diff of the changes from the before to the after
+// content of the file name to w. When showWhitespace is set, whitespace is
+// rendered visibly, and when color is set, lines are colored with ANSI escape
+// sequences. Nothing is written when the contents are equal.
+func writeDiff(w io.Writer, name string, before, after []byte, showWhitespace, color bool) error {
+	if string(before) == string(after) {
+		return nil
+	}
+
+	ops := diffLines(splitLines(string(before)), splitLines(string(after)))
+
+	out := bufio.NewWriter(w)
+	// use forward slashes so patches apply on every platform
+	name = filepath.ToSlash(name)
+	fmt.Fprintln(out, colorize("--- "+name, ansiBold, color))
+	fmt.Fprintln(out, colorize("+++ "+name, ansiBold, color))
+
+	for i := 0; i < len(ops); {
+		// find the next change
+		for i < len(ops) && ops[i].kind == ' ' {
+			i++
+		}
+		if i == len(ops) {
+			break
+		}
+
+		// merge changes separated by less than two contexts
+		end := i
+		for j := i; j < len(ops); j++ {
+			if ops[j].kind != ' ' {
+				end = j + 1
+			} else if j-end >= 2*diffContext {
+				break
+			}
+		}
+		start := max(i-diffContext, 0)
+		stop := min(end+diffContext, len(ops))
+
+		countA, countB := 0, 0
+		for _, op := range ops[start:stop] {
+			if op.kind != '+' {
+				countA++
+			}
+			if op.kind != '-' {
+				countB++
+			}
+		}
+		header := fmt.Sprintf("@@ -%s +%s @@", hunkRange(ops[start].a, countA), hunkRange(ops[start].b, countB))
+		fmt.Fprintln(out, colorize(header, ansiCyan, color))
+
+		for _, op := range ops[start:stop] {
+			line := op.line
+			if showWhitespace {
+				line = visibleWhitespace(line)
+			}
+			line = string(op.kind) + line
+			if code, ok := diffColors[op.kind]; ok {
+				line = colorize(line, code, color)
+			}
+			fmt.Fprintln(out, line)
+		}
+
+		i = stop
+	}
+
+	return out.Flush()
+}
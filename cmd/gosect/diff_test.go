@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test writeDiff output
+// /////////////////////////////////////////////////////////////////////////////
+func TestWriteDiff(t *testing.T) {
+	tests := []struct {
+		name           string
+		before         string
+		after          string
+		showWhitespace bool
+		color          bool
+		want           string
+	}{
+		{
+			name:   "No changes",
+			before: "a\nb\n",
+			after:  "a\nb\n",
+			want:   "",
+		},
+		{
+			name:   "Changed line with context",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n",
+			after:  "1\n2\n3\n4\nfive\n6\n7\n8\n",
+			want:   "--- doc.md\n+++ doc.md\n@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name:   "Added lines",
+			before: "a\n",
+			after:  "a\nb\nc\n",
+			want:   "--- doc.md\n+++ doc.md\n@@ -1,1 +1,3 @@\n a\n+b\n+c\n",
+		},
+		{
+			name:   "Separate hunks",
+			before: "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+			after:  "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+			want:   "--- doc.md\n+++ doc.md\n@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n 3\n@@ -6,4 +6,4 @@\n 5\n 6\n 7\n-b\n+B\n",
+		},
+		{
+			name:           "Whitespace only change",
+			before:         "key:\tvalue\n",
+			after:          "key:\tvalue  \r\n",
+			showWhitespace: true,
+			want:           "--- doc.md\n+++ doc.md\n@@ -1,1 +1,1 @@\n-key:→value\n+key:→value··␍\n",
+		},
+		{
+			name:   "Colored lines",
+			before: "a\nb\n",
+			after:  "a\nc\n",
+			color:  true,
+			want:   "\x1b[1m--- doc.md\x1b[0m\n\x1b[1m+++ doc.md\x1b[0m\n\x1b[36m@@ -1,2 +1,2 @@\x1b[0m\n a\n\x1b[31m-b\x1b[0m\n\x1b[32m+c\x1b[0m\n",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			err := writeDiff(&out, "doc.md", []byte(tt.before), []byte(tt.after), tt.showWhitespace, tt.color)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+
+	"github.com/badele/gosect"
+)
+
+// sectionBody returns the body of a section: the lines between its BEGIN and
+// END lines, without the surrounding blank lines
+func sectionBody(content []byte, s gosect.Section) []byte {
+	start := s.StartIdx
+	if nl := bytes.IndexByte(content[start:], '\n'); nl != -1 {
+		start += nl + 1
+	}
+	end := bytes.LastIndexByte(content[:s.EndIdx], '\n') + 1
+	if end < start {
+		return nil
+	}
+
+	return bytes.Trim(content[start:end], "\r\n")
+}
+
+// extractSections returns the bodies of the sections of content matching
+// patterns, each followed by a newline
+func extractSections(content []byte, patterns []string, reBegin, reEnd *regexp.Regexp) ([]byte, error) {
+	sections, err := gosect.FindSectionsBytes(content, reBegin, reEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	sections, err = gosect.FilterSections(sections, patterns)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	for _, s := range sections {
+		out.Write(sectionBody(content, s))
+		out.WriteByte('\n')
+	}
+
+	return out.Bytes(), nil
+}
+
+// runExtract prints the current body of sections of target files:
+// gosect extract [flags] file...
+func runExtract(args []string) error {
+	fs := flag.NewFlagSet("extract", flag.ExitOnError)
+	markers := addMarkerFlags(fs)
+	var only stringList
+	fs.Var(&only, "section", "only extract sections matching this name or glob (repeatable)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect extract [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("extract: at least one file required")
+	}
+
+	if _, err := markers.loadConfig(); err != nil {
+		return err
+	}
+
+	for _, path := range fs.Args() {
+		content, err := readText(path)
+		if err != nil {
+			return err
+		}
+
+		reBegin, reEnd := markers.regex(path)
+		out, err := extractSections(content, only, reBegin, reEnd)
+		if err != nil {
+			return gosect.FileErrors(path, err)
+		}
+		writeStdout(out)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test extracting section bodies
+// /////////////////////////////////////////////////////////////////////////////
+func TestExtractSections(t *testing.T) {
+	content := "# Doc\n" +
+		"<!-- BEGIN SECTION a file=a.md -->\n\n  indented\nbody a\n\n<!-- END SECTION a -->\n" +
+		"<!-- BEGIN SECTION b file=b.md -->\n<!-- END SECTION b -->\n" +
+		"<!-- BEGIN SECTION c file=c.md -->\r\n\r\nbody c\r\n\r\n<!-- END SECTION c -->\r\n"
+
+	tests := []struct {
+		name     string
+		patterns []string
+		want     string
+	}{
+		{name: "All sections", want: "  indented\nbody a\n\nbody c\n"},
+		{name: "Selected section", patterns: []string{"a"}, want: "  indented\nbody a\n"},
+		{name: "Empty section", patterns: []string{"b"}, want: "\n"},
+	}
+
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractSections([]byte(content), tt.patterns, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/badele/gosect"
+)
+
+// confirmFixes keeps the fixes of the target file at path that do not need
+// confirmation, and the others when the user answers yes on in. Every kept
+// fix is reported to log.
+func confirmFixes(path string, fixes []gosect.MarkerFix, yes bool, in *bufio.Reader, log io.Writer) []gosect.MarkerFix {
+	var kept []gosect.MarkerFix
+	for _, fix := range fixes {
+		if fix.Confirm && !yes {
+			fmt.Fprintf(log, "%s:%d: %s? [y/N] ", path, fix.Line, fix.Message)
+			answer, _ := in.ReadString('\n')
+			if a := strings.ToLower(strings.TrimSpace(answer)); a != "y" && a != "yes" {
+				continue
+			}
+		} else {
+			fmt.Fprintf(log, "%s:%d: %s\n", path, fix.Line, fix.Message)
+		}
+		kept = append(kept, fix)
+	}
+
+	return kept
+}
+
+// runFix repairs damaged markers of target files:
+// gosect fix [flags] file...
+func runFix(args []string) error {
+	fs := flag.NewFlagSet("fix", flag.ExitOnError)
+	markers := addMarkerFlags(fs)
+	yes := fs.Bool("yes", false, "apply repairs needing confirmation without asking")
+	stdout := fs.Bool("stdout", false, "print to stdout instead of writing file")
+	fsync := fs.Bool("fsync", false, "fsync written files and their directory")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect fix [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("fix: at least one file required")
+	}
+
+	if _, err := markers.loadConfig(); err != nil {
+		return err
+	}
+	if markers.rawBegin != nil {
+		return errors.New("fix: -begin-re and -end-re are not supported, markers are repaired from -begin and -end")
+	}
+
+	in := bufio.NewReader(os.Stdin)
+	for _, path := range fs.Args() {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		content, enc, err := gosect.DecodeText(raw)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		fixes := gosect.FixMarkers(content, path, *markers.begin, *markers.end)
+		fixes = confirmFixes(path, fixes, *yes, in, os.Stderr)
+		fixed := gosect.EncodeText(gosect.ApplyFixes(content, fixes), enc)
+
+		if *stdout {
+			writeStdout(fixed)
+			continue
+		}
+		if len(fixes) == 0 {
+			continue
+		}
+		if err := writeTarget(path, fixed, *fsync); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
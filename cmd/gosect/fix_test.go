@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test confirmation of marker repairs
+// /////////////////////////////////////////////////////////////////////////////
+func TestConfirmFixes(t *testing.T) {
+	fixes := []gosect.MarkerFix{
+		{Line: 1, Message: "close the comment with -->"},
+		{Line: 3, Message: "add END SECTION a at the end of the file", Confirm: true},
+		{Line: 5, Message: "add END SECTION b at the end of the file", Confirm: true},
+	}
+
+	var log strings.Builder
+	in := bufio.NewReader(strings.NewReader("y\nn\n"))
+	kept := confirmFixes("doc.md", fixes, false, in, &log)
+	if len(kept) != 2 || kept[1].Line != 3 {
+		t.Errorf("Expected the fixes of lines 1 and 3, got %v", kept)
+	}
+	if !strings.Contains(log.String(), "doc.md:5: add END SECTION b at the end of the file? [y/N]") {
+		t.Errorf("Expected a confirmation prompt, got %q", log.String())
+	}
+
+	if kept := confirmFixes("doc.md", fixes, true, in, &log); len(kept) != 3 {
+		t.Errorf("Expected every fix with -yes, got %v", kept)
+	}
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// hookMarker identifies the git hooks written by gosect hook install, which
+// can be replaced without -force
+const hookMarker = "# Installed by gosect hook install"
+
+// git runs git with args in the current directory and returns its trimmed
+// output
+func git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// stagedTargets returns the files added, copied, modified or renamed in the
+// git index, relative to the current directory. When files is not empty,
+// only the staged files among them are returned. Staged files which also
+// have unstaged changes are returned apart, as staging their update would
+// stage those changes too.
+func stagedTargets(files []string) ([]string, []string, error) {
+	top, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err := git("diff", "--cached", "--name-only", "-z", "--diff-filter=ACMR")
+	if err != nil {
+		return nil, nil, err
+	}
+	unstaged, err := git("diff", "--name-only", "-z")
+	if err != nil {
+		return nil, nil, err
+	}
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	modified := map[string]bool{}
+	for name := range strings.SplitSeq(unstaged, "\x00") {
+		modified[name] = true
+	}
+
+	wanted := map[string]bool{}
+	for _, path := range files {
+		if abs, err := filepath.Abs(path); err == nil {
+			wanted[abs] = true
+		}
+	}
+
+	var staged, partial []string
+	for name := range strings.SplitSeq(out, "\x00") {
+		if name == "" {
+			continue
+		}
+		abs := filepath.Join(top, filepath.FromSlash(name))
+		if len(files) > 0 && !wanted[abs] {
+			continue
+		}
+		if rel, err := filepath.Rel(cwd, abs); err == nil {
+			abs = rel
+		}
+		if modified[name] {
+			partial = append(partial, abs)
+			continue
+		}
+		staged = append(staged, abs)
+	}
+
+	return staged, partial, nil
+}
+
+// stageFiles adds the files at paths to the git index
+func stageFiles(paths []string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	_, err := git(append([]string{"add", "--"}, paths...)...)
+
+	return err
+}
+
+// shellQuote quotes s for POSIX shells
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// preCommitHook returns the pre-commit hook running gosect update -staged
+// with the update flags args
+func preCommitHook(args []string) []byte {
+	command := "exec gosect update -staged"
+	for _, arg := range args {
+		command += " " + shellQuote(arg)
+	}
+
+	return []byte("#!/bin/sh\n" + hookMarker + ": update the sections of staged files\n" + command + "\n")
+}
+
+// runHook installs the git pre-commit hook updating the sections of staged
+// files: gosect hook install [-force] [-- update flags]
+func runHook(args []string) error {
+	fs := flag.NewFlagSet("hook", flag.ExitOnError)
+	force := fs.Bool("force", false, "overwrite an existing pre-commit hook not installed by gosect")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect hook install [-force] [-- update flags]")
+		fs.PrintDefaults()
+	}
+	if len(args) == 0 || args[0] != "install" {
+		fs.Usage()
+		return errors.New("hook: expected the install action")
+	}
+	fs.Parse(args[1:])
+
+	// core.hooksPath and worktrees move the hooks directory
+	dir, err := git("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(dir, "pre-commit")
+
+	existing, err := os.ReadFile(path)
+	if err == nil && !bytes.Contains(existing, []byte(hookMarker)) && !*force {
+		return fmt.Errorf("hook: %s already exists (use -force to overwrite it)", path)
+	}
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, preCommitHook(fs.Args()), 0755); err != nil {
+		return err
+	}
+	if err := os.Chmod(path, 0755); err != nil {
+		return err
+	}
+	fmt.Printf("installed %s\n", path)
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// gitRepo initializes a git repository in a temporary directory, which
+// becomes the current directory, and returns a function running git in it
+func gitRepo(t *testing.T) (string, func(args ...string) string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+	run := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+		}
+		return string(out)
+	}
+	run("init", "-q")
+
+	return tmpDir, run
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test update -staged
+// /////////////////////////////////////////////////////////////////////////////
+func TestRunUpdateStaged(t *testing.T) {
+	tmpDir, run := gitRepo(t)
+
+	stale := "<!-- BEGIN SECTION s file=src.txt -->\nold\n<!-- END SECTION s -->\n"
+	want := "<!-- BEGIN SECTION s file=src.txt -->\n\nnew\n\n<!-- END SECTION s -->\n"
+	files := map[string]string{"src.txt": "new\n", "staged.md": stale, "unstaged.md": stale}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	run("add", "src.txt", "staged.md")
+
+	if err := runUpdate([]string{"-staged"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// only the staged file is updated, and staged again
+	if got, _ := os.ReadFile(filepath.Join(tmpDir, "staged.md")); string(got) != want {
+		t.Errorf("Expected staged.md to be updated, got %q", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(tmpDir, "unstaged.md")); string(got) != stale {
+		t.Errorf("Expected unstaged.md to be unchanged, got %q", got)
+	}
+	if got := run("show", ":staged.md"); got != want {
+		t.Errorf("Expected the updated staged.md in the index, got %q", got)
+	}
+
+	// the given files restrict the staged files
+	if err := os.WriteFile(filepath.Join(tmpDir, "staged.md"), []byte(stale), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("add", "staged.md")
+	if err := runUpdate([]string{"-staged", "unstaged.md"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(tmpDir, "staged.md")); string(got) != stale {
+		t.Errorf("Expected staged.md to be skipped, got %q", got)
+	}
+
+	// files with unstaged changes are skipped, which keeps them unstaged
+	partial := stale + "unstaged line\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "staged.md"), []byte(partial), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runUpdate([]string{"-staged"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(tmpDir, "staged.md")); string(got) != partial {
+		t.Errorf("Expected the partially staged file to be skipped, got %q", got)
+	}
+	if got := run("show", ":staged.md"); got != stale {
+		t.Errorf("Expected the index to keep the staged content, got %q", got)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test hook install
+// /////////////////////////////////////////////////////////////////////////////
+func TestRunHookInstall(t *testing.T) {
+	tmpDir, _ := gitRepo(t)
+	hook := filepath.Join(tmpDir, ".git", "hooks", "pre-commit")
+
+	if err := runHook([]string{"install", "--", "-checksum", "-section", "it's"}); err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile(hook)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `exec gosect update -staged '-checksum' '-section' 'it'\''s'`; !strings.Contains(string(got), want) {
+		t.Errorf("Expected hook running %s, got %q", want, got)
+	}
+	if info, err := os.Stat(hook); err != nil || runtime.GOOS != "windows" && info.Mode().Perm()&0100 == 0 {
+		t.Errorf("Expected an executable hook, got %v", info.Mode())
+	}
+
+	// a gosect hook is replaced, another one requires -force
+	if err := runHook([]string{"install"}); err != nil {
+		t.Errorf("Expected the gosect hook to be replaced, got %v", err)
+	}
+	if err := os.WriteFile(hook, []byte("#!/bin/sh\nmake lint\n"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := runHook([]string{"install"}); err == nil || !strings.Contains(err.Error(), "already exists") {
+		t.Errorf("Expected already exists error, got %v", err)
+	}
+	if err := runHook([]string{"install", "-force"}); err != nil {
+		t.Errorf("Expected -force to overwrite the hook, got %v", err)
+	}
+
+	if err := runHook([]string{"uninstall"}); err == nil {
+		t.Error("Expected an error for an unknown action")
+	}
+}
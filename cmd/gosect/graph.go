@@ -0,0 +1,233 @@
+package main
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/badele/gosect"
+)
+
+// graphSource is a dependency of a document: a section and its source, of
+// kind file, git, resolver (a custom scheme), database, url, cmd or layout
+type graphSource struct {
+	Section string `json:"section"`
+	Kind    string `json:"kind"`
+	Source  string `json:"source"`
+}
+
+// graphDocument is a target document, or a source with nested sections, and
+// its dependencies
+type graphDocument struct {
+	File    string        `json:"file"`
+	Sources []graphSource `json:"sources"`
+}
+
+// sectionSources returns the sources s depends on. Relative file=, extractor
+// (such as openapi=), ref=, layout= and asset= paths are resolved with opts.
+func sectionSources(s gosect.Section, opts gosect.Options) ([]graphSource, error) {
+	var sources []graphSource
+	_, spec, extracted := gosect.ExtractorSource(s)
+	switch {
+	case strings.HasPrefix(s.SrcFile, "git:"):
+		sources = append(sources, graphSource{s.Name, "git", s.SrcFile})
+	case opts.SchemeSource(s):
+		sources = append(sources, graphSource{s.Name, "resolver", s.SrcFile})
+	case s.SrcFile != "":
+		path, err := opts.SourcePath(s)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, graphSource{s.Name, "file", filepath.ToSlash(path)})
+	case extracted && gosect.DatabaseURL(spec):
+		// database URLs may hold a password
+		if u, err := url.Parse(spec); err == nil {
+			spec = u.Redacted()
+		}
+		sources = append(sources, graphSource{s.Name, "database", spec})
+	case extracted:
+		path, err := opts.SourcePath(gosect.Section{Name: s.Name, SrcFile: spec})
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, graphSource{s.Name, "file", filepath.ToSlash(path)})
+	case s.Attrs["ref"] != "":
+		path, _, _ := gosect.RefSource(s)
+		path, err := opts.SourcePath(gosect.Section{Name: s.Name, SrcFile: path})
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, graphSource{s.Name, "file", filepath.ToSlash(path)})
+	case s.Attrs["url"] != "":
+		sources = append(sources, graphSource{s.Name, "url", s.Attrs["url"]})
+	case s.Attrs["cmd"] != "":
+		sources = append(sources, graphSource{s.Name, "cmd", s.Attrs["cmd"]})
+	}
+
+	if layout, ok := s.Attrs["layout"]; ok {
+		path, err := opts.SourcePath(gosect.Section{Name: s.Name, SrcFile: layout})
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, graphSource{s.Name, "layout", filepath.ToSlash(path)})
+	}
+	if asset, ok := s.Attrs["asset"]; ok {
+		path, err := opts.SourcePath(gosect.Section{Name: s.Name, SrcFile: asset})
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, graphSource{s.Name, "asset", filepath.ToSlash(path)})
+	}
+
+	return sources, nil
+}
+
+// dependencyGraph returns the documents at paths and their sources, followed
+// by the file= sources holding nested sections, matched with reBegin and
+// reEnd. src= pseudo-sources depend on the document itself and are omitted,
+// as are frozen sections.
+func dependencyGraph(paths []string, markers func(string) (*regexp.Regexp, *regexp.Regexp), reBegin, reEnd *regexp.Regexp, opts gosect.Options, base string) ([]graphDocument, error) {
+	documents := []graphDocument{}
+	seen := map[string]bool{}
+	queue := paths
+	for i := 0; i < len(queue); i++ {
+		path := queue[i]
+		nested := i >= len(paths)
+		if seen[path] {
+			continue
+		}
+		seen[path] = true
+
+		content, err := readText(path)
+		if err != nil {
+			// missing sources are reported by update
+			if nested {
+				continue
+			}
+			return nil, err
+		}
+
+		b, e := reBegin, reEnd
+		if !nested {
+			b, e = markers(path)
+		}
+		sections, err := gosect.FindSectionsBytes(content, b, e)
+		if err != nil {
+			return nil, gosect.FileErrors(path, err)
+		}
+		if nested && len(sections) == 0 {
+			continue
+		}
+
+		// nested sections resolve from the directory of their source
+		opts.BaseDir = filepath.Dir(path)
+		if !nested {
+			opts.BaseDir = cmp.Or(base, opts.BaseDir)
+		}
+
+		doc := graphDocument{File: filepath.ToSlash(path), Sources: []graphSource{}}
+		for _, s := range sections {
+			// frozen sections are never rendered from their sources
+			if gosect.Frozen(s) {
+				continue
+			}
+			s = gosect.ExpandLanguage(s, cmp.Or(gosect.DocumentLanguage(path), gosect.DefaultLanguage))
+			sources, err := sectionSources(s, opts)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			for _, src := range sources {
+				if src.Kind == "file" {
+					queue = append(queue, filepath.FromSlash(src.Source))
+				}
+			}
+			doc.Sources = append(doc.Sources, sources...)
+		}
+		documents = append(documents, doc)
+	}
+
+	return documents, nil
+}
+
+// graphDOT renders documents as a Graphviz digraph, with edges from sources
+// to the documents including them, labelled with the section name
+func graphDOT(documents []graphDocument) []byte {
+	shapes := map[string]string{"git": "folder", "resolver": "folder", "database": "cylinder", "url": "ellipse", "cmd": "box", "layout": "component"}
+
+	var out strings.Builder
+	out.WriteString("digraph gosect {\n\trankdir=LR;\n\tnode [shape=note];\n")
+	declared := map[string]bool{}
+	for _, doc := range documents {
+		for _, src := range doc.Sources {
+			node := src.Source
+			if src.Kind == "cmd" {
+				node = "cmd:" + node
+			}
+			if shape, ok := shapes[src.Kind]; ok && !declared[node] {
+				declared[node] = true
+				fmt.Fprintf(&out, "\t%s [shape=%s];\n", dotQuote(node), shape)
+			}
+			fmt.Fprintf(&out, "\t%s -> %s [label=%s];\n", dotQuote(node), dotQuote(doc.File), dotQuote(src.Section))
+		}
+	}
+	out.WriteString("}\n")
+
+	return []byte(out.String())
+}
+
+// dotQuote returns s as a quoted DOT identifier
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// runGraph prints the sources target documents depend on, for build systems
+// and caches: gosect graph [-format dot|json] file...
+func runGraph(args []string) error {
+	fs := flag.NewFlagSet("graph", flag.ExitOnError)
+	format := fs.String("format", "dot", "output format: dot (Graphviz) or json")
+	base := fs.String("base", "", "directory relative file= sources are resolved from (default: the directory of each file)")
+	markers := addMarkerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect graph [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("graph: at least one file required")
+	}
+	if *format != "dot" && *format != "json" {
+		return fmt.Errorf("graph: unknown -format %s (expected dot or json)", *format)
+	}
+
+	cfg, err := markers.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	reBegin, reEnd := markers.nested()
+	documents, err := dependencyGraph(fs.Args(), markers.regex, reBegin, reEnd, gosect.Options{Roots: cfg.Roots, Plugins: true}, *base)
+	if err != nil {
+		return err
+	}
+
+	if *format == "dot" {
+		writeStdout(graphDOT(documents))
+		return nil
+	}
+
+	out, err := json.MarshalIndent(documents, "", "  ")
+	if err != nil {
+		return err
+	}
+	writeStdout(append(out, '\n'))
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the dependency graph of target documents
+// /////////////////////////////////////////////////////////////////////////////
+func TestDependencyGraph(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"doc.md": "<!-- BEGIN SECTION usage file=parts/usage.md -->\n<!-- END SECTION usage -->\n" +
+			"<!-- BEGIN SECTION help cmd=\"gosect -h\" layout=layout.tmpl -->\n<!-- END SECTION help -->\n" +
+			"<!-- BEGIN SECTION toc src=toc -->\n<!-- END SECTION toc -->\n" +
+			"<!-- BEGIN SECTION api openapi=api.yaml path=/users -->\n<!-- END SECTION api -->\n",
+		"parts/usage.md":   "<!-- BEGIN SECTION example file=example.go -->\n<!-- END SECTION example -->\n",
+		"parts/example.go": "package main\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	markers := func(path string) (*regexp.Regexp, *regexp.Regexp) {
+		return gosect.MarkersFor(path, gosect.DefaultBegin, gosect.DefaultEnd)
+	}
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	doc := filepath.Join(tmpDir, "doc.md")
+	got, err := dependencyGraph([]string{doc}, markers, reBegin, reEnd, gosect.Options{}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.ToSlash(tmpDir)
+	want := []graphDocument{
+		{File: dir + "/doc.md", Sources: []graphSource{
+			{"usage", "file", dir + "/parts/usage.md"},
+			{"help", "cmd", "gosect -h"},
+			{"help", "layout", dir + "/layout.tmpl"},
+			{"api", "file", dir + "/api.yaml"},
+		}},
+		{File: dir + "/parts/usage.md", Sources: []graphSource{
+			{"example", "file", dir + "/parts/example.go"},
+		}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	dot := string(graphDOT(got))
+	for _, line := range []string{
+		`"cmd:gosect -h" [shape=box];`,
+		`"` + dir + `/parts/usage.md" -> "` + dir + `/doc.md" [label="usage"];`,
+	} {
+		if !strings.Contains(dot, line) {
+			t.Errorf("Expected %q in %s", line, dot)
+		}
+	}
+
+	if _, err := dependencyGraph([]string{filepath.Join(tmpDir, "missing.md")}, markers, reBegin, reEnd, gosect.Options{}, ""); err == nil {
+		t.Error("Expected an error for a missing document")
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/badele/gosect"
+)
+
+// underHeading wraps the content under a Markdown heading of the targets in
+// the markers of a new section, with -under-heading
+type underHeading struct {
+	heading string // heading line, such as "## Usage"
+	name    string // section name, the anchor of the heading
+	attrs   string // source attributes of the BEGIN marker
+}
+
+// newUnderHeading checks the -under-heading and -heading-attrs flags. It
+// returns nil without heading.
+func newUnderHeading(heading, attrs string) (*underHeading, error) {
+	if heading == "" {
+		return nil, nil
+	}
+	if attrs == "" {
+		return nil, errors.New("-under-heading needs -heading-attrs, the source of the section (such as file=docs/usage.md)")
+	}
+	name, err := gosect.HeadingSection(heading)
+	if err != nil {
+		return nil, fmt.Errorf("-under-heading: %w", err)
+	}
+
+	return &underHeading{heading: heading, name: name, attrs: attrs}, nil
+}
+
+// adopt returns the content of the target file at path with the markers of
+// the heading section inserted, on the first run, or unchanged once the
+// section exists. It does nothing on a nil underHeading.
+func (h *underHeading) adopt(path string, content []byte, reBegin, reEnd *regexp.Regexp) ([]byte, error) {
+	if h == nil {
+		return content, nil
+	}
+
+	sections, err := gosect.FindSectionsBytes(content, reBegin, reEnd)
+	if err != nil {
+		return nil, markerError{gosect.FileErrors(path, err)}
+	}
+	if slices.ContainsFunc(sections, func(s gosect.Section) bool { return s.Name == h.name }) {
+		return content, nil
+	}
+
+	// Markers in the comment syntax of the target
+	begin, end, suffix := gosect.DefaultManifestBegin, gosect.DefaultManifestEnd, gosect.DefaultManifestSuffix
+	if style, ok := gosect.CommentStyleFor(path); ok {
+		begin, end, suffix = style.Markers(gosect.DefaultBegin, gosect.DefaultEnd)
+	}
+	adopted, err := gosect.InsertUnderHeading(content,
+		h.heading,
+		fmt.Sprintf("%s %s %s%s", begin, h.name, h.attrs, suffix),
+		fmt.Sprintf("%s %s%s", end, h.name, suffix))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Custom markers would not find the inserted section on the next run
+	sections, err = gosect.FindSectionsBytes(adopted, reBegin, reEnd)
+	if err != nil || !slices.ContainsFunc(sections, func(s gosect.Section) bool { return s.Name == h.name }) {
+		return nil, fmt.Errorf("%s: the markers inserted under %q do not match -begin and -end", path, h.heading)
+	}
+
+	return adopted, nil
+}
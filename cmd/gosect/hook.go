@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/badele/gosect"
+)
+
+// runHooks runs the shell commands of the kind hooks (pre or post) in order,
+// writing their output to w, and stops at the first failure. Hooks need
+// -allow-cmd, like cmd= sources.
+func (c updateConfig) runHooks(kind string, commands []string, w io.Writer) error {
+	if len(commands) > 0 && !c.opts.AllowCommands {
+		return fmt.Errorf("%s hooks run shell commands and need -allow-cmd", kind)
+	}
+
+	for _, line := range commands {
+		c.logger().Info("running hook", "hook", kind, "cmd", line)
+
+		cmd := gosect.ShellCommand(line)
+		cmd.Stdout = w
+		cmd.Stderr = w
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s-cmd %q: %w", kind, line, err)
+		}
+	}
+
+	return nil
+}
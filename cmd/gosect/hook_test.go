@@ -0,0 +1,47 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test -pre-cmd and -post-cmd hooks
+// /////////////////////////////////////////////////////////////////////////////
+func TestRunHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts not supported")
+	}
+
+	tmpDir := t.TempDir()
+	out := filepath.Join(tmpDir, "hooks.txt")
+
+	var c updateConfig
+	commands := []string{"echo first >> " + out}
+	if err := c.runHooks("pre", commands, io.Discard); err == nil || !strings.Contains(err.Error(), "need -allow-cmd") {
+		t.Errorf("Expected hooks without -allow-cmd to fail, got %v", err)
+	}
+
+	c.opts.AllowCommands = true
+	commands = []string{"echo first >> " + out, "echo second >> " + out}
+	if err := c.runHooks("pre", commands, io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(out); string(got) != "first\nsecond\n" {
+		t.Errorf("Expected hooks to run in order, got %q", got)
+	}
+
+	// a failing hook stops the run
+	commands = []string{"exit 3", "echo third >> " + out}
+	err := c.runHooks("post", commands, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), `post-cmd "exit 3"`) {
+		t.Errorf("Expected post-cmd error, got %v", err)
+	}
+	if got, _ := os.ReadFile(out); string(got) != "first\nsecond\n" {
+		t.Errorf("Expected the hooks after the failure to be skipped, got %q", got)
+	}
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/badele/gosect"
+)
+
+// interactiveHelp explains the answers of the -interactive prompt
+const interactiveHelp = `y - apply this change
+n - skip this change
+a - apply this change and all the remaining ones
+q - quit, skipping this change and all the remaining ones
+`
+
+// approver asks, with -interactive, whether to apply the change of each
+// section, like git add -p. Its answers a and q apply to the remaining
+// sections of every target.
+type approver struct {
+	mu             sync.Mutex
+	in             *bufio.Reader
+	out            io.Writer
+	showWhitespace bool
+	color          bool
+	all, quit      bool
+}
+
+// sectionChange is the change of a section, from its span in the input of a
+// target to its span in the result
+type sectionChange struct {
+	name     string
+	from, to [2]int
+	after    []byte
+}
+
+// approve shows the diff of each changed section of the target file at path
+// and returns result with the changes the user declined reverted to input.
+// It returns result unchanged on a nil approver.
+func (a *approver) approve(path string, input, result []byte, reBegin, reEnd *regexp.Regexp) []byte {
+	if a == nil || bytes.Equal(input, result) {
+		return result
+	}
+
+	// One prompt sequence at a time, whatever the number of workers
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	changes := sectionChanges(input, result, reBegin, reEnd)
+	var out bytes.Buffer
+	prev := 0
+	for i, ch := range changes {
+		if a.ask(path, input, ch, i+1, len(changes)) {
+			continue
+		}
+		out.Write(result[prev:ch.to[0]])
+		out.Write(input[ch.from[0]:ch.from[1]])
+		prev = ch.to[1]
+	}
+	out.Write(result[prev:])
+
+	return out.Bytes()
+}
+
+// ask shows the change ch of the target file at path, whose content is input,
+// and reports whether the user applies it
+func (a *approver) ask(path string, input []byte, ch sectionChange, n, total int) bool {
+	switch {
+	case a.all:
+		return true
+	case a.quit:
+		return false
+	}
+
+	// Diff the whole target so hunks have the line numbers of the file
+	applied := bytes.Join([][]byte{input[:ch.from[0]], ch.after, input[ch.from[1]:]}, nil)
+	writeDiff(a.out, path, input, applied, a.showWhitespace, a.color)
+
+	for {
+		fmt.Fprintf(a.out, "(%d/%d) Apply %s [y,n,a,q,?]? ", n, total, ch.name)
+		answer, err := a.in.ReadString('\n')
+		if err != nil && answer == "" {
+			// no more answers: skip the remaining changes
+			fmt.Fprintln(a.out)
+			a.quit = true
+			return false
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		case "a":
+			a.all = true
+			return true
+		case "q":
+			a.quit = true
+			return false
+		}
+		fmt.Fprint(a.out, interactiveHelp)
+	}
+}
+
+// sectionChanges returns the changes of the outermost sections between input
+// and result, and the changes between them, such as a final newline added for
+// EditorConfig, or a single change of the whole file when their sections do
+// not pair, such as when a change adds markers
+func sectionChanges(input, result []byte, reBegin, reEnd *regexp.Regexp) []sectionChange {
+	whole := []sectionChange{{
+		name:  "the changes",
+		from:  [2]int{0, len(input)},
+		to:    [2]int{0, len(result)},
+		after: result,
+	}}
+
+	before, err := gosect.FindSectionsBytes(input, reBegin, reEnd)
+	if err != nil {
+		return whole
+	}
+	after, err := gosect.FindSectionsBytes(result, reBegin, reEnd)
+	if err != nil || len(after) != len(before) {
+		return whole
+	}
+
+	var changes []sectionChange
+	// add appends the change from input[from] to result[to] when they differ
+	add := func(name string, from, to [2]int) {
+		if !bytes.Equal(input[from[0]:from[1]], result[to[0]:to[1]]) {
+			changes = append(changes, sectionChange{
+				name:  name,
+				from:  from,
+				to:    to,
+				after: result[to[0]:to[1]],
+			})
+		}
+	}
+
+	outer, outerAfter := 0, 0
+	for i, s := range before {
+		// nested sections change with the section containing them
+		if s.StartIdx < outer {
+			continue
+		}
+
+		add("the change before section "+s.Name, [2]int{outer, s.StartIdx}, [2]int{outerAfter, after[i].StartIdx})
+		add("section "+s.Name, [2]int{s.StartIdx, s.EndIdx}, [2]int{after[i].StartIdx, after[i].EndIdx})
+		outer, outerAfter = s.EndIdx, after[i].EndIdx
+	}
+	add("the change after the sections", [2]int{outer, len(input)}, [2]int{outerAfter, len(result)})
+
+	return changes
+}
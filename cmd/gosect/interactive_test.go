@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the per section approval of -interactive
+// /////////////////////////////////////////////////////////////////////////////
+func TestApprove(t *testing.T) {
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	section := func(name, body string) string {
+		return "<!-- BEGIN SECTION " + name + " -->\n" + body + "<!-- END SECTION " + name + " -->\n"
+	}
+	input := "# Doc\n" + section("a", "old a\n") + section("b", "old b\n") + section("c", "same\n")
+	result := "# Doc\n" + section("a", "new a\n") + section("b", "new b\n") + section("c", "same\n")
+
+	tests := []struct {
+		name    string
+		answers string
+		want    string
+		prompts int
+	}{
+		{
+			name:    "Apply both",
+			answers: "y\ny\n",
+			want:    result,
+			prompts: 2,
+		},
+		{
+			name:    "Skip the first",
+			answers: "n\ny\n",
+			want:    "# Doc\n" + section("a", "old a\n") + section("b", "new b\n") + section("c", "same\n"),
+			prompts: 2,
+		},
+		{
+			name:    "Apply all",
+			answers: "a\n",
+			want:    result,
+			prompts: 1,
+		},
+		{
+			name:    "Quit",
+			answers: "y\nq\n",
+			want:    "# Doc\n" + section("a", "new a\n") + section("b", "old b\n") + section("c", "same\n"),
+			prompts: 2,
+		},
+		{
+			name:    "Unknown answer",
+			answers: "x\nn\nn\n",
+			want:    input,
+			prompts: 3,
+		},
+		{
+			name:    "No more answers",
+			answers: "y\n",
+			want:    "# Doc\n" + section("a", "new a\n") + section("b", "old b\n") + section("c", "same\n"),
+			prompts: 2,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			a := &approver{in: bufio.NewReader(strings.NewReader(tt.answers)), out: &out}
+			got := a.approve("doc.md", []byte(input), []byte(result), reBegin, reEnd)
+
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+			if n := strings.Count(out.String(), "Apply section"); n != tt.prompts {
+				t.Errorf("Expected %d prompts, got %d in %q", tt.prompts, n, out.String())
+			}
+			if !strings.Contains(out.String(), "-old a\n+new a\n") {
+				t.Errorf("Expected the diff of section a, got %q", out.String())
+			}
+		})
+	}
+
+	// Once quit, the other targets are left unchanged without prompting
+	var out strings.Builder
+	a := &approver{in: bufio.NewReader(strings.NewReader("q\n")), out: &out}
+	a.approve("doc.md", []byte(input), []byte(result), reBegin, reEnd)
+	if got := a.approve("other.md", []byte(input), []byte(result), reBegin, reEnd); string(got) != input {
+		t.Errorf("Expected no change after q, got %q", got)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the approval of changes outside sections
+// /////////////////////////////////////////////////////////////////////////////
+func TestApproveOutsideSections(t *testing.T) {
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	input := "# Doc\n<!-- BEGIN SECTION a -->\nold\n<!-- END SECTION a -->\ntail"
+	result := "# Doc\n<!-- BEGIN SECTION a -->\nnew\n<!-- END SECTION a -->\ntail\n"
+
+	var out strings.Builder
+	a := &approver{in: bufio.NewReader(strings.NewReader("y\nn\n")), out: &out}
+	got := a.approve("doc.md", []byte(input), []byte(result), reBegin, reEnd)
+
+	want := "# Doc\n<!-- BEGIN SECTION a -->\nnew\n<!-- END SECTION a -->\ntail"
+	if string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if !strings.Contains(out.String(), "(2/2) Apply the change after the sections") {
+		t.Errorf("Expected a prompt for the final newline, got %q", out.String())
+	}
+}
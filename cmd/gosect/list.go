@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/badele/gosect"
+)
+
+// listEntry describes a section found in a target file. Start and End are the
+// byte offsets of the BEGIN and END markers, StartLine and EndLine their line
+// numbers.
+type listEntry struct {
+	File      string            `json:"file"`
+	Name      string            `json:"name"`
+	Attrs     map[string]string `json:"attrs"`
+	EndAttrs  map[string]string `json:"endAttrs,omitempty"`
+	Start     int               `json:"start"`
+	End       int               `json:"end"`
+	StartLine int               `json:"startLine"`
+	EndLine   int               `json:"endLine"`
+}
+
+// listSections returns the sections found in the target files at paths,
+// using the marker regexes returned by markers for each file
+func listSections(paths []string, markers func(string) (*regexp.Regexp, *regexp.Regexp)) ([]listEntry, error) {
+	entries := []listEntry{}
+	for _, path := range paths {
+		content, err := readText(path)
+		if err != nil {
+			return nil, err
+		}
+
+		reBegin, reEnd := markers(path)
+		sections, err := gosect.FindSectionsBytes(content, reBegin, reEnd)
+		if err != nil {
+			return nil, gosect.FileErrors(path, err)
+		}
+
+		for _, s := range sections {
+			entries = append(entries, listEntry{
+				File:      path,
+				Name:      s.Name,
+				Attrs:     s.Attrs,
+				EndAttrs:  s.EndAttrs,
+				Start:     s.StartIdx,
+				End:       s.EndIdx,
+				StartLine: bytes.Count(content[:s.StartIdx], []byte("\n")) + 1,
+				EndLine:   bytes.Count(content[:s.EndIdx], []byte("\n")) + 1,
+			})
+		}
+	}
+
+	return entries, nil
+}
+
+// runList prints the sections of target files:
+// gosect list [flags] file...
+func runList(args []string) error {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	jsonFlag := fs.Bool("json", false, "print sections as a JSON array")
+	markers := addMarkerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect list [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("list: at least one file required")
+	}
+
+	if _, err := markers.loadConfig(); err != nil {
+		return err
+	}
+
+	entries, err := listSections(fs.Args(), markers.regex)
+	if err != nil {
+		return err
+	}
+
+	if *jsonFlag {
+		out, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			return err
+		}
+		writeStdout(append(out, '\n'))
+		return nil
+	}
+
+	var out strings.Builder
+	for _, e := range entries {
+		fmt.Fprintf(&out, "%s:%d-%d %s", e.File, e.StartLine, e.EndLine, e.Name)
+		for _, key := range slices.Sorted(maps.Keys(e.Attrs)) {
+			fmt.Fprintf(&out, " %s=%s", key, e.Attrs[key])
+		}
+		out.WriteByte('\n')
+	}
+	writeStdout([]byte(out.String()))
+
+	return nil
+}
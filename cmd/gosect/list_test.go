@@ -0,0 +1,53 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test listing sections of target files
+// /////////////////////////////////////////////////////////////////////////////
+func TestListSections(t *testing.T) {
+	tmpDir := t.TempDir()
+	doc := filepath.Join(tmpDir, "doc.md")
+	content := "# Title\n<!-- BEGIN SECTION intro file=intro.md fence=true -->\nold\n<!-- END SECTION intro -->\n"
+	if err := os.WriteFile(doc, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	empty := filepath.Join(tmpDir, "empty.md")
+	if err := os.WriteFile(empty, []byte("no markers\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	markers := func(path string) (*regexp.Regexp, *regexp.Regexp) {
+		return gosect.MarkersFor(path, gosect.DefaultBegin, gosect.DefaultEnd)
+	}
+	got, err := listSections([]string{doc, empty}, markers)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []listEntry{{
+		File:      doc,
+		Name:      "intro",
+		Attrs:     map[string]string{"file": "intro.md", "fence": "true"},
+		EndAttrs:  map[string]string{},
+		Start:     8,
+		End:       66,
+		StartLine: 2,
+		EndLine:   4,
+	}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %+v, got %+v", want, got)
+	}
+
+	if _, err := listSections([]string{filepath.Join(tmpDir, "missing.md")}, markers); err == nil {
+		t.Error("Expected error for a missing file")
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test leveled JSON logging
+// /////////////////////////////////////////////////////////////////////////////
+func TestNewLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger, err := newLogger(&out, "info", "json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	logger.Debug("hidden")
+	logger.Info("file written", "file", "README.md", "bytes", 42)
+
+	var record map[string]any
+	if err := json.Unmarshal(out.Bytes(), &record); err != nil {
+		t.Fatalf("Expected a single JSON record, got %q: %v", out.String(), err)
+	}
+	if record["msg"] != "file written" || record["file"] != "README.md" || record["bytes"] != 42.0 {
+		t.Errorf("Unexpected record %v", record)
+	}
+
+	for _, test := range [][2]string{{"verbose", "text"}, {"info", "xml"}} {
+		if _, err := newLogger(&out, test[0], test[1]); err == nil {
+			t.Errorf("Expected error for -log-level %s -log-format %s", test[0], test[1])
+		}
+	}
+}
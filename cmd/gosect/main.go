@@ -0,0 +1,330 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/badele/gosect"
+)
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// backupFlag is the -backup flag. It is used as a boolean (-backup) or with
+// the suffix of the backup file (-backup=.orig).
+type backupFlag struct {
+	suffix string
+}
+
+func (b *backupFlag) String() string {
+	return b.suffix
+}
+
+func (b *backupFlag) Set(value string) error {
+	switch value {
+	case "true":
+		b.suffix = defaultBackupSuffix
+	case "false":
+		b.suffix = ""
+	case "":
+		return errors.New("empty backup suffix")
+	default:
+		b.suffix = value
+	}
+	return nil
+}
+
+func (b *backupFlag) IsBoolFlag() bool {
+	return true
+}
+
+// markerFlags holds the flags selecting the section markers and the
+// configuration file
+type markerFlags struct {
+	fs     *flag.FlagSet
+	config *string
+	begin  *string
+	end    *string
+	detect *bool
+
+	// raw marker regexes of -begin-re and -end-re, set by loadConfig
+	beginRe, endRe *string
+	rawBegin       *regexp.Regexp
+	rawEnd         *regexp.Regexp
+}
+
+// addMarkerFlags registers the marker and configuration flags on fs
+func addMarkerFlags(fs *flag.FlagSet) *markerFlags {
+	return &markerFlags{
+		fs:      fs,
+		config:  fs.String("config", gosect.DefaultConfigFile, "configuration file, optional unless given explicitly"),
+		begin:   fs.String("begin", gosect.DefaultBegin, "begin marker prefix"),
+		end:     fs.String("end", gosect.DefaultEnd, "end marker prefix"),
+		detect:  fs.Bool("detect-comments", false, "without -begin/-end, only match markers inside comments of the file type (<!-- in Markdown, # in YAML...)"),
+		beginRe: fs.String("begin-re", "", "begin marker regex replacing -begin, with a (?P<name>...) group and optional (?P<attrs>...), (?P<file>...) or other attribute groups"),
+		endRe:   fs.String("end-re", "", "end marker regex replacing -end, with a (?P<name>...) group matching the begin marker name"),
+	}
+}
+
+// detecting reports whether markers are matched with the comment syntax of
+// each file type
+func (m *markerFlags) detecting() bool {
+	return *m.detect && m.rawBegin == nil && !isFlagSet(m.fs, "begin", "end")
+}
+
+// isFlagSet reports whether one of the named flags was given on the command
+// line
+func isFlagSet(fs *flag.FlagSet, names ...string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if slices.Contains(names, f.Name) {
+			set = true
+		}
+	})
+
+	return set
+}
+
+// loadConfig reads the configuration file and uses its marker prefixes,
+// unless -begin or -end are given, and compiles -begin-re and -end-re
+func (m *markerFlags) loadConfig() (*gosect.Config, error) {
+	cfg, err := gosect.LoadConfig(*m.config, !isFlagSet(m.fs, "config"))
+	if err != nil {
+		return nil, err
+	}
+
+	if *m.beginRe != "" || *m.endRe != "" {
+		if *m.beginRe == "" || *m.endRe == "" {
+			return nil, errors.New("-begin-re and -end-re must be given together")
+		}
+		if isFlagSet(m.fs, "begin", "end") {
+			return nil, errors.New("-begin-re and -end-re replace -begin and -end, which cannot be given with them")
+		}
+		if m.rawBegin, m.rawEnd, err = gosect.RawMarkers(*m.beginRe, *m.endRe); err != nil {
+			return nil, err
+		}
+	}
+
+	if !isFlagSet(m.fs, "begin", "end") {
+		*m.begin = cmp.Or(cfg.Begin, *m.begin)
+		*m.end = cmp.Or(cfg.End, *m.end)
+	}
+
+	return cfg, nil
+}
+
+// regex returns the marker regexes for the target file at path
+func (m *markerFlags) regex(path string) (*regexp.Regexp, *regexp.Regexp) {
+	if m.rawBegin != nil {
+		return m.rawBegin, m.rawEnd
+	}
+	// -detect-comments only decides whether markers must sit in a comment;
+	// other formats, such as notebooks, always match with their own markers
+	format := gosect.FormatFor(path)
+	if _, comment := format.(gosect.CommentFormat); m.detecting() || !comment {
+		return format.Markers(*m.begin, *m.end)
+	}
+
+	return gosect.MakeRegex(*m.begin, *m.end)
+}
+
+// nested returns the marker regexes of the sections found in included
+// sources, matched without the comment syntax of a file type
+func (m *markerFlags) nested() (*regexp.Regexp, *regexp.Regexp) {
+	if m.rawBegin != nil {
+		return m.rawBegin, m.rawEnd
+	}
+
+	return gosect.MakeRegex(*m.begin, *m.end)
+}
+
+// commands maps subcommand names to their entry point
+var commands = map[string]func([]string) error{
+	"update":       runUpdate,
+	"check":        runCheck,
+	"diff":         runDiff,
+	"list":         runList,
+	"extract":      runExtract,
+	"assemble":     runAssemble,
+	"new-snippet":  runNewSnippet,
+	"vendor":       runVendor,
+	"badge":        runBadge,
+	"review":       runReview,
+	"fix":          runFix,
+	"serve":        runServe,
+	"daemon":       runDaemon,
+	"graph":        runGraph,
+	"validate":     runValidate,
+	"schema":       runSchema,
+	"sync":         runSync,
+	"verify":       runVerify,
+	"hook":         runHook,
+	"translations": runTranslations,
+}
+
+// entry point
+func main() {
+	// Run the subcommand, update being the default
+	run, args := runUpdate, os.Args[1:]
+	if len(args) > 0 {
+		if cmd, ok := commands[args[0]]; ok {
+			run, args = cmd, args[1:]
+		}
+	}
+
+	if err := run(args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		code := 1
+		var exit exitError
+		if errors.As(err, &exit) {
+			code = exit.code
+		}
+		os.Exit(code)
+	}
+}
+
+// Exit statuses of -exit-code and -generate runs. Successful runs exit with 0
+// when no file changed or needs to.
+const (
+	exitChanges = 1 // files were updated, or need to be with check and diff
+	exitMarkers = 2 // damaged markers, misordered sections or invalid arguments
+	exitSources = 3 // unreadable targets, failed sources or hooks
+)
+
+// errOutOfDate is the failure of check on target files needing changes
+var errOutOfDate = errors.New("sections are out of date")
+
+// markerError is a failure caused by the markers of a target, rather than by
+// its sources
+type markerError struct {
+	err error
+}
+
+// Error returns the message of the error
+func (e markerError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e markerError) Unwrap() error {
+	return e.err
+}
+
+// exitStatus returns the -exit-code status of a run which failed with err:
+// marker errors take precedence over source errors, and runs which only
+// found out of date files report changes
+func exitStatus(err error) int {
+	var marker markerError
+	switch {
+	case errors.As(err, &marker):
+		return exitMarkers
+	case onlyOutOfDate(err):
+		return exitChanges
+	}
+
+	return exitSources
+}
+
+// onlyOutOfDate reports whether every error joined in err is errOutOfDate
+func onlyOutOfDate(err error) bool {
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			if !onlyOutOfDate(err) {
+				return false
+			}
+		}
+		return true
+	case interface{ Unwrap() error }:
+		return onlyOutOfDate(e.Unwrap())
+	}
+
+	return err == errOutOfDate
+}
+
+// usageError returns err, exiting with exitMarkers with -exit-code, as
+// invalid flags do
+func usageError(exitCode bool, err error) error {
+	if exitCode {
+		return exitError{exitMarkers, err}
+	}
+
+	return err
+}
+
+// exitError is an error exiting gosect with a status other than 1
+type exitError struct {
+	code int
+	err  error
+}
+
+// Error returns the message of the error
+func (e exitError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e exitError) Unwrap() error {
+	return e.err
+}
+
+// readText reads the file at path, transcoding UTF-16 content to UTF-8
+func readText(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	content, _, err := gosect.DecodeText(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return content, nil
+}
+
+// writeStdout prints data to stdout, exiting on write errors
+func writeStdout(data []byte) {
+	if _, err := os.Stdout.Write(data); err != nil {
+		fmt.Fprintln(os.Stderr, "stdout:", err)
+		os.Exit(1)
+	}
+}
+
+// checkOrder validates the section order requested with -order or
+// -order-file, if any
+func checkOrder(sections []gosect.Section, list, file string, exact bool) error {
+	var order []string
+	if list != "" {
+		order = strings.Split(list, ",")
+	}
+
+	if file != "" {
+		names, err := gosect.LoadOrder(file)
+		if err != nil {
+			return err
+		}
+		order = append(order, names...)
+	}
+
+	if order == nil {
+		return nil
+	}
+
+	return gosect.CheckOrder(sections, order, exact)
+}
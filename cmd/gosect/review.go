@@ -0,0 +1,40 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// runReview updates sections and stamps their END markers with the reviewer
+// and the review hash of their new body:
+// gosect review -by name [flags] file...
+func runReview(args []string) error {
+	fs := flag.NewFlagSet("review", flag.ExitOnError)
+	f := addUpdateFlags(fs)
+	by := fs.String("by", "", "name of the reviewer recorded in reviewed-by=")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect review -by name [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	files := append(f.files, fs.Args()...)
+	if len(files) == 0 {
+		fs.Usage()
+		return errors.New("review: at least one file required")
+	}
+	if *by == "" || strings.ContainsAny(*by, " \t\r\n>") {
+		return fmt.Errorf("review: -by must be a name without spaces, got %q", *by)
+	}
+
+	c, err := f.settings()
+	if err != nil {
+		return err
+	}
+	c.opts.Reviewer = *by
+
+	return c.updateFiles(os.Stdout, files, *f.jobs)
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// schemas are the JSON Schemas of the files read and written by gosect, so
+// that tools can validate them and generate code from stable formats
+//
+//go:embed schemas/*.schema.json
+var schemas embed.FS
+
+// schemaNames returns the names of the embedded schemas
+func schemaNames() []string {
+	paths, _ := fs.Glob(schemas, "schemas/*.schema.json")
+
+	var names []string
+	for _, path := range paths {
+		names = append(names, strings.TrimSuffix(strings.TrimPrefix(path, "schemas/"), ".schema.json"))
+	}
+
+	return names
+}
+
+// runSchema prints the JSON Schema of a format, or lists the formats:
+// gosect schema [name]
+func runSchema(args []string) error {
+	switch len(args) {
+	case 0:
+		writeStdout([]byte(strings.Join(schemaNames(), "\n") + "\n"))
+		return nil
+	case 1:
+	default:
+		return errors.New("usage: gosect schema [name]")
+	}
+
+	data, err := schemas.ReadFile("schemas/" + args[0] + ".schema.json")
+	if err != nil {
+		return fmt.Errorf("schema: unknown format %q (expected one of %s)", args[0], strings.Join(schemaNames(), ", "))
+	}
+	writeStdout(data)
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the schemas against the types they describe
+// /////////////////////////////////////////////////////////////////////////////
+func TestSchemas(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		path  []string // path of the object schema of value
+	}{
+		{"config", gosect.Config{}, nil},
+		{"manifest", gosect.Manifest{}, nil},
+		{"lock", gosect.VendorLock{}, nil},
+		{"list", listEntry{}, []string{"items"}},
+		{"graph", graphDocument{}, []string{"items"}},
+		{"status", docStatus{}, []string{"properties", "documents", "items"}},
+		{"verify", verifyManifest{}, nil},
+		{"summary", runSummary{}, nil},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := schemas.ReadFile("schemas/" + tt.name + ".schema.json")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var schema map[string]any
+			if err := json.Unmarshal(data, &schema); err != nil {
+				t.Fatalf("Invalid schema: %v", err)
+			}
+			for _, key := range tt.path {
+				schema, _ = schema[key].(map[string]any)
+			}
+			properties, _ := schema["properties"].(map[string]any)
+
+			var fields []string
+			typ := reflect.TypeOf(tt.value)
+			for i := range typ.NumField() {
+				name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+				fields = append(fields, name)
+			}
+
+			got := slices.Sorted(maps.Keys(properties))
+			if slices.Sort(fields); !slices.Equal(got, fields) {
+				t.Errorf("Expected properties %v, got %v", fields, got)
+			}
+		})
+	}
+
+	if !slices.Equal(schemaNames(), []string{"config", "graph", "list", "lock", "manifest", "status", "summary", "verify"}) {
+		t.Errorf("Unexpected schemas %v", schemaNames())
+	}
+}
@@ -0,0 +1,220 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/badele/gosect"
+)
+
+// staleSection is a section whose body differs from its generated content
+type staleSection struct {
+	Name  string `json:"name"`
+	Line  int    `json:"line"`
+	Error string `json:"error,omitempty"` // why the section cannot be generated
+}
+
+// docStatus is the freshness of the sections of a target file
+type docStatus struct {
+	File     string         `json:"file"`
+	Fresh    int            `json:"fresh"`
+	Total    int            `json:"total"`
+	Stale    []staleSection `json:"stale"`
+	Modified time.Time      `json:"modified"` // last update of the file
+	Checked  time.Time      `json:"checked"`  // last check of the sections
+	Error    string         `json:"error,omitempty"`
+}
+
+// statusCache holds the statuses of target files, checked again only once
+// they are older than refresh or the file changed, so requests do not run
+// the sources of every section each time
+type statusCache struct {
+	c       updateConfig
+	refresh time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*statusEntry
+}
+
+// statusEntry is the cached status of a target file. Its lock is held while
+// the file is checked, so a slow source only delays the requests of its file.
+type statusEntry struct {
+	mu     sync.Mutex
+	status docStatus
+}
+
+// entry returns the cache entry of the target file at path
+func (sc *statusCache) entry(path string) *statusEntry {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if sc.entries == nil {
+		sc.entries = map[string]*statusEntry{}
+	}
+	e, ok := sc.entries[path]
+	if !ok {
+		e = &statusEntry{}
+		sc.entries[path] = e
+	}
+
+	return e
+}
+
+// status returns the status of the target file at path, from the cache
+// when it is recent enough
+func (sc *statusCache) status(path string) docStatus {
+	e := sc.entry(path)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if !e.status.Checked.IsZero() && time.Since(e.status.Checked) < sc.refresh {
+		if info, err := os.Stat(path); err == nil && info.ModTime().Equal(e.status.Modified) {
+			return e.status
+		}
+	}
+
+	status, err := sc.c.documentStatus(path)
+	if err != nil {
+		status.Error = err.Error()
+		sc.c.logger().Warn("status check failed", "file", path, "error", err)
+	}
+	status.Checked = time.Now().UTC()
+	e.status = status
+
+	return status
+}
+
+// documentStatus checks the sections of the target file at path against
+// their generated content, without writing anything. Sections which cannot
+// be generated are stale.
+func (c updateConfig) documentStatus(path string) (docStatus, error) {
+	status := docStatus{File: path, Stale: []staleSection{}}
+	info, err := os.Stat(path)
+	if err != nil {
+		return status, err
+	}
+	status.Modified = info.ModTime()
+
+	input, err := readText(path)
+	if err != nil {
+		return status, err
+	}
+
+	reBegin, reEnd := c.targetMarkers(path)
+	sections, err := gosect.FindSectionsBytes(input, reBegin, reEnd)
+	if err != nil {
+		return status, gosect.FileErrors(path, err)
+	}
+	sections, err = gosect.FilterSections(sections, c.only)
+	if err != nil {
+		return status, err
+	}
+
+	opts, err := c.targetOptions(path)
+	if err != nil {
+		return status, err
+	}
+	for _, s := range sections {
+		status.Total++
+		result, err := gosect.Replace(input, []gosect.Section{s}, opts)
+		if err == nil && bytes.Equal(result, input) {
+			status.Fresh++
+			continue
+		}
+
+		stale := staleSection{Name: s.Name, Line: s.Pos.Begin.Start.Line}
+		if err != nil {
+			stale.Error = err.Error()
+		}
+		status.Stale = append(status.Stale, stale)
+	}
+
+	return status, nil
+}
+
+// statusHandler serves the freshness of the target files at paths as JSON:
+// every file on GET /status, or one with GET /status?file=path. Files are
+// checked again at most once per refresh interval, or when they change.
+func (c updateConfig) statusHandler(paths []string, refresh time.Duration) http.Handler {
+	cache := &statusCache{c: c, refresh: refresh}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", func(w http.ResponseWriter, r *http.Request) {
+		files := paths
+		if file := r.URL.Query().Get("file"); file != "" {
+			if !slices.Contains(paths, file) {
+				http.Error(w, fmt.Sprintf("unknown file %q", file), http.StatusNotFound)
+				return
+			}
+			files = []string{file}
+		}
+
+		// checked is the oldest check of the files
+		statuses := []docStatus{}
+		checked := time.Now().UTC()
+		for _, path := range files {
+			status := cache.status(path)
+			statuses = append(statuses, status)
+			if status.Checked.Before(checked) {
+				checked = status.Checked
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(map[string]any{"checked": checked, "documents": statuses})
+	})
+
+	return mux
+}
+
+// runServe serves the freshness of target files over HTTP, for docs portals
+// showing outdated pages: gosect serve -check-only [flags] file...
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	f := addUpdateFlags(fs)
+	checkOnly := fs.Bool("check-only", false, "only report the freshness of the files, never writing them (required)")
+	addr := fs.String("addr", "localhost:8080", "address the server listens on")
+	refresh := fs.Duration("refresh", time.Minute, "minimum interval between two checks of the sources of a file")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect serve -check-only [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	files := append(f.files, fs.Args()...)
+	if len(files) == 0 {
+		fs.Usage()
+		return errors.New("serve: at least one file required")
+	}
+	if !*checkOnly {
+		return errors.New("serve: -check-only is required, the server never writes files")
+	}
+
+	c, err := f.settings()
+	if err != nil {
+		return err
+	}
+	// any client can make the server render the sections of the files: without
+	// -allow-cmd, cmd= sections are reported stale rather than run
+	// checks never record snapshots
+	c.opts.SnapshotDir = ""
+
+	c.logger().Info("serving freshness status", "addr", *addr, "files", len(files))
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           c.statusHandler(files, *refresh),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	return server.ListenAndServe()
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the freshness status endpoint
+// /////////////////////////////////////////////////////////////////////////////
+func TestStatusHandler(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "source.txt"), []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := filepath.Join(tmpDir, "doc.md")
+	content := "<!-- BEGIN SECTION fresh file=source.txt -->\n\ngenerated\n\n<!-- END SECTION fresh -->\n" +
+		"<!-- BEGIN SECTION stale file=source.txt -->\nold\n<!-- END SECTION stale -->\n"
+	if err := os.WriteFile(doc, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	c := updateConfig{opts: gosect.Options{ReBegin: reBegin, ReEnd: reEnd}}
+	server := httptest.NewServer(c.statusHandler([]string{doc}, time.Hour))
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		query  string
+		status int
+	}{
+		{"Every file", "", http.StatusOK},
+		{"One file", "?file=" + doc, http.StatusOK},
+		{"Unknown file", "?file=/etc/passwd", http.StatusNotFound},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := http.Get(server.URL + "/status" + tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.status {
+				t.Fatalf("Expected status %d, got %d", tt.status, resp.StatusCode)
+			}
+			if tt.status != http.StatusOK {
+				return
+			}
+
+			var body struct {
+				Documents []docStatus `json:"documents"`
+			}
+			if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if len(body.Documents) != 1 {
+				t.Fatalf("Expected 1 document, got %d", len(body.Documents))
+			}
+			got := body.Documents[0]
+			if got.Fresh != 1 || got.Total != 2 || len(got.Stale) != 1 || got.Stale[0].Name != "stale" || got.Stale[0].Line != 6 {
+				t.Errorf("Unexpected status %+v", got)
+			}
+			if got.Modified.IsZero() {
+				t.Error("Expected the last update of the file")
+			}
+		})
+	}
+
+	// the server never writes files
+	if got, _ := os.ReadFile(doc); string(got) != content {
+		t.Errorf("Expected %s to be unchanged, got %q", doc, got)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the statuses are cached until the refresh interval or a file change
+// /////////////////////////////////////////////////////////////////////////////
+func TestStatusCache(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "source.txt")
+	if err := os.WriteFile(source, []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	doc := filepath.Join(tmpDir, "doc.md")
+	if err := os.WriteFile(doc, []byte("<!-- BEGIN SECTION s file=source.txt -->\n\ngenerated\n\n<!-- END SECTION s -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	cache := &statusCache{c: updateConfig{opts: gosect.Options{ReBegin: reBegin, ReEnd: reEnd}}, refresh: time.Hour}
+	if got := cache.status(doc); got.Fresh != 1 {
+		t.Fatalf("Expected a fresh section, got %+v", got)
+	}
+
+	// source changes are only seen after the refresh interval
+	if err := os.WriteFile(source, []byte("changed\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := cache.status(doc); got.Fresh != 1 {
+		t.Errorf("Expected the cached status, got %+v", got)
+	}
+	cache.refresh = 0
+	if got := cache.status(doc); got.Fresh != 0 {
+		t.Errorf("Expected a stale section after the refresh interval, got %+v", got)
+	}
+
+	// file changes are seen at once
+	cache.refresh = time.Hour
+	if err := os.WriteFile(doc, []byte("<!-- BEGIN SECTION s file=source.txt -->\n\nchanged\n\n<!-- END SECTION s -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(doc, time.Time{}, time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if got := cache.status(doc); got.Fresh != 1 {
+		t.Errorf("Expected the changed file to be checked again, got %+v", got)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test cmd= sections are reported stale without -allow-cmd
+// /////////////////////////////////////////////////////////////////////////////
+func TestStatusWithoutCommands(t *testing.T) {
+	doc := filepath.Join(t.TempDir(), "doc.md")
+	if err := os.WriteFile(doc, []byte("<!-- BEGIN SECTION s cmd=\"echo generated\" -->\n\ngenerated\n\n<!-- END SECTION s -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	tests := []struct {
+		name          string
+		allowCommands bool
+		fresh         int
+	}{
+		{"Without -allow-cmd", false, 0},
+		{"With -allow-cmd", true, 1},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := updateConfig{opts: gosect.Options{ReBegin: reBegin, ReEnd: reEnd, AllowCommands: tt.allowCommands}}
+			got, err := c.documentStatus(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Fresh != tt.fresh || got.Total != 1 {
+				t.Fatalf("Expected %d fresh section, got %+v", tt.fresh, got)
+			}
+			if !tt.allowCommands && !strings.Contains(got.Stale[0].Error, "-allow-cmd") {
+				t.Errorf("Expected the disabled cmd= error, got %q", got.Stale[0].Error)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test a slow file does not delay the statuses of the other files
+// /////////////////////////////////////////////////////////////////////////////
+func TestStatusCacheLocksPerFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	slow := filepath.Join(tmpDir, "slow.md")
+	if err := os.WriteFile(slow, []byte("<!-- BEGIN SECTION s cmd=\"sleep 1\" -->\n<!-- END SECTION s -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	fast := filepath.Join(tmpDir, "fast.md")
+	if err := os.WriteFile(fast, []byte("<!-- BEGIN SECTION s -->\n<!-- END SECTION s -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	cache := &statusCache{c: updateConfig{opts: gosect.Options{ReBegin: reBegin, ReEnd: reEnd, AllowCommands: true}}, refresh: time.Hour}
+
+	started := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		close(started)
+		cache.status(slow)
+		close(done)
+	}()
+	<-started
+	time.Sleep(100 * time.Millisecond)
+
+	cache.status(fast)
+	select {
+	case <-done:
+		t.Error("Expected the fast file before the end of the slow one")
+	default:
+	}
+	<-done
+}
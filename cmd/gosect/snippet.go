@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"text/template"
+	"time"
+
+	"github.com/badele/gosect"
+)
+
+// defaultSnippetTemplate is used when no -template is given
+const defaultSnippetTemplate = `---
+name: {{ .Name }}
+owner: {{ .Owner }}
+created: {{ .Date }}
+---
+TODO: write the {{ .Name }} snippet
+`
+
+// reSnippetName matches valid snippet and section names
+var reSnippetName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// snippetData is the data context of snippet templates
+type snippetData struct {
+	Name  string
+	Owner string
+	Date  string
+}
+
+// runNewSnippet creates a snippet file and inserts its markers in a document:
+// gosect new-snippet [flags] NAME
+func runNewSnippet(args []string) error {
+	fs := flag.NewFlagSet("new-snippet", flag.ExitOnError)
+	dir := fs.String("dir", "snippets", "directory of snippet files")
+	ext := fs.String("ext", ".md", "snippet file extension")
+	tmplFile := fs.String("template", "", "snippet template file (text/template with .Name, .Owner, .Date)")
+	owner := fs.String("owner", os.Getenv("USER"), "snippet owner")
+	doc := fs.String("doc", "", "document to insert the section markers into")
+	after := fs.String("after", "", "insert the markers after this section instead of at the end of the document")
+	begin := fs.String("begin", gosect.DefaultManifestBegin, "begin marker prefix, from the comment syntax of -doc when known")
+	end := fs.String("end", gosect.DefaultManifestEnd, "end marker prefix, from the comment syntax of -doc when known")
+	suffix := fs.String("suffix", gosect.DefaultManifestSuffix, "text closing marker lines, from the comment syntax of -doc when known")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect new-snippet [flags] NAME")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("new-snippet: one snippet name required")
+	}
+	name := fs.Arg(0)
+	if !reSnippetName.MatchString(name) {
+		return fmt.Errorf("new-snippet: invalid snippet name %q", name)
+	}
+
+	snippetPath := filepath.Join(*dir, name+*ext)
+	if _, err := os.Stat(snippetPath); err == nil {
+		return fmt.Errorf("%s: snippet already exists", snippetPath)
+	}
+
+	content, err := renderSnippet(*tmplFile, snippetData{
+		Name:  name,
+		Owner: *owner,
+		Date:  time.Now().Format(time.DateOnly),
+	})
+	if err != nil {
+		return err
+	}
+
+	// Insert markers first so a rejected document leaves no snippet behind
+	var updated []byte
+	// Use the comment syntax of the document unless markers are given
+	if style, ok := gosect.CommentStyleFor(*doc); ok && !isFlagSet(fs, "begin", "end", "suffix") {
+		*begin, *end, *suffix = style.Markers(gosect.DefaultBegin, gosect.DefaultEnd)
+	}
+
+	if *doc != "" {
+		input, err := os.ReadFile(*doc)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		block := fmt.Sprintf("%s %s file=%s frontmatter=strip%s\n%s %s%s\n",
+			*begin, name, snippetRef(*doc, snippetPath), *suffix, *end, name, *suffix)
+		reBegin, reEnd := gosect.MakeRegex(*begin, *end)
+		updated, err = insertMarkers(input, []byte(block), name, *after, reBegin, reEnd)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *doc, err)
+		}
+	}
+
+	if err := os.MkdirAll(*dir, 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(snippetPath, content, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintln(os.Stderr, "created", snippetPath)
+
+	if *doc == "" {
+		return nil
+	}
+
+	return writeTarget(*doc, updated, false)
+}
+
+// snippetRef returns the file= reference of the snippet at path, relative to
+// the directory of the document doc
+func snippetRef(doc, path string) string {
+	dir, err := filepath.Abs(filepath.Dir(doc))
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	rel, err := filepath.Rel(dir, abs)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+
+	return filepath.ToSlash(rel)
+}
+
+// renderSnippet renders the snippet template, or the default template when
+// path is empty
+func renderSnippet(path string, data snippetData) ([]byte, error) {
+	text := defaultSnippetTemplate
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		text = string(b)
+	}
+
+	tmpl, err := template.New("snippet").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, err
+	}
+
+	return out.Bytes(), nil
+}
+
+// insertMarkers inserts the marker block of section name into content, after
+// the END line of section after or at the end of the document
+func insertMarkers(content, block []byte, name, after string, reBegin, reEnd *regexp.Regexp) ([]byte, error) {
+	sections, err := gosect.FindSectionsBytes(content, reBegin, reEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	pos := -1
+	for _, s := range sections {
+		if s.Name == name {
+			return nil, fmt.Errorf("section %s already exists", name)
+		}
+		if s.Name == after {
+			pos = s.EndIdx
+		}
+	}
+
+	if after == "" {
+		pos = len(content)
+	} else if pos == -1 {
+		return nil, fmt.Errorf("section %s not found", after)
+	} else if next := bytes.IndexByte(content[pos:], '\n'); next != -1 {
+		pos += next + 1
+	} else {
+		pos = len(content)
+	}
+
+	var out bytes.Buffer
+	out.Write(content[:pos])
+	if pos > 0 && content[pos-1] != '\n' {
+		out.WriteByte('\n')
+	}
+	if pos > 0 {
+		out.WriteByte('\n')
+	}
+	out.Write(block)
+	if pos < len(content) {
+		out.WriteByte('\n')
+	}
+	out.Write(content[pos:])
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test insertMarkers function
+// /////////////////////////////////////////////////////////////////////////////
+func TestInsertMarkers(t *testing.T) {
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultManifestBegin, gosect.DefaultManifestEnd)
+	block := "<!-- BEGIN SECTION new file=snippets/new.md -->\n<!-- END SECTION new -->\n"
+	doc := "# Doc\n<!-- BEGIN SECTION a file=a.md -->\n<!-- END SECTION a -->\nFooter\n"
+
+	tests := []struct {
+		name      string
+		content   string
+		section   string
+		after     string
+		want      string
+		wantError bool
+	}{
+		{
+			name:    "Empty document",
+			section: "new",
+			want:    block,
+		},
+		{
+			name:    "Append at end",
+			content: doc,
+			section: "new",
+			want:    doc + "\n" + block,
+		},
+		{
+			name:    "Insert after section",
+			content: doc,
+			section: "new",
+			after:   "a",
+			want:    "# Doc\n<!-- BEGIN SECTION a file=a.md -->\n<!-- END SECTION a -->\n\n" + block + "\nFooter\n",
+		},
+		{
+			name:      "Existing section",
+			content:   doc,
+			section:   "a",
+			wantError: true,
+		},
+		{
+			name:      "Unknown after section",
+			content:   doc,
+			section:   "new",
+			after:     "missing",
+			wantError: true,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := insertMarkers([]byte(tt.content), []byte(strings.ReplaceAll(block, "new", tt.section)), tt.section, tt.after, reBegin, reEnd)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected:\n%q\ngot:\n%q", tt.want, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test renderSnippet default template
+// /////////////////////////////////////////////////////////////////////////////
+func TestRenderSnippet(t *testing.T) {
+	got, err := renderSnippet("", snippetData{Name: "install", Owner: "docs-team", Date: "2025-01-01"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"name: install", "owner: docs-team", "created: 2025-01-01", "TODO: write the install snippet"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Expected snippet to contain %q, got:\n%s", want, got)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test snippet references relative to the document
+// /////////////////////////////////////////////////////////////////////////////
+func TestSnippetRef(t *testing.T) {
+	tests := []struct {
+		doc  string
+		path string
+		want string
+	}{
+		{doc: "README.md", path: "snippets/a.md", want: "snippets/a.md"},
+		{doc: "docs/guide.md", path: "snippets/a.md", want: "../snippets/a.md"},
+		{doc: "docs/guide.md", path: "docs/snippets/a.md", want: "snippets/a.md"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		if got := snippetRef(tt.doc, tt.path); got != tt.want {
+			t.Errorf("snippetRef(%q, %q): expected %q, got %q", tt.doc, tt.path, tt.want, got)
+		}
+	}
+}
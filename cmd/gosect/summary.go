@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sync"
+
+	"github.com/badele/gosect"
+)
+
+// runSummary is what an update run did, printed by -summary
+type runSummary struct {
+	Files             int `json:"files"`
+	SectionsUpdated   int `json:"sectionsUpdated"`
+	SectionsUnchanged int `json:"sectionsUnchanged"`
+	Errors            int `json:"errors"`
+}
+
+// summaryRecorder collects the summary of a run from the concurrent workers
+type summaryRecorder struct {
+	mu      sync.Mutex
+	format  string // text or json
+	summary runSummary
+}
+
+// parseSummaryFormat checks a -summary format: text or json, or empty for
+// no summary
+func parseSummaryFormat(format string) (*summaryRecorder, error) {
+	switch format {
+	case "":
+		return nil, nil
+	case "text", "json":
+		return &summaryRecorder{format: format}, nil
+	}
+
+	return nil, fmt.Errorf("invalid -summary %q: expected text or json", format)
+}
+
+// addSections counts the rendered sections of a target, updated when their
+// content differs between input and result. It does nothing on a nil
+// recorder.
+func (r *summaryRecorder) addSections(input, result []byte, reBegin, reEnd *regexp.Regexp, rendered int) {
+	if r == nil {
+		return
+	}
+
+	updated := changedSections(input, result, reBegin, reEnd)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.summary.SectionsUpdated += updated
+	r.summary.SectionsUnchanged += max(rendered-updated, 0)
+}
+
+// finish records the number of processed files and of failures of the run
+func (r *summaryRecorder) finish(files int, err error) {
+	if r == nil {
+		return
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.summary.Files = files
+	r.summary.Errors = failureCount(err)
+}
+
+// write prints the summary to w, as a line of text or a JSON object
+func (r *summaryRecorder) write(w io.Writer) error {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.summary
+	if r.format == "json" {
+		return json.NewEncoder(w).Encode(s)
+	}
+	_, err := fmt.Fprintf(w, "%d files processed, %d sections updated, %d unchanged, %d errors\n", s.Files, s.SectionsUpdated, s.SectionsUnchanged, s.Errors)
+
+	return err
+}
+
+// changedSections returns the number of sections whose content, markers
+// included, differs between input and result
+func changedSections(input, result []byte, reBegin, reEnd *regexp.Regexp) int {
+	before, err := gosect.FindSectionsBytes(input, reBegin, reEnd)
+	if err != nil {
+		return 0
+	}
+	after, err := gosect.FindSectionsBytes(result, reBegin, reEnd)
+	if err != nil || len(after) != len(before) {
+		return 0
+	}
+
+	n := 0
+	for i, s := range before {
+		if !bytes.Equal(input[s.StartIdx:s.EndIdx], result[after[i].StartIdx:after[i].EndIdx]) {
+			n++
+		}
+	}
+
+	return n
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+
+	"github.com/badele/gosect"
+)
+
+// syncAttrs are the attributes of the sections whose body can be copied back
+// to their source: the body is then the source content itself
+var syncAttrs = map[string]bool{"file": true, "sha": true}
+
+// syncable reports whether the body of s can be copied back to its source
+func syncable(s gosect.Section, opts gosect.Options) bool {
+	// git: and resolved sources are read only
+	if s.SrcFile == "" || opts.SchemeSource(s) {
+		return false
+	}
+	for key := range s.Attrs {
+		if !syncAttrs[key] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sourceBody returns the body of s in content as source content, without the
+// indentation of its BEGIN marker
+func sourceBody(content []byte, s gosect.Section) []byte {
+	lineStart := bytes.LastIndexByte(content[:s.StartIdx], '\n') + 1
+	indent := content[lineStart:s.StartIdx]
+	indent = indent[:len(indent)-len(bytes.TrimLeft(indent, " \t"))]
+
+	var out bytes.Buffer
+	for line := range bytes.Lines(sectionBody(content, s)) {
+		out.Write(bytes.TrimPrefix(line, indent))
+	}
+	out.WriteByte('\n')
+
+	return out.Bytes()
+}
+
+// recordedBody returns the body of s in content as checksummed by its sha=
+// attribute: the lines between its BEGIN and END lines
+func recordedBody(content []byte, s gosect.Section) []byte {
+	start := s.StartIdx + bytes.IndexByte(content[s.StartIdx:], '\n') + 1
+	end := bytes.LastIndexByte(content[:s.EndIdx], '\n') + 1
+	if end < start {
+		return nil
+	}
+
+	return content[start:end]
+}
+
+// changedSides reports whether the body of s in input and its source changed
+// since the checksum recorded in its sha= attribute
+func changedSides(input []byte, s gosect.Section, opts gosect.Options, reBegin, reEnd *regexp.Regexp) (bool, bool, error) {
+	recorded, ok := s.Attrs["sha"]
+	if !ok {
+		return false, false, fmt.Errorf("section %s has no sha= checksum telling whether it or its source changed (run update -checksum first, or use -prefer source or target)", s.Name)
+	}
+	bodyChanged := gosect.BodyChecksum(recordedBody(input, s)) != recorded
+
+	// render the section from its source, which records the checksum of
+	// the generated body, without writing snapshots or logging
+	opts.Force, opts.Checksum, opts.SnapshotDir = true, true, ""
+	opts.Logger = slog.New(slog.DiscardHandler)
+	result, err := gosect.Replace(input, []gosect.Section{s}, opts)
+	if err != nil {
+		return false, false, err
+	}
+	sections, err := gosect.FindSectionsBytes(result, reBegin, reEnd)
+	if err != nil {
+		return false, false, err
+	}
+	i := slices.IndexFunc(sections, func(r gosect.Section) bool { return r.StartIdx == s.StartIdx })
+	if i == -1 {
+		return false, false, fmt.Errorf("section %s not found once rendered", s.Name)
+	}
+
+	return bodyChanged, sections[i].Attrs["sha"] != recorded, nil
+}
+
+// syncFile copies the sections of the target file at path whose body differs
+// from their source in the direction of prefer: source, target, or newest
+// (the side changed since the sha= checksum of the section, which fails when
+// both changed). written records the sources copied from their sections,
+// which must not disagree.
+func (c updateConfig) syncFile(path, prefer string, written map[string][]byte) error {
+	input, err := readText(path)
+	if err != nil {
+		return err
+	}
+
+	reBegin, reEnd := c.targetMarkers(path)
+	sections, err := gosect.FindSectionsBytes(input, reBegin, reEnd)
+	if err != nil {
+		return gosect.FileErrors(path, err)
+	}
+	sections, err = gosect.FilterSections(sections, c.only)
+	if err != nil {
+		return err
+	}
+
+	opts, err := c.targetOptions(path)
+	if err != nil {
+		return err
+	}
+
+	var fromSource, toSource []string
+	for _, s := range sections {
+		if gosect.Frozen(s) {
+			continue
+		}
+		if result, err := gosect.Replace(input, []gosect.Section{s}, opts); err == nil && bytes.Equal(result, input) {
+			continue
+		}
+
+		// sections which cannot be copied back keep the edits of the target
+		// with -prefer target
+		if !syncable(s, opts) && prefer == "target" {
+			c.logger().Warn("section cannot be copied to its source, left unchanged", "file", path, "section", s.Name)
+			continue
+		}
+		if !syncable(s, opts) || prefer == "source" {
+			fromSource = append(fromSource, s.Name)
+			continue
+		}
+
+		src, err := opts.SourcePath(s)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		if prefer == "newest" {
+			bodyChanged, sourceChanged, err := changedSides(input, s, opts, reBegin, reEnd)
+			switch {
+			case err != nil:
+				return gosect.FileErrors(path, err)
+			case bodyChanged && sourceChanged:
+				return fmt.Errorf("%s: section %s and its source %s both changed since they were last synced (use -prefer source or -prefer target)", path, s.Name, src)
+			case !bodyChanged:
+				fromSource = append(fromSource, s.Name)
+				continue
+			}
+		}
+
+		body := sourceBody(input, s)
+		if previous, ok := written[src]; ok && !bytes.Equal(previous, body) {
+			return fmt.Errorf("%s: section %s: %s was already synced from a different section body", path, s.Name, src)
+		}
+		written[src] = body
+		if err := writeTarget(src, body, c.fsync); err != nil {
+			return err
+		}
+		c.logger().Info("source synced from section", "file", path, "section", s.Name, "source", src)
+		toSource = append(toSource, s.Name)
+	}
+
+	// Update the sections of newer sources, then the checksums of the
+	// sections copied to their source, whose body is already up to date
+	if len(fromSource) > 0 {
+		c.only = fromSource
+		if _, err := c.updateFile(path); err != nil {
+			return err
+		}
+	}
+	if len(toSource) > 0 {
+		c.only = toSource
+		c.opts.Force = true
+		if _, err := c.updateFile(path); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runSync copies every changed section to or from its source, whichever
+// changed since they were last synced:
+// gosect sync [-prefer newest|source|target] [flags] file...
+func runSync(args []string) error {
+	fs := flag.NewFlagSet("sync", flag.ExitOnError)
+	f := addUpdateFlags(fs)
+	prefer := fs.String("prefer", "newest", "direction of changed sections: newest (the side changed since the sha= checksum of the section), source or target")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect sync [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	files := append(f.files, fs.Args()...)
+	if len(files) == 0 {
+		fs.Usage()
+		return errors.New("sync: at least one file required")
+	}
+	switch *prefer {
+	case "newest", "source", "target":
+	default:
+		return fmt.Errorf("sync: unknown -prefer %s (expected newest, source or target)", *prefer)
+	}
+
+	c, err := f.settings()
+	if err != nil {
+		return err
+	}
+	if c.diff || c.stdout || c.tx != nil {
+		return errors.New("sync: -stdout, -diff and -transactional are not supported")
+	}
+	// newest tells the changed side from the checksums of the sections
+	c.opts.Checksum = true
+
+	written := map[string][]byte{}
+	var errs []error
+	for _, path := range files {
+		if err := c.syncFile(path, *prefer, written); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test syncing sections with their sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestSyncFile(t *testing.T) {
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	c := updateConfig{opts: gosect.Options{ReBegin: reBegin, ReEnd: reEnd, Checksum: true}}
+
+	// checksum of the body synced last, from the base source
+	synced := " sha=" + gosect.BodyChecksum([]byte("\n  base\n\n"))
+
+	tests := []struct {
+		name       string
+		prefer     string
+		attrs      string
+		body       string
+		source     string
+		wantBody   string
+		wantSource string
+		wantErr    string
+	}{
+		{"Edited target", "newest", synced, "edited", "base", "edited", "edited", ""},
+		{"Edited source", "newest", synced, "base", "generated", "generated", "generated", ""},
+		{"Edited target and source", "newest", synced, "edited", "generated", "edited", "generated", "both changed since they were last synced"},
+		{"Without checksum", "newest", "", "edited", "generated", "edited", "generated", "has no sha= checksum"},
+		{"Prefer source", "source", "", "edited", "generated", "generated", "generated", ""},
+		{"Prefer target", "target", "", "edited", "generated", "edited", "edited", ""},
+		{"One-way section", "target", " transform=upper", "edited", "generated", "edited", "generated", ""},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			source := filepath.Join(tmpDir, "source.txt")
+			doc := filepath.Join(tmpDir, "doc.txt")
+			if err := os.WriteFile(source, []byte(tt.source+"\n"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			content := "  BEGIN SECTION a file=source.txt" + tt.attrs + "\n\n  " + tt.body + "\n\n  END SECTION a\n"
+			if err := os.WriteFile(doc, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := c.syncFile(doc, tt.prefer, map[string][]byte{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+
+			if got, _ := os.ReadFile(doc); !strings.Contains(string(got), "\n\n  "+tt.wantBody+"\n\n") {
+				t.Errorf("Expected target body %q, got %q", tt.wantBody, got)
+			}
+			if got, _ := os.ReadFile(source); string(got) != tt.wantSource+"\n" {
+				t.Errorf("Expected source %q, got %q", tt.wantSource+"\n", got)
+			}
+		})
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package main
+
+// syncDir is a no-op on platforms that cannot sync directories
+func syncDir(dir string) error {
+	return nil
+}
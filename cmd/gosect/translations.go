@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/badele/gosect"
+)
+
+// compareTranslations writes the sections of the document at path missing or
+// stale in its translations, and the other way round, to w, and returns the
+// number of issues. markers returns the marker regexes of each document.
+func compareTranslations(w io.Writer, path string, markers func(string) (*regexp.Regexp, *regexp.Regexp)) (int, error) {
+	translations, err := gosect.Translations(path)
+	if err != nil {
+		return 0, err
+	}
+	if len(translations) == 0 {
+		return 0, nil
+	}
+
+	reBegin, reEnd := markers(path)
+	content, sections, err := readSections(path, reBegin, reEnd)
+	if err != nil {
+		return 0, err
+	}
+
+	n := 0
+	for _, translation := range translations {
+		reBegin, reEnd := markers(translation)
+		translatedContent, translated, err := readSections(translation, reBegin, reEnd)
+		if err != nil {
+			return n, err
+		}
+
+		docIssues, translationIssues := gosect.CompareTranslation(sections, translated, content, translatedContent)
+		for _, issue := range docIssues {
+			fmt.Fprintf(w, "%s:%s (%s)\n", path, issue, translation)
+		}
+		for _, issue := range translationIssues {
+			fmt.Fprintf(w, "%s:%s (%s)\n", translation, issue, path)
+		}
+		n += len(docIssues) + len(translationIssues)
+	}
+
+	return n, nil
+}
+
+// readSections returns the content of the target file at path and its
+// sections
+func readSections(path string, reBegin, reEnd *regexp.Regexp) ([]byte, []gosect.Section, error) {
+	content, err := readText(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	sections, err := gosect.FindSectionsBytes(content, reBegin, reEnd)
+	if err != nil {
+		return nil, nil, gosect.FileErrors(path, err)
+	}
+
+	return content, sections, nil
+}
+
+// runTranslations reports the sections present or updated in a document but
+// missing or stale in its translations, README.fr.md for README.md:
+// gosect translations [flags] file...
+func runTranslations(args []string) error {
+	fs := flag.NewFlagSet("translations", flag.ExitOnError)
+	markers := addMarkerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect translations [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("translations: at least one file required")
+	}
+
+	if _, err := markers.loadConfig(); err != nil {
+		return err
+	}
+
+	// Compare each original document once, whichever of its translations
+	// are given
+	var docs []string
+	for _, path := range fs.Args() {
+		if doc := gosect.OriginalDocument(path); !slices.Contains(docs, doc) {
+			docs = append(docs, doc)
+		}
+	}
+
+	issues := 0
+	for _, doc := range docs {
+		n, err := compareTranslations(os.Stdout, doc, markers.regex)
+		if err != nil {
+			return err
+		}
+		issues += n
+	}
+
+	if issues > 0 {
+		return fmt.Errorf("translations: %d issue(s)", issues)
+	}
+
+	return nil
+}
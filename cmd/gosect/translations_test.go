@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test reporting the sections missing or stale in translations
+// /////////////////////////////////////////////////////////////////////////////
+func TestCompareTranslations(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"README.md":    "BEGIN SECTION usage file=usage.txt\nnew\nEND SECTION usage\nBEGIN SECTION flags file=flags.txt\n-v\nEND SECTION flags\n",
+		"README.fr.md": "BEGIN SECTION usage file=usage.txt\nold\nEND SECTION usage\n",
+		"GUIDE.md":     "BEGIN SECTION usage file=usage.txt\nnew\nEND SECTION usage\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	markers := func(string) (*regexp.Regexp, *regexp.Regexp) {
+		return gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	}
+
+	readme, translation := filepath.Join(tmpDir, "README.md"), filepath.Join(tmpDir, "README.fr.md")
+	var out bytes.Buffer
+	n, err := compareTranslations(&out, readme, markers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := readme + ":4:15: section flags is missing in the translation (" + translation + ")\n" +
+		translation + ":1:15: section usage differs from the original document, generated from the same source: one of them is stale (" + readme + ")\n"
+	if n != 2 || out.String() != want {
+		t.Errorf("Expected 2 issues %q, got %d %q", want, n, out.String())
+	}
+
+	// Documents without translations have no issue
+	out.Reset()
+	if n, err := compareTranslations(&out, filepath.Join(tmpDir, "GUIDE.md"), markers); err != nil || n != 0 || out.Len() != 0 {
+		t.Errorf("Expected no issue, got %d %q %v", n, out.String(), err)
+	}
+}
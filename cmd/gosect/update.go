@@ -0,0 +1,748 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/badele/gosect"
+)
+
+// updateFlags holds the flags shared by the update, check and diff
+// subcommands
+type updateFlags struct {
+	markers         *markerFlags
+	files, only     stringList
+	stdout          *bool
+	verbose         *bool
+	quiet           *bool
+	summary         *string
+	fsync           *bool
+	renderTemplates *bool
+	values          *string
+	vars            *string
+	varList         stringList
+	maxDepth        *int
+	mmapThreshold   *int64
+	maxSourceSize   *int64
+	onOversize      *string
+	validateDiagram *bool
+	lang            *string
+	editorConfig    *bool
+	underHeading    *string
+	headingAttrs    *string
+	regionBegin     *string
+	regionEnd       *string
+	order           *string
+	orderFile       *string
+	orderExact      *bool
+	checksum        *bool
+	force           *bool
+	merge           *bool
+	snapshotDir     *string
+	diff            *bool
+	showWhitespace  *bool
+	color           *string
+	backup          backupFlag
+	jobs            *int
+	allowCmd        *bool
+	cmdTimeout      *time.Duration
+	vendored        *bool
+	vendorDir       *string
+	httpCache       *bool
+	cacheDir        *string
+	base            *string
+	expandEnv       *bool
+	keepGoing       *bool
+	maxFailures     *int
+	strict          *bool
+	transactional   *bool
+	logLevel        *string
+	logFormat       *string
+	onMissing       *string
+	preCmds         stringList
+	postCmds        stringList
+	generate        *bool
+	staged          *bool
+	exitCode        *bool
+	interactive     *bool
+}
+
+// addUpdateFlags registers the update flags on fs
+func addUpdateFlags(fs *flag.FlagSet) *updateFlags {
+	f := &updateFlags{}
+	f.markers = addMarkerFlags(fs)
+	fs.Var(&f.files, "file", "input file path (repeatable, files can also be given as arguments)")
+	f.stdout = fs.Bool("stdout", false, "print to stdout instead of writing file")
+	f.verbose = fs.Bool("verbose", false, "log details about processed sections (same as -log-level debug)")
+	f.logLevel = fs.String("log-level", "warn", "minimum level of logged records: debug, info, warn or error")
+	f.quiet = fs.Bool("quiet", false, "only log errors")
+	f.summary = fs.String("summary", "", "print a summary of the run to stderr: text or json")
+	f.logFormat = fs.String("log-format", "text", "format of logged records: text or json")
+	f.fsync = fs.Bool("fsync", false, "fsync written files and their directory")
+	f.renderTemplates = fs.Bool("render-templates", false, "render every source through text/template")
+	f.values = fs.String("values", "", "JSON values file exposed to templates as .Values")
+	f.vars = fs.String("vars", "", "file of NAME=value variables replacing {{NAME}} placeholders in sources")
+	fs.Var(&f.varList, "var", "NAME=value variable replacing {{NAME}} placeholders in sources, overriding -vars (repeatable)")
+	f.maxDepth = fs.Int("max-depth", gosect.DefaultMaxDepth, "maximum depth of nested section expansion")
+	f.mmapThreshold = fs.Int64("mmap-threshold", gosect.DefaultMmapThreshold, "source size in bytes above which sources are memory-mapped (-1 disables)")
+	f.maxSourceSize = fs.Int64("max-source-size", 0, "maximum size in bytes of file= and url= sources (0 disables)")
+	f.onOversize = fs.String("on-oversize", string(gosect.OversizeError), "handling of file= and url= sources larger than -max-source-size: error or truncate (keep the lines within the limit with a warning)")
+	f.validateDiagram = fs.Bool("validate-diagrams", false, "check the syntax of mermaid and plantuml sections")
+	f.lang = fs.String("lang", gosect.DefaultLanguage, "language of target files without a language code in their name, replacing {{.lang}} in source attributes (README.fr.md is fr)")
+	f.editorConfig = fs.Bool("editorconfig", false, "honor the .editorconfig settings of target files (end_of_line, indent_style, insert_final_newline, charset)")
+	f.regionBegin = fs.String("region-begin", gosect.DefaultRegionBegin, "begin marker of named regions in source files")
+	f.regionEnd = fs.String("region-end", gosect.DefaultRegionEnd, "end marker of named regions in source files")
+	f.order = fs.String("order", "", "comma separated list of section names that must appear in this order")
+	f.orderFile = fs.String("order-file", "", "file listing section names, one per line, that must appear in this order")
+	f.orderExact = fs.Bool("order-exact", false, "require the document to contain exactly the ordered sections")
+	f.checksum = fs.Bool("checksum", false, "record a sha= checksum of generated content on BEGIN markers")
+	f.force = fs.Bool("force", false, "overwrite sections edited by hand since they were generated")
+	f.merge = fs.Bool("merge", false, "merge hand edits of sections with the changes of their source (implies -checksum)")
+	f.snapshotDir = fs.String("snapshot-dir", gosect.DefaultSnapshotDir, "directory recording generated sections for -merge")
+	f.underHeading = fs.String("under-heading", "", "manage the content under this Markdown heading, such as \"## Usage\", inserting its markers on the first run")
+	f.headingAttrs = fs.String("heading-attrs", "", "source attributes of the section inserted by -under-heading, such as file=docs/usage.md")
+	fs.Var(&f.only, "section", "only update sections matching this name or glob (repeatable)")
+	f.diff = fs.Bool("diff", false, "print a diff of the changes instead of writing file")
+	f.showWhitespace = fs.Bool("show-whitespace", false, "render tabs, trailing spaces and CR characters visibly in -diff output")
+	f.interactive = fs.Bool("interactive", false, "show the diff of each changed section and ask whether to apply it: y (yes), n (no), a (all remaining) or q (quit)")
+	f.color = fs.String("color", "auto", "color -diff output: auto (on terminals), always or never")
+	fs.Var(&f.backup, "backup", "save the original file with the .bak suffix, or -backup=suffix, before overwriting it")
+	f.jobs = fs.Int("jobs", runtime.NumCPU(), "number of files processed concurrently")
+	f.allowCmd = fs.Bool("allow-cmd", false, "allow cmd= sources and resolver plugins, which run commands")
+	f.cmdTimeout = fs.Duration("cmd-timeout", gosect.DefaultCommandTimeout, "kill cmd= sources and resolver plugins running longer")
+	f.vendored = fs.Bool("vendored", false, "read url= sources from their vendored copy when available")
+	f.vendorDir = fs.String("vendor-dir", gosect.DefaultVendorDir, "vendor directory used by -vendored")
+	f.httpCache = fs.Bool("http-cache", false, "cache url= sources on disk and revalidate them with conditional requests")
+	f.cacheDir = fs.String("cache-dir", gosect.DefaultCacheDir, "cache directory used by -http-cache")
+	f.expandEnv = fs.Bool("expand-env", false, "expand $VAR and ${VAR} in file=, url= and sql= attributes (cmd= commands are expanded by the shell)")
+	f.keepGoing = fs.Bool("keep-going", false, "write the sections which update successfully and report the failed ones")
+	f.maxFailures = fs.Int("max-failures", 0, "only fail when more sections or files fail (implies -keep-going)")
+	f.strict = fs.Bool("strict", false, "fail on orphaned, misordered, duplicate or overlapping markers")
+	f.transactional = fs.Bool("transactional", false, "only write the files once every file updated successfully")
+	f.onMissing = fs.String("on-missing", string(gosect.MissingError), "handling of missing file= sources: error, warn (keep the current body with a warning) or skip")
+	fs.Var(&f.preCmds, "pre-cmd", "shell command run before sources are read (repeatable)")
+	fs.Var(&f.postCmds, "post-cmd", "shell command run after targets are written (repeatable)")
+	f.generate = fs.Bool("generate", false, "go:generate mode: quiet, only write changed files, exit like -exit-code")
+	f.exitCode = fs.Bool("exit-code", false, "exit with status 1 when files changed or need changes, 2 on marker errors and 3 on file or source errors")
+	f.staged = fs.Bool("staged", false, "only update the files staged in git, restricted to the given files if any, and stage the results")
+	f.base = fs.String("base", "", "directory relative file= sources are resolved from (default: the directory of each file)")
+
+	return f
+}
+
+// settings builds the update settings from the parsed flags and the
+// configuration file
+func (f *updateFlags) settings() (updateConfig, error) {
+	cfg, err := f.markers.loadConfig()
+	if err != nil {
+		return updateConfig{}, err
+	}
+
+	reBegin, reEnd := f.markers.nested()
+
+	level := *f.logLevel
+	switch {
+	case *f.verbose:
+		level = "debug"
+	case *f.quiet, *f.generate && level == "warn":
+		level = "error"
+	}
+	logger, err := newLogger(os.Stderr, level, *f.logFormat)
+	if err != nil {
+		return updateConfig{}, err
+	}
+
+	onMissing, err := gosect.ParseMissingPolicy(*f.onMissing)
+	if err != nil {
+		return updateConfig{}, err
+	}
+
+	onOversize, err := gosect.ParseOversizePolicy(*f.onOversize)
+	if err != nil {
+		return updateConfig{}, err
+	}
+
+	color, err := useColor(*f.color, os.Stdout)
+	if err != nil {
+		return updateConfig{}, err
+	}
+
+	summary, err := parseSummaryFormat(*f.summary)
+	if err != nil {
+		return updateConfig{}, err
+	}
+
+	heading, err := newUnderHeading(*f.underHeading, *f.headingAttrs)
+	if err != nil {
+		return updateConfig{}, err
+	}
+
+	c := updateConfig{
+		opts: gosect.Options{
+			Verbose:          *f.verbose,
+			Logger:           logger,
+			OnMissing:        onMissing,
+			RenderTemplates:  *f.renderTemplates,
+			ReBegin:          reBegin,
+			ReEnd:            reEnd,
+			MaxDepth:         *f.maxDepth,
+			MmapThreshold:    *f.mmapThreshold,
+			MaxSourceSize:    *f.maxSourceSize,
+			OnOversize:       onOversize,
+			ValidateDiagrams: *f.validateDiagram,
+			RegionBegin:      *f.regionBegin,
+			RegionEnd:        *f.regionEnd,
+			Checksum:         *f.checksum || *f.merge,
+			Force:            *f.force,
+			AllowCommands:    *f.allowCmd,
+			CommandTimeout:   *f.cmdTimeout,
+			Plugins:          *f.allowCmd,
+			Roots:            cfg.Roots,
+			ExpandEnv:        *f.expandEnv,
+			KeepGoing:        *f.keepGoing || *f.maxFailures > 0,
+		},
+		only:           f.only,
+		lang:           *f.lang,
+		editorConfig:   *f.editorConfig,
+		order:          *f.order,
+		orderFile:      *f.orderFile,
+		orderExact:     *f.orderExact,
+		stdout:         *f.stdout,
+		diff:           *f.diff,
+		showWhitespace: *f.showWhitespace,
+		color:          color,
+		fsync:          *f.fsync,
+		backup:         f.backup.suffix,
+		markers:        f.markers.regex,
+		base:           *f.base,
+		maxFailures:    *f.maxFailures,
+		strict:         *f.strict,
+		preCmds:        append(cfg.Hooks.Pre, f.preCmds...),
+		postCmds:       append(cfg.Hooks.Post, f.postCmds...),
+		profiles:       cfg.Profiles,
+		summary:        summary,
+		heading:        heading,
+	}
+	if *f.interactive {
+		// prompts are written to stderr, colored like -diff
+		color, err := useColor(*f.color, os.Stderr)
+		if err != nil {
+			return c, err
+		}
+		c.approver = &approver{
+			in:             bufio.NewReader(os.Stdin),
+			out:            os.Stderr,
+			showWhitespace: *f.showWhitespace,
+			color:          color,
+		}
+	}
+	if *f.transactional {
+		c.tx = &transaction{}
+	}
+	if *f.generate {
+		if c.stdout || c.diff {
+			return c, errors.New("-generate writes files: -stdout and -diff are not supported")
+		}
+		c.changed = &changedFiles{}
+	}
+	if *f.staged {
+		if c.stdout || c.diff {
+			return c, errors.New("-staged writes files: -stdout and -diff are not supported")
+		}
+		c.staged = true
+		if c.changed == nil {
+			c.changed = &changedFiles{}
+		}
+	}
+	if *f.vendored {
+		c.opts.VendorDir = *f.vendorDir
+	}
+	if *f.httpCache {
+		c.opts.CacheDir = *f.cacheDir
+	}
+	if *f.merge {
+		c.opts.SnapshotDir = *f.snapshotDir
+	}
+	if *f.values != "" {
+		values, err := gosect.LoadValues(*f.values)
+		if err != nil {
+			return c, err
+		}
+		c.opts.Values = values
+	}
+	if *f.vars != "" {
+		vars, err := gosect.LoadVars(*f.vars)
+		if err != nil {
+			return c, err
+		}
+		c.opts.Vars = vars
+	}
+	for _, assignment := range f.varList {
+		name, value, err := gosect.ParseVar(assignment)
+		if err != nil {
+			return c, err
+		}
+		if c.opts.Vars == nil {
+			c.opts.Vars = map[string]string{}
+		}
+		c.opts.Vars[name] = value
+	}
+
+	return c, nil
+}
+
+// runUpdateMode parses the update flags of a subcommand, lets mode adjust the
+// settings, and processes the target files
+func runUpdateMode(name string, args []string, mode func(*updateConfig)) error {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	f := addUpdateFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: gosect %s [flags] file...\n", name)
+		if name == "update" {
+			fmt.Fprintln(fs.Output(), "\nSubcommands: update (default), check, diff, list, extract, assemble, new-snippet, vendor, badge, review, fix, serve, daemon, graph, validate, schema, sync, verify, hook, translations")
+		}
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	// -generate runs follow the -exit-code contract
+	exitCode := *f.exitCode || *f.generate
+
+	files := append(f.files, fs.Args()...)
+	if len(files) == 0 && !*f.staged {
+		return usageError(exitCode, errors.New("-file required"))
+	}
+
+	c, err := f.settings()
+	if err != nil {
+		return usageError(exitCode, err)
+	}
+	mode(&c)
+	// snapshots record the bodies written to targets
+	c.opts.ReadOnlySnapshots = c.check || c.diff || c.stdout
+
+	if c.staged && c.check {
+		return usageError(exitCode, errors.New("-staged is not supported by check"))
+	}
+	if c.approver != nil && (c.check || c.diff || c.stdout) {
+		return usageError(exitCode, errors.New("-interactive writes files: check, diff and -stdout are not supported"))
+	}
+	if exitCode && c.changed == nil {
+		c.changed = &changedFiles{}
+	}
+
+	// With -exit-code, the outcome of the run is its exit status
+	err = c.process(files, *f.jobs)
+	writes := !c.check && !c.diff && !c.stdout
+	switch {
+	case err != nil && exitCode:
+		return exitError{exitStatus(err), err}
+	case err != nil:
+		return err
+	case c.changed == nil || len(c.changed.list()) == 0:
+		return nil
+	case exitCode && !writes:
+		return exitError{exitChanges, fmt.Errorf("%d file(s) to update", len(c.changed.list()))}
+	case exitCode:
+		return exitError{exitChanges, fmt.Errorf("%d file(s) updated", len(c.changed.list()))}
+	}
+
+	return nil
+}
+
+// process updates the target files at paths with jobs workers, between the
+// pre and post hooks
+func (c updateConfig) process(files []string, jobs int) error {
+	var err error
+
+	// Only process the staged files, which may be none
+	if c.staged {
+		staged, partial, err := stagedTargets(files)
+		if err != nil {
+			return err
+		}
+		for _, path := range partial {
+			c.logger().Warn("staged file has unstaged changes, not updated (stage or stash them first)", "file", path)
+		}
+		if files = staged; len(files) == 0 {
+			return nil
+		}
+	}
+
+	// Process every file once, in the same order whatever the arguments
+	if c.changed != nil {
+		slices.Sort(files)
+		files = slices.Compact(files)
+	}
+
+	// Prompt for the changes of one target after the other
+	if c.approver != nil {
+		jobs = 1
+	}
+
+	// Hooks only run around updates writing targets
+	readOnly := c.check || c.diff || c.stdout
+	if !readOnly {
+		if err := c.runHooks("pre", c.preCmds, os.Stderr); err != nil {
+			return err
+		}
+	}
+	err = c.updateFiles(os.Stdout, files, jobs)
+	if err := c.summary.write(os.Stderr); err != nil {
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	// Post hooks process written targets
+	if readOnly {
+		return nil
+	}
+	if err := c.runHooks("post", c.postCmds, os.Stderr); err != nil {
+		return err
+	}
+
+	// Stage the updated files so the commit includes them
+	if c.staged {
+		return stageFiles(c.changed.list())
+	}
+	return nil
+}
+
+// runUpdate updates the sections of target files:
+// gosect [update] [flags] file...
+func runUpdate(args []string) error {
+	return runUpdateMode("update", args, func(*updateConfig) {})
+}
+
+// runCheck reports the target files whose sections are out of date, without
+// writing them: gosect check [flags] file...
+func runCheck(args []string) error {
+	return runUpdateMode("check", args, func(c *updateConfig) {
+		c.check = true
+	})
+}
+
+// runDiff prints the changes an update would make:
+// gosect diff [flags] file...
+func runDiff(args []string) error {
+	return runUpdateMode("diff", args, func(c *updateConfig) {
+		c.diff = true
+	})
+}
+
+// updateConfig holds the settings of an update run, shared by every target
+type updateConfig struct {
+	opts           gosect.Options
+	only           []string
+	lang           string // language of targets without a language code
+	editorConfig   bool   // honor the .editorconfig settings of targets
+	order          string
+	orderFile      string
+	orderExact     bool
+	stdout         bool
+	diff           bool
+	showWhitespace bool
+	color          bool
+	fsync          bool
+	backup         string
+	check          bool
+	markers        func(string) (*regexp.Regexp, *regexp.Regexp)
+	base           string
+	maxFailures    int
+	strict         bool
+	tx             *transaction
+	preCmds        []string
+	postCmds       []string
+	profiles       map[string]gosect.ConfigProfile
+	changed        *changedFiles // files written, with -generate or -staged
+	staged         bool
+	summary        *summaryRecorder // counts printed by -summary
+	approver       *approver        // prompts of -interactive
+	heading        *underHeading    // section inserted by -under-heading
+}
+
+// targetMarkers returns the marker regexes of the target file at path
+func (c updateConfig) targetMarkers(path string) (*regexp.Regexp, *regexp.Regexp) {
+	if c.markers != nil {
+		return c.markers(path)
+	}
+
+	return c.opts.ReBegin, c.opts.ReEnd
+}
+
+// targetOptions returns the rendering options of the target file at path:
+// sources resolve from its directory unless -base is given, {{.lang}} is its
+// language, and its .editorconfig settings apply with -editorconfig
+func (c updateConfig) targetOptions(path string) (gosect.Options, error) {
+	opts := c.opts
+	opts.BaseDir = cmp.Or(c.base, filepath.Dir(path))
+	opts.Format = gosect.FormatFor(path)
+	opts.Language = cmp.Or(gosect.DocumentLanguage(path), c.lang)
+	if c.editorConfig {
+		ec, err := gosect.LoadEditorConfig(path)
+		if err != nil {
+			return opts, err
+		}
+		opts.EditorConfig = ec
+	}
+
+	return opts, nil
+}
+
+// updateFile updates the sections of the target file at path. It returns the
+// output to print instead of writing the file, with -stdout or -diff.
+func (c updateConfig) updateFile(path string) ([]byte, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Process UTF-16 files as UTF-8
+	input, enc, err := gosect.DecodeText(raw)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	// Insert the markers of -under-heading on the first run
+	reBegin, reEnd := c.targetMarkers(path)
+	original := input
+	if input, err = c.heading.adopt(path, input, reBegin, reEnd); err != nil {
+		return nil, err
+	}
+
+	// Nothing to do when the file contains no marker
+	if !gosect.HasMarkers(input, reBegin) {
+		if c.stdout && !c.diff && !c.check {
+			return raw, nil
+		}
+		return nil, nil
+	}
+
+	// Report every marker problem before pairing markers
+	if c.strict {
+		var errs []error
+		for _, issue := range gosect.CheckMarkers(input, reBegin, reEnd) {
+			errs = append(errs, fmt.Errorf("%s:%w", path, issue))
+		}
+		if len(errs) > 0 {
+			return nil, markerError{errors.Join(errs...)}
+		}
+	}
+
+	// Find all sections
+	sections, err := gosect.FindSectionsBytes(input, reBegin, reEnd)
+	if err != nil {
+		return nil, markerError{gosect.FileErrors(path, err)}
+	}
+
+	// Check section ordering
+	if err := checkOrder(sections, c.order, c.orderFile, c.orderExact); err != nil {
+		return nil, markerError{fmt.Errorf("%s: %w", path, err)}
+	}
+
+	// Keep only the requested sections
+	sections, err = gosect.FilterSections(sections, c.only)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve sources from the target directory unless -base is given
+	opts, err := c.targetOptions(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Replace all sections. With -keep-going, failed sections are left
+	// unchanged and reported after the others are written.
+	result, err := gosect.Replace(input, sections, opts)
+	if sectionErrs, ok := err.(gosect.SectionErrors); ok {
+		result = c.approver.approve(path, original, result, reBegin, reEnd)
+		result, enc = finishResult(opts.EditorConfig, original, result, enc)
+		c.summary.addSections(input, result, reBegin, reEnd, len(sections)-len(sectionErrs))
+		output, err := c.writeResult(path, original, result, enc)
+		return output, errors.Join(gosect.FileErrors(path, sectionErrs), err)
+	}
+	if err != nil {
+		return nil, gosect.FileErrors(path, err)
+	}
+	result = c.approver.approve(path, original, result, reBegin, reEnd)
+	result, enc = finishResult(opts.EditorConfig, original, result, enc)
+	c.summary.addSections(input, result, reBegin, reEnd, len(sections))
+
+	return c.writeResult(path, original, result, enc)
+}
+
+// finishResult applies the insert_final_newline and charset settings of ec
+// to the result of the update of the target whose decoded content is input.
+// Targets left unchanged are kept as they are.
+func finishResult(ec gosect.EditorConfig, input, result []byte, enc gosect.Encoding) ([]byte, gosect.Encoding) {
+	if bytes.Equal(input, result) {
+		return result, enc
+	}
+
+	return ec.FinalNewline(result), ec.Encoding(enc)
+}
+
+// writeResult checks, prints or writes the updated content result of the
+// target file at path, whose decoded content is input
+func (c updateConfig) writeResult(path string, input, result []byte, enc gosect.Encoding) ([]byte, error) {
+	// Record the changed files for -generate, -staged and -exit-code
+	if c.changed != nil && !bytes.Equal(input, result) {
+		c.changed.add(path)
+	}
+
+	// Report out of date files without writing them
+	if c.check {
+		if !bytes.Equal(input, result) {
+			return nil, fmt.Errorf("%s: %w", path, errOutOfDate)
+		}
+		return nil, nil
+	}
+
+	// Output result to stdout
+	if c.stdout {
+		return gosect.EncodeText(result, enc), nil
+	}
+
+	// Show changes without writing them
+	if c.diff {
+		var out bytes.Buffer
+		err := writeDiff(&out, path, input, result, c.showWhitespace, c.color)
+		return out.Bytes(), err
+	}
+
+	// Unchanged files are not written, keeping their modification time for
+	// build tools
+	if bytes.Equal(input, result) {
+		c.logger().Debug("file unchanged", "file", path)
+		return nil, nil
+	}
+
+	// Stage the result until every target rendered
+	if c.tx != nil {
+		t, err := stageTarget(path, gosect.EncodeText(result, enc))
+		if err != nil {
+			return nil, err
+		}
+		c.tx.add(t)
+		return nil, nil
+	}
+
+	// Keep a copy of the original file
+	if c.backup != "" {
+		if err := backupTarget(path, c.backup, c.fsync); err != nil {
+			return nil, err
+		}
+	}
+
+	// Write result to file in its original encoding
+	data := gosect.EncodeText(result, enc)
+	if err := writeTarget(path, data, c.fsync); err != nil {
+		return nil, err
+	}
+	c.logger().Info("file written", "file", path, "bytes", len(data))
+
+	return nil, nil
+}
+
+// logger returns the logger of the run
+func (c updateConfig) logger() *slog.Logger {
+	return cmp.Or(c.opts.Logger, slog.New(slog.DiscardHandler))
+}
+
+// updateFiles updates the target files at paths with a pool of at most jobs
+// workers. Outputs are written to w in the order of paths, and the errors of
+// every target are joined.
+func (c updateConfig) updateFiles(w io.Writer, paths []string, jobs int) error {
+	type result struct {
+		output []byte
+		err    error
+	}
+
+	results := make([]result, len(paths))
+	indexes := make(chan int)
+
+	var wg sync.WaitGroup
+	for range max(min(jobs, len(paths)), 1) {
+		wg.Go(func() {
+			for i := range indexes {
+				start := time.Now()
+				output, err := c.updateFile(paths[i])
+				results[i] = result{output, err}
+				c.logger().Debug("file processed", "file", paths[i], "duration", time.Since(start), "failed", err != nil)
+			}
+		})
+	}
+
+	for i := range paths {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	var errs []error
+	for _, r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+		}
+		if _, err := w.Write(r.output); err != nil {
+			return fmt.Errorf("stdout: %w", err)
+		}
+	}
+
+	err := errors.Join(errs...)
+	c.summary.finish(len(paths), err)
+
+	// Write every staged target, or none when a target failed
+	if c.tx != nil {
+		if err != nil {
+			c.tx.rollback()
+			return fmt.Errorf("%w\nno file written (-transactional)", err)
+		}
+		return c.tx.commit(c.backup, c.fsync)
+	}
+
+	if err != nil && c.opts.KeepGoing {
+		if n := failureCount(err); n <= c.maxFailures {
+			fmt.Fprintf(os.Stderr, "%v\n%d failure(s), within -max-failures %d\n", err, n, c.maxFailures)
+			return nil
+		}
+	}
+
+	return err
+}
+
+// failureCount returns the number of failed sections and files reported by
+// err
+func failureCount(err error) int {
+	switch e := err.(type) {
+	case nil:
+		return 0
+	case gosect.SectionErrors:
+		return len(e)
+	case interface{ Unwrap() []error }:
+		n := 0
+		for _, err := range e.Unwrap() {
+			n += failureCount(err)
+		}
+		return n
+	case interface{ Unwrap() error }:
+		return max(failureCount(e.Unwrap()), 1)
+	}
+
+	return 1
+}
@@ -0,0 +1,575 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test updating several targets concurrently
+// /////////////////////////////////////////////////////////////////////////////
+func TestUpdateFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "source.txt")
+	if err := os.WriteFile(source, []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var paths []string
+	for i := range 5 {
+		path := filepath.Join(tmpDir, "doc"+string(rune('a'+i))+".md")
+		content := "<!-- BEGIN SECTION s file=" + source + " -->\n<!-- END SECTION s -->\n"
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	broken := filepath.Join(tmpDir, "broken.md")
+	if err := os.WriteFile(broken, []byte("<!-- BEGIN SECTION s file=x -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	missing := filepath.Join(tmpDir, "missing.md")
+
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	c := updateConfig{opts: gosect.Options{ReBegin: reBegin, ReEnd: reEnd}}
+
+	var out strings.Builder
+	err := c.updateFiles(&out, append(paths, broken, missing), 3)
+	if err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	for _, path := range []string{broken, missing} {
+		if !strings.Contains(err.Error(), path) {
+			t.Errorf("Expected error for %s, got %v", path, err)
+		}
+	}
+
+	for _, path := range paths {
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(got), "\ngenerated\n") {
+			t.Errorf("Expected %s to be updated, got %q", path, got)
+		}
+	}
+
+	// outputs keep the order of the targets
+	c.stdout = true
+	out.Reset()
+	if err := c.updateFiles(&out, paths, 4); err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.Repeat("<!-- BEGIN SECTION s file="+source+" -->\n\ngenerated\n\n<!-- END SECTION s -->\n", len(paths)); out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+
+	// up to date files pass the check
+	c.stdout = false
+	c.check = true
+	if err := c.updateFiles(&out, paths, 2); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if err := os.WriteFile(paths[0], []byte("<!-- BEGIN SECTION s file="+source+" -->\n<!-- END SECTION s -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.updateFiles(&out, paths, 2); err == nil || !strings.Contains(err.Error(), paths[0]) {
+		t.Errorf("Expected %s to be out of date, got %v", paths[0], err)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test up to date targets are not written
+// /////////////////////////////////////////////////////////////////////////////
+func TestUpdateFileUnchanged(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "source.txt"), []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(tmpDir, "doc.md")
+	content := "<!-- BEGIN SECTION s file=source.txt -->\n\ngenerated\n\n<!-- END SECTION s -->\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(path, past, past); err != nil {
+		t.Fatal(err)
+	}
+
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	c := updateConfig{opts: gosect.Options{ReBegin: reBegin, ReEnd: reEnd}, backup: defaultBackupSuffix}
+	if _, err := c.updateFile(path); err != nil {
+		t.Fatal(err)
+	}
+
+	if info, err := os.Stat(path); err != nil || !info.ModTime().Equal(past) {
+		t.Errorf("Expected %s not to be written, got %v", path, err)
+	}
+	if _, err := os.Stat(path + defaultBackupSuffix); err == nil {
+		t.Error("Expected no backup of an unchanged file")
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test UTF-16 and UTF-8 BOM targets are written back in their encoding
+// /////////////////////////////////////////////////////////////////////////////
+func TestUpdateFileEncodings(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "source.txt"), []byte("généré\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	encodings := []gosect.Encoding{
+		{Name: gosect.UTF16LE, BOM: true},
+		{Name: gosect.UTF8, BOM: true},
+	}
+
+	// Run tests
+	for _, enc := range encodings {
+		path := filepath.Join(tmpDir, "doc.md")
+		content := "<!-- BEGIN SECTION s file=source.txt -->\r\n<!-- END SECTION s -->\r\n"
+		if err := os.WriteFile(path, gosect.EncodeText([]byte(content), enc), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+		c := updateConfig{opts: gosect.Options{ReBegin: reBegin, ReEnd: reEnd}}
+		if _, err := c.updateFile(path); err != nil {
+			t.Fatal(err)
+		}
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := "<!-- BEGIN SECTION s file=source.txt -->\r\n\r\ngénéré\r\n\r\n<!-- END SECTION s -->\r\n"
+		if string(raw) != string(gosect.EncodeText([]byte(want), enc)) {
+			t.Errorf("Expected %s %q, got %q", enc.Name, want, raw)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test partial updates with -keep-going and -max-failures
+// /////////////////////////////////////////////////////////////////////////////
+func TestUpdateFilesKeepGoing(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "source.txt"), []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "<!-- BEGIN SECTION ok file=source.txt -->\n<!-- END SECTION ok -->\n" +
+		"<!-- BEGIN SECTION a file=missing-a.txt -->\n<!-- END SECTION a -->\n" +
+		"<!-- BEGIN SECTION b file=missing-b.txt -->\n<!-- END SECTION b -->\n"
+	path := filepath.Join(tmpDir, "doc.md")
+	missing := filepath.Join(tmpDir, "missing.md")
+
+	tests := []struct {
+		name        string
+		keepGoing   bool
+		maxFailures int
+		wantUpdated bool
+		wantErr     bool
+	}{
+		{"abort", false, 0, false, true},
+		{"keep going", true, 0, true, true},
+		{"below threshold", true, 3, true, false},
+		{"above threshold", true, 2, true, true},
+	}
+
+	// Run tests
+	for _, test := range tests {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+		c := updateConfig{
+			opts:        gosect.Options{ReBegin: reBegin, ReEnd: reEnd, KeepGoing: test.keepGoing},
+			maxFailures: test.maxFailures,
+		}
+
+		// two failed sections and a missing file
+		err := c.updateFiles(io.Discard, []string{path, missing}, 1)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: expected error %v, got %v", test.name, test.wantErr, err)
+		}
+
+		got, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if updated := strings.Contains(string(got), "\ngenerated\n"); updated != test.wantUpdated {
+			t.Errorf("%s: expected updated %v, got %q", test.name, test.wantUpdated, got)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test no target is written by -transactional when one fails
+// /////////////////////////////////////////////////////////////////////////////
+func TestUpdateFilesTransactional(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "source.txt"), []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	good := filepath.Join(tmpDir, "good.md")
+	goodContent := "<!-- BEGIN SECTION s file=source.txt -->\n<!-- END SECTION s -->\n"
+	bad := filepath.Join(tmpDir, "bad.md")
+	badContent := "<!-- BEGIN SECTION s file=missing.txt -->\n<!-- END SECTION s -->\n"
+	for path, content := range map[string]string{good: goodContent, bad: badContent} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	c := updateConfig{opts: gosect.Options{ReBegin: reBegin, ReEnd: reEnd}, tx: &transaction{}}
+
+	if err := c.updateFiles(io.Discard, []string{good, bad}, 2); err == nil {
+		t.Fatal("Expected error, got nil")
+	}
+	if got, _ := os.ReadFile(good); string(got) != goodContent {
+		t.Errorf("Expected %s to be unchanged, got %q", good, got)
+	}
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 {
+		t.Errorf("Expected staged files to be removed, got %d files", len(entries))
+	}
+
+	// every target is written once they all succeed
+	c.tx = &transaction{}
+	if err := c.updateFiles(io.Discard, []string{good}, 2); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile(good); !strings.Contains(string(got), "\ngenerated\n") {
+		t.Errorf("Expected %s to be updated, got %q", good, got)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the go:generate mode
+// /////////////////////////////////////////////////////////////////////////////
+func TestRunUpdateGenerate(t *testing.T) {
+	tmpDir := t.TempDir()
+	t.Chdir(tmpDir)
+	if err := os.WriteFile("source.txt", []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("doc.md", []byte("<!-- BEGIN SECTION s file=source.txt -->\n<!-- END SECTION s -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// changes exit with a distinct status
+	err := runUpdate([]string{"-generate", "doc.md", "doc.md"})
+	var exit exitError
+	if !errors.As(err, &exit) || exit.code != exitChanges || err.Error() != "1 file(s) updated" {
+		t.Fatalf("Expected exit status %d, got %v", exitChanges, err)
+	}
+
+	// unchanged files are not written again
+	past := time.Now().Add(-time.Hour)
+	if err := os.Chtimes("doc.md", past, past); err != nil {
+		t.Fatal(err)
+	}
+	if err := runUpdate([]string{"-generate", "doc.md"}); err != nil {
+		t.Fatalf("Expected no-op run to succeed, got %v", err)
+	}
+	if info, err := os.Stat("doc.md"); err != nil || !info.ModTime().Equal(past) {
+		t.Errorf("Expected doc.md not to be written, got %v", err)
+	}
+
+	// failures exit with the statuses of -exit-code, unlike changes
+	if err := os.WriteFile("broken.md", []byte("<!-- BEGIN SECTION s file=source.txt -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("missing.md", []byte("<!-- BEGIN SECTION s file=missing.txt -->\n<!-- END SECTION s -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		args []string
+		code int
+	}{
+		{"Damaged markers", []string{"-generate", "broken.md"}, exitMarkers},
+		{"Missing source", []string{"-generate", "missing.md"}, exitSources},
+		{"Rejected flags", []string{"-generate", "-diff", "doc.md"}, exitMarkers},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runUpdate(tt.args)
+			if !errors.As(err, &exit) || exit.code != tt.code {
+				t.Errorf("Expected exit status %d, got %v", tt.code, err)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the -summary report of a run
+// /////////////////////////////////////////////////////////////////////////////
+func TestUpdateFilesSummary(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "source.txt"), []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	docs := map[string]string{
+		"a.md": "<!-- BEGIN SECTION s file=source.txt -->\n<!-- END SECTION s -->\n<!-- BEGIN SECTION t file=source.txt -->\n\ngenerated\n\n<!-- END SECTION t -->\n",
+		"b.md": "<!-- BEGIN SECTION s file=source.txt -->\n<!-- END SECTION s -->\n<!-- BEGIN SECTION u file=missing.txt -->\n<!-- END SECTION u -->\n",
+		"c.md": "no markers\n",
+	}
+	var paths []string
+	for name, content := range docs {
+		path := filepath.Join(tmpDir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	tests := []struct {
+		format string
+		want   string
+	}{
+		{"text", "3 files processed, 2 sections updated, 1 unchanged, 1 errors\n"},
+		{"json", `{"files":3,"sectionsUpdated":2,"sectionsUnchanged":1,"errors":1}` + "\n"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			summary, err := parseSummaryFormat(tt.format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+			c := updateConfig{opts: gosect.Options{ReBegin: reBegin, ReEnd: reEnd, KeepGoing: true}, stdout: true, summary: summary}
+			c.updateFiles(io.Discard, paths, 2)
+
+			var out strings.Builder
+			if err := c.summary.write(&out); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, out.String())
+			}
+		})
+	}
+
+	if _, err := parseSummaryFormat("yaml"); err == nil {
+		t.Error("Expected error for an invalid -summary format")
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the exit statuses of -exit-code runs
+// /////////////////////////////////////////////////////////////////////////////
+func TestRunUpdateExitCode(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("source.txt", []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	stale := "<!-- BEGIN SECTION s file=source.txt -->\n<!-- END SECTION s -->\n"
+
+	tests := []struct {
+		name    string
+		run     func([]string) error
+		content string
+		args    []string
+		want    int
+	}{
+		{"Changes applied", runUpdate, stale, []string{"doc.md"}, exitChanges},
+		{"Nothing to change", runUpdate, "<!-- BEGIN SECTION s file=source.txt -->\n\ngenerated\n\n<!-- END SECTION s -->\n", []string{"doc.md"}, 0},
+		{"Changes needed", runCheck, stale, []string{"doc.md"}, exitChanges},
+		{"Changes shown", runDiff, stale, []string{"doc.md"}, exitChanges},
+		{"Damaged markers", runUpdate, "<!-- BEGIN SECTION s file=source.txt -->\n", []string{"doc.md"}, exitMarkers},
+		{"Missing source", runCheck, "<!-- BEGIN SECTION s file=missing.txt -->\n<!-- END SECTION s -->\n", []string{"doc.md"}, exitSources},
+		{"Missing target", runUpdate, stale, []string{"missing.md"}, exitSources},
+		{"No file", runUpdate, stale, nil, exitMarkers},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile("doc.md", []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			err := tt.run(append([]string{"-exit-code", "-color", "never"}, tt.args...))
+			code := 0
+			var exit exitError
+			if errors.As(err, &exit) {
+				code = exit.code
+			} else if err != nil {
+				code = 1
+			}
+			if code != tt.want {
+				t.Errorf("Expected exit status %d, got %d (%v)", tt.want, code, err)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test -under-heading inserts the markers of documents without markers
+// /////////////////////////////////////////////////////////////////////////////
+func TestRunUpdateUnderHeading(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("usage.txt", []byte("gosect [flags] file...\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("doc.md", []byte("# Tool\n\n## Usage\n\nold usage\n\n## License\n\nMIT\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# Tool\n\n## Usage\n\n<!-- BEGIN SECTION usage file=usage.txt -->\n\ngosect [flags] file...\n\n<!-- END SECTION usage -->\n\n## License\n\nMIT\n"
+	args := []string{"-under-heading", "## Usage", "-heading-attrs", "file=usage.txt", "doc.md"}
+
+	// The second run finds the inserted markers
+	for run := 1; run <= 2; run++ {
+		if err := runUpdate(args); err != nil {
+			t.Fatalf("Run %d: %v", run, err)
+		}
+		got, err := os.ReadFile("doc.md")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Errorf("Run %d: expected %q, got %q", run, want, got)
+		}
+	}
+
+	if err := runUpdate([]string{"-under-heading", "## Usage", "doc.md"}); err == nil {
+		t.Error("Expected -under-heading without -heading-attrs to fail")
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test honoring the .editorconfig settings of targets
+// /////////////////////////////////////////////////////////////////////////////
+func TestRunUpdateEditorConfig(t *testing.T) {
+	t.Chdir(t.TempDir())
+	files := map[string]string{
+		".editorconfig": "root = true\n\n[*]\nend_of_line = lf\n\n[*.md]\nend_of_line = crlf\ninsert_final_newline = true\n",
+		"usage.txt":     "gosect [flags] file...\n",
+		"doc.md":        "<!-- BEGIN SECTION usage file=usage.txt -->\n<!-- END SECTION usage -->",
+		"fresh.md":      "no markers",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{
+			name: "Without .editorconfig",
+			args: []string{"doc.md"},
+			want: "<!-- BEGIN SECTION usage file=usage.txt -->\n\ngosect [flags] file...\n\n<!-- END SECTION usage -->",
+		},
+		{
+			name: "With .editorconfig",
+			args: []string{"-editorconfig", "doc.md"},
+			want: "<!-- BEGIN SECTION usage file=usage.txt -->\n\r\ngosect [flags] file...\r\n\r\n<!-- END SECTION usage -->\r\n",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile("doc.md", []byte(files["doc.md"]), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := runUpdate(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile("doc.md")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	// Unchanged targets are not rewritten to add their final newline
+	if err := runUpdate([]string{"-editorconfig", "fresh.md"}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := os.ReadFile("fresh.md"); string(got) != "no markers" {
+		t.Errorf("Expected fresh.md unchanged, got %q", got)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test raw marker regexes
+// /////////////////////////////////////////////////////////////////////////////
+func TestRunUpdateRawMarkers(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if err := os.WriteFile("usage.txt", []byte("gosect [flags] file...\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile("doc.md", []byte("<!-- START usage.txt -->\n<!-- END -->\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantErr string
+	}{
+		{
+			name:    "Without -end-re",
+			args:    []string{"-begin-re", `<!-- START (?P<file>\S+)(?P<name>) -->`, "doc.md"},
+			wantErr: "-begin-re and -end-re must be given together",
+		},
+		{
+			name:    "With -begin",
+			args:    []string{"-begin", "START", "-begin-re", `<!-- START (?P<file>\S+)(?P<name>) -->`, "-end-re", `<!-- END(?P<name>) -->`, "doc.md"},
+			wantErr: "-begin-re and -end-re replace -begin and -end, which cannot be given with them",
+		},
+		{
+			name: "Update",
+			args: []string{"-begin-re", `<!-- START (?P<file>\S+)(?P<name>) -->`, "-end-re", `<!-- END(?P<name>) -->`, "doc.md"},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runUpdate(tt.args)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := "<!-- START usage.txt -->\n\ngosect [flags] file...\n\n<!-- END -->\n"
+			if got, _ := os.ReadFile("doc.md"); string(got) != want {
+				t.Errorf("Expected %q, got %q", want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/badele/gosect"
+)
+
+// validateFile writes the marker problems, lint issues and missing or stale
+// assets of the target file at path to w, and returns the number of errors
+// and warnings
+func validateFile(w io.Writer, path string, reBegin, reEnd *regexp.Regexp, cfg gosect.Config) (int, int, error) {
+	content, err := readText(path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// Marker problems are always errors, and prevent linting sections
+	if issues := gosect.CheckMarkers(content, reBegin, reEnd); len(issues) > 0 {
+		for _, issue := range issues {
+			fmt.Fprintf(w, "%s:%d:%d: %s: %s (markers)\n", path, issue.Line, issue.Column, gosect.SeverityError, issue.Message)
+		}
+		return len(issues), 0, nil
+	}
+
+	sections, err := gosect.FindSectionsBytes(content, reBegin, reEnd)
+	if err != nil {
+		return 0, 0, gosect.FileErrors(path, err)
+	}
+
+	errs, warnings := 0, 0
+	for _, issue := range gosect.Lint(sections, cfg.Lint) {
+		fmt.Fprintf(w, "%s:%s\n", path, issue)
+		if issue.Severity == gosect.SeverityError {
+			errs++
+		} else {
+			warnings++
+		}
+	}
+
+	// Assets are resolved from the directory of the file
+	opts := gosect.Options{BaseDir: filepath.Dir(path), Roots: cfg.Roots}
+	for _, issue := range opts.CheckAssets(sections) {
+		fmt.Fprintf(w, "%s:%d:%d: %s: %s (assets)\n", path, issue.Line, issue.Column, gosect.SeverityError, issue.Message)
+		errs++
+	}
+
+	return errs, warnings, nil
+}
+
+// runValidate checks the markers of target files and the lint rules of the
+// configuration file: gosect validate [flags] file...
+func runValidate(args []string) error {
+	fs := flag.NewFlagSet("validate", flag.ExitOnError)
+	markers := addMarkerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect validate [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("validate: at least one file required")
+	}
+
+	cfg, err := markers.loadConfig()
+	if err != nil {
+		return err
+	}
+
+	errs, warnings := 0, 0
+	for _, path := range fs.Args() {
+		reBegin, reEnd := markers.regex(path)
+		e, w, err := validateFile(os.Stdout, path, reBegin, reEnd, *cfg)
+		if err != nil {
+			return err
+		}
+		errs, warnings = errs+e, warnings+w
+	}
+
+	if errs > 0 {
+		return fmt.Errorf("validate: %d error(s), %d warning(s)", errs, warnings)
+	}
+	if warnings > 0 {
+		fmt.Fprintf(os.Stderr, "validate: %d warning(s)\n", warnings)
+	}
+
+	return nil
+}
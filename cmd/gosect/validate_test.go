@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test validating the markers of target files
+// /////////////////////////////////////////////////////////////////////////////
+func TestValidateFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	rules := gosect.LintRules{
+		RequiredAttrs: gosect.LintRule{Attrs: []string{"owner"}, Severity: gosect.SeverityWarning},
+		AbsolutePaths: gosect.LintRule{Severity: gosect.SeverityError},
+	}
+
+	tests := []struct {
+		name     string
+		content  string
+		errs     int
+		warnings int
+		output   string
+	}{
+		{"Clean", "BEGIN SECTION a file=a.txt owner=docs\nEND SECTION a\n", 0, 0, ""},
+		{"Warning", "BEGIN SECTION a file=a.txt\nEND SECTION a\n", 0, 1, ":1:1: warning: section a has no owner= attribute (requiredAttrs)\n"},
+		{"Error", "BEGIN SECTION a file=/a.txt owner=docs\nEND SECTION a\n", 1, 0, ":1:22: error: section a has absolute file=/a.txt (absolutePaths)\n"},
+		{"Damaged markers", "BEGIN SECTION a file=a.txt\n", 1, 0, ":1:1: error: no END SECTION for a (markers)\n"},
+		{"Missing asset", "BEGIN SECTION a asset=ui.png owner=docs\nEND SECTION a\n", 1, 0, ":1:23: error: section a: asset ui.png is missing (assets)\n"},
+	}
+
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, "doc.txt")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			errs, warnings, err := validateFile(&out, path, reBegin, reEnd, gosect.Config{Lint: rules})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if errs != tt.errs || warnings != tt.warnings {
+				t.Errorf("Expected %d error(s) and %d warning(s), got %d and %d", tt.errs, tt.warnings, errs, warnings)
+			}
+
+			output := ""
+			if tt.output != "" {
+				output = path + tt.output
+			}
+			if out.String() != output {
+				t.Errorf("Expected %q, got %q", output, out.String())
+			}
+		})
+	}
+}
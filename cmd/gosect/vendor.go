@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/badele/gosect"
+)
+
+// runVendor copies the url= sources of target files to a vendor directory:
+// gosect vendor [flags] file...
+func runVendor(args []string) error {
+	fs := flag.NewFlagSet("vendor", flag.ExitOnError)
+	dir := fs.String("dir", gosect.DefaultVendorDir, "vendor directory")
+	markers := addMarkerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect vendor [flags] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return errors.New("vendor: at least one file required")
+	}
+
+	if _, err := markers.loadConfig(); err != nil {
+		return err
+	}
+
+	var sections []gosect.Section
+	for _, path := range fs.Args() {
+		content, err := readText(path)
+		if err != nil {
+			return err
+		}
+
+		reBegin, reEnd := markers.regex(path)
+		found, err := gosect.FindSectionsBytes(content, reBegin, reEnd)
+		if err != nil {
+			return gosect.FileErrors(path, err)
+		}
+		sections = append(sections, found...)
+	}
+
+	lock, err := gosect.Vendor(sections, *dir, gosect.Options{})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%d source(s) vendored in %s\n", len(lock.Sources), *dir)
+
+	return nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/badele/gosect"
+)
+
+// verifyManifest records the hashes of generated files, so they can be
+// verified without their sources
+type verifyManifest struct {
+	Files []verifiedFile `json:"files"`
+}
+
+// verifiedFile is a file of a verify manifest, with the hashes of its content
+// and of the body of each of its sections
+type verifiedFile struct {
+	File     string            `json:"file"`
+	SHA256   string            `json:"sha256"`
+	Sections []verifiedSection `json:"sections"`
+}
+
+// verifiedSection is the hash of the body of a section
+type verifiedSection struct {
+	Name   string `json:"name"`
+	SHA256 string `json:"sha256"`
+}
+
+// sha256Hex returns the hex SHA-256 hash of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// recordFile returns the hashes of the file at path and of its sections
+func recordFile(path string, reBegin, reEnd *regexp.Regexp) (verifiedFile, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return verifiedFile{}, err
+	}
+	content, _, err := gosect.DecodeText(raw)
+	if err != nil {
+		return verifiedFile{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	sections, err := gosect.FindSectionsBytes(content, reBegin, reEnd)
+	if err != nil {
+		return verifiedFile{}, gosect.FileErrors(path, err)
+	}
+
+	f := verifiedFile{File: filepath.ToSlash(path), SHA256: sha256Hex(raw), Sections: []verifiedSection{}}
+	for _, s := range sections {
+		f.Sections = append(f.Sections, verifiedSection{Name: s.Name, SHA256: sha256Hex(sectionBody(content, s))})
+	}
+
+	return f, nil
+}
+
+// verifyFile writes to w the differences between the file recorded in want
+// and its current content, and returns their number
+func verifyFile(w io.Writer, want verifiedFile, reBegin, reEnd *regexp.Regexp) int {
+	path := filepath.FromSlash(want.File)
+	got, err := recordFile(path, reBegin, reEnd)
+	if err != nil {
+		fmt.Fprintf(w, "%s: %v\n", want.File, err)
+		return 1
+	}
+	if got.SHA256 == want.SHA256 {
+		return 0
+	}
+
+	// Locate the changes in sections when possible
+	current := map[string]string{}
+	for _, s := range got.Sections {
+		current[s.Name] = s.SHA256
+	}
+	problems := 0
+	for _, s := range want.Sections {
+		sum, ok := current[s.Name]
+		switch {
+		case !ok:
+			fmt.Fprintf(w, "%s: section %s is missing\n", want.File, s.Name)
+		case sum != s.SHA256:
+			fmt.Fprintf(w, "%s: section %s was modified\n", want.File, s.Name)
+		default:
+			delete(current, s.Name)
+			continue
+		}
+		delete(current, s.Name)
+		problems++
+	}
+	for _, s := range got.Sections {
+		if _, ok := current[s.Name]; ok {
+			fmt.Fprintf(w, "%s: section %s was added\n", want.File, s.Name)
+			problems++
+		}
+	}
+	if problems == 0 {
+		fmt.Fprintf(w, "%s: modified outside sections\n", want.File)
+		problems++
+	}
+
+	return problems
+}
+
+// runVerify records the hashes of generated files in a manifest, or checks
+// files against it without their sources:
+// gosect verify -manifest out.json [-record file...]
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	manifest := fs.String("manifest", "", "manifest of the file hashes (required)")
+	record := fs.Bool("record", false, "record the hashes of the given files in the manifest instead of verifying them")
+	markers := addMarkerFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: gosect verify -manifest out.json [-record file...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if *manifest == "" {
+		fs.Usage()
+		return errors.New("verify: -manifest required")
+	}
+	if *record && fs.NArg() == 0 {
+		return errors.New("verify: -record requires at least one file")
+	}
+	if !*record && fs.NArg() > 0 {
+		return errors.New("verify: the files to verify are read from the manifest")
+	}
+
+	if _, err := markers.loadConfig(); err != nil {
+		return err
+	}
+
+	if *record {
+		m := verifyManifest{Files: []verifiedFile{}}
+		for _, path := range fs.Args() {
+			reBegin, reEnd := markers.regex(path)
+			f, err := recordFile(path, reBegin, reEnd)
+			if err != nil {
+				return err
+			}
+			m.Files = append(m.Files, f)
+		}
+		data, err := json.MarshalIndent(m, "", "  ")
+		if err != nil {
+			return err
+		}
+		return writeTarget(*manifest, append(data, '\n'), false)
+	}
+
+	data, err := os.ReadFile(*manifest)
+	if err != nil {
+		return err
+	}
+	var m verifyManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("%s: %w", *manifest, err)
+	}
+
+	problems := 0
+	for _, f := range m.Files {
+		reBegin, reEnd := markers.regex(filepath.FromSlash(f.File))
+		problems += verifyFile(os.Stdout, f, reBegin, reEnd)
+	}
+	if problems > 0 {
+		return fmt.Errorf("verify: %d file(s) or section(s) do not match %s", problems, *manifest)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/badele/gosect"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test verifying files against their recorded hashes
+// /////////////////////////////////////////////////////////////////////////////
+func TestVerifyFile(t *testing.T) {
+	tmpDir := t.TempDir()
+	original := "intro\nBEGIN SECTION a file=a.txt\nA\nEND SECTION a\nBEGIN SECTION b\nB\nEND SECTION b\n"
+
+	tests := []struct {
+		name     string
+		content  string // current content, removed when empty
+		problems int
+		output   string
+	}{
+		{"Unchanged", original, 0, ""},
+		{"Modified section", "intro\nBEGIN SECTION a file=a.txt\nchanged\nEND SECTION a\nBEGIN SECTION b\nB\nEND SECTION b\n", 1, "doc.md: section a was modified\n"},
+		{"Modified outside sections", "changed\nBEGIN SECTION a file=a.txt\nA\nEND SECTION a\nBEGIN SECTION b\nB\nEND SECTION b\n", 1, "doc.md: modified outside sections\n"},
+		{"Missing and added sections", "intro\nBEGIN SECTION a file=a.txt\nA\nEND SECTION a\nBEGIN SECTION c\nB\nEND SECTION c\n", 2, "doc.md: section b is missing\ndoc.md: section c was added\n"},
+		{"Missing file", "", 1, "doc.md: open doc.md: no such file or directory\n"},
+	}
+
+	reBegin, reEnd := gosect.MakeRegex(gosect.DefaultBegin, gosect.DefaultEnd)
+	t.Chdir(tmpDir)
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := os.WriteFile("doc.md", []byte(original), 0644); err != nil {
+				t.Fatal(err)
+			}
+			want, err := recordFile("doc.md", reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.content == "" {
+				os.Remove("doc.md")
+			} else if err := os.WriteFile(filepath.Join(tmpDir, "doc.md"), []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			var out bytes.Buffer
+			if problems := verifyFile(&out, want, reBegin, reEnd); problems != tt.problems {
+				t.Errorf("Expected %d problems, got %d", tt.problems, problems)
+			}
+			if out.String() != tt.output {
+				t.Errorf("Expected output %q, got %q", tt.output, out.String())
+			}
+		})
+	}
+}
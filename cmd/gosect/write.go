@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// defaultMode is the permission of newly created targets
+const defaultMode fs.FileMode = 0644
+
+// defaultBackupSuffix is the suffix of backup files created by -backup
+const defaultBackupSuffix = ".bak"
+
+// writeTarget atomically replaces the target file at path with data. The data
+// is written to a temporary file in the same directory, synced to disk, given
+// the permissions of the original file and renamed over the target, so a
+// crash or a failed write (disk full, I/O error) never leaves a truncated or
+// half-written target. Symbolic links are followed so the link itself is
+// preserved. Every error is reported with the target path. When fsync is set
+// the directory is synced too, making the rename itself durable.
+func writeTarget(path string, data []byte, fsync bool) error {
+	t, err := stageTarget(path, data)
+	if err != nil {
+		return err
+	}
+
+	return t.commit(fsync)
+}
+
+// stagedTarget is the new content of a target written to a temporary file,
+// waiting to be renamed over the target
+type stagedTarget struct {
+	path    string
+	tmpPath string
+}
+
+// stageTarget writes data to a temporary file next to the target file at
+// path, synced to disk and with the permissions of the target (see
+// writeTarget)
+func stageTarget(path string, data []byte) (t stagedTarget, err error) {
+	mode := defaultMode
+	if real, err := filepath.EvalSymlinks(path); err == nil {
+		path = real
+	}
+	info, err := os.Stat(path)
+	switch {
+	case err == nil:
+		mode = info.Mode().Perm()
+	case !errors.Is(err, fs.ErrNotExist):
+		return t, err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return t, fmt.Errorf("%s: %w", path, err)
+	}
+	tmpPath := f.Name()
+
+	// roll back the temporary file on any error
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	// Write result to file
+	w := bufio.NewWriter(f)
+	if _, err := w.Write(data); err != nil {
+		return t, fmt.Errorf("%s: write: %w", path, err)
+	}
+	if err := w.Flush(); err != nil {
+		return t, fmt.Errorf("%s: write: %w", path, err)
+	}
+
+	if err := f.Sync(); err != nil {
+		return t, fmt.Errorf("%s: fsync: %w", path, err)
+	}
+	if err := f.Chmod(mode); err != nil {
+		return t, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := f.Close(); err != nil {
+		return t, fmt.Errorf("%s: close: %w", path, err)
+	}
+
+	return stagedTarget{path: path, tmpPath: tmpPath}, nil
+}
+
+// commit renames the staged content over the target
+func (t stagedTarget) commit(fsync bool) error {
+	if err := os.Rename(t.tmpPath, t.path); err != nil {
+		os.Remove(t.tmpPath)
+		return fmt.Errorf("%s: %w", t.path, err)
+	}
+
+	if fsync {
+		if err := syncDir(filepath.Dir(t.path)); err != nil {
+			return fmt.Errorf("%s: fsync directory: %w", t.path, err)
+		}
+	}
+
+	return nil
+}
+
+// discard removes the staged content, leaving the target unchanged
+func (t stagedTarget) discard() {
+	os.Remove(t.tmpPath)
+}
+
+// transaction collects the targets staged by -transactional, so that they are
+// only written once every target rendered successfully
+type transaction struct {
+	mu     sync.Mutex
+	staged []stagedTarget
+}
+
+// add records a staged target
+func (tx *transaction) add(t stagedTarget) {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+	tx.staged = append(tx.staged, t)
+}
+
+// changedFiles collects the targets whose content changed, with -generate
+// and -staged
+type changedFiles struct {
+	mu    sync.Mutex
+	paths []string
+}
+
+// add records a changed target
+func (c *changedFiles) add(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.paths = append(c.paths, path)
+}
+
+// list returns the changed targets in sorted order
+func (c *changedFiles) list() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Sorted(slices.Values(c.paths))
+}
+
+// commit writes every staged target, after saving a copy of the original
+// files with the backup suffix when not empty
+func (tx *transaction) commit(backup string, fsync bool) error {
+	for i, t := range tx.staged {
+		if backup != "" {
+			if err := backupTarget(t.path, backup, fsync); err != nil {
+				tx.staged = tx.staged[i:]
+				tx.rollback()
+				return err
+			}
+		}
+		if err := t.commit(fsync); err != nil {
+			tx.staged = tx.staged[i+1:]
+			tx.rollback()
+			return err
+		}
+	}
+	tx.staged = nil
+
+	return nil
+}
+
+// rollback discards every staged target
+func (tx *transaction) rollback() {
+	for _, t := range tx.staged {
+		t.discard()
+	}
+	tx.staged = nil
+}
+
+// backupTarget saves a copy of the target file at path to path+suffix, with
+// the same permissions, before it is overwritten. A missing target has nothing
+// to back up.
+func backupTarget(path, suffix string, fsync bool) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	backup := path + suffix
+	if err := writeTarget(backup, data, fsync); err != nil {
+		return fmt.Errorf("backup: %w", err)
+	}
+
+	return os.Chmod(backup, info.Mode().Perm())
+}
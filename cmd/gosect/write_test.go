@@ -0,0 +1,209 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test writeTarget function
+// /////////////////////////////////////////////////////////////////////////////
+func TestWriteTarget(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	tests := []struct {
+		name      string
+		path      string
+		fsync     bool
+		wantError bool
+	}{
+		{name: "Plain write", path: filepath.Join(tmpDir, "a.md")},
+		{name: "Write with fsync", path: filepath.Join(tmpDir, "b.md"), fsync: true},
+		{name: "Missing directory", path: filepath.Join(tmpDir, "missing", "c.md"), wantError: true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := writeTarget(tt.path, []byte("content\n"), tt.fsync)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			got, err := os.ReadFile(tt.path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != "content\n" {
+				t.Errorf("Expected written content, got %q", got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test writeTarget leaves no temporary file and keeps the original on error
+// /////////////////////////////////////////////////////////////////////////////
+func TestWriteTargetRollback(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "doc.md")
+	err := os.WriteFile(target, []byte("original"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeTarget(target, []byte("updated"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("Expected only the target file, found %d entries", len(entries))
+	}
+
+	// a directory in place of the target makes the rename fail
+	dirTarget := filepath.Join(tmpDir, "subdir")
+	if err := os.MkdirAll(filepath.Join(dirTarget, "child"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeTarget(dirTarget, []byte("data"), false); err == nil {
+		t.Fatal("Expected error when target is a directory")
+	}
+
+	entries, err = os.ReadDir(tmpDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("Expected temporary file to be removed, found %d entries", len(entries))
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test writeTarget preserves permissions and symbolic links
+// /////////////////////////////////////////////////////////////////////////////
+func TestWriteTargetPreservesMode(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "script.sh")
+	err := os.WriteFile(target, []byte("#!/bin/sh\n"), 0750)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(target, 0750); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeTarget(target, []byte("#!/bin/sh\necho updated\n"), false); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Windows only keeps the read-only bit of file modes
+	if runtime.GOOS != "windows" && info.Mode().Perm() != 0750 {
+		t.Errorf("Expected mode 0750, got %o", info.Mode().Perm())
+	}
+
+	// new files get the default mode
+	created := filepath.Join(tmpDir, "new.md")
+	if err := writeTarget(created, []byte("new\n"), false); err != nil {
+		t.Fatal(err)
+	}
+	info, err = os.Stat(created)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS != "windows" && info.Mode().Perm() != defaultMode {
+		t.Errorf("Expected mode %o, got %o", defaultMode, info.Mode().Perm())
+	}
+
+	// symbolic links are preserved
+	link := filepath.Join(tmpDir, "link.sh")
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symbolic links not supported:", err)
+	}
+	if err := writeTarget(link, []byte("via link\n"), false); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Lstat(link); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected %s to remain a symbolic link", link)
+	}
+	if got, _ := os.ReadFile(target); string(got) != "via link\n" {
+		t.Errorf("Expected link target to be updated, got %q", got)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test backupTarget and the -backup flag
+// /////////////////////////////////////////////////////////////////////////////
+func TestBackupTarget(t *testing.T) {
+	tmpDir := t.TempDir()
+	target := filepath.Join(tmpDir, "doc.md")
+	err := os.WriteFile(target, []byte("original\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "Default suffix", value: "true", want: ".bak"},
+		{name: "Custom suffix", value: ".orig", want: ".orig"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var backup backupFlag
+			if err := backup.Set(tt.value); err != nil {
+				t.Fatal(err)
+			}
+			if backup.suffix != tt.want {
+				t.Fatalf("Expected suffix %q, got %q", tt.want, backup.suffix)
+			}
+
+			if err := backupTarget(target, backup.suffix, false); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := os.ReadFile(target + tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != "original\n" {
+				t.Errorf("Expected original content, got %q", got)
+			}
+			info, err := os.Stat(target + tt.want)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+				t.Errorf("Expected mode 0600, got %o", info.Mode().Perm())
+			}
+		})
+	}
+
+	// a missing target has nothing to back up
+	missing := filepath.Join(tmpDir, "missing.md")
+	if err := backupTarget(missing, ".bak", false); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := os.Stat(missing + ".bak"); err == nil {
+		t.Error("Expected no backup for a missing target")
+	}
+}
@@ -0,0 +1,117 @@
+package gosect
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultRetryDelay is the default delay before retrying a failed cmd=
+// source; it doubles after every attempt
+const DefaultRetryDelay = 500 * time.Millisecond
+
+// DefaultCommandTimeout is the timeout of cmd= sources and resolver plugins
+// run by the command line
+const DefaultCommandTimeout = 5 * time.Minute
+
+// ShellCommand returns the command running line through the system shell, sh
+// or cmd on Windows
+func ShellCommand(line string) *exec.Cmd {
+	return shellCommand(context.Background(), line)
+}
+
+// shellCommand returns the command running line through the system shell,
+// killed when ctx is done
+func shellCommand(ctx context.Context, line string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", line)
+	}
+
+	return exec.CommandContext(ctx, "sh", "-c", line)
+}
+
+// commandContext returns the context of a cmd= source or resolver plugin,
+// done after CommandTimeout
+func (opts Options) commandContext() (context.Context, context.CancelFunc) {
+	if opts.CommandTimeout > 0 {
+		return context.WithTimeout(context.Background(), opts.CommandTimeout)
+	}
+
+	return context.WithCancel(context.Background())
+}
+
+// runTimed runs cmd, created with the context ctx of commandContext
+func (opts Options) runTimed(ctx context.Context, cmd *exec.Cmd) error {
+	// the children of a killed shell may keep its output open
+	cmd.WaitDelay = time.Second
+
+	err := cmd.Run()
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out after %s", opts.CommandTimeout)
+	}
+
+	return err
+}
+
+// sectionRetries returns the number of retries of a failed cmd= source
+// requested with the retries= attribute
+func sectionRetries(s Section) (int, error) {
+	value, ok := s.Attrs["retries"]
+	if !ok {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("section %s has invalid retries=%s", s.Name, value)
+	}
+
+	return n, nil
+}
+
+// runCommand runs the cmd= source of a section and returns its standard
+// output. A failed command is retried as requested by the retries=
+// attribute, with an exponential backoff.
+func (opts Options) runCommand(s Section, line string) ([]byte, error) {
+	if !opts.AllowCommands {
+		return nil, fmt.Errorf("section %s: cmd= sources are disabled (use -allow-cmd)", s.Name)
+	}
+
+	retries, err := sectionRetries(s)
+	if err != nil {
+		return nil, err
+	}
+
+	delay := opts.retryDelay()
+	for attempt := 0; ; attempt++ {
+		var stdout, stderr bytes.Buffer
+		ctx, cancel := opts.commandContext()
+		cmd := shellCommand(ctx, line)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+
+		err := opts.runTimed(ctx, cmd)
+		cancel()
+		if err == nil {
+			return stdout.Bytes(), nil
+		}
+
+		if attempt == retries {
+			msg := strings.TrimSpace(stderr.String())
+			if msg != "" {
+				err = fmt.Errorf("%w: %s", err, msg)
+			}
+			return nil, fmt.Errorf("section %s: command %q failed after %d attempt(s): %w", s.Name, line, attempt+1, err)
+		}
+
+		opts.logger().Info("command failed, retrying", "section", s.Name, "command", line, "error", err, "delay", delay)
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
@@ -0,0 +1,115 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test cmd= sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestCommandSource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts not supported")
+	}
+
+	tmpDir := t.TempDir()
+	script := filepath.Join(tmpDir, "version.sh")
+	err := os.WriteFile(script, []byte("#!/bin/sh\necho v1.2.3\n"), 0755)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := "<!-- BEGIN SECTION v cmd=" + script + " -->\n<!-- END SECTION v -->\n"
+	result := replaceAll(t, content, Options{AllowCommands: true})
+	if !strings.Contains(result, "\nv1.2.3\n") {
+		t.Errorf("Expected command output, got %q", result)
+	}
+
+	// commands are disabled by default
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Replace([]byte(content), sections, Options{}); err == nil {
+		t.Error("Expected error when commands are disabled")
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test retries of failed commands
+// /////////////////////////////////////////////////////////////////////////////
+func TestCommandRetries(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts not supported")
+	}
+
+	tmpDir := t.TempDir()
+	counter := filepath.Join(tmpDir, "attempts")
+	// fails on the first two attempts
+	line := "echo x >> " + counter + "; test $(wc -l < " + counter + ") -ge 3 && echo ok"
+
+	tests := []struct {
+		name    string
+		retries string
+		wantErr bool
+	}{
+		{name: "Without retries", wantErr: true},
+		{name: "Not enough retries", retries: "1", wantErr: true},
+		{name: "Enough retries", retries: "2"},
+		{name: "Invalid retries", retries: "-1", wantErr: true},
+	}
+
+	opts := Options{AllowCommands: true, RetryDelay: time.Millisecond}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Remove(counter)
+
+			s := Section{Name: "flaky", Attrs: map[string]string{"cmd": line}}
+			if tt.retries != "" {
+				s.Attrs["retries"] = tt.retries
+			}
+
+			output, err := opts.runCommand(s, line)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(output) != "ok\n" {
+				t.Errorf("Expected ok, got %q", output)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test commands killed after their timeout
+// /////////////////////////////////////////////////////////////////////////////
+func TestCommandTimeout(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts not supported")
+	}
+
+	opts := Options{AllowCommands: true, CommandTimeout: 100 * time.Millisecond}
+	s := Section{Name: "slow", Attrs: map[string]string{"cmd": "sleep 10"}}
+
+	start := time.Now()
+	_, err := opts.runCommand(s, "sleep 10")
+	if err == nil || !strings.Contains(err.Error(), "timed out after 100ms") {
+		t.Errorf("Expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the command to be killed, ran %s", elapsed)
+	}
+}
@@ -0,0 +1,75 @@
+package gosect
+
+import "regexp"
+
+// CommentStyle is the comment syntax wrapping markers in a file type
+type CommentStyle struct {
+	Prefix string
+	Suffix string
+}
+
+// commentStyles maps the languages of languageFor to their comment syntax
+var commentStyles = map[string]CommentStyle{
+	"bash":       {Prefix: "#"},
+	"c":          {Prefix: "//"},
+	"cmake":      {Prefix: "#"},
+	"cpp":        {Prefix: "//"},
+	"csharp":     {Prefix: "//"},
+	"css":        {Prefix: "/*", Suffix: "*/"},
+	"dockerfile": {Prefix: "#"},
+	"go":         {Prefix: "//"},
+	"hcl":        {Prefix: "#"},
+	"html":       {Prefix: "<!--", Suffix: "-->"},
+	"ini":        {Prefix: ";"},
+	"java":       {Prefix: "//"},
+	"javascript": {Prefix: "//"},
+	"jsx":        {Prefix: "//"},
+	"just":       {Prefix: "#"},
+	"kotlin":     {Prefix: "//"},
+	"lua":        {Prefix: "--"},
+	"makefile":   {Prefix: "#"},
+	"markdown":   {Prefix: "<!--", Suffix: "-->"},
+	"nix":        {Prefix: "#"},
+	"php":        {Prefix: "//"},
+	"powershell": {Prefix: "#"},
+	"protobuf":   {Prefix: "//"},
+	"python":     {Prefix: "#"},
+	"ruby":       {Prefix: "#"},
+	"rust":       {Prefix: "//"},
+	"scss":       {Prefix: "//"},
+	"sql":        {Prefix: "--"},
+	"swift":      {Prefix: "//"},
+	"toml":       {Prefix: "#"},
+	"tsx":        {Prefix: "//"},
+	"typescript": {Prefix: "//"},
+	"xml":        {Prefix: "<!--", Suffix: "-->"},
+	"yaml":       {Prefix: "#"},
+	"zsh":        {Prefix: "#"},
+}
+
+// CommentStyleFor returns the comment syntax of the file type of path, as
+// inferred from its name, and whether the file type is known
+func CommentStyleFor(path string) (CommentStyle, bool) {
+	style, ok := commentStyles[languageFor(path)]
+	return style, ok
+}
+
+// Markers returns the BEGIN and END marker prefixes for begin and end
+// wrapped in the comment syntax, and the text closing marker lines
+func (style CommentStyle) Markers(begin, end string) (string, string, string) {
+	suffix := ""
+	if style.Suffix != "" {
+		suffix = " " + style.Suffix
+	}
+
+	return style.Prefix + " " + begin, style.Prefix + " " + end, suffix
+}
+
+// MarkersFor builds the BEGIN and END marker regexes for the file at path
+// with the markers of its format (see FormatFor). When its comment syntax is
+// known, markers only match inside a comment of that file type (e.g. <!--
+// BEGIN SECTION in Markdown, # BEGIN SECTION in YAML); otherwise MakeRegex is
+// used.
+func MarkersFor(path, begin, end string) (*regexp.Regexp, *regexp.Regexp) {
+	return FormatFor(path).Markers(begin, end)
+}
@@ -0,0 +1,80 @@
+package gosect
+
+import "testing"
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test markers matching the comment syntax of file types
+// /////////////////////////////////////////////////////////////////////////////
+func TestMarkersFor(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "Markdown ignores markers outside HTML comments",
+			path:    "README.md",
+			content: "<!-- BEGIN SECTION a file=a.md -->\n<!-- END SECTION a -->\n```yaml\n# BEGIN SECTION b file=b.md\n# END SECTION b\n```\n",
+			want:    []string{"a"},
+		},
+		{
+			name:    "YAML hash comments",
+			path:    "deploy/values.yaml",
+			content: "# BEGIN SECTION a file=a.yaml\n#END SECTION a\n",
+			want:    []string{"a"},
+		},
+		{
+			name:    "Go line comments",
+			path:    "main.go",
+			content: "// BEGIN SECTION a file=a.go\n// END SECTION a\n# BEGIN SECTION b file=b.go\n# END SECTION b\n",
+			want:    []string{"a"},
+		},
+		{
+			name:    "Known file name",
+			path:    "Dockerfile",
+			content: "# BEGIN SECTION a file=a\n# END SECTION a\n",
+			want:    []string{"a"},
+		},
+		{
+			name:    "Unknown file type matches any marker",
+			path:    "notes.txt",
+			content: "BEGIN SECTION a file=a.txt\nEND SECTION a\n",
+			want:    []string{"a"},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reBegin, reEnd := MarkersFor(tt.path, DefaultBegin, DefaultEnd)
+			sections, err := FindSections(tt.content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var names []string
+			for _, s := range sections {
+				names = append(names, s.Name)
+			}
+			if len(names) != len(tt.want) || (len(names) > 0 && names[0] != tt.want[0]) {
+				t.Errorf("Expected sections %v, got %v", tt.want, names)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test marker prefixes built from comment styles
+// /////////////////////////////////////////////////////////////////////////////
+func TestCommentStyleMarkers(t *testing.T) {
+	style, ok := CommentStyleFor("styles.css")
+	if !ok {
+		t.Fatal("Expected CSS comment style")
+	}
+
+	begin, end, suffix := style.Markers(DefaultBegin, DefaultEnd)
+	if begin != "/* BEGIN SECTION" || end != "/* END SECTION" || suffix != " */" {
+		t.Errorf("Unexpected markers %q %q %q", begin, end, suffix)
+	}
+}
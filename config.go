@@ -0,0 +1,203 @@
+package gosect
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/badele/gosect/internal/yaml"
+)
+
+// DefaultConfigFile is the configuration file read from the current
+// directory when it exists
+const DefaultConfigFile = ".gosect.yaml"
+
+// maxConfigDepth limits nested configuration includes
+const maxConfigDepth = 10
+
+// Config is the gosect configuration file
+type Config struct {
+	// Include lists configuration fragments merged before this file, which
+	// overrides them
+	Include []ConfigInclude `json:"include"`
+
+	// Begin and End are the marker prefixes used when -begin and -end are not
+	// given
+	Begin string `json:"begin"`
+	End   string `json:"end"`
+
+	// Roots maps workspace names to directories, so that file=@name/path
+	// reads path from the directory of the name workspace. Relative
+	// directories are resolved from the directory of the configuration file.
+	Roots map[string]string `json:"roots"`
+
+	// Hooks are shell commands run around update runs
+	Hooks ConfigHooks `json:"hooks"`
+
+	// Profiles are the update runs of gosect daemon, by name
+	Profiles map[string]ConfigProfile `json:"profiles"`
+
+	// Lint are the marker policies checked by gosect validate
+	Lint LintRules `json:"lint"`
+}
+
+// ConfigProfile is an update run repeated by gosect daemon on a cron
+// schedule, when its files change, or both
+type ConfigProfile struct {
+	Files    []string `json:"files"`    // glob patterns of the target files
+	Sections []string `json:"sections"` // patterns of the updated sections, all when empty
+	Schedule string   `json:"schedule"` // cron expression of periodic updates
+	Watch    bool     `json:"watch"`    // update when a target or its local sources change
+}
+
+// ConfigHooks lists the shell commands run before the sources of an update
+// are read (Pre) and after its targets are written (Post)
+type ConfigHooks struct {
+	Pre  []string `json:"pre"`
+	Post []string `json:"post"`
+}
+
+// ConfigInclude is a configuration fragment read from a path, relative to
+// the including file, or downloaded from a URL. URL fragments must be pinned
+// with their SHA-256 checksum, which is optional for paths.
+type ConfigInclude struct {
+	Path   string `json:"path"`
+	URL    string `json:"url"`
+	SHA256 string `json:"sha256"`
+}
+
+// LoadConfig reads a YAML (or JSON) configuration file and the fragments it
+// includes. When optional is set, a missing file yields an empty
+// configuration.
+func LoadConfig(path string, optional bool) (*Config, error) {
+	b, err := os.ReadFile(path)
+	if optional && errors.Is(err, fs.ErrNotExist) {
+		return &Config{Roots: map[string]string{}}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return parseConfig(b, path, filepath.Dir(path), 0)
+}
+
+// parseConfig decodes the configuration read from name, resolving relative
+// roots from dir, and merges it over the fragments it includes
+func parseConfig(data []byte, name, dir string, depth int) (*Config, error) {
+	cfg := &Config{}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	if err := cfg.Lint.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	for root, rootDir := range cfg.Roots {
+		if !filepath.IsAbs(rootDir) {
+			cfg.Roots[root] = filepath.Join(dir, rootDir)
+		}
+	}
+
+	merged := &Config{Roots: map[string]string{}}
+	for _, inc := range cfg.Include {
+		if depth >= maxConfigDepth {
+			return nil, fmt.Errorf("%s: configuration includes nested deeper than %d", name, maxConfigDepth)
+		}
+
+		fragment, err := loadInclude(inc, dir, depth+1)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		// hooks run shell commands: only the ones of the file itself count
+		fragment.Hooks = ConfigHooks{}
+		merged.merge(fragment)
+	}
+	merged.merge(cfg)
+
+	return merged, nil
+}
+
+// loadInclude reads an included configuration fragment
+func loadInclude(inc ConfigInclude, dir string, depth int) (*Config, error) {
+	var data []byte
+	var err error
+	name, fragmentDir := inc.Path, dir
+
+	switch {
+	case inc.URL != "":
+		if inc.SHA256 == "" {
+			return nil, fmt.Errorf("include %s must be pinned with sha256", inc.URL)
+		}
+		name = inc.URL
+		data, _, err = Options{}.download(inc.URL)
+	case inc.Path != "":
+		if !filepath.IsAbs(name) {
+			name = filepath.Join(dir, name)
+		}
+		fragmentDir = filepath.Dir(name)
+		data, err = os.ReadFile(name)
+	default:
+		return nil, errors.New("include requires a path or a url")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if inc.SHA256 != "" {
+		if sum := sha256Hex(data); sum != inc.SHA256 {
+			return nil, fmt.Errorf("include %s does not match its sha256 (expected %s, got %s)", name, inc.SHA256, sum)
+		}
+	}
+
+	return parseConfig(data, name, fragmentDir, depth)
+}
+
+// merge overrides the settings of cfg with the ones set in other
+func (cfg *Config) merge(other *Config) {
+	cfg.Begin = cmp.Or(other.Begin, cfg.Begin)
+	cfg.End = cmp.Or(other.End, cfg.End)
+	maps.Copy(cfg.Roots, other.Roots)
+	if len(other.Hooks.Pre) > 0 {
+		cfg.Hooks.Pre = other.Hooks.Pre
+	}
+	if len(other.Hooks.Post) > 0 {
+		cfg.Hooks.Post = other.Hooks.Post
+	}
+	if len(other.Profiles) > 0 && cfg.Profiles == nil {
+		cfg.Profiles = map[string]ConfigProfile{}
+	}
+	maps.Copy(cfg.Profiles, other.Profiles)
+	cfg.Lint.merge(other.Lint)
+}
+
+// SourcePath returns the path of the file= source of a section, resolving
+// workspace prefixes (@name/path) and relative paths from the base directory.
+// Backslash separators are converted to slashes outside Windows.
+func (opts Options) SourcePath(s Section) (string, error) {
+	path, err := localPath(s, s.SrcFile, runtime.GOOS)
+	if err != nil {
+		return "", err
+	}
+
+	rest, ok := strings.CutPrefix(path, "@")
+	if !ok {
+		if opts.BaseDir == "" || filepath.IsAbs(path) {
+			return path, nil
+		}
+		return filepath.Join(opts.BaseDir, path), nil
+	}
+
+	name, rel := cutRoot(rest, runtime.GOOS)
+	root, ok := opts.Roots[name]
+	if !ok {
+		return "", fmt.Errorf("section %s: unknown workspace root @%s", s.Name, name)
+	}
+
+	return filepath.Join(root, filepath.FromSlash(rel)), nil
+}
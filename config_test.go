@@ -0,0 +1,168 @@
+package gosect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test workspace roots of the configuration file
+// /////////////////////////////////////////////////////////////////////////////
+func TestWorkspaceRoots(t *testing.T) {
+	tmpDir := t.TempDir()
+	shared := filepath.Join(tmpDir, "shared", "snippets")
+	if err := os.MkdirAll(shared, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(shared, "license.md"), []byte("MIT License\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	repo := filepath.Join(tmpDir, "repo")
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		t.Fatal(err)
+	}
+	configFile := filepath.Join(repo, DefaultConfigFile)
+	if err := os.WriteFile(configFile, []byte("roots:\n  shared: ../shared\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := LoadConfig(configFile, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Roots["shared"] != filepath.Join(tmpDir, "shared") {
+		t.Fatalf("Expected root relative to the configuration file, got %q", cfg.Roots["shared"])
+	}
+
+	result := replaceAll(t, "<!-- BEGIN SECTION license file=@shared/snippets/license.md -->\n<!-- END SECTION license -->\n", Options{Roots: cfg.Roots})
+	if !strings.Contains(result, "\nMIT License\n") {
+		t.Errorf("Expected shared snippet, got %q", result)
+	}
+
+	content := "<!-- BEGIN SECTION license file=@unknown/license.md -->\n<!-- END SECTION license -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Replace([]byte(content), sections, Options{Roots: cfg.Roots}); err == nil || !strings.Contains(err.Error(), "@unknown") {
+		t.Errorf("Expected unknown root error, got %v", err)
+	}
+
+	// a missing optional configuration is empty
+	if _, err := LoadConfig(filepath.Join(tmpDir, "missing.yaml"), true); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := LoadConfig(filepath.Join(tmpDir, "missing.yaml"), false); err == nil {
+		t.Error("Expected error for a missing configuration")
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test configuration includes and hash pinning
+// /////////////////////////////////////////////////////////////////////////////
+func TestConfigInclude(t *testing.T) {
+	remote := "begin: BEGIN SNIPPET\nend: END SNIPPET\nroots:\n  platform: /opt/platform\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml")
+		w.Write([]byte(remote))
+	}))
+	defer server.Close()
+
+	tmpDir := t.TempDir()
+	base := filepath.Join(tmpDir, "base", "gosect.yaml")
+	if err := os.MkdirAll(filepath.Dir(base), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(base, []byte("end: END BASE\nroots:\n  shared: snippets\nhooks:\n  pre:\n    - make examples\n  post:\n    - gofmt -w .\nprofiles:\n  remote:\n    files:\n      - README.md\n    schedule: \"0 2 * * *\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		config  string
+		wantErr bool
+	}{
+		{
+			name:   "Pinned URL and path",
+			config: "include:\n  - url: " + server.URL + "\n    sha256: " + sha256Hex([]byte(remote)) + "\n  - path: base/gosect.yaml\nend: END LOCAL\nhooks:\n  post:\n    - prettier --write README.md\nprofiles:\n  local:\n    files:\n      - docs/*.md\n    watch: true\n",
+		},
+		{
+			name:    "Unpinned URL",
+			config:  "include:\n  - url: " + server.URL + "\n",
+			wantErr: true,
+		},
+		{
+			name:    "Checksum mismatch",
+			config:  "include:\n  - url: " + server.URL + "\n    sha256: 0000\n",
+			wantErr: true,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(tmpDir, DefaultConfigFile)
+			if err := os.WriteFile(path, []byte(tt.config), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			cfg, err := LoadConfig(path, false)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			// later fragments and the including file override earlier ones
+			if cfg.Begin != "BEGIN SNIPPET" || cfg.End != "END LOCAL" {
+				t.Errorf("Unexpected markers %q %q", cfg.Begin, cfg.End)
+			}
+			if cfg.Roots["platform"] != "/opt/platform" || cfg.Roots["shared"] != filepath.Join(tmpDir, "base", "snippets") {
+				t.Errorf("Unexpected roots %v", cfg.Roots)
+			}
+			// hooks of included fragments are ignored
+			if len(cfg.Hooks.Pre) > 0 || !slices.Equal(cfg.Hooks.Post, []string{"prettier --write README.md"}) {
+				t.Errorf("Unexpected hooks %+v", cfg.Hooks)
+			}
+			if cfg.Profiles["remote"].Schedule != "0 2 * * *" || !cfg.Profiles["local"].Watch {
+				t.Errorf("Unexpected profiles %+v", cfg.Profiles)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test relative sources resolved from the base directory
+// /////////////////////////////////////////////////////////////////////////////
+func TestBaseDir(t *testing.T) {
+	tmpDir := t.TempDir()
+	docs := filepath.Join(tmpDir, "docs")
+	if err := os.MkdirAll(filepath.Join(docs, "parts"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// nested sources are resolved from the directory of the including file
+	files := map[string]string{
+		"parts/outer.md": "outer\n<!-- BEGIN SECTION inner file=inner.md -->\n<!-- END SECTION inner -->\n",
+		"parts/inner.md": "inner\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(docs, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result := replaceAll(t, "<!-- BEGIN SECTION outer file=parts/outer.md -->\n<!-- END SECTION outer -->\n", Options{BaseDir: docs})
+	if !strings.Contains(result, "\nouter\n") || !strings.Contains(result, "\ninner\n") {
+		t.Errorf("Expected sources resolved from %s, got %q", docs, result)
+	}
+}
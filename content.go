@@ -0,0 +1,256 @@
+package gosect
+
+import (
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// resolve and render section content
+// /////////////////////////////////////////////////////////////////////////////
+
+// sectionContent reads the source of a section of the target document doc
+// and applies the rendering steps requested by its attributes
+func (opts Options) sectionContent(s Section, doc []byte, chain []string) ([]byte, error) {
+	if opts.ExpandEnv {
+		var err error
+		if s, err = expandEnv(s); err != nil {
+			return nil, err
+		}
+	}
+
+	s, err := opts.expandLanguage(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := opts.checkAsset(s); err != nil {
+		return nil, err
+	}
+
+	raw, err := opts.loadSource(s, doc)
+	if err != nil {
+		return nil, err
+	}
+	defer raw.close()
+	src, err := opts.selectRegion(s, selectFrontMatter(s, raw.data))
+	if err != nil {
+		return nil, err
+	}
+
+	src, err = selectObject(s, src)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err = selectLines(s, src)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err = selectHeadTail(s, src)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err = renderFormat(s, src)
+	if err != nil {
+		return nil, err
+	}
+	src = opts.substituteVars(s, src)
+
+	if layout, ok := s.Attrs["layout"]; ok {
+		src, err = opts.renderLayout(s, layout, src)
+		if err != nil {
+			return nil, err
+		}
+	} else if opts.templateEnabled(s) {
+		src, err = opts.renderTemplate(s, src)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	src, err = opts.expandNested(s, src, chain)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err = opts.applyTransforms(s, src)
+	if err != nil {
+		return nil, err
+	}
+
+	src, err = applyWidth(s, bytes.TrimSpace(src))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := opts.validateDiagram(s, src); err != nil {
+		return nil, err
+	}
+	if fence, lang := fenceEnabled(s); fence {
+		src = wrapFence(src, lang)
+	}
+	src = applyPrefix(s, src)
+
+	return raw.detach(src), nil
+}
+
+// loadSource returns the raw content of the file=, extractor (such as
+// openapi=), ref=, url=, cmd= or src= source of a section of the target
+// document doc, or the image of its asset= file
+func (opts Options) loadSource(s Section, doc []byte) (*source, error) {
+	ref, gitPath, isGit, err := gitSource(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if data, ok, err := opts.resolve(s); ok {
+		if err != nil {
+			return nil, err
+		}
+		return &source{data: data}, nil
+	}
+
+	if s.SrcFile != "" && !isGit {
+		path, err := opts.SourcePath(s)
+		if err != nil {
+			return nil, err
+		}
+		src, err := openSource(path, opts.mmapThreshold(), opts.MaxSourceSize, opts.OnOversize == OversizeTruncate)
+		if errors.Is(err, fs.ErrNotExist) {
+			err = missingSourceError{err}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", s.Name, err)
+		}
+		if src.truncated {
+			opts.logger().Warn("source truncated", "section", s.Name, "source", path, "bytes", len(src.data))
+		}
+		return src, nil
+	}
+
+	var data []byte
+	if isGit {
+		data, err = opts.readGit(s, ref, gitPath)
+	} else if attr, spec, ok := ExtractorSource(s); ok {
+		data, err = opts.readExtractor(s, attr, spec)
+	} else if path, name, ok := RefSource(s); ok {
+		data, err = opts.readRef(s, path, name)
+	} else if url, ok := s.Attrs["url"]; ok {
+		data, err = opts.fetchURL(s, url)
+	} else if line, ok := s.Attrs["cmd"]; ok {
+		data, err = opts.runCommand(s, line)
+	} else if builtinSource(s) {
+		data, err = readBuiltin(s, doc)
+	} else if _, ok := s.Attrs["asset"]; ok {
+		data = assetImage(s)
+	} else {
+		err = fmt.Errorf("section %s has no file=, ref=, url=, cmd= or src= source", s.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &source{data: data}, nil
+}
+
+// sourceName describes the source of a section in logs
+func sourceName(s Section) string {
+	if s.SrcFile != "" {
+		return s.SrcFile
+	}
+	if url, ok := s.Attrs["url"]; ok {
+		return url
+	}
+	if attr, spec, ok := ExtractorSource(s); ok {
+		return attr + ":" + redactDatabaseURL(spec)
+	}
+	if _, ok := s.Attrs["ref"]; ok {
+		return "ref:" + s.Attrs["ref"]
+	}
+	if builtinSource(s) {
+		return "src:" + s.Attrs["src"]
+	}
+	if _, ok := s.Attrs["asset"]; ok {
+		return "asset:" + s.Attrs["asset"]
+	}
+
+	return "cmd:" + s.Attrs["cmd"]
+}
+
+// sourceKey identifies the source of a section in include chains
+func (opts Options) sourceKey(s Section) (string, error) {
+	if opts.SchemeSource(s) {
+		return s.SrcFile, nil
+	}
+	if s.SrcFile != "" {
+		path, err := opts.SourcePath(s)
+		if err != nil {
+			return "", err
+		}
+		return filepath.Abs(path)
+	}
+	if _, name, ok := RefSource(s); ok {
+		path, err := opts.refPath(s)
+		return path + "#" + name, err
+	}
+	if url, ok := s.Attrs["url"]; ok {
+		return url, nil
+	}
+
+	return sourceName(s), nil
+}
+
+// expandNested replaces the sections found in the source of s, detecting
+// inclusion cycles and enforcing the maximum depth
+func (opts Options) expandNested(s Section, src []byte, chain []string) ([]byte, error) {
+	reBegin, reEnd := opts.markers()
+	if !HasMarkers(src, reBegin) {
+		return src, nil
+	}
+
+	path, err := opts.sourceKey(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if slices.Contains(chain, path) {
+		cycle := append(slices.Clone(chain), path)
+		return nil, fmt.Errorf("include cycle detected in section %s: %s", s.Name, strings.Join(cycle, " -> "))
+	}
+
+	if len(chain) >= opts.maxDepth() {
+		return nil, fmt.Errorf("section %s: include depth exceeds %d", s.Name, opts.maxDepth())
+	}
+
+	source := cmp.Or(s.SrcFile, path)
+	sections, err := FindSectionsBytes(src, reBegin, reEnd)
+	if err != nil {
+		return nil, &IncludeError{Section: s.Name, Source: source, Err: FileErrors(source, err)}
+	}
+
+	// nested sections of git: and resolved sources resolve relative to the
+	// including document, those of ref= sources to the referenced document
+	nested := opts
+	nested.Format = nil
+	if s.SrcFile != "" && !opts.SchemeSource(s) {
+		nested.BaseDir = filepath.Dir(path)
+	} else if _, name, ok := RefSource(s); ok {
+		nested.BaseDir = filepath.Dir(strings.TrimSuffix(path, "#"+name))
+	}
+
+	out, err := nested.replace(src, sections, append(slices.Clone(chain), path))
+	if err != nil {
+		return nil, &IncludeError{Section: s.Name, Source: source, Err: FileErrors(source, err)}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,83 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test recursive section expansion
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceNested(t *testing.T) {
+	tmpDir := t.TempDir()
+	deep := filepath.Join(tmpDir, "deep.txt")
+	leaf := filepath.Join(tmpDir, "leaf.md")
+	middle := filepath.Join(tmpDir, "middle.md")
+
+	err := os.WriteFile(deep, []byte("DEEP CONTENT"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(leaf, []byte("LEAF CONTENT\n<!-- BEGIN SECTION deep file="+deep+" -->\n<!-- END SECTION deep -->\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(middle, []byte("Middle\n<!-- BEGIN SECTION leaf file="+leaf+" -->\n<!-- END SECTION leaf -->\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := "<!-- BEGIN SECTION middle file=" + middle + " -->\n<!-- END SECTION middle -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Replace([]byte(content), sections, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{"Middle", "LEAF CONTENT", "DEEP CONTENT", "<!-- END SECTION leaf -->"} {
+		if !strings.Contains(string(result), want) {
+			t.Errorf("Expected result to contain %q, got:\n%s", want, result)
+		}
+	}
+
+	// Depth limit
+	_, err = Replace([]byte(content), sections, Options{MaxDepth: 1})
+	if err == nil || !strings.Contains(err.Error(), "depth") {
+		t.Errorf("Expected depth error, got %v", err)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test inclusion cycle detection
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceCycle(t *testing.T) {
+	tmpDir := t.TempDir()
+	a := filepath.Join(tmpDir, "a.md")
+	b := filepath.Join(tmpDir, "b.md")
+
+	err := os.WriteFile(a, []byte("<!-- BEGIN SECTION b file="+b+" -->\n<!-- END SECTION b -->\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(b, []byte("<!-- BEGIN SECTION a file="+a+" -->\n<!-- END SECTION a -->\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := "<!-- BEGIN SECTION a file=" + a + " -->\n<!-- END SECTION a -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Replace([]byte(content), sections, Options{})
+	if err == nil || !strings.Contains(err.Error(), "cycle") {
+		t.Fatalf("Expected cycle error, got %v", err)
+	}
+}
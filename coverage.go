@@ -0,0 +1,115 @@
+package gosect
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// coverage= sources: Go coverage profiles
+// /////////////////////////////////////////////////////////////////////////////
+
+// coverBlock is a block of a coverage profile: its number of statements and
+// whether they ran
+type coverBlock struct {
+	statements int
+	covered    bool
+}
+
+// packageCoverage counts the statements of a package and the covered ones
+type packageCoverage struct {
+	statements, covered int
+}
+
+// extractCoverage renders the go test -coverprofile file of the coverage=
+// source of s as a Markdown table of the statement coverage of each package,
+// with a total row. Blocks repeated by profiles of several test runs are
+// covered when any run covered them. The match= attribute selects packages
+// by comma separated glob patterns, and trim= removes a prefix, such as the
+// module path, from their names.
+func extractCoverage(s Section, data []byte) ([]byte, error) {
+	lines := strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")
+	if !strings.HasPrefix(lines[0], "mode: ") {
+		return nil, fmt.Errorf("section %s: %s is not a coverage profile", s.Name, s.Attrs["coverage"])
+	}
+
+	// blocks by file:start,end, merging repeated blocks
+	blocks := map[string]coverBlock{}
+	for i, line := range lines[1:] {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "mode: ") {
+			continue
+		}
+		key, block, ok := parseCoverBlock(line)
+		if !ok {
+			return nil, fmt.Errorf("section %s: %s:%d: invalid coverage block", s.Name, s.Attrs["coverage"], i+2)
+		}
+		block.covered = block.covered || blocks[key].covered
+		blocks[key] = block
+	}
+
+	var patterns []string
+	if match, ok := s.Attrs["match"]; ok {
+		patterns = strings.Split(match, ",")
+	}
+
+	packages := map[string]*packageCoverage{}
+	var total packageCoverage
+	for key, block := range blocks {
+		file, _, _ := strings.Cut(key, ":")
+		pkg := path.Dir(file)
+		if patterns != nil && !matchAny(patterns, pkg) {
+			continue
+		}
+		if packages[pkg] == nil {
+			packages[pkg] = &packageCoverage{}
+		}
+		for _, c := range []*packageCoverage{packages[pkg], &total} {
+			c.statements += block.statements
+			if block.covered {
+				c.covered += block.statements
+			}
+		}
+	}
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("section %s: no package covered in %s", s.Name, s.Attrs["coverage"])
+	}
+
+	var rows [][]string
+	for _, pkg := range slices.Sorted(maps.Keys(packages)) {
+		name := cmp.Or(strings.TrimPrefix(pkg, s.Attrs["trim"]), pkg)
+		rows = append(rows, coverageRow(tableCell(name), *packages[pkg]))
+	}
+	rows = append(rows, coverageRow("**Total**", total))
+
+	return formatTable([]string{"Package", "Statements", "Covered", "Coverage"}, rows), nil
+}
+
+// parseCoverBlock parses a block line of a coverage profile, such as
+// example.com/pkg/file.go:10.2,12.3 2 1, returning its file:start,end key
+func parseCoverBlock(line string) (string, coverBlock, bool) {
+	fields := strings.Fields(line)
+	if len(fields) != 3 || !strings.Contains(fields[0], ":") {
+		return "", coverBlock{}, false
+	}
+	statements, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return "", coverBlock{}, false
+	}
+
+	return fields[0], coverBlock{statements: statements, covered: fields[2] != "0"}, true
+}
+
+// coverageRow returns the cells of the coverage of a package
+func coverageRow(name string, c packageCoverage) []string {
+	percent := 0.0
+	if c.statements > 0 {
+		percent = 100 * float64(c.covered) / float64(c.statements)
+	}
+
+	return []string{name, strconv.Itoa(c.statements), strconv.Itoa(c.covered), fmt.Sprintf("%.1f%%", percent)}
+}
@@ -0,0 +1,83 @@
+package gosect
+
+import (
+	"strings"
+	"testing"
+)
+
+// coverProfile is the coverage profile of the coverage= tests, with blocks
+// repeated by a second test run
+const coverProfile = `mode: set
+example.com/tool/cli/main.go:10.2,12.3 2 1
+example.com/tool/cli/main.go:14.2,16.3 3 0
+example.com/tool/parse.go:5.2,9.3 4 1
+example.com/tool/parse.go:11.2,13.3 1 0
+example.com/tool/cli/main.go:14.2,16.3 3 1
+example.com/tool/internal/x/x.go:1.2,2.3 2 0
+`
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test rendering the package coverage of coverage= sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestExtractCoverage(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		attrs   map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "Packages and total",
+			data:  coverProfile,
+			attrs: map[string]string{"coverage": "coverage.out"},
+			want: "| Package                     | Statements | Covered | Coverage |\n" +
+				"| --------------------------- | ---------: | ------: | -------: |\n" +
+				"| example.com/tool            |          5 |       4 |    80.0% |\n" +
+				"| example.com/tool/cli        |          5 |       5 |   100.0% |\n" +
+				"| example.com/tool/internal/x |          2 |       0 |     0.0% |\n" +
+				"| **Total**                   |         12 |       9 |    75.0% |\n",
+		},
+		{
+			name:  "Selected packages with trimmed names",
+			data:  coverProfile,
+			attrs: map[string]string{"coverage": "coverage.out", "match": "example.com/tool,example.com/tool/cli", "trim": "example.com/tool/"},
+			want: "| Package          | Statements | Covered | Coverage |\n" +
+				"| ---------------- | ---------: | ------: | -------: |\n" +
+				"| example.com/tool |          5 |       4 |    80.0% |\n" +
+				"| cli              |          5 |       5 |   100.0% |\n" +
+				"| **Total**        |         10 |       9 |    90.0% |\n",
+		},
+		{
+			name:    "Not a coverage profile",
+			data:    "PASS\n",
+			attrs:   map[string]string{"coverage": "coverage.out"},
+			wantErr: "coverage.out is not a coverage profile",
+		},
+		{
+			name:    "Invalid block",
+			data:    "mode: set\nexample.com/tool/parse.go:5.2,9.3 x 1\n",
+			attrs:   map[string]string{"coverage": "coverage.out"},
+			wantErr: "coverage.out:2: invalid coverage block",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractCoverage(Section{Name: "coverage", Attrs: tt.attrs}, []byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
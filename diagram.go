@@ -0,0 +1,213 @@
+package gosect
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// validate=true: syntax checks of Mermaid and PlantUML diagrams
+// /////////////////////////////////////////////////////////////////////////////
+
+// mermaidDiagrams are the keywords starting a Mermaid diagram
+var mermaidDiagrams = map[string]bool{
+	"flowchart": true, "graph": true, "sequenceDiagram": true,
+	"classDiagram": true, "classDiagram-v2": true, "stateDiagram": true,
+	"stateDiagram-v2": true, "erDiagram": true, "journey": true, "gantt": true,
+	"pie": true, "quadrantChart": true, "requirementDiagram": true,
+	"gitGraph": true, "C4Context": true, "C4Container": true,
+	"C4Component": true, "C4Dynamic": true, "C4Deployment": true,
+	"mindmap": true, "timeline": true, "sankey-beta": true,
+	"xychart-beta": true, "block-beta": true, "packet-beta": true,
+	"architecture-beta": true, "kanban": true, "radar-beta": true,
+}
+
+// mermaidBlocks are the statements opening a block closed by end, by
+// diagram type
+var mermaidBlocks = map[string]*regexp.Regexp{
+	"flowchart":       regexp.MustCompile(`^subgraph\b`),
+	"graph":           regexp.MustCompile(`^subgraph\b`),
+	"sequenceDiagram": regexp.MustCompile(`^(?:loop|alt|opt|par|critical|break|rect|box)\b`),
+	"block-beta":      regexp.MustCompile(`^block\b`),
+}
+
+// plantUMLBlocks pairs the PlantUML statements opening a block with the
+// statement closing it
+var plantUMLBlocks = []struct {
+	open, close *regexp.Regexp
+	name        string
+}{
+	{regexp.MustCompile(`^if\s*\(`), regexp.MustCompile(`^end\s?if\b`), "if"},
+	{regexp.MustCompile(`^while\s*\(`), regexp.MustCompile(`^end\s?while\b`), "while"},
+	{regexp.MustCompile(`^fork\b`), regexp.MustCompile(`^end\s?fork\b|^end merge\b`), "fork"},
+	{regexp.MustCompile(`^repeat\s*$`), regexp.MustCompile(`^repeat\s?while\b`), "repeat"},
+}
+
+// rePlantUMLStart matches the @startuml (or @startmindmap...) line of a
+// PlantUML diagram, capturing its type
+var rePlantUMLStart = regexp.MustCompile(`^@start(\w+)`)
+
+// diagramLanguage returns the diagram language of s, mermaid or plantuml,
+// from its lang= attribute or the extension of its source file
+func diagramLanguage(s Section) string {
+	lang, ok := s.Attrs["lang"]
+	if !ok {
+		lang = languageFor(s.SrcFile)
+	}
+	if lang == "puml" {
+		lang = "plantuml"
+	}
+
+	return lang
+}
+
+// validateDiagram checks the syntax of the Mermaid or PlantUML diagram src
+// of s, with validate=true or Options.ValidateDiagrams, so broken diagrams
+// fail when documents are generated rather than when they are rendered.
+// validate=false disables the check of a section.
+func (opts Options) validateDiagram(s Section, src []byte) error {
+	validate := s.Attrs["validate"]
+	if validate == "false" || (validate != "true" && !opts.ValidateDiagrams) {
+		return nil
+	}
+
+	var err error
+	lang := diagramLanguage(s)
+	switch lang {
+	case "mermaid":
+		err = checkMermaid(src)
+	case "plantuml":
+		err = checkPlantUML(src)
+	default:
+		if validate == "true" {
+			return fmt.Errorf("section %s: validate=true needs lang=mermaid or lang=plantuml, not %q", s.Name, lang)
+		}
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("section %s: invalid %s diagram: %w", s.Name, lang, err)
+	}
+
+	return nil
+}
+
+// checkMermaid checks that src starts with a known diagram type, that its
+// quotes are closed on each line and that its blocks are closed by end
+func checkMermaid(src []byte) error {
+	diagram := ""
+	frontMatter := false
+	var open []int // lines of the open blocks
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "---" && diagram == "":
+			frontMatter = !frontMatter
+			continue
+		case frontMatter, line == "", strings.HasPrefix(line, "%%"):
+			continue
+		case diagram == "":
+			diagram = strings.Fields(line)[0]
+			if !mermaidDiagrams[diagram] {
+				return fmt.Errorf("line %d: unknown diagram type %s", n, diagram)
+			}
+			continue
+		}
+
+		if strings.Count(line, `"`)%2 != 0 {
+			return fmt.Errorf("line %d: unclosed quote", n)
+		}
+		if re := mermaidBlocks[diagram]; re != nil && re.MatchString(line) {
+			open = append(open, n)
+		} else if line == "end" && len(open) > 0 {
+			open = open[:len(open)-1]
+		} else if line == "end" && mermaidBlocks[diagram] != nil {
+			return fmt.Errorf("line %d: end without block", n)
+		}
+	}
+	if diagram == "" {
+		return fmt.Errorf("no diagram")
+	}
+	if len(open) > 0 {
+		return fmt.Errorf("line %d: block not closed by end", open[len(open)-1])
+	}
+
+	return nil
+}
+
+// checkPlantUML checks that the @start and @end lines of src match, that
+// its braces are balanced and that its if, while, fork and repeat blocks are
+// closed
+func checkPlantUML(src []byte) error {
+	start, startLine := "", 0
+	braces, braceLine := 0, 0
+	type block struct {
+		name string
+		line int
+	}
+	var open []block
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "'") {
+			continue
+		}
+
+		if m := rePlantUMLStart.FindStringSubmatch(line); m != nil {
+			if start != "" {
+				return fmt.Errorf("line %d: @start%s inside @start%s", n, m[1], start)
+			}
+			start, startLine = m[1], n
+			continue
+		}
+		if end, ok := strings.CutPrefix(line, "@end"); ok {
+			if end = strings.Fields(end + " ")[0]; end != start {
+				return fmt.Errorf("line %d: @end%s does not match @start%s", n, end, start)
+			}
+			start = ""
+			continue
+		}
+
+		for _, c := range line {
+			switch c {
+			case '{':
+				if braces == 0 {
+					braceLine = n
+				}
+				braces++
+			case '}':
+				braces--
+			}
+			if braces < 0 {
+				return fmt.Errorf("line %d: unbalanced }", n)
+			}
+		}
+
+		for _, b := range plantUMLBlocks {
+			switch {
+			case b.open.MatchString(line):
+				open = append(open, block{b.name, n})
+			case b.close.MatchString(line):
+				if len(open) == 0 || open[len(open)-1].name != b.name {
+					return fmt.Errorf("line %d: %s without %s", n, line, b.name)
+				}
+				open = open[:len(open)-1]
+			}
+		}
+	}
+
+	switch {
+	case start != "":
+		return fmt.Errorf("line %d: @start%s without @end%s", startLine, start, start)
+	case braces > 0:
+		return fmt.Errorf("line %d: unclosed {", braceLine)
+	case len(open) > 0:
+		b := open[len(open)-1]
+		return fmt.Errorf("line %d: %s not closed", b.line, b.name)
+	}
+
+	return nil
+}
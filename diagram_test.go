@@ -0,0 +1,195 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the syntax checks of Mermaid and PlantUML diagrams
+// /////////////////////////////////////////////////////////////////////////////
+func TestCheckDiagrams(t *testing.T) {
+	tests := []struct {
+		name    string
+		check   func([]byte) error
+		src     string
+		wantErr string
+	}{
+		{
+			name:  "Mermaid flowchart",
+			check: checkMermaid,
+			src:   "---\ntitle: Build\n---\n%% pipeline\nflowchart LR\n  subgraph ci\n    a[\"lint\"] --> b\n  end\n",
+		},
+		{
+			name:  "Mermaid sequence diagram",
+			check: checkMermaid,
+			src:   "sequenceDiagram\n  loop every minute\n    alt ok\n      A->>B: ping\n    else\n      A->>C: ping\n    end\n  end\n",
+		},
+		{
+			name:    "Mermaid unknown diagram type",
+			check:   checkMermaid,
+			src:     "%% typo\nflowchar LR\n  a --> b\n",
+			wantErr: "line 2: unknown diagram type flowchar",
+		},
+		{
+			name:    "Mermaid unclosed subgraph",
+			check:   checkMermaid,
+			src:     "graph TD\n  subgraph one\n  a --> b\n",
+			wantErr: "line 2: block not closed by end",
+		},
+		{
+			name:    "Mermaid end without block",
+			check:   checkMermaid,
+			src:     "sequenceDiagram\n  A->>B: hi\nend\n",
+			wantErr: "line 3: end without block",
+		},
+		{
+			name:    "Mermaid unclosed quote",
+			check:   checkMermaid,
+			src:     "flowchart LR\n  a[\"lint] --> b\n",
+			wantErr: "line 2: unclosed quote",
+		},
+		{
+			name:    "Mermaid empty diagram",
+			check:   checkMermaid,
+			src:     "%% nothing\n",
+			wantErr: "no diagram",
+		},
+		{
+			name:  "PlantUML activity diagram",
+			check: checkPlantUML,
+			src:   "@startuml\n' build\nstart\nif (ok?) then (yes)\n  :deploy;\nelse (no)\n  while (retry?)\n    :build;\n  endwhile\nendif\nstop\n@enduml\n",
+		},
+		{
+			name:  "PlantUML component braces",
+			check: checkPlantUML,
+			src:   "@startuml\npackage api {\n  [Server]\n}\n@enduml\n",
+		},
+		{
+			name:    "PlantUML missing @enduml",
+			check:   checkPlantUML,
+			src:     "@startuml\nA -> B\n",
+			wantErr: "line 1: @startuml without @enduml",
+		},
+		{
+			name:    "PlantUML mismatched @end",
+			check:   checkPlantUML,
+			src:     "@startmindmap\n* root\n@enduml\n",
+			wantErr: "line 3: @enduml does not match @startmindmap",
+		},
+		{
+			name:    "PlantUML unclosed brace",
+			check:   checkPlantUML,
+			src:     "@startuml\npackage api {\n  [Server]\n@enduml\n",
+			wantErr: "line 2: unclosed {",
+		},
+		{
+			name:    "PlantUML unclosed if",
+			check:   checkPlantUML,
+			src:     "@startuml\nstart\nif (ok?) then (yes)\n  :deploy;\nstop\n@enduml\n",
+			wantErr: "line 3: if not closed",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.check([]byte(tt.src))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test validate= and Options.ValidateDiagrams on sections
+// /////////////////////////////////////////////////////////////////////////////
+func TestValidateDiagrams(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"broken.mmd":  "flowchart LR\n  subgraph ci\n  a --> b\n",
+		"broken.puml": "@startuml\nA -> B\n",
+		"broken.txt":  "flowchart LR\n  subgraph ci\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		attrs    string
+		validate bool
+		wantErr  string
+	}{
+		{
+			name:  "Not validated by default",
+			attrs: "file=broken.mmd",
+		},
+		{
+			name:    "validate=true",
+			attrs:   "file=broken.mmd validate=true",
+			wantErr: "section diagram: invalid mermaid diagram: line 2: block not closed by end",
+		},
+		{
+			name:     "ValidateDiagrams",
+			attrs:    "file=broken.puml",
+			validate: true,
+			wantErr:  "section diagram: invalid plantuml diagram: line 1: @startuml without @enduml",
+		},
+		{
+			name:     "lang= attribute",
+			attrs:    "file=broken.txt lang=mermaid",
+			validate: true,
+			wantErr:  "section diagram: invalid mermaid diagram",
+		},
+		{
+			name:     "validate=false",
+			attrs:    "file=broken.mmd validate=false",
+			validate: true,
+		},
+		{
+			name:     "Other languages are not validated",
+			attrs:    "file=broken.txt",
+			validate: true,
+		},
+		{
+			name:    "validate=true needs a diagram",
+			attrs:   "file=broken.txt validate=true",
+			wantErr: "section diagram: validate=true needs lang=mermaid or lang=plantuml",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "<!-- BEGIN SECTION diagram " + tt.attrs + " -->\n<!-- END SECTION diagram -->\n"
+			sections, err := FindSections(content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			opts := Options{BaseDir: tmpDir, ValidateDiagrams: tt.validate}
+			_, err = Replace([]byte(content), sections, opts)
+
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Expected no error, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/badele/gosect/internal/yaml"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// dockerfile= and compose= sources: build stages and services
+// /////////////////////////////////////////////////////////////////////////////
+
+// reDockerFrom matches the FROM instruction starting a build stage, capturing
+// the name of the stage
+var reDockerFrom = regexp.MustCompile(`(?im)^[ \t]*FROM[ \t]+(?:--\S+[ \t]+)*\S+(?:[ \t]+AS[ \t]+([^\s#]+))?[ \t]*\r?$`)
+
+// extractDockerfile returns the build stage of the dockerfile= source of s
+// named by its stage= attribute, or its index for unnamed stages, from its
+// FROM instruction and the comments above it to the next stage
+func extractDockerfile(s Section, data []byte) ([]byte, error) {
+	stage, ok := s.Attrs["stage"]
+	if !ok {
+		return nil, fmt.Errorf("section %s has dockerfile= but no stage= attribute", s.Name)
+	}
+
+	froms := reDockerFrom.FindAllSubmatchIndex(data, -1)
+	index, err := strconv.Atoi(stage)
+	if err != nil {
+		index = -1
+		for i, from := range froms {
+			if from[2] != -1 && strings.EqualFold(string(data[from[2]:from[3]]), stage) {
+				index = i
+				break
+			}
+		}
+	}
+	if index < 0 || index >= len(froms) {
+		return nil, fmt.Errorf("section %s: no stage %s in %s", s.Name, stage, s.Attrs["dockerfile"])
+	}
+
+	end := len(data)
+	if index+1 < len(froms) {
+		end = commentStart(data, froms[index+1][0], "#")
+	}
+	out := dedent(bytes.TrimRight(data[commentStart(data, froms[index][0], "#"):end], " \t\r\n"))
+
+	return append(out, '\n'), nil
+}
+
+// extractCompose returns the block of the service of the compose= source of
+// s named by its service= attribute, with the comments above it
+func extractCompose(s Section, data []byte) ([]byte, error) {
+	name, ok := s.Attrs["service"]
+	if !ok {
+		return nil, fmt.Errorf("section %s has compose= but no service= attribute", s.Name)
+	}
+
+	docs, err := yaml.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %s: %w", s.Name, s.Attrs["compose"], err)
+	}
+	var services *yaml.Node
+	if len(docs) > 0 {
+		services = docs[0].Get("services")
+	}
+	service := services.Get(name)
+	if service == nil {
+		return nil, fmt.Errorf("section %s: no service %s in %s", s.Name, name, s.Attrs["compose"])
+	}
+
+	// offsets of the start of each line, and of the end of data
+	starts := []int{0}
+	for i, c := range data {
+		if c == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	if starts[len(starts)-1] != len(data) {
+		starts = append(starts, len(data))
+	}
+
+	// the service starts at its key, above its value unless inline
+	reKey := regexp.MustCompile(`^[ \t]*(?:` + regexp.QuoteMeta(name) + `|"` + regexp.QuoteMeta(name) + `"|'` + regexp.QuoteMeta(name) + `')[ \t]*:`)
+	first := service.Line
+	for line := service.Line; line >= services.Line-1 && line >= 1; line-- {
+		if reKey.Match(data[starts[line-1]:starts[line]]) {
+			first = line
+			break
+		}
+	}
+	last := min(service.EndLine, len(starts)-1)
+	out := dedent(bytes.TrimRight(data[commentStart(data, starts[first-1], "#"):starts[last]], " \t\r\n"))
+
+	return append(out, '\n'), nil
+}
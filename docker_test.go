@@ -0,0 +1,123 @@
+package gosect
+
+import (
+	"strings"
+	"testing"
+)
+
+// dockerfile is the Dockerfile of the dockerfile= tests
+const dockerfile = `# syntax=docker/dockerfile:1
+
+# Build the binary
+FROM golang:1.25 AS build
+WORKDIR /src
+RUN go build -o /gosect ./cmd/gosect
+
+# Minimal runtime image
+FROM --platform=linux/amd64 gcr.io/distroless/static AS runtime
+COPY --from=build /gosect /gosect
+
+FROM scratch
+COPY --from=runtime / /
+`
+
+// composeFile is the Compose file of the compose= tests
+const composeFile = `services:
+  # Documentation server
+  docs:
+    image: nginx
+    ports:
+      - "8080:80"
+
+  "db":
+    image: postgres
+    environment:
+      POSTGRES_DB: docs
+  cache: {image: redis}
+volumes:
+  data: {}
+`
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test extracting stages and services of dockerfile= and compose= sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestExtractDocker(t *testing.T) {
+	tests := []struct {
+		name    string
+		attrs   map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "Named stage",
+			attrs: map[string]string{"dockerfile": "Dockerfile", "stage": "build"},
+			want:  "# Build the binary\nFROM golang:1.25 AS build\nWORKDIR /src\nRUN go build -o /gosect ./cmd/gosect\n",
+		},
+		{
+			name:  "Stage with flags, case insensitive",
+			attrs: map[string]string{"dockerfile": "Dockerfile", "stage": "Runtime"},
+			want:  "# Minimal runtime image\nFROM --platform=linux/amd64 gcr.io/distroless/static AS runtime\nCOPY --from=build /gosect /gosect\n",
+		},
+		{
+			name:  "Unnamed stage by index",
+			attrs: map[string]string{"dockerfile": "Dockerfile", "stage": "2"},
+			want:  "FROM scratch\nCOPY --from=runtime / /\n",
+		},
+		{
+			name:    "Unknown stage",
+			attrs:   map[string]string{"dockerfile": "Dockerfile", "stage": "test"},
+			wantErr: "no stage test in Dockerfile",
+		},
+		{
+			name:  "Service with comments",
+			attrs: map[string]string{"compose": "compose.yaml", "service": "docs"},
+			want:  "# Documentation server\ndocs:\n  image: nginx\n  ports:\n    - \"8080:80\"\n",
+		},
+		{
+			name:  "Quoted service",
+			attrs: map[string]string{"compose": "compose.yaml", "service": "db"},
+			want:  "\"db\":\n  image: postgres\n  environment:\n    POSTGRES_DB: docs\n",
+		},
+		{
+			name:  "Inline service",
+			attrs: map[string]string{"compose": "compose.yaml", "service": "cache"},
+			want:  "cache: {image: redis}\n",
+		},
+		{
+			name:    "Unknown service",
+			attrs:   map[string]string{"compose": "compose.yaml", "service": "data"},
+			wantErr: "no service data in compose.yaml",
+		},
+		{
+			name:    "No service",
+			attrs:   map[string]string{"compose": "compose.yaml"},
+			wantErr: "no service= attribute",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Section{Name: "deploy", Attrs: tt.attrs}
+			var got []byte
+			var err error
+			if _, ok := tt.attrs["dockerfile"]; ok {
+				got, err = extractDockerfile(s, []byte(dockerfile))
+			} else {
+				got, err = extractCompose(s, []byte(composeFile))
+			}
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package gosect
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// .editorconfig settings of targets
+// /////////////////////////////////////////////////////////////////////////////
+
+// EditorConfigFile is the name of the files holding EditorConfig settings
+const EditorConfigFile = ".editorconfig"
+
+// EditorConfig holds the .editorconfig properties of a target which gosect
+// honors when rendering and writing it. Unset properties are empty or 0, and
+// keep the current behavior.
+type EditorConfig struct {
+	IndentStyle        string // tab or space
+	IndentSize         int    // columns of an indentation level
+	TabWidth           int    // columns of a tab, IndentSize when 0
+	EndOfLine          string // lf or crlf
+	Charset            string // utf-8, utf-8-bom, utf-16le or utf-16be
+	InsertFinalNewline string // true or false
+}
+
+// LoadEditorConfig returns the EditorConfig settings of the target file at
+// path, read from the .editorconfig files of its directory and of its
+// parents, up to the one declaring root = true. Settings of nearer files,
+// and of later sections in a file, take precedence.
+func LoadEditorConfig(path string) (EditorConfig, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return EditorConfig{}, err
+	}
+
+	// .editorconfig files, from the nearest one
+	var files []string
+	for dir := filepath.Dir(abs); ; dir = filepath.Dir(dir) {
+		name := filepath.Join(dir, EditorConfigFile)
+		data, err := os.ReadFile(name)
+		if err == nil {
+			files = append(files, name)
+			if editorConfigRoot(data) {
+				break
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return EditorConfig{}, err
+		}
+		if dir == filepath.Dir(dir) {
+			break
+		}
+	}
+
+	props := map[string]string{}
+	for i := len(files) - 1; i >= 0; i-- {
+		data, err := os.ReadFile(files[i])
+		if err != nil {
+			return EditorConfig{}, err
+		}
+		rel, err := filepath.Rel(filepath.Dir(files[i]), abs)
+		if err != nil {
+			return EditorConfig{}, err
+		}
+		if err := parseEditorConfig(data, filepath.ToSlash(rel), props); err != nil {
+			return EditorConfig{}, fmt.Errorf("%s: %w", files[i], err)
+		}
+	}
+
+	return newEditorConfig(props), nil
+}
+
+// editorConfigRoot reports whether the .editorconfig content data declares
+// root = true in its preamble
+func editorConfigRoot(data []byte) bool {
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") {
+			return false
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "root") {
+			return strings.EqualFold(strings.TrimSpace(value), "true")
+		}
+	}
+
+	return false
+}
+
+// parseEditorConfig sets props to the properties of the .editorconfig
+// content data whose sections match the target at the slash-separated path
+// rel, relative to the directory of the file
+func parseEditorConfig(data []byte, rel string, props map[string]string) error {
+	matched := false
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || line[0] == '#' || line[0] == ';':
+		case line[0] == '[':
+			end := strings.LastIndexByte(line, ']')
+			if end == -1 {
+				return fmt.Errorf("line %d: unterminated section %q", n, line)
+			}
+			re, err := editorConfigGlob(line[1:end])
+			if err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+			matched = re.MatchString(rel)
+		case matched:
+			key, value, ok := strings.Cut(line, "=")
+			if !ok {
+				return fmt.Errorf("line %d: invalid property %q (expected key = value)", n, line)
+			}
+			props[strings.ToLower(strings.TrimSpace(key))] = strings.ToLower(strings.TrimSpace(value))
+		}
+	}
+
+	return scanner.Err()
+}
+
+// newEditorConfig returns the settings of the properties props, ignoring
+// unset, unknown and invalid values
+func newEditorConfig(props map[string]string) EditorConfig {
+	var ec EditorConfig
+	switch props["indent_style"] {
+	case "tab", "space":
+		ec.IndentStyle = props["indent_style"]
+	}
+	if n, err := strconv.Atoi(props["indent_size"]); err == nil && n > 0 {
+		ec.IndentSize = n
+	}
+	if n, err := strconv.Atoi(props["tab_width"]); err == nil && n > 0 {
+		ec.TabWidth = n
+	}
+	switch props["end_of_line"] {
+	case "lf", "crlf":
+		ec.EndOfLine = props["end_of_line"]
+	}
+	switch props["charset"] {
+	case "utf-8", "utf-8-bom", "utf-16le", "utf-16be":
+		ec.Charset = props["charset"]
+	}
+	switch props["insert_final_newline"] {
+	case "true", "false":
+		ec.InsertFinalNewline = props["insert_final_newline"]
+	}
+
+	return ec
+}
+
+// editorConfigGlob returns the regex of the section glob of an
+// .editorconfig file. Globs without a slash match file names in any
+// directory.
+func editorConfigGlob(glob string) (*regexp.Regexp, error) {
+	if !strings.Contains(glob, "/") {
+		glob = "**/" + glob
+	}
+	glob = strings.TrimPrefix(glob, "/")
+
+	var re strings.Builder
+	re.WriteString("^")
+	braces := 0
+	for i := 0; i < len(glob); i++ {
+		switch c := glob[i]; c {
+		case '\\':
+			if i+1 < len(glob) {
+				i++
+				re.WriteString(regexp.QuoteMeta(glob[i : i+1]))
+			}
+		case '*':
+			switch {
+			case strings.HasPrefix(glob[i:], "**/"):
+				re.WriteString("(?:.*/)?")
+				i += 2
+			case strings.HasPrefix(glob[i:], "**"):
+				re.WriteString(".*")
+				i++
+			default:
+				re.WriteString("[^/]*")
+			}
+		case '?':
+			re.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(glob[i+1:], ']')
+			if end == -1 {
+				re.WriteString(`\[`)
+				continue
+			}
+			class := glob[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			re.WriteString("[" + class + "]")
+			i += end + 1
+		case '{':
+			end := strings.IndexByte(glob[i:], '}')
+			if end == -1 {
+				re.WriteString(`\{`)
+				continue
+			}
+			if from, to, ok := numericRange(glob[i+1 : i+end]); ok {
+				re.WriteString(numericRangeRegex(from, to))
+				i += end
+				continue
+			}
+			if !strings.Contains(glob[i:i+end], ",") {
+				re.WriteString(regexp.QuoteMeta(glob[i : i+end+1]))
+				i += end
+				continue
+			}
+			re.WriteString("(?:")
+			braces++
+		case ',':
+			if braces == 0 {
+				re.WriteString(",")
+				continue
+			}
+			re.WriteString("|")
+		case '}':
+			if braces == 0 {
+				re.WriteString(`\}`)
+				continue
+			}
+			re.WriteString(")")
+			braces--
+		default:
+			re.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	if braces > 0 {
+		return nil, fmt.Errorf("invalid glob %q: unterminated {", glob)
+	}
+	re.WriteString("$")
+
+	compiled, err := regexp.Compile(re.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid glob %q: %w", glob, err)
+	}
+
+	return compiled, nil
+}
+
+// numericRange parses the {from..to} range of an .editorconfig glob
+func numericRange(s string) (int, int, bool) {
+	a, b, ok := strings.Cut(s, "..")
+	if !ok {
+		return 0, 0, false
+	}
+	from, errFrom := strconv.Atoi(a)
+	to, errTo := strconv.Atoi(b)
+	if errFrom != nil || errTo != nil {
+		return 0, 0, false
+	}
+
+	return min(from, to), max(from, to), true
+}
+
+// numericRangeRegex returns the regex of the integers from from to to
+func numericRangeRegex(from, to int) string {
+	if to-from > 1000 {
+		return `[+-]?\d+`
+	}
+
+	alternatives := make([]string, 0, to-from+1)
+	for n := to; n >= from; n-- {
+		alternatives = append(alternatives, strconv.Itoa(n))
+	}
+
+	return "(?:" + strings.Join(alternatives, "|") + ")"
+}
+
+// tabWidth returns the columns of a tab, 0 when unknown
+func (ec EditorConfig) tabWidth() int {
+	if ec.TabWidth > 0 {
+		return ec.TabWidth
+	}
+
+	return ec.IndentSize
+}
+
+// indentation returns the indent= indentation of a section in the
+// indent_style of the target: leading spaces become tabs with
+// indent_style = tab, and tabs become spaces with indent_style = space
+func (ec EditorConfig) indentation(indent string) string {
+	width := ec.tabWidth()
+	if width == 0 {
+		return indent
+	}
+
+	switch ec.IndentStyle {
+	case "tab":
+		spaces := len(indent) - len(strings.TrimLeft(indent, " "))
+		if spaces == len(indent) {
+			return strings.Repeat("\t", spaces/width) + strings.Repeat(" ", spaces%width)
+		}
+	case "space":
+		return strings.ReplaceAll(indent, "\t", strings.Repeat(" ", width))
+	}
+
+	return indent
+}
+
+// lineEndings reports whether inserted content uses CRLF line endings:
+// those of end_of_line when set, or else those of content
+func (ec EditorConfig) lineEndings(content []byte) bool {
+	if ec.EndOfLine != "" {
+		return ec.EndOfLine == "crlf"
+	}
+
+	return usesCRLF(content)
+}
+
+// FinalNewline returns content ending with a newline with
+// insert_final_newline = true, or without one with insert_final_newline =
+// false, and unchanged when the property is unset
+func (ec EditorConfig) FinalNewline(content []byte) []byte {
+	switch ec.InsertFinalNewline {
+	case "true":
+		if len(content) > 0 && !bytes.HasSuffix(content, []byte("\n")) {
+			nl := "\n"
+			if ec.lineEndings(content) {
+				nl = "\r\n"
+			}
+			return append(content[:len(content):len(content)], nl...)
+		}
+	case "false":
+		return bytes.TrimRight(content, "\r\n")
+	}
+
+	return content
+}
+
+// Encoding returns the encoding of the charset of the target, or enc when
+// the property is unset
+func (ec EditorConfig) Encoding(enc Encoding) Encoding {
+	switch ec.Charset {
+	case "utf-8":
+		return Encoding{Name: UTF8}
+	case "utf-8-bom":
+		return Encoding{Name: UTF8, BOM: true}
+	case "utf-16le":
+		return Encoding{Name: UTF16LE, BOM: enc.Name == UTF16LE && enc.BOM}
+	case "utf-16be":
+		return Encoding{Name: UTF16BE, BOM: enc.Name == UTF16BE && enc.BOM}
+	}
+
+	return enc
+}
@@ -0,0 +1,148 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test loading the .editorconfig settings of targets
+// /////////////////////////////////////////////////////////////////////////////
+func TestLoadEditorConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		".editorconfig":      "root = true\n\n[*]\nindent_style = space\nindent_size = 2\ncharset = utf-8\n\n[*.{md,txt}]\ninsert_final_newline = true\n\n[docs/**]\nend_of_line = crlf\n\n[Makefile]\nindent_style = tab\n",
+		"docs/.editorconfig": "# nearer settings win\n[*.md]\nindent_size = 4\ntab_width = 8\n\n[v{1..3}.md]\ncharset = utf-8-bom\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		want   EditorConfig
+	}{
+		{
+			name:   "Root file",
+			target: "README.md",
+			want:   EditorConfig{IndentStyle: "space", IndentSize: 2, Charset: "utf-8", InsertFinalNewline: "true"},
+		},
+		{
+			name:   "File name glob",
+			target: "sub/Makefile",
+			want:   EditorConfig{IndentStyle: "tab", IndentSize: 2, Charset: "utf-8"},
+		},
+		{
+			name:   "Nearer file",
+			target: "docs/guide.md",
+			want:   EditorConfig{IndentStyle: "space", IndentSize: 4, TabWidth: 8, Charset: "utf-8", EndOfLine: "crlf", InsertFinalNewline: "true"},
+		},
+		{
+			name:   "Numeric range",
+			target: "docs/v2.md",
+			want:   EditorConfig{IndentStyle: "space", IndentSize: 4, TabWidth: 8, Charset: "utf-8-bom", EndOfLine: "crlf", InsertFinalNewline: "true"},
+		},
+		{
+			name:   "Outside the range",
+			target: "docs/v4.md",
+			want:   EditorConfig{IndentStyle: "space", IndentSize: 4, TabWidth: 8, Charset: "utf-8", EndOfLine: "crlf", InsertFinalNewline: "true"},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := LoadEditorConfig(filepath.Join(tmpDir, tt.target))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test rendering sections with the .editorconfig settings of targets
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceEditorConfig(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "src.txt"), []byte("a\n  b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		ec      EditorConfig
+		want    string
+	}{
+		{
+			name:    "Line endings of the content",
+			content: "<!-- BEGIN SECTION s file=src.txt -->\n<!-- END SECTION s -->\n",
+			want:    "<!-- BEGIN SECTION s file=src.txt -->\n\na\n  b\n\n<!-- END SECTION s -->\n",
+		},
+		{
+			name:    "end_of_line",
+			content: "<!-- BEGIN SECTION s file=src.txt -->\n<!-- END SECTION s -->\n",
+			ec:      EditorConfig{EndOfLine: "crlf"},
+			want:    "<!-- BEGIN SECTION s file=src.txt -->\n\r\na\r\n  b\r\n\r\n<!-- END SECTION s -->\n",
+		},
+		{
+			name:    "indent_style = tab",
+			content: "<!-- BEGIN SECTION s file=src.txt indent=8 fence=false -->\n<!-- END SECTION s -->\n",
+			ec:      EditorConfig{IndentStyle: "tab", IndentSize: 4},
+			want:    "<!-- BEGIN SECTION s file=src.txt indent=8 fence=false -->\n\n\t\ta\n\t\t  b\n\n<!-- END SECTION s -->\n",
+		},
+		{
+			name:    "indent_style = space",
+			content: "<!-- BEGIN SECTION s file=src.txt indent=tab -->\n<!-- END SECTION s -->\n",
+			ec:      EditorConfig{IndentStyle: "space", IndentSize: 2},
+			want:    "<!-- BEGIN SECTION s file=src.txt indent=tab -->\n\n  a\n    b\n\n<!-- END SECTION s -->\n",
+		},
+		{
+			name:    "Marker indentation is kept",
+			content: "\t<!-- BEGIN SECTION s file=src.txt -->\n\t<!-- END SECTION s -->\n",
+			ec:      EditorConfig{IndentStyle: "space", IndentSize: 2},
+			want:    "\t<!-- BEGIN SECTION s file=src.txt -->\n\n\ta\n\t  b\n\n\t<!-- END SECTION s -->\n",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := FindSections(tt.content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Replace([]byte(tt.content), sections, Options{BaseDir: tmpDir, EditorConfig: tt.ec})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	// insert_final_newline
+	final := map[string]struct{ content, want string }{
+		"":      {"a\n", "a\n"},
+		"true":  {"a\r\nb", "a\r\nb\r\n"},
+		"false": {"a\n\n", "a"},
+	}
+	for value, tt := range final {
+		if got := (EditorConfig{InsertFinalNewline: value}).FinalNewline([]byte(tt.content)); string(got) != tt.want {
+			t.Errorf("insert_final_newline=%s: expected %q, got %q", value, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package gosect
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"slices"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Text encodings of target documents
+const (
+	UTF8    = "utf-8"
+	UTF16LE = "utf-16le"
+	UTF16BE = "utf-16be"
+)
+
+// Encoding describes the text encoding of a document
+type Encoding struct {
+	// Name is UTF8, UTF16LE or UTF16BE
+	Name string
+
+	// BOM reports whether the document starts with a byte order mark
+	BOM bool
+}
+
+// utf8BOM is the byte order mark some Windows editors start UTF-8 files with
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// detectEncoding guesses the encoding of data from its byte order mark or,
+// without one, from the NUL bytes of ASCII characters encoded as UTF-16
+func detectEncoding(data []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(data, utf8BOM):
+		return Encoding{Name: UTF8, BOM: true}
+	case len(data) >= 2 && data[0] == 0xFF && data[1] == 0xFE:
+		return Encoding{Name: UTF16LE, BOM: true}
+	case len(data) >= 2 && data[0] == 0xFE && data[1] == 0xFF:
+		return Encoding{Name: UTF16BE, BOM: true}
+	case len(data) < 2 || len(data)%2 != 0 || utf8.Valid(data) && bytes.IndexByte(data, 0) == -1:
+		return Encoding{Name: UTF8}
+	}
+
+	// count NUL bytes at even and odd offsets
+	var even, odd int
+	for i := 0; i+1 < len(data); i += 2 {
+		if data[i] == 0 {
+			even++
+		}
+		if data[i+1] == 0 {
+			odd++
+		}
+	}
+
+	half := len(data) / 2
+	switch {
+	case odd*2 > half && even*10 < half:
+		return Encoding{Name: UTF16LE}
+	case even*2 > half && odd*10 < half:
+		return Encoding{Name: UTF16BE}
+	}
+
+	return Encoding{Name: UTF8}
+}
+
+// byteOrder is a byte order reading and appending integers
+type byteOrder interface {
+	binary.ByteOrder
+	binary.AppendByteOrder
+}
+
+// byteOrder returns the byte order of a UTF-16 encoding
+func (enc Encoding) byteOrder() byteOrder {
+	if enc.Name == UTF16BE {
+		return binary.BigEndian
+	}
+
+	return binary.LittleEndian
+}
+
+// DecodeText detects the encoding of a document and returns its content
+// transcoded to UTF-8, without byte order mark, for processing
+func DecodeText(data []byte) ([]byte, Encoding, error) {
+	enc := detectEncoding(data)
+	if enc.Name == UTF8 {
+		return bytes.TrimPrefix(data, utf8BOM), enc, nil
+	}
+
+	if enc.BOM {
+		data = data[2:]
+	}
+	if len(data)%2 != 0 {
+		return nil, enc, errors.New(enc.Name + ": truncated content")
+	}
+
+	order := enc.byteOrder()
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+
+	return []byte(string(utf16.Decode(units))), enc, nil
+}
+
+// EncodeText converts UTF-8 content back to the encoding enc
+func EncodeText(data []byte, enc Encoding) []byte {
+	if enc.Name == UTF8 && enc.BOM {
+		return append(slices.Clip(utf8BOM), data...)
+	}
+	if enc.Name == UTF8 {
+		return data
+	}
+
+	units := utf16.Encode([]rune(string(data)))
+	out := make([]byte, 0, 2*len(units)+2)
+	order := enc.byteOrder()
+	if enc.BOM {
+		out = order.AppendUint16(out, 0xFEFF)
+	}
+	for _, u := range units {
+		out = order.AppendUint16(out, u)
+	}
+
+	return out
+}
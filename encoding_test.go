@@ -0,0 +1,49 @@
+package gosect
+
+import (
+	"bytes"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test UTF-8 BOM and UTF-16 detection and round trips
+// /////////////////////////////////////////////////////////////////////////////
+func TestDecodeText(t *testing.T) {
+	text := "<!-- BEGIN SECTION é file=a.md -->\r\n<!-- END SECTION é -->\r\n"
+
+	tests := []struct {
+		name string
+		data []byte
+		want Encoding
+	}{
+		{name: "UTF-8", data: []byte(text), want: Encoding{Name: UTF8}},
+		{name: "UTF-8 with BOM", data: append([]byte("\uFEFF"), text...), want: Encoding{Name: UTF8, BOM: true}},
+		{name: "UTF-16LE with BOM", data: EncodeText([]byte(text), Encoding{Name: UTF16LE, BOM: true}), want: Encoding{Name: UTF16LE, BOM: true}},
+		{name: "UTF-16BE with BOM", data: EncodeText([]byte(text), Encoding{Name: UTF16BE, BOM: true}), want: Encoding{Name: UTF16BE, BOM: true}},
+		{name: "UTF-16LE without BOM", data: EncodeText([]byte(text), Encoding{Name: UTF16LE}), want: Encoding{Name: UTF16LE}},
+		{name: "UTF-16BE without BOM", data: EncodeText([]byte(text), Encoding{Name: UTF16BE}), want: Encoding{Name: UTF16BE}},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, enc, err := DecodeText(tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if enc != tt.want {
+				t.Errorf("Expected encoding %+v, got %+v", tt.want, enc)
+			}
+			if string(got) != text {
+				t.Errorf("Expected %q, got %q", text, got)
+			}
+			if back := EncodeText(got, enc); !bytes.Equal(back, tt.data) {
+				t.Errorf("Expected round trip to original bytes, got %q", back)
+			}
+		})
+	}
+
+	if _, _, err := DecodeText([]byte{0xFF, 0xFE, 'a'}); err == nil {
+		t.Error("Expected error for truncated UTF-16")
+	}
+}
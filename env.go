@@ -0,0 +1,44 @@
+package gosect
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+)
+
+// envAttrs lists the source attributes expanded by Options.ExpandEnv. cmd=
+// is left to the shell, which reads the variables from its environment.
+var envAttrs = []string{"file", "url", "sql"}
+
+// langAttrs lists the source attributes whose {{.lang}} placeholders are
+// replaced by Options.Language
+var langAttrs = []string{"file", "url", "cmd"}
+
+// expandEnv returns s with the $VAR and ${VAR} references of its source
+// attributes replaced by environment variables. Undefined variables are
+// reported as errors rather than expanded to empty strings.
+func expandEnv(s Section) (Section, error) {
+	var missing []string
+	lookup := func(name string) string {
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	}
+
+	s.Attrs = maps.Clone(s.Attrs)
+	for _, key := range envAttrs {
+		if value, ok := s.Attrs[key]; ok {
+			s.Attrs[key] = os.Expand(value, lookup)
+		}
+	}
+	if len(missing) > 0 {
+		return s, fmt.Errorf("section %s: environment variable %s is not set", s.Name, strings.Join(missing, ", "))
+	}
+
+	s.SrcFile = s.Attrs["file"]
+
+	return s, nil
+}
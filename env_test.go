@@ -0,0 +1,84 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test environment variable expansion in source attributes
+// /////////////////////////////////////////////////////////////////////////////
+func TestExpandEnv(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "demo.sh"), []byte("echo demo\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOSECT_ROOT", tmpDir)
+
+	tests := []struct {
+		name    string
+		file    string
+		opts    Options
+		wantErr bool
+	}{
+		{name: "Braced variable", file: "${GOSECT_ROOT}/demo.sh", opts: Options{ExpandEnv: true}},
+		{name: "Plain variable", file: "$GOSECT_ROOT/demo.sh", opts: Options{ExpandEnv: true}},
+		{name: "Undefined variable", file: "${GOSECT_UNDEFINED}/demo.sh", opts: Options{ExpandEnv: true}, wantErr: true},
+		{name: "Expansion disabled", file: "${GOSECT_ROOT}/demo.sh", wantErr: true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "<!-- BEGIN SECTION demo file=" + tt.file + " -->\n<!-- END SECTION demo -->\n"
+			sections, err := FindSections(content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Replace([]byte(content), sections, tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if !strings.Contains(string(result), "\necho demo\n") {
+				t.Errorf("Expected expanded source, got %q", result)
+			}
+			// markers are left unchanged
+			if !strings.Contains(string(result), "file="+tt.file) {
+				t.Errorf("Expected marker to keep %s, got %q", tt.file, result)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test that cmd= commands are expanded by the shell only
+// /////////////////////////////////////////////////////////////////////////////
+func TestExpandEnvCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell syntax not supported")
+	}
+	t.Setenv("GOSECT_GREETING", "hello; echo injected")
+
+	content := `<!-- BEGIN SECTION demo cmd="printf '%s|%s|%s\\n' \"$GOSECT_GREETING\" \"${GOSECT_UNDEFINED:-default}\" $$ | cut -c1-29" -->` + "\n<!-- END SECTION demo -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Replace([]byte(content), sections, Options{ExpandEnv: true, AllowCommands: true})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if !strings.Contains(string(result), "\nhello; echo injected|default|\n") {
+		t.Errorf("Expected the shell to expand the command, got %q", result)
+	}
+}
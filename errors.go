@@ -0,0 +1,87 @@
+package gosect
+
+import (
+	"errors"
+	"fmt"
+)
+
+// PositionError is an error located at a 1-based line and column of a file,
+// such as the BEGIN marker of the section which failed
+type PositionError struct {
+	File         string
+	Line, Column int
+	Err          error
+}
+
+// newPositionError locates err at offset in content
+func newPositionError(content []byte, offset int, err error) *PositionError {
+	line, column := lineColumn(content, offset)
+	return &PositionError{Line: line, Column: column, Err: err}
+}
+
+// Error formats the error as file:line:column: message, without the file
+// when unknown
+func (e *PositionError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("%d:%d: %v", e.Line, e.Column, e.Err)
+	}
+
+	return fmt.Sprintf("%s:%d:%d: %v", e.File, e.Line, e.Column, e.Err)
+}
+
+// Unwrap returns the located error
+func (e *PositionError) Unwrap() error {
+	return e.Err
+}
+
+// IncludeError is the failure of a nested section of the source of Section,
+// read from Source. Nested failures wrap each other, so the message follows
+// the resolution chain from the document to the failing section:
+//
+//	doc.md:2:1: section outer -> a.md:2:6: section inner: open b.txt: no such file
+type IncludeError struct {
+	Section string
+	Source  string
+	Err     error
+}
+
+// Error formats the error as section name -> nested error
+func (e *IncludeError) Error() string {
+	return fmt.Sprintf("section %s -> %v", e.Section, e.Err)
+}
+
+// Unwrap returns the error of the nested section
+func (e *IncludeError) Unwrap() error {
+	return e.Err
+}
+
+// FileErrors attributes err, which may join several errors, to the file
+// name: the file of position errors is set and other errors are prefixed
+// with name
+func FileErrors(name string, err error) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *PositionError:
+		if e.File != "" {
+			return e
+		}
+		located := *e
+		located.File = name
+		return &located
+	case SectionErrors:
+		errs := make(SectionErrors, len(e))
+		for i, err := range e {
+			errs[i] = FileErrors(name, err)
+		}
+		return errs
+	case interface{ Unwrap() []error }:
+		var errs []error
+		for _, err := range e.Unwrap() {
+			errs = append(errs, FileErrors(name, err))
+		}
+		return errors.Join(errs...)
+	}
+
+	return fmt.Errorf("%s: %w", name, err)
+}
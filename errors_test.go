@@ -0,0 +1,94 @@
+package gosect
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test errors are located and collected
+// /////////////////////////////////////////////////////////////////////////////
+func TestPositionErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "missing END",
+			content: "intro\n<!-- BEGIN SECTION a file=x -->\n<!-- BEGIN SECTION b file=x -->\n",
+			want: []string{
+				"doc.md:2:6: no END SECTION for a",
+				"doc.md:3:6: no END SECTION for b",
+			},
+		},
+		{
+			name: "missing sources",
+			content: "<!-- BEGIN SECTION a file=missing-a.txt -->\n<!-- END SECTION a -->\n" +
+				"\n<!-- BEGIN SECTION b file=missing-b.txt -->\n<!-- END SECTION b -->\n",
+			want: []string{
+				"doc.md:1:6: section a: open missing-a.txt",
+				"doc.md:4:6: section b: open missing-b.txt",
+			},
+		},
+	}
+
+	// Run tests
+	for _, test := range tests {
+		sections, err := FindSections(test.content, reBegin, reEnd)
+		if err == nil {
+			_, err = Replace([]byte(test.content), sections, Options{})
+		}
+		if err == nil {
+			t.Fatalf("%s: expected error, got nil", test.name)
+		}
+
+		err = FileErrors("doc.md", err)
+		lines := strings.Split(err.Error(), "\n")
+		if len(lines) != len(test.want) {
+			t.Fatalf("%s: expected %d errors, got %q", test.name, len(test.want), err)
+		}
+		for i, want := range test.want {
+			if !strings.HasPrefix(lines[i], want) {
+				t.Errorf("%s: expected error starting with %q, got %q", test.name, want, lines[i])
+			}
+		}
+
+		var located *PositionError
+		if !errors.As(err, &located) || located.File != "doc.md" {
+			t.Errorf("%s: expected a position error in doc.md, got %v", test.name, err)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test errors of nested sections are located in their source file, after
+// the resolution chain
+// /////////////////////////////////////////////////////////////////////////////
+func TestNestedPositionErrors(t *testing.T) {
+	tmpDir := t.TempDir()
+	nested := "line\n<!-- BEGIN SECTION inner file=missing.txt -->\n<!-- END SECTION inner -->\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "part.md"), []byte(nested), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "<!-- BEGIN SECTION outer file=part.md -->\n<!-- END SECTION outer -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = Replace([]byte(content), sections, Options{BaseDir: tmpDir})
+	want := "doc.md:1:6: section outer -> part.md:2:6: section inner: open "
+	if err == nil || !strings.HasPrefix(FileErrors("doc.md", err).Error(), want) {
+		t.Errorf("Expected error starting with %q, got %v", want, err)
+	}
+
+	var include *IncludeError
+	if !errors.As(err, &include) || include.Section != "outer" || include.Source != "part.md" {
+		t.Errorf("Expected an include error of section outer, got %#v", include)
+	}
+}
@@ -0,0 +1,88 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+)
+
+// extractors render a part of a local source file, selected by the other
+// attributes of the section, by source attribute: openapi=spec.yaml
+// path=/users reads spec.yaml and renders the /users operation
+var extractors = map[string]func(s Section, data []byte) ([]byte, error){
+	"bench":      extractBench,
+	"compose":    extractCompose,
+	"coverage":   extractCoverage,
+	"dockerfile": extractDockerfile,
+	"goflags":    extractGoFlags,
+	"gomod":      extractGoMod,
+	"makefile":   extractMakefile,
+	"openapi":    extractOpenAPI,
+	"proto":      extractProto,
+	"sql":        extractSQL,
+}
+
+// ExtractorSource returns the attribute and the path of the extractor source
+// of s, such as openapi=spec.yaml, when it has one
+func ExtractorSource(s Section) (string, string, bool) {
+	for _, attr := range slices.Sorted(maps.Keys(extractors)) {
+		if path, ok := s.Attrs[attr]; ok {
+			return attr, path, true
+		}
+	}
+
+	return "", "", false
+}
+
+// readExtractor reads the source file of the extractor attr of s, the
+// schema of the database of a sql= URL or the Go package of a goflags=
+// directory, and renders the part selected by its attributes
+func (opts Options) readExtractor(s Section, attr, spec string) ([]byte, error) {
+	var data []byte
+	if attr == "sql" && DatabaseURL(spec) {
+		dump, err := opts.dumpSchema(s, spec)
+		if err != nil {
+			return nil, err
+		}
+		data = dump
+	} else {
+		path, err := opts.SourcePath(Section{Name: s.Name, SrcFile: spec})
+		if err != nil {
+			return nil, err
+		}
+		// goflags= sources may be package directories
+		if info, err := os.Stat(path); err == nil && info.IsDir() && attr == "goflags" {
+			return extractGoPackageFlags(s, path)
+		}
+		if data, err = os.ReadFile(path); err != nil {
+			return nil, fmt.Errorf("section %s: %w", s.Name, err)
+		}
+	}
+
+	return extractors[attr](s, data)
+}
+
+// commentStart returns the start of the comment lines directly above the line
+// starting at offset start, or start when there are none. Comments are lines
+// starting with linePrefix, or /* */ blocks.
+func commentStart(data []byte, start int, linePrefix string) int {
+	inBlock := false
+	for start > 0 {
+		lineStart := bytes.LastIndexByte(data[:start-1], '\n') + 1
+		line := bytes.TrimSpace(data[lineStart : start-1])
+		switch {
+		case inBlock:
+			inBlock = !bytes.HasPrefix(line, []byte("/*"))
+		case bytes.HasPrefix(line, []byte(linePrefix)):
+		case bytes.HasSuffix(line, []byte("*/")):
+			inBlock = !bytes.HasPrefix(line, []byte("/*"))
+		default:
+			return start
+		}
+		start = lineStart
+	}
+
+	return start
+}
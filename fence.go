@@ -0,0 +1,110 @@
+package gosect
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// languages maps source file extensions to Markdown code fence languages
+var languages = map[string]string{
+	".bash":       "bash",
+	".c":          "c",
+	".cfg":        "ini",
+	".conf":       "ini",
+	".cpp":        "cpp",
+	".cs":         "csharp",
+	".css":        "css",
+	".diff":       "diff",
+	".dockerfile": "dockerfile",
+	".go":         "go",
+	".h":          "c",
+	".hpp":        "cpp",
+	".html":       "html",
+	".ini":        "ini",
+	".java":       "java",
+	".js":         "javascript",
+	".json":       "json",
+	".jsx":        "jsx",
+	".kt":         "kotlin",
+	".lua":        "lua",
+	".md":         "markdown",
+	".mermaid":    "mermaid",
+	".mmd":        "mermaid",
+	".nix":        "nix",
+	".php":        "php",
+	".plantuml":   "plantuml",
+	".proto":      "protobuf",
+	".ps1":        "powershell",
+	".puml":       "plantuml",
+	".py":         "python",
+	".rb":         "ruby",
+	".rs":         "rust",
+	".scss":       "scss",
+	".sh":         "bash",
+	".sql":        "sql",
+	".swift":      "swift",
+	".tf":         "hcl",
+	".toml":       "toml",
+	".ts":         "typescript",
+	".tsx":        "tsx",
+	".xml":        "xml",
+	".yaml":       "yaml",
+	".yml":        "yaml",
+	".zsh":        "zsh",
+}
+
+// languages of well-known file names without a meaningful extension
+var fileLanguages = map[string]string{
+	"Dockerfile":     "dockerfile",
+	"Makefile":       "makefile",
+	"Justfile":       "just",
+	"justfile":       "just",
+	"go.mod":         "go",
+	"CMakeLists.txt": "cmake",
+	"Containerfile":  "dockerfile",
+}
+
+// languageFor infers the code fence language of a source file
+func languageFor(path string) string {
+	base := filepath.Base(path)
+	if lang, ok := fileLanguages[base]; ok {
+		return lang
+	}
+
+	return languages[strings.ToLower(filepath.Ext(base))]
+}
+
+// fenceEnabled reports whether the content of s must be wrapped in a code
+// fence, and with which language
+func fenceEnabled(s Section) (bool, string) {
+	lang, hasLang := s.Attrs["lang"]
+	switch s.Attrs["fence"] {
+	case "true":
+		if !hasLang {
+			lang = languageFor(s.SrcFile)
+		}
+		return true, lang
+	case "false":
+		return false, ""
+	}
+
+	return hasLang, lang
+}
+
+// wrapFence wraps content in a Markdown code fence. The fence is made longer
+// than any backtick run found in content so embedded fences are preserved.
+func wrapFence(content []byte, lang string) []byte {
+	fence := "```"
+	for bytes.Contains(content, []byte(fence)) {
+		fence += "`"
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(content) + 2*len(fence) + len(lang) + 2)
+	out.WriteString(fence + lang + "\n")
+	out.Write(content)
+	out.WriteString("\n" + fence)
+
+	return out.Bytes()
+}
@@ -0,0 +1,76 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test languageFor function
+// /////////////////////////////////////////////////////////////////////////////
+func TestLanguageFor(t *testing.T) {
+	tests := map[string]string{
+		"main.go":           "go",
+		"./config/app.YAML": "yaml",
+		"scripts/run.sh":    "bash",
+		"Dockerfile":        "dockerfile",
+		"notes.unknown":     "",
+	}
+
+	for path, want := range tests {
+		if got := languageFor(path); got != want {
+			t.Errorf("languageFor(%q): expected %q, got %q", path, want, got)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test code fence wrapping
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceFence(t *testing.T) {
+	tmpDir := t.TempDir()
+	goFile := filepath.Join(tmpDir, "main.go")
+	mdFile := filepath.Join(tmpDir, "example.md")
+
+	err := os.WriteFile(goFile, []byte("package main\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(mdFile, []byte("```sh\nls\n```\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		attrs string
+		want  string
+	}{
+		{name: "Inferred language", attrs: "file=" + goFile + " fence=true", want: "```go\npackage main\n```"},
+		{name: "Explicit language", attrs: "file=" + goFile + " lang=golang", want: "```golang\npackage main\n```"},
+		{name: "Fence disabled", attrs: "file=" + goFile + " lang=go fence=false", want: "\npackage main\n\n"},
+		{name: "Nested fence", attrs: "file=" + mdFile + " fence=true", want: "````markdown\n```sh\nls\n```\n````"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "<!-- BEGIN SECTION code " + tt.attrs + " -->\n<!-- END SECTION code -->\n"
+			sections, err := FindSections(content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Replace([]byte(content), sections, Options{})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !strings.Contains(string(result), tt.want) {
+				t.Errorf("Expected result to contain %q, got:\n%s", tt.want, result)
+			}
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// MarkerFix is a repair of damaged markers proposed by FixMarkers: the
+// replacement of content[Start:End] by Text
+type MarkerFix struct {
+	Line    int    // line of the damaged marker
+	Message string // description of the repair
+	Confirm bool   // the repair guesses where a section ends and needs confirmation
+
+	Start, End int
+	Text       string
+}
+
+// FixMarkers proposes repairs of common marker damage in the content of the
+// target file at path, whose markers use the prefixes begin and end:
+//
+//   - an unterminated section gets an END marker before the next BEGIN marker,
+//     or at the end of the content (these repairs need confirmation);
+//   - a marker line opening a comment of the file type without closing it
+//     gets the closing text (e.g. --> in Markdown);
+//   - an END marker whose name does not match the open section is renamed,
+//     when neither marker can be paired otherwise.
+func FixMarkers(content []byte, path, begin, end string) []MarkerFix {
+	style, _ := CommentStyleFor(path)
+	reBegin, reEnd := MarkersFor(path, begin, end)
+
+	var markers []marker
+	nameSpans := map[int][2]int{}
+	for _, loc := range reBegin.FindAllSubmatchIndex(content, -1) {
+		markers = append(markers, marker{offset: loc[0], name: string(content[loc[2]:loc[3]]), begin: true})
+	}
+	for _, loc := range reEnd.FindAllSubmatchIndex(content, -1) {
+		markers = append(markers, marker{offset: loc[0], name: string(content[loc[2]:loc[3]])})
+		nameSpans[loc[0]] = [2]int{loc[2], loc[3]}
+	}
+	slices.SortFunc(markers, func(a, b marker) int { return a.offset - b.offset })
+
+	newline := "\n"
+	if usesCRLF(content) {
+		newline = "\r\n"
+	}
+
+	var fixes []MarkerFix
+	lineOf := func(offset int) int {
+		line, _ := lineColumn(content, offset)
+		return line
+	}
+
+	// endLine returns an END marker line for the section opened by m
+	endLine := func(m marker) string {
+		start := bytes.LastIndexByte(content[:m.offset], '\n') + 1
+		prefix := content[start:m.offset]
+		indent := prefix[:len(prefix)-len(bytes.TrimLeft(prefix, " \t"))]
+
+		line := end + " " + m.name
+		if style.Prefix != "" {
+			_, endPrefix, suffix := style.Markers(begin, end)
+			line = endPrefix + " " + m.name + suffix
+		}
+		return string(indent) + line + newline
+	}
+
+	// closeAt ends the section opened by m at offset
+	closeAt := func(m marker, offset int, where string) {
+		text := endLine(m)
+		if offset == len(content) && len(content) > 0 && content[len(content)-1] != '\n' {
+			text = newline + text
+		}
+		fixes = append(fixes, MarkerFix{
+			Line:    lineOf(m.offset),
+			Message: fmt.Sprintf("add END SECTION %s %s", m.name, where),
+			Confirm: true,
+			Start:   offset,
+			End:     offset,
+			Text:    text,
+		})
+	}
+
+	// hasLater reports whether a marker of name and kind follows offset
+	hasLater := func(offset int, name string, begin bool) bool {
+		return slices.ContainsFunc(markers, func(m marker) bool {
+			return m.offset > offset && m.name == name && m.begin == begin
+		})
+	}
+
+	var open *marker
+	for _, m := range markers {
+		// close the comments opened on marker lines
+		if style.Suffix != "" {
+			lineStart := bytes.LastIndexByte(content[:m.offset], '\n') + 1
+			lineEnd := len(content)
+			if nl := bytes.IndexByte(content[m.offset:], '\n'); nl != -1 {
+				lineEnd = m.offset + nl
+			}
+			lineEnd -= len(content[lineStart:lineEnd]) - len(bytes.TrimRight(content[lineStart:lineEnd], " \t\r"))
+			line := string(content[lineStart:lineEnd])
+			if strings.Contains(line, style.Prefix) && !strings.HasSuffix(line, style.Suffix) {
+				fixes = append(fixes, MarkerFix{
+					Line:    lineOf(m.offset),
+					Message: fmt.Sprintf("close the comment with %s", style.Suffix),
+					Start:   lineEnd,
+					End:     lineEnd,
+					Text:    " " + style.Suffix,
+				})
+			}
+		}
+
+		switch {
+		case m.begin:
+			if open != nil {
+				start := bytes.LastIndexByte(content[:m.offset], '\n') + 1
+				closeAt(*open, start, fmt.Sprintf("before BEGIN SECTION %s", m.name))
+			}
+			open = &m
+		case open == nil:
+			// orphaned END marker, nothing to pair it with
+		case open.name == m.name:
+			open = nil
+		case !hasLater(m.offset, open.name, false) && !hasLater(m.offset, m.name, true):
+			span := nameSpans[m.offset]
+			fixes = append(fixes, MarkerFix{
+				Line:    lineOf(m.offset),
+				Message: fmt.Sprintf("rename END SECTION %s to %s", m.name, open.name),
+				Start:   span[0],
+				End:     span[1],
+				Text:    open.name,
+			})
+			open = nil
+		}
+	}
+	if open != nil {
+		closeAt(*open, len(content), "at the end of the file")
+	}
+
+	slices.SortStableFunc(fixes, func(a, b MarkerFix) int { return a.Start - b.Start })
+
+	return fixes
+}
+
+// ApplyFixes returns content with fixes applied
+func ApplyFixes(content []byte, fixes []MarkerFix) []byte {
+	sorted := slices.Clone(fixes)
+	slices.SortStableFunc(sorted, func(a, b MarkerFix) int { return a.Start - b.Start })
+
+	var out bytes.Buffer
+	last := 0
+	for _, fix := range sorted {
+		out.Write(content[last:fix.Start])
+		out.WriteString(fix.Text)
+		last = fix.End
+	}
+	out.Write(content[last:])
+
+	return out.Bytes()
+}
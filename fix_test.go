@@ -0,0 +1,73 @@
+package gosect
+
+import "testing"
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test repairs of damaged markers
+// /////////////////////////////////////////////////////////////////////////////
+func TestFixMarkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		want    string
+		confirm int
+	}{
+		{
+			name:    "clean",
+			path:    "doc.md",
+			content: "<!-- BEGIN SECTION a file=a.md -->\n<!-- END SECTION a -->\n",
+			want:    "<!-- BEGIN SECTION a file=a.md -->\n<!-- END SECTION a -->\n",
+		},
+		{
+			name:    "END before next BEGIN",
+			path:    "doc.md",
+			content: "<!-- BEGIN SECTION a file=a.md -->\nbody\n  <!-- BEGIN SECTION b file=b.md -->\n  <!-- END SECTION b -->\n",
+			want:    "<!-- BEGIN SECTION a file=a.md -->\nbody\n<!-- END SECTION a -->\n  <!-- BEGIN SECTION b file=b.md -->\n  <!-- END SECTION b -->\n",
+			confirm: 1,
+		},
+		{
+			name:    "END at end of file",
+			path:    "values.yaml",
+			content: "  # BEGIN SECTION a file=a.yaml\n  key: value",
+			want:    "  # BEGIN SECTION a file=a.yaml\n  key: value\n  # END SECTION a\n",
+			confirm: 1,
+		},
+		{
+			name:    "unclosed comment",
+			path:    "doc.md",
+			content: "<!-- BEGIN SECTION a file=a.md\r\n<!-- END SECTION a -->\r\n",
+			want:    "<!-- BEGIN SECTION a file=a.md -->\r\n<!-- END SECTION a -->\r\n",
+		},
+		{
+			name:    "name mismatch",
+			path:    "doc.md",
+			content: "<!-- BEGIN SECTION install file=a.md -->\n<!-- END SECTION instal -->\n",
+			want:    "<!-- BEGIN SECTION install file=a.md -->\n<!-- END SECTION install -->\n",
+		},
+		{
+			name:    "ambiguous mismatch",
+			path:    "doc.md",
+			content: "<!-- BEGIN SECTION a file=a.md -->\n<!-- END SECTION b -->\n<!-- END SECTION a -->\n",
+			want:    "<!-- BEGIN SECTION a file=a.md -->\n<!-- END SECTION b -->\n<!-- END SECTION a -->\n",
+		},
+	}
+
+	// Run tests
+	for _, test := range tests {
+		fixes := FixMarkers([]byte(test.content), test.path, DefaultBegin, DefaultEnd)
+		if got := string(ApplyFixes([]byte(test.content), fixes)); got != test.want {
+			t.Errorf("%s: expected %q, got %q", test.name, test.want, got)
+		}
+
+		confirm := 0
+		for _, fix := range fixes {
+			if fix.Confirm {
+				confirm++
+			}
+		}
+		if confirm != test.confirm {
+			t.Errorf("%s: expected %d fixes to confirm, got %d", test.name, test.confirm, confirm)
+		}
+	}
+}
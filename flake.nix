@@ -24,6 +24,7 @@
           src = ./.;
 
           vendorHash = null;
+          subPackages = [ "cmd/gosect" ];
 
           meta = with pkgs.lib; {
             description = "A tool to replace sections in files written in Go";
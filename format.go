@@ -0,0 +1,134 @@
+package gosect
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// TargetFormat governs a type of target document: where its markers may
+// appear and how rendered content is spliced between them. New formats are
+// added with RegisterFormat, without changes to the engine.
+type TargetFormat interface {
+	// Markers builds the BEGIN and END marker regexes for the marker
+	// prefixes begin and end. The BEGIN regex captures the section name and
+	// its attributes, the END regex the section name.
+	Markers(begin, end string) (*regexp.Regexp, *regexp.Regexp)
+
+	// Body returns the text written between the BEGIN and END lines for the
+	// rendered content src, indented with indent, using CRLF line endings
+	// when crlf is set
+	Body(src []byte, indent string, crlf bool) []byte
+}
+
+// PlainFormat is the format of targets whose markers may appear anywhere
+type PlainFormat struct{}
+
+// Markers returns the regexes of MakeRegex
+func (PlainFormat) Markers(begin, end string) (*regexp.Regexp, *regexp.Regexp) {
+	return MakeRegex(begin, end)
+}
+
+// Body puts the content on its own lines, surrounded by blank lines
+func (PlainFormat) Body(src []byte, indent string, crlf bool) []byte {
+	return renderBody(src, indent, crlf)
+}
+
+// CommentFormat is the format of targets whose markers must appear inside a
+// comment, such as Markdown, HTML or YAML. Its body is the one of
+// PlainFormat, which these formats all accept.
+type CommentFormat struct {
+	PlainFormat
+	Style CommentStyle
+}
+
+// Markers only matches markers inside a comment of the format
+func (f CommentFormat) Markers(begin, end string) (*regexp.Regexp, *regexp.Regexp) {
+	prefix := regexp.QuoteMeta(f.Style.Prefix) + `[ \t]*`
+
+	return makeRegex(prefix+regexp.QuoteMeta(begin), prefix+regexp.QuoteMeta(end))
+}
+
+// notebookAttrsPattern captures the attribute list of a marker inside a JSON
+// string, whose values stop before the quote closing the string
+const notebookAttrsPattern = `((?:[ \t]+` + attrKeyPattern + `=(?:'[^'"\\\n]*'|[^\s>"\\]+))*)`
+
+// NotebookFormat is the format of Jupyter notebooks, whose cells hold their
+// source as a JSON array of strings, one per line
+type NotebookFormat struct{}
+
+// Markers matches markers inside the source strings of the cells
+func (NotebookFormat) Markers(begin, end string) (*regexp.Regexp, *regexp.Regexp) {
+	b := regexp.MustCompile("(?m)" + regexp.QuoteMeta(begin) + ` ([A-Za-z0-9_-]+)` + notebookAttrsPattern)
+	e := regexp.MustCompile("(?m)" + regexp.QuoteMeta(end) + ` ([A-Za-z0-9_-]+)` + notebookAttrsPattern)
+
+	return b, e
+}
+
+// Body writes each line of the content as a string of the cell source,
+// followed by a comma as the END marker line comes next
+func (NotebookFormat) Body(src []byte, indent string, crlf bool) []byte {
+	eol := "\n"
+	if crlf {
+		eol = "\r\n"
+	}
+
+	var out bytes.Buffer
+	for line := range strings.Lines(string(src)) {
+		if !strings.HasSuffix(line, "\n") {
+			line += "\n"
+		}
+		out.WriteString(indent + jsonString(line) + "," + eol)
+	}
+
+	return out.Bytes()
+}
+
+// jsonString returns s as a JSON string, leaving <, > and & unescaped
+func jsonString(s string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s)
+
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// registered formats, indexed by lower case file extension
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]TargetFormat{}
+)
+
+// RegisterFormat makes FormatFor return format for files with the extension
+// ext (e.g. ".ipynb"), overriding the built-in formats
+func RegisterFormat(ext string, format TargetFormat) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[strings.ToLower(ext)] = format
+}
+
+// FormatFor returns the format of the target file at path: the format
+// registered for its extension, else NotebookFormat for .ipynb files, else
+// a CommentFormat when its comment syntax is known, else PlainFormat
+func FormatFor(path string) TargetFormat {
+	ext := strings.ToLower(filepath.Ext(path))
+	formatsMu.RLock()
+	format, ok := formats[ext]
+	formatsMu.RUnlock()
+	if ok {
+		return format
+	}
+	if ext == ".ipynb" {
+		return NotebookFormat{}
+	}
+
+	if style, ok := CommentStyleFor(path); ok {
+		return CommentFormat{Style: style}
+	}
+
+	return PlainFormat{}
+}
@@ -0,0 +1,123 @@
+package gosect
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"testing"
+)
+
+// shoutFormat is a test format whose markers start with "!" and whose content
+// is upper case
+type shoutFormat struct{}
+
+func (shoutFormat) Markers(begin, end string) (*regexp.Regexp, *regexp.Regexp) {
+	return makeRegex("!"+regexp.QuoteMeta(begin), "!"+regexp.QuoteMeta(end))
+}
+
+func (shoutFormat) Body(src []byte, indent string, crlf bool) []byte {
+	return renderBody(bytes.ToUpper(src), indent, crlf)
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test target formats
+// /////////////////////////////////////////////////////////////////////////////
+func TestFormatFor(t *testing.T) {
+	RegisterFormat(".Shout", shoutFormat{})
+
+	tests := []struct {
+		path string
+		want TargetFormat
+	}{
+		{"README.md", CommentFormat{Style: CommentStyle{Prefix: "<!--", Suffix: "-->"}}},
+		{"values.yaml", CommentFormat{Style: CommentStyle{Prefix: "#"}}},
+		{"notes.txt", PlainFormat{}},
+		{"demo.ipynb", NotebookFormat{}},
+		{"doc.shout", shoutFormat{}},
+	}
+
+	// Run tests
+	for _, test := range tests {
+		if got := FormatFor(test.path); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Expected %#v for %s, got %#v", test.want, test.path, got)
+		}
+	}
+
+	source := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(source, []byte("hello\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "!BEGIN SECTION a file=" + source + "\n!END SECTION a\nBEGIN SECTION b file=b.txt\nEND SECTION b\n"
+	format := FormatFor("doc.shout")
+	reBegin, reEnd := format.Markers(DefaultBegin, DefaultEnd)
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 1 || sections[0].Name != "a" {
+		t.Fatalf("Expected section a only, got %v", sections)
+	}
+
+	result, err := Replace([]byte(content), sections, Options{Format: format})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "!BEGIN SECTION a file=" + source + "\n\nHELLO\n\n!END SECTION a\nBEGIN SECTION b file=b.txt\nEND SECTION b\n"
+	if string(result) != want {
+		t.Errorf("Expected %q, got %q", want, result)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test notebook format
+// /////////////////////////////////////////////////////////////////////////////
+func TestNotebookFormat(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "a.py")
+	if err := os.WriteFile(source, []byte("print(\"<ok>\")\n\tpass"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := `{
+ "cells": [
+  {
+   "cell_type": "code",
+   "source": [
+    "# BEGIN SECTION a file=` + source + `\n",
+    "old\n",
+    "# END SECTION a"
+   ]
+  }
+ ]
+}
+`
+	reBegin, reEnd := NotebookFormat{}.Markers(DefaultBegin, DefaultEnd)
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 1 || sections[0].SrcFile != source {
+		t.Fatalf("Expected section a with file=%s, got %v", source, sections)
+	}
+
+	result, err := Replace([]byte(content), sections, Options{Format: NotebookFormat{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var notebook struct {
+		Cells []struct {
+			Source []string `json:"source"`
+		} `json:"cells"`
+	}
+	if err := json.Unmarshal(result, &notebook); err != nil {
+		t.Fatalf("Expected valid JSON, got %v in %s", err, result)
+	}
+	want := []string{"# BEGIN SECTION a file=" + source + "\n", "print(\"<ok>\")\n", "\tpass\n", "# END SECTION a"}
+	if got := notebook.Cells[0].Source; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,76 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// gitSource splits a file=git:ref:path source into its ref and its path,
+// relative to the root of the repository
+func gitSource(s Section) (string, string, bool, error) {
+	rest, ok := strings.CutPrefix(s.SrcFile, "git:")
+	if !ok {
+		return "", "", false, nil
+	}
+
+	ref, path, ok := strings.Cut(rest, ":")
+	if !ok || ref == "" || path == "" {
+		return "", "", false, fmt.Errorf("section %s has invalid file=%s (expected git:ref:path)", s.Name, s.SrcFile)
+	}
+	// git would take a ref starting with a dash for one of its options
+	if strings.HasPrefix(ref, "-") {
+		return "", "", false, fmt.Errorf("section %s has invalid file=%s (git refs cannot start with -)", s.Name, s.SrcFile)
+	}
+
+	// git paths are separated by slashes on every platform
+	return ref, strings.ReplaceAll(path, `\`, "/"), true, nil
+}
+
+// git runs a git command in dir and returns its standard output
+func git(dir string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, err
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// readGit returns the content of path at ref in the git repository holding
+// the base directory
+func (opts Options) readGit(s Section, ref, path string) ([]byte, error) {
+	dir := opts.BaseDir
+	if dir == "" {
+		dir = "."
+	}
+
+	if _, err := git(dir, "rev-parse", "--verify", "--quiet", ref+"^{commit}"); err != nil {
+		return nil, fmt.Errorf("section %s: unknown git ref %q", s.Name, ref)
+	}
+
+	object := ref + ":" + path
+	kind, err := git(dir, "cat-file", "-t", object)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %s does not exist at git ref %s", s.Name, path, ref)
+	}
+	if t := strings.TrimSpace(string(kind)); t != "blob" {
+		return nil, fmt.Errorf("section %s: %s at git ref %s is a %s, not a file", s.Name, path, ref, t)
+	}
+
+	data, err := git(dir, "cat-file", "blob", object)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %w", s.Name, err)
+	}
+
+	return data, nil
+}
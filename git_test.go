@@ -0,0 +1,80 @@
+package gosect
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test git:ref:path sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestGitSource(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+
+	tmpDir := t.TempDir()
+	run := func(args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = tmpDir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v: %s", strings.Join(args, " "), err, out)
+		}
+	}
+
+	usage := filepath.Join(tmpDir, "examples", "usage.go")
+	if err := os.MkdirAll(filepath.Dir(usage), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(usage, []byte("tagged\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	run("init", "-q")
+	run("add", ".")
+	run("commit", "-q", "-m", "init")
+	run("tag", "v1.2.0")
+	if err := os.WriteFile(usage, []byte("working tree\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file    string
+		want    string
+		wantErr string
+	}{
+		{"git:v1.2.0:examples/usage.go", "\ntagged\n", ""},
+		{"git:HEAD:examples/usage.go", "\ntagged\n", ""},
+		{"git:v9.9.9:examples/usage.go", "", "unknown git ref"},
+		{"git:v1.2.0:examples/missing.go", "", "does not exist at git ref v1.2.0"},
+		{"git:v1.2.0:examples", "", "not a file"},
+		{"git:v1.2.0", "", "expected git:ref:path"},
+		{"git:--output=/tmp/x:examples/usage.go", "", "git refs cannot start with -"},
+	}
+
+	// Run tests
+	for _, test := range tests {
+		content := "<!-- BEGIN SECTION s file=" + test.file + " -->\n<!-- END SECTION s -->\n"
+		sections, err := FindSections(content, reBegin, reEnd)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := Replace([]byte(content), sections, Options{BaseDir: tmpDir})
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("Expected error containing %q for %s, got %v", test.wantErr, test.file, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Unexpected error for %s: %v", test.file, err)
+			continue
+		}
+		if !strings.Contains(string(result), test.want) {
+			t.Errorf("Expected %q for %s, got %q", test.want, test.file, result)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package gosect
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// goflags= sources: command line flags defined by Go code
+// /////////////////////////////////////////////////////////////////////////////
+
+// flagTypes are the value types of the flag and pflag (cobra) definition
+// methods: String defines a string flag, StringVar binds it to a variable
+// and StringP or StringVarP add a shorthand
+var flagTypes = map[string]bool{
+	"Bool": true, "BoolFunc": true, "BoolSlice": true, "Count": true,
+	"Duration": true, "DurationSlice": true, "Float32": true, "Float64": true,
+	"Float64Slice": true, "Func": true, "IP": true, "IPSlice": true,
+	"Int": true, "Int8": true, "Int16": true, "Int32": true, "Int64": true,
+	"IntSlice": true, "String": true, "StringArray": true, "StringSlice": true,
+	"StringToString": true, "Text": true, "Uint": true, "Uint8": true,
+	"Uint16": true, "Uint32": true, "Uint64": true, "Var": true,
+}
+
+// flagPackages are the import paths defining flags
+var flagPackages = map[string]bool{
+	"flag":                   true,
+	"github.com/spf13/pflag": true,
+	"github.com/spf13/cobra": true,
+}
+
+// goFlag is a command line flag found in Go code
+type goFlag struct {
+	name, shorthand, typ, value, usage string
+}
+
+// goFlagCall returns the flag defined by call, such as fs.Int("jobs", 4,
+// "workers") or cmd.Flags().StringVarP(&out, "output", "o", "", "file"), or
+// false when call defines no flag. src is the source of the file of call.
+func goFlagCall(call *ast.CallExpr, fset *token.FileSet, src []byte, packages map[string]bool) (goFlag, bool) {
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return goFlag{}, false
+	}
+	// calls of other packages, such as strings.Count, define no flag.
+	// Unlike local variables, package names are not resolved to objects.
+	if ident, ok := sel.X.(*ast.Ident); ok && ident.Obj == nil {
+		if flagPackage, imported := packages[ident.Name]; imported && !flagPackage {
+			return goFlag{}, false
+		}
+	}
+
+	typ, shorthand := sel.Sel.Name, false
+	if base, ok := strings.CutSuffix(typ, "P"); ok && flagTypes[strings.TrimSuffix(base, "Var")] {
+		typ, shorthand = base, true
+	}
+	typ, bound := strings.CutSuffix(typ, "Var")
+	if typ == "" {
+		typ, bound = "Var", false
+	}
+	if !flagTypes[typ] {
+		return goFlag{}, false
+	}
+
+	// arguments: [pointer] name [shorthand] [default] usage
+	i := 0
+	if bound || typ == "Var" {
+		i++
+	}
+	name, ok := stringLit(call, i)
+	if !ok {
+		return goFlag{}, false
+	}
+	f := goFlag{name: name, typ: strings.ToLower(typ[:1]) + typ[1:]}
+	if shorthand {
+		i++
+		f.shorthand, _ = stringLit(call, i)
+	}
+	switch typ {
+	case "Var", "Func", "BoolFunc":
+		f.typ = "value"
+		if typ == "BoolFunc" {
+			f.typ = "bool"
+		}
+	case "Count":
+		f.typ = "count"
+	default:
+		i++
+		if i >= len(call.Args) {
+			return goFlag{}, false
+		}
+		f.value = goDefault(call.Args[i], fset, src)
+	}
+	i++
+	if i >= len(call.Args) {
+		return goFlag{}, false
+	}
+	if usage, ok := stringLit(call, i); ok {
+		f.usage = usage
+	} else {
+		f.usage = nodeSource(call.Args[i], fset, src)
+	}
+
+	return f, true
+}
+
+// stringLit returns the value of the argument i of call when it is a string
+// literal, or a concatenation of string literals
+func stringLit(call *ast.CallExpr, i int) (string, bool) {
+	if i >= len(call.Args) {
+		return "", false
+	}
+
+	var value func(ast.Expr) (string, bool)
+	value = func(e ast.Expr) (string, bool) {
+		switch e := e.(type) {
+		case *ast.BasicLit:
+			if e.Kind != token.STRING {
+				return "", false
+			}
+			s, err := strconv.Unquote(e.Value)
+			return s, err == nil
+		case *ast.BinaryExpr:
+			x, ok := value(e.X)
+			y, ok2 := value(e.Y)
+			return x + y, ok && ok2 && e.Op == token.ADD
+		case *ast.ParenExpr:
+			return value(e.X)
+		}
+		return "", false
+	}
+
+	return value(call.Args[i])
+}
+
+// nodeSource returns the source code of node
+func nodeSource(node ast.Node, fset *token.FileSet, src []byte) string {
+	return string(src[fset.Position(node.Pos()).Offset:fset.Position(node.End()).Offset])
+}
+
+// goDefault returns the source of the default value of a flag, or "" for
+// zero values, which the flag package does not print either
+func goDefault(e ast.Expr, fset *token.FileSet, src []byte) string {
+	value := nodeSource(e, fset, src)
+	switch value {
+	case `""`, "``", "0", "false", "nil":
+		return ""
+	}
+
+	return value
+}
+
+// goFileFlags returns the flags defined in the Go source file src, within
+// the function fn when not empty, and whether the file uses pflag (or cobra)
+// double dash flags
+func goFileFlags(name string, src []byte, fn string) ([]goFlag, bool, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, name, src, 0)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// imported package names, and whether they define flags
+	packages := map[string]bool{}
+	pflag := false
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		local := filepath.Base(path)
+		if spec.Name != nil {
+			local = spec.Name.Name
+		}
+		packages[local] = flagPackages[path]
+		pflag = pflag || strings.HasPrefix(path, "github.com/spf13/")
+	}
+
+	var flags []goFlag
+	for _, decl := range file.Decls {
+		if f, ok := decl.(*ast.FuncDecl); fn != "" && (!ok || f.Name.Name != fn) {
+			continue
+		}
+		ast.Inspect(decl, func(node ast.Node) bool {
+			if call, ok := node.(*ast.CallExpr); ok {
+				if f, ok := goFlagCall(call, fset, src, packages); ok {
+					flags = append(flags, f)
+				}
+			}
+			return true
+		})
+	}
+
+	return flags, pflag, nil
+}
+
+// renderGoFlags renders flags as a Markdown table, keeping the first flag of
+// each name
+func renderGoFlags(s Section, flags []goFlag, pflag bool) ([]byte, error) {
+	if len(flags) == 0 {
+		return nil, fmt.Errorf("section %s: no flag defined in %s", s.Name, s.Attrs["goflags"])
+	}
+	if s.Attrs["sort"] == "name" {
+		slices.SortStableFunc(flags, func(a, b goFlag) int { return strings.Compare(a.name, b.name) })
+	}
+
+	dash := "-"
+	if pflag {
+		dash = "--"
+	}
+	seen := map[string]bool{}
+	var rows [][]string
+	for _, f := range flags {
+		if seen[f.name] {
+			continue
+		}
+		seen[f.name] = true
+
+		flag := "`" + dash + f.name + "`"
+		if f.shorthand != "" {
+			flag = "`-" + f.shorthand + "`, " + flag
+		}
+		value := ""
+		if f.value != "" {
+			value = "`" + f.value + "`"
+		}
+		rows = append(rows, []string{tableCell(flag), tableCell(f.typ), tableCell(value), tableCell(f.usage)})
+	}
+
+	return formatTable([]string{"Flag", "Type", "Default", "Description"}, rows), nil
+}
+
+// extractGoFlags renders the flags defined in the Go file of the goflags=
+// source of s, within the function named by its func= attribute if any
+func extractGoFlags(s Section, data []byte) ([]byte, error) {
+	flags, pflag, err := goFileFlags(s.Attrs["goflags"], data, s.Attrs["func"])
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %w", s.Name, err)
+	}
+
+	return renderGoFlags(s, flags, pflag)
+}
+
+// extractGoPackageFlags renders the flags defined in the Go files of the
+// package directory dir, in file name order, except its tests
+func extractGoPackageFlags(s Section, dir string) ([]byte, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.go"))
+	if err != nil {
+		return nil, err
+	}
+
+	var flags []goFlag
+	pflag := false
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", s.Name, err)
+		}
+		found, double, err := goFileFlags(path, data, s.Attrs["func"])
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", s.Name, err)
+		}
+		flags = append(flags, found...)
+		pflag = pflag || double
+	}
+
+	return renderGoFlags(s, flags, pflag)
+}
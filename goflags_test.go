@@ -0,0 +1,155 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// flagsFile is the Go file of the goflags= tests
+const flagsFile = `package main
+
+import (
+	"flag"
+	"io/fs"
+	"runtime"
+	"strings"
+)
+
+func addFlags(fs *flag.FlagSet) {
+	fs.Var(&files, "file", "input file (repeatable)")
+	jobs := fs.Int("jobs", runtime.NumCPU(), "number of " +
+		"concurrent workers")
+	fs.BoolVar(&verbose, "verbose", false, "log | details")
+	_ = strings.Count("a", "b")
+}
+
+func main() {
+	flag.String("output", "out.md", "output file")
+	flag.Duration("timeout", 30*time.Second, usage)
+}
+`
+
+// cobraFile is the Go file of the cobra goflags= tests
+const cobraFile = `package cmd
+
+import "github.com/spf13/cobra"
+
+func init() {
+	rootCmd.Flags().StringVarP(&output, "output", "o", "", "output file")
+	rootCmd.PersistentFlags().CountP("verbose", "v", "verbosity")
+	rootCmd.Flags().IntSlice("ports", []int{80, 443}, "ports")
+}
+`
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test rendering the flags defined by goflags= sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestExtractGoFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		attrs   map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "flag package",
+			data:  flagsFile,
+			attrs: map[string]string{"goflags": "main.go"},
+			want: "| Flag       | Type     | Default            | Description                  |\n" +
+				"| ---------- | -------- | ------------------ | ---------------------------- |\n" +
+				"| `-file`    | value    |                    | input file (repeatable)      |\n" +
+				"| `-jobs`    | int      | `runtime.NumCPU()` | number of concurrent workers |\n" +
+				"| `-verbose` | bool     |                    | log \\| details               |\n" +
+				"| `-output`  | string   | `\"out.md\"`         | output file                  |\n" +
+				"| `-timeout` | duration | `30*time.Second`   | usage                        |\n",
+		},
+		{
+			name:  "Function and sort",
+			data:  flagsFile,
+			attrs: map[string]string{"goflags": "main.go", "func": "main", "sort": "name"},
+			want: "| Flag       | Type     | Default          | Description |\n" +
+				"| ---------- | -------- | ---------------- | ----------- |\n" +
+				"| `-output`  | string   | `\"out.md\"`       | output file |\n" +
+				"| `-timeout` | duration | `30*time.Second` | usage       |\n",
+		},
+		{
+			name:  "cobra flags",
+			data:  cobraFile,
+			attrs: map[string]string{"goflags": "root.go"},
+			want: "| Flag              | Type     | Default          | Description |\n" +
+				"| ----------------- | -------- | ---------------- | ----------- |\n" +
+				"| `-o`, `--output`  | string   |                  | output file |\n" +
+				"| `-v`, `--verbose` | count    |                  | verbosity   |\n" +
+				"| `--ports`         | intSlice | `[]int{80, 443}` | ports       |\n",
+		},
+		{
+			name:    "No flags",
+			data:    "package main\n\nfunc main() {}\n",
+			attrs:   map[string]string{"goflags": "main.go"},
+			wantErr: "no flag defined in main.go",
+		},
+		{
+			name:    "Invalid Go",
+			data:    "package main\n\nfunc main() {\n",
+			attrs:   map[string]string{"goflags": "main.go"},
+			wantErr: "main.go:3",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractGoFlags(Section{Name: "flags", Attrs: tt.attrs}, []byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test goflags= package directories
+// /////////////////////////////////////////////////////////////////////////////
+func TestGoFlagsPackage(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"main.go":      "package main\n\nimport \"flag\"\n\nvar out = flag.String(\"out\", \"\", \"output file\")\n",
+		"flags.go":     "package main\n\nimport \"flag\"\n\nvar n = flag.Int(\"n\", 1, \"count\")\n",
+		"main_test.go": "package main\n\nimport \"flag\"\n\nvar update = flag.Bool(\"update\", false, \"update golden files\")\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	content := "<!-- BEGIN SECTION flags goflags=. -->\n<!-- END SECTION flags -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Replace([]byte(content), sections, Options{BaseDir: tmpDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"| `-n`   | int    | `1`     | count       |", "| `-out` | string |"} {
+		if !strings.Contains(string(got), want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Contains(string(got), "update") {
+		t.Errorf("Expected test flags to be skipped, got:\n%s", got)
+	}
+}
@@ -0,0 +1,134 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// gomod= sources: Go version and dependencies of a module
+// /////////////////////////////////////////////////////////////////////////////
+
+// goRequirement is a module required by a go.mod file
+type goRequirement struct {
+	path, version string
+	indirect      bool
+}
+
+// goModFields returns the fields of a go.mod line, without its comment, and
+// whether the comment marks an indirect requirement
+func goModFields(line string) ([]string, bool) {
+	line, comment, _ := strings.Cut(line, "//")
+	fields := strings.Fields(line)
+	for i, field := range fields {
+		if unquoted, err := strconv.Unquote(field); err == nil {
+			fields[i] = unquoted
+		}
+	}
+
+	return fields, strings.TrimSpace(comment) == "indirect"
+}
+
+// parseGoMod returns the go and toolchain directives and the requirements of
+// a go.mod file, with the versions of their replacements
+func parseGoMod(data []byte) (goVersion, toolchain string, requires []goRequirement) {
+	replaced := map[string]string{}
+	block := ""
+	for line := range bytes.Lines(data) {
+		fields, indirect := goModFields(string(line))
+		if len(fields) == 0 {
+			continue
+		}
+
+		// lines of a require ( ... ) or replace ( ... ) block
+		verb := block
+		switch {
+		case block != "" && fields[0] == ")":
+			block = ""
+			continue
+		case block == "" && len(fields) == 2 && fields[1] == "(":
+			block = fields[0]
+			continue
+		case block == "":
+			verb, fields = fields[0], fields[1:]
+		}
+
+		switch {
+		case verb == "go" && len(fields) == 1:
+			goVersion = fields[0]
+		case verb == "toolchain" && len(fields) == 1:
+			toolchain = fields[0]
+		case verb == "require" && len(fields) == 2:
+			requires = append(requires, goRequirement{fields[0], fields[1], indirect})
+		case verb == "replace":
+			// old [version] => new [version]
+			arrow := slices.Index(fields, "=>")
+			if arrow < 1 || arrow == len(fields)-1 {
+				continue
+			}
+			replaced[strings.Join(fields[:arrow], "@")] = strings.Join(fields[arrow+1:], " ")
+		}
+	}
+
+	for i, r := range requires {
+		replacement, ok := replaced[r.path+"@"+r.version]
+		if !ok {
+			replacement, ok = replaced[r.path]
+		}
+		if ok {
+			requires[i].version += " => " + replacement
+		}
+	}
+
+	return goVersion, toolchain, requires
+}
+
+// extractGoMod renders the Go version, toolchain and direct requirements of
+// the go.mod file of the gomod= source of s as a Markdown table. The deps=
+// attribute selects requirements by comma separated glob patterns, and
+// indirect=true includes the indirect ones.
+func extractGoMod(s Section, data []byte) ([]byte, error) {
+	goVersion, toolchain, requires := parseGoMod(data)
+	if goVersion == "" && len(requires) == 0 {
+		return nil, fmt.Errorf("section %s: %s is not a go.mod file", s.Name, s.Attrs["gomod"])
+	}
+
+	var patterns []string
+	if deps, ok := s.Attrs["deps"]; ok {
+		patterns = strings.Split(deps, ",")
+	}
+
+	var rows [][]string
+	if goVersion != "" {
+		rows = append(rows, []string{"Go", tableCell(goVersion)})
+	}
+	if toolchain != "" {
+		rows = append(rows, []string{"Toolchain", tableCell(toolchain)})
+	}
+	for _, r := range requires {
+		if r.indirect && s.Attrs["indirect"] != "true" {
+			continue
+		}
+		if patterns != nil && !matchAny(patterns, r.path) {
+			continue
+		}
+		rows = append(rows, []string{tableCell(r.path), tableCell(r.version)})
+	}
+
+	return formatTable([]string{"Requirement", "Version"}, rows), nil
+}
+
+// matchAny reports whether name matches one of the glob patterns
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(strings.TrimSpace(pattern), name); ok {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,87 @@
+package gosect
+
+import (
+	"strings"
+	"testing"
+)
+
+// goMod is the go.mod file of the gomod= tests
+const goMod = `module example.com/tool
+
+go 1.25.1
+
+toolchain go1.27.0
+
+require (
+	github.com/spf13/cobra v1.9.1
+	golang.org/x/sync v0.12.0 // indirect
+	golang.org/x/text v0.23.0
+)
+
+require "github.com/BurntSushi/toml" v1.5.0 // config files
+
+replace golang.org/x/text => ../text
+replace github.com/spf13/cobra v1.9.1 => github.com/fork/cobra v1.9.2
+`
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test rendering the requirements of gomod= sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestExtractGoMod(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		attrs   map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "Direct requirements",
+			data:  goMod,
+			attrs: map[string]string{"gomod": "go.mod"},
+			want: "| Requirement                | Version                                |\n" +
+				"| -------------------------- | -------------------------------------- |\n" +
+				"| Go                         | 1.25.1                                 |\n" +
+				"| Toolchain                  | go1.27.0                               |\n" +
+				"| github.com/spf13/cobra     | v1.9.1 => github.com/fork/cobra v1.9.2 |\n" +
+				"| golang.org/x/text          | v0.23.0 => ../text                     |\n" +
+				"| github.com/BurntSushi/toml | v1.5.0                                 |\n",
+		},
+		{
+			name:  "Selected and indirect requirements",
+			data:  goMod,
+			attrs: map[string]string{"gomod": "go.mod", "deps": "golang.org/x/*", "indirect": "true"},
+			want: "| Requirement       | Version            |\n" +
+				"| ----------------- | ------------------ |\n" +
+				"| Go                | 1.25.1             |\n" +
+				"| Toolchain         | go1.27.0           |\n" +
+				"| golang.org/x/sync | v0.12.0            |\n" +
+				"| golang.org/x/text | v0.23.0 => ../text |\n",
+		},
+		{
+			name:    "Not a go.mod file",
+			data:    "# README\n",
+			attrs:   map[string]string{"gomod": "README.md"},
+			wantErr: "README.md is not a go.mod file",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractGoMod(Section{Name: "deps", Attrs: tt.attrs}, []byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,464 @@
+// Package gosect finds and replaces marker-delimited sections in text
+// documents with the content of external source files.
+package gosect
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Default marker prefixes
+const (
+	DefaultBegin = "BEGIN SECTION"
+	DefaultEnd   = "END SECTION"
+)
+
+// Section represents a found section in the content
+type Section struct {
+	Name     string
+	StartIdx int
+	EndIdx   int
+	SrcFile  string
+	Content  string
+	Attrs    map[string]string
+	EndAttrs map[string]string // attributes of the END marker, such as review annotations
+	Pos      Positions         // location of the markers, attributes and body, set by FindSections
+
+	attrsStart, attrsEnd       int // span of the BEGIN marker attributes, 0 when unknown
+	endAttrsStart, endAttrsEnd int // span of the END marker attributes, 0 when unknown
+}
+
+// attribute syntax: keys are letters, digits, '_', '.' and '-', starting
+// with a letter or '_'. Values are bare words, double quoted with backslash
+// escapes, or single quoted.
+const (
+	attrKeyPattern   = `[A-Za-z_][A-Za-z0-9_.-]*`
+	attrValuePattern = `"(?:[^"\\\n]|\\.)*"|'[^'\n]*'|[^\s>]+`
+
+	// attrsPattern captures the attribute list of a marker
+	attrsPattern = `((?:[ \t]+` + attrKeyPattern + `=(?:` + attrValuePattern + `))*)`
+)
+
+// initial regex patterns, capturing name + optional attributes
+var reBegin, reEnd = MakeRegex(DefaultBegin, DefaultEnd)
+
+// reAttr matches a key=value pair of an attribute list
+var reAttr = regexp.MustCompile(`(` + attrKeyPattern + `)=(` + attrValuePattern + `)`)
+
+// MakeRegex builds the BEGIN and END marker regexes for the given prefixes
+func MakeRegex(begin, end string) (*regexp.Regexp, *regexp.Regexp) {
+	return makeRegex(regexp.QuoteMeta(begin), regexp.QuoteMeta(end))
+}
+
+// makeRegex builds the BEGIN and END marker regexes for prefixes given as
+// regular expressions
+func makeRegex(begin, end string) (*regexp.Regexp, *regexp.Regexp) {
+	b := regexp.MustCompile("(?m)" + begin + ` ([A-Za-z0-9_-]+)` + attrsPattern)
+	e := regexp.MustCompile("(?m)" + end + ` ([A-Za-z0-9_-]+)` + attrsPattern)
+
+	return b, e
+}
+
+// newSection builds a section from the name and raw attribute list captured
+// by a BEGIN marker
+func newSection(name, rawAttrs string) Section {
+	attrs := parseAttrs(rawAttrs)
+
+	return Section{
+		Name:    name,
+		SrcFile: attrs["file"],
+		Attrs:   attrs,
+	}
+}
+
+// parseAttrs parses a list of key=value pairs separated by blanks, removing
+// the quotes around quoted values. Every attribute is kept, including the ones
+// gosect does not use, so tools can annotate markers with their own keys.
+func parseAttrs(raw string) map[string]string {
+	attrs := map[string]string{}
+	for _, m := range reAttr.FindAllStringSubmatch(raw, -1) {
+		attrs[m[1]] = unquoteAttr(m[2])
+	}
+
+	return attrs
+}
+
+// unquoteAttr returns the value of a bare or quoted attribute value
+func unquoteAttr(value string) string {
+	if len(value) < 2 || value[0] != value[len(value)-1] {
+		return value
+	}
+
+	switch value[0] {
+	case '\'':
+		return value[1 : len(value)-1]
+	case '"':
+		// only quotes are escaped: backslashes are kept, as in Windows paths
+		return strings.ReplaceAll(value[1:len(value)-1], `\"`, `"`)
+	default:
+		return value
+	}
+}
+
+// quoteAttr returns value as an attribute value, quoted when it is empty or
+// contains blanks, quotes or '>'. Values a double quoted value cannot hold,
+// ending with a backslash or holding \", are single quoted.
+func quoteAttr(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t\"'>") {
+		return value
+	}
+	if (strings.HasSuffix(value, `\`) || strings.Contains(value, `\"`)) && !strings.Contains(value, "'") {
+		return "'" + value + "'"
+	}
+
+	return `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+}
+
+// submatch returns the text of capture group n of loc, or nil when the group
+// did not participate in the match
+func submatch[T string | []byte](content T, loc []int, n int) T {
+	if len(loc) <= 2*n+1 || loc[2*n] == -1 || loc[2*n+1] == -1 {
+		var zero T
+		return zero
+	}
+
+	return content[loc[2*n]:loc[2*n+1]]
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// find all sections in content
+// /////////////////////////////////////////////////////////////////////////////
+
+// FindSections returns every BEGIN/END section pair found in content, in the
+// order of their BEGIN marker. Content is read in a single pass, line by
+// line: markers match within a line. Markers pair like brackets: an END
+// marker closes the innermost open section of its name. Overlapping
+// sections, a section repeated inside itself and BEGIN markers without END
+// marker are reported as errors located at the offending marker.
+func FindSections(content string, reBegin, reEnd *regexp.Regexp) ([]Section, error) {
+	return FindSectionsBytes([]byte(content), reBegin, reEnd)
+}
+
+// renderBody returns the section body written between the BEGIN and END
+// lines for the source content src, using CRLF line endings when crlf is set
+func renderBody(src []byte, indent string, crlf bool) []byte {
+	src = bytes.ReplaceAll(src, []byte("\r\n"), []byte("\n"))
+
+	var body bytes.Buffer
+	body.Grow(len(src) + 3)
+	body.WriteByte('\n')
+	writeIndented(&body, src, indent)
+	body.WriteString("\n\n")
+
+	if crlf {
+		return bytes.ReplaceAll(body.Bytes(), []byte("\n"), []byte("\r\n"))
+	}
+
+	return body.Bytes()
+}
+
+// usesCRLF reports whether most lines of content end with CRLF
+func usesCRLF(content []byte) bool {
+	crlf := bytes.Count(content, []byte("\r\n"))
+
+	return crlf > 0 && 2*crlf >= bytes.Count(content, []byte("\n"))
+}
+
+// HasMarkers reports whether content may contain a BEGIN marker. It only
+// checks for the literal prefix of reBegin, which is much cheaper than
+// running the regex, so a true result may still yield no section.
+func HasMarkers(content []byte, reBegin *regexp.Regexp) bool {
+	prefix, _ := reBegin.LiteralPrefix()
+	if prefix == "" {
+		return reBegin.Match(content)
+	}
+
+	return bytes.Contains(content, []byte(prefix))
+}
+
+// FindSectionsBytes is like FindSections but works on a byte slice without
+// copying it
+func FindSectionsBytes(content []byte, reBegin, reEnd *regexp.Regexp) ([]Section, error) {
+
+	// fast path: skip regex matching when no BEGIN marker can be found
+	if !HasMarkers(content, reBegin) {
+		return nil, nil
+	}
+
+	// pair BEGIN and END markers line by line
+	sc := newLineScanner(reBegin, reEnd)
+	var sections []Section
+	for line := range bytes.Lines(content) {
+		sections = append(sections, sc.scan(line)...)
+	}
+	if err := sc.finish(); err != nil {
+		return nil, err
+	}
+
+	// sections in the order of their BEGIN marker, enclosing ones first
+	slices.SortFunc(sections, func(a, b Section) int { return a.StartIdx - b.StartIdx })
+
+	return sections, nil
+}
+
+// moved returns the section moved by offset bytes and lines lines in its
+// document, such as when the content before it is rendered
+func (s Section) moved(offset, lines int) Section {
+	s.StartIdx += offset
+	s.EndIdx += offset
+	if s.attrsEnd > 0 {
+		s.attrsStart += offset
+		s.attrsEnd += offset
+	}
+	if s.endAttrsEnd > 0 {
+		s.endAttrsStart += offset
+		s.endAttrsEnd += offset
+	}
+	s.Pos = s.Pos.moved(offset, lines)
+
+	return s
+}
+
+// FilterSections keeps the sections whose name matches one of the glob
+// patterns (see path.Match). All sections are kept when patterns is empty.
+func FilterSections(sections []Section, patterns []string) ([]Section, error) {
+	if len(patterns) == 0 {
+		return sections, nil
+	}
+
+	var kept []Section
+	for _, s := range sections {
+		for _, pattern := range patterns {
+			ok, err := path.Match(pattern, s.Name)
+			if err != nil {
+				return nil, fmt.Errorf("invalid section pattern %q: %w", pattern, err)
+			}
+			if ok {
+				kept = append(kept, s)
+				break
+			}
+		}
+	}
+
+	return kept, nil
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// replace sections content
+// /////////////////////////////////////////////////////////////////////////////
+
+// ReplaceSections replaces the body of each section with its source file
+func ReplaceSections(content string, sections []Section, verbose bool, reBegin, reEnd *regexp.Regexp) (string, error) {
+	out, err := ReplaceSectionsBytes([]byte(content), sections, verbose, reBegin, reEnd)
+	if err != nil {
+		return "", err
+	}
+
+	return string(out), nil
+}
+
+// ReplaceSectionsBytes is like ReplaceSections but works on byte slices
+func ReplaceSectionsBytes(content []byte, sections []Section, verbose bool, reBegin, reEnd *regexp.Regexp) ([]byte, error) {
+	return Replace(content, sections, Options{Verbose: verbose})
+}
+
+// SectionErrors lists the errors of the sections left unchanged by Replace
+// with Options.KeepGoing
+type SectionErrors []error
+
+// Error joins the section errors, one per line
+func (e SectionErrors) Error() string {
+	return errors.Join(e...).Error()
+}
+
+// Unwrap returns the section errors
+func (e SectionErrors) Unwrap() []error {
+	return e
+}
+
+// Replace replaces the body of each section with its rendered source
+// content. Sections found in included sources are expanded recursively. The
+// errors of every failed section are reported, located at their BEGIN
+// marker. With Options.KeepGoing, sections which cannot be rendered are left
+// unchanged: the result is returned along with their SectionErrors.
+// Inserted content uses the line endings (LF or CRLF) of content. The output
+// is built in a single pass, copying the unchanged parts of content between
+// sections.
+func Replace(content []byte, sections []Section, opts Options) ([]byte, error) {
+	return opts.replace(content, sections, nil)
+}
+
+// Frozen reports whether s has the skip=true or frozen=true attribute: the
+// section is found and listed, but its body is never rewritten
+func Frozen(s Section) bool {
+	return s.Attrs["skip"] == "true" || s.Attrs["frozen"] == "true"
+}
+
+// replace renders sections of content; chain lists the source files being
+// expanded, from the outermost include. Sections with a src= pseudo-source
+// are rendered last, from the document holding the other rendered sections.
+func (opts Options) replace(content []byte, sections []Section, chain []string) ([]byte, error) {
+	out, deferred, err := opts.replacePass(content, sections, chain, true)
+	if len(deferred) == 0 || out == nil {
+		return out, err
+	}
+
+	final, _, finalErr := opts.replacePass(out, deferred, chain, false)
+	var failed SectionErrors
+	for _, e := range []error{err, finalErr} {
+		switch e := e.(type) {
+		case nil:
+		case SectionErrors:
+			failed = append(failed, e...)
+		default:
+			return nil, errors.Join(err, finalErr)
+		}
+	}
+	if len(failed) > 0 {
+		return final, failed
+	}
+
+	return final, nil
+}
+
+// replacePass renders sections of content. When deferBuiltin is set, the
+// sections with a src= pseudo-source are left unchanged and returned, located
+// in the output.
+func (opts Options) replacePass(content []byte, sections []Section, chain []string, deferBuiltin bool) ([]byte, []Section, error) {
+
+	var out bytes.Buffer
+	out.Grow(len(content))
+	last := 0
+	crlf := usesCRLF(content)
+	if len(chain) == 0 {
+		crlf = opts.EditorConfig.lineEndings(content)
+	}
+	var failed SectionErrors
+	var deferred []Section
+
+	for _, s := range sections {
+		if s.StartIdx < last {
+			return nil, nil, newPositionError(content, s.StartIdx, fmt.Errorf("section %s overlaps a previous section", s.Name))
+		}
+
+		// inline sections hold a single line between markers sharing a line
+		if inlineSection(content, s) {
+			if Frozen(s) {
+				continue
+			}
+			body, start, end, err := opts.renderInline(content, s, chain)
+			if err != nil {
+				if !opts.tolerateMissing(s, err) {
+					failed = append(failed, newPositionError(content, s.StartIdx, err))
+				}
+				continue
+			}
+			out.Write(content[last:start])
+			out.Write(body)
+			last = end
+			continue
+		}
+
+		// find end of BEGIN line and start of END line
+		endOfBeginLine := bytes.IndexByte(content[s.StartIdx:], '\n')
+		if endOfBeginLine == -1 {
+			return nil, nil, newPositionError(content, s.StartIdx, fmt.Errorf("malformed BEGIN line for section %s", s.Name))
+		}
+		endOfBeginLine += s.StartIdx
+
+		startOfEndLine := bytes.LastIndexByte(content[:s.EndIdx], '\n') + 1
+		if startOfEndLine <= endOfBeginLine {
+			return nil, nil, newPositionError(content, s.EndIdx, fmt.Errorf("malformed END line for section %s", s.Name))
+		}
+
+		// frozen sections keep their body, such as a hand-tweaked snippet
+		if Frozen(s) {
+			opts.logger().Debug("section frozen", "section", s.Name)
+			continue
+		}
+
+		// leave the section unchanged until the document is rendered
+		if deferBuiltin && builtinSource(s) {
+			out.Write(content[last:s.StartIdx])
+			last = s.StartIdx
+			lines := bytes.Count(out.Bytes(), []byte("\n")) - bytes.Count(content[:s.StartIdx], []byte("\n"))
+			deferred = append(deferred, s.moved(out.Len()-s.StartIdx, lines))
+			continue
+		}
+
+		// report every failed section, leaving it unchanged
+		r, err := opts.renderSection(content, s, endOfBeginLine, startOfEndLine, crlf, chain)
+		if err != nil {
+			if !opts.tolerateMissing(s, err) {
+				failed = append(failed, newPositionError(content, s.StartIdx, err))
+			}
+			continue
+		}
+
+		out.Write(content[last:s.StartIdx])
+		out.Write(r.beginLine)
+		out.Write(r.body)
+		last = startOfEndLine
+		if r.endAttrs != nil {
+			out.Write(content[startOfEndLine:r.endAttrsStart])
+			out.Write(r.endAttrs)
+			last = r.endAttrsEnd
+		}
+	}
+
+	out.Write(content[last:])
+
+	if len(failed) > 0 && opts.KeepGoing {
+		return out.Bytes(), deferred, failed
+	}
+	if len(failed) > 0 {
+		return nil, nil, errors.Join(failed...)
+	}
+
+	return out.Bytes(), deferred, nil
+}
+
+// renderedSection is the new content of a section: its BEGIN line, its body
+// and, when they change, the attributes of its END marker found at
+// endAttrsStart:endAttrsEnd
+type renderedSection struct {
+	beginLine, body            []byte
+	endAttrs                   []byte
+	endAttrsStart, endAttrsEnd int
+}
+
+// renderSection renders section s of content, whose BEGIN line ends at
+// endOfBeginLine and END line starts at startOfEndLine
+func (opts Options) renderSection(content []byte, s Section, endOfBeginLine, startOfEndLine int, crlf bool, chain []string) (renderedSection, error) {
+	var r renderedSection
+	start := time.Now()
+	src, err := opts.sectionContent(s, content, chain)
+	if err != nil {
+		return r, err
+	}
+
+	indent, err := sectionIndent(s, markerIndent(content, s.StartIdx))
+	if err != nil {
+		return r, err
+	}
+	if _, ok := s.Attrs["indent"]; ok && len(chain) == 0 {
+		indent = opts.EditorConfig.indentation(indent)
+	}
+
+	r.body = opts.format().Body(src, indent, crlf)
+	opts.logger().Debug("section rendered", "section", s.Name, "source", sourceName(s), "bytes", len(r.body), "duration", time.Since(start))
+	r.beginLine, r.body, err = opts.checksumBegin(s, content[s.StartIdx:endOfBeginLine+1], content[endOfBeginLine+1:startOfEndLine], r.body)
+	if err != nil {
+		return r, err
+	}
+
+	r.endAttrs, r.endAttrsStart, r.endAttrsEnd, err = opts.reviewEnd(content, s, r.body)
+
+	return r, err
+}
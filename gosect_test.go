@@ -0,0 +1,717 @@
+package gosect
+
+import (
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test MakeRegex function
+// /////////////////////////////////////////////////////////////////////////////
+func TestRegexMarker(t *testing.T) {
+	tests := []struct {
+		name       string
+		beginMark  string
+		endMark    string
+		testString string
+		shouldFind bool
+	}{
+		{
+			name:       "Default markers",
+			beginMark:  "BEGIN SECTION",
+			endMark:    "END SECTION",
+			testString: "BEGIN SECTION test",
+			shouldFind: true,
+		},
+		{
+			name:       "Custom markers",
+			beginMark:  "START",
+			endMark:    "STOP",
+			testString: "START mysection",
+			shouldFind: true,
+		},
+		{
+			name:       "HTML comment markers",
+			beginMark:  "<!-- BEGIN",
+			endMark:    "<!-- END",
+			testString: "<!-- BEGIN test",
+			shouldFind: true,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reBegin, reEnd := MakeRegex(tt.beginMark, tt.endMark)
+			if reBegin == nil || reEnd == nil {
+				t.Fatal("MakeRegex returned nil")
+			}
+			matched := reBegin.MatchString(tt.testString)
+			if matched != tt.shouldFind {
+				t.Errorf("Expected match=%v, got %v for string: %s", tt.shouldFind, matched, tt.testString)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test FindSections function
+// /////////////////////////////////////////////////////////////////////////////
+func TestFindSections(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		wantSections  int
+		wantFirstName string
+		wantError     bool
+	}{
+		{
+			name: "Single section",
+			content: `<!-- BEGIN SECTION test file=test.txt -->
+content here
+<!-- END SECTION test -->`,
+			wantSections:  1,
+			wantFirstName: "test",
+			wantError:     false,
+		},
+		{
+			name: "Multiple sections",
+			content: `<!-- BEGIN SECTION first file=a.txt -->
+content1
+<!-- END SECTION first -->
+
+<!-- BEGIN SECTION second file=b.txt -->
+content2
+<!-- END SECTION second -->`,
+			wantSections:  2,
+			wantFirstName: "first",
+			wantError:     false,
+		},
+		{
+			name: "No END marker",
+			content: `<!-- BEGIN SECTION test file=test.txt -->
+content here`,
+			wantSections: 0,
+			wantError:    true,
+		},
+		{
+			name: "Section with hyphen and underscore",
+			content: `<!-- BEGIN SECTION test-name_123 file=test.txt -->
+content
+<!-- END SECTION test-name_123 -->`,
+			wantSections:  1,
+			wantFirstName: "test-name_123",
+			wantError:     false,
+		},
+	}
+
+	// run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := FindSections(tt.content, reBegin, reEnd)
+
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if len(sections) != tt.wantSections {
+				t.Errorf("Expected %d sections, got %d", tt.wantSections, len(sections))
+			}
+
+			if len(sections) > 0 && sections[0].Name != tt.wantFirstName {
+				t.Errorf("Expected first section name %q, got %q", tt.wantFirstName, sections[0].Name)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test pairing nested, overlapping and repeated sections
+// /////////////////////////////////////////////////////////////////////////////
+func TestFindSectionsPairing(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		want      []string // name=body of each section
+		wantError string
+	}{
+		{
+			name:    "Repeated names",
+			content: "BEGIN SECTION a\n1\nEND SECTION a\nBEGIN SECTION a\n2\nEND SECTION a\n",
+			want:    []string{"a=1\n", "a=2\n"},
+		},
+		{
+			name:    "Nested sections",
+			content: "BEGIN SECTION a\nBEGIN SECTION b\n1\nEND SECTION b\nEND SECTION a\n",
+			want:    []string{"a=BEGIN SECTION b\n1\nEND SECTION b\n", "b=1\n"},
+		},
+		{
+			name:    "Orphaned END before BEGIN",
+			content: "END SECTION a\nBEGIN SECTION a\n1\nEND SECTION a\n",
+			want:    []string{"a=1\n"},
+		},
+		{
+			name:      "Overlapping sections",
+			content:   "BEGIN SECTION a\nBEGIN SECTION b\nEND SECTION a\nEND SECTION b\n",
+			wantError: "3:1: sections a and b overlap: END SECTION a comes before END SECTION b (BEGIN at line 2)",
+		},
+		{
+			name:      "Repeated name inside itself",
+			content:   "BEGIN SECTION a\nBEGIN SECTION a\nEND SECTION a\nEND SECTION a\n",
+			wantError: "2:1: repeated section a begins inside section a (BEGIN at line 1)",
+		},
+		{
+			name:      "Unclosed inner section",
+			content:   "BEGIN SECTION a\nBEGIN SECTION b\nEND SECTION a\n",
+			wantError: "2:1: no END SECTION for b",
+		},
+		{
+			name:      "Errors in document order",
+			content:   "BEGIN SECTION a\nBEGIN SECTION b\nEND SECTION b\nBEGIN SECTION c\nBEGIN SECTION d\nEND SECTION c\nEND SECTION d\n",
+			wantError: "1:1: no END SECTION for a\n6:1: sections c and d overlap: END SECTION c comes before END SECTION d (BEGIN at line 5)",
+		},
+	}
+
+	// run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := FindSections(tt.content, reBegin, reEnd)
+
+			if tt.wantError != "" {
+				if err == nil || err.Error() != tt.wantError {
+					t.Errorf("Expected error %q, got %v", tt.wantError, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var got []string
+			for _, s := range sections {
+				got = append(got, s.Name+"="+tt.content[s.Pos.Body.Start.Offset:s.Pos.Body.End.Offset])
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test parsing marker attributes
+// /////////////////////////////////////////////////////////////////////////////
+func TestParseAttrs(t *testing.T) {
+	tests := []struct {
+		name   string
+		marker string
+		want   map[string]string
+	}{
+		{
+			name:   "Bare values",
+			marker: "<!-- BEGIN SECTION a file=a.md lang=go -->",
+			want:   map[string]string{"file": "a.md", "lang": "go"},
+		},
+		{
+			name:   "Double quoted value with spaces",
+			marker: `<!-- BEGIN SECTION a file="my docs/example.md" -->`,
+			want:   map[string]string{"file": "my docs/example.md"},
+		},
+		{
+			name:   "Escaped quotes",
+			marker: `# BEGIN SECTION a cmd="echo \"hi\" \o/"`,
+			want:   map[string]string{"cmd": `echo "hi" \o/`},
+		},
+		{
+			name:   "Single quoted value",
+			marker: `# BEGIN SECTION a cmd='echo "hi"'  prefix='> '`,
+			want:   map[string]string{"cmd": `echo "hi"`, "prefix": "> "},
+		},
+		{
+			name:   "Windows drive letter path",
+			marker: `<!-- BEGIN SECTION a file=C:\docs\usage.md -->`,
+			want:   map[string]string{"file": `C:\docs\usage.md`},
+		},
+		{
+			name:   "Quoted Windows path keeps its backslashes",
+			marker: `<!-- BEGIN SECTION a file="C:\Program Files\tool\new.txt" -->`,
+			want:   map[string]string{"file": `C:\Program Files\tool\new.txt`},
+		},
+		{
+			name:   "Quoted UNC path keeps its backslashes",
+			marker: `<!-- BEGIN SECTION a file="\\server\My Share\x.md" -->`,
+			want:   map[string]string{"file": `\\server\My Share\x.md`},
+		},
+		{
+			name:   "UNC path",
+			marker: `<!-- BEGIN SECTION a file=\\server\share\usage.md -->`,
+			want:   map[string]string{"file": `\\server\share\usage.md`},
+		},
+		{
+			name:   "Unknown keys",
+			marker: "<!-- BEGIN SECTION a file=a.md x-owner=docs ci.skip=true _id=42 -->",
+			want:   map[string]string{"file": "a.md", "x-owner": "docs", "ci.skip": "true", "_id": "42"},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := FindSections(tt.marker+"\n# END SECTION a\n", reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(sections) != 1 {
+				t.Fatalf("Expected 1 section, got %d", len(sections))
+			}
+			if !maps.Equal(sections[0].Attrs, tt.want) {
+				t.Errorf("Expected %v, got %v", tt.want, sections[0].Attrs)
+			}
+			if sections[0].SrcFile != tt.want["file"] {
+				t.Errorf("Expected file %q, got %q", tt.want["file"], sections[0].SrcFile)
+			}
+		})
+	}
+
+	for value, want := range map[string]string{"a.md": "a.md", "my docs": `"my docs"`, `say "hi"`: `"say \"hi\""`, "": `""`,
+		`\\server\My Share`: `"\\server\My Share"`, `C:\My Docs\`: `'C:\My Docs\'`,
+	} {
+		if got := quoteAttr(value); got != want || unquoteAttr(got) != value {
+			t.Errorf("Expected %s to quote as %s and back, got %s", value, want, got)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test ReplaceSections function
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceSections(t *testing.T) {
+	// Create temporary test files
+	tmpDir := t.TempDir()
+	testFile1 := filepath.Join(tmpDir, "test1.txt")
+	testFile2 := filepath.Join(tmpDir, "test2.txt")
+
+	err := os.WriteFile(testFile1, []byte("REPLACEMENT_1"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(testFile2, []byte("REPLACEMENT_2"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name         string
+		content      string
+		sections     []Section
+		wantContains []string
+		wantError    bool
+	}{
+		{
+			name: "Single section replacement",
+			content: `Header
+<!-- BEGIN SECTION test -->
+old content
+<!-- END SECTION test -->
+Footer`,
+			sections: []Section{
+				{
+					Name: "test",
+					StartIdx: strings.Index(`Header
+<!-- BEGIN SECTION test -->
+old content
+<!-- END SECTION test -->
+Footer`, "BEGIN"),
+					EndIdx: strings.Index(`Header
+<!-- BEGIN SECTION test -->
+old content
+<!-- END SECTION test -->
+Footer`, "END"),
+					SrcFile: testFile1,
+				},
+			},
+			wantContains: []string{"REPLACEMENT_1", "Header", "Footer", "<!-- BEGIN SECTION test -->", "<!-- END SECTION test -->"},
+			wantError:    false,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := ReplaceSections(tt.content, tt.sections, false, reBegin, reEnd)
+
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			for _, want := range tt.wantContains {
+				if !strings.Contains(result, want) {
+					t.Errorf("Expected result to contain %q, but it doesn't.\nResult:\n%s", want, result)
+				}
+			}
+
+			// Verify old content is gone
+			if strings.Contains(result, "old content") {
+				t.Error("Old content should have been replaced")
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test end-to-end workflow
+// /////////////////////////////////////////////////////////////////////////////
+func TestEndToEnd(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	// Create source file
+	sourceFile := filepath.Join(tmpDir, "source.txt")
+	err := os.WriteFile(sourceFile, []byte("NEW CONTENT"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Create target file with section
+	targetFile := filepath.Join(tmpDir, "target.md")
+	originalContent := `# Document
+
+<!-- BEGIN SECTION example file=` + sourceFile + ` -->
+old content here
+<!-- END SECTION example -->
+
+End of document`
+
+	err = os.WriteFile(targetFile, []byte(originalContent), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Read the file
+	content, err := os.ReadFile(targetFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Find sections
+	sections, err := FindSections(string(content), reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sections) != 1 {
+		t.Fatalf("Expected 1 section, got %d", len(sections))
+	}
+
+	// Replace sections
+	result, err := ReplaceSections(string(content), sections, false, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Verify result
+	if !strings.Contains(result, "NEW CONTENT") {
+		t.Error("Result should contain new content")
+	}
+
+	if strings.Contains(result, "old content here") {
+		t.Error("Result should not contain old content")
+	}
+
+	if !strings.Contains(result, "<!-- BEGIN SECTION example") {
+		t.Error("Result should preserve BEGIN marker")
+	}
+
+	if !strings.Contains(result, "<!-- END SECTION example -->") {
+		t.Error("Result should preserve END marker")
+	}
+
+	if !strings.Contains(result, "# Document") {
+		t.Error("Result should preserve header")
+	}
+
+	if !strings.Contains(result, "End of document") {
+		t.Error("Result should preserve footer")
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test custom markers
+// /////////////////////////////////////////////////////////////////////////////
+func TestCustomMarkers(t *testing.T) {
+	tmpDir := t.TempDir()
+	sourceFile := filepath.Join(tmpDir, "source.txt")
+	err := os.WriteFile(sourceFile, []byte("CONTENT"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := `[[ START mysection file=` + sourceFile + ` ]]
+old
+[[ STOP mysection ]]`
+
+	customBegin, customEnd := MakeRegex("[[ START", "[[ STOP")
+
+	sections, err := FindSections(content, customBegin, customEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sections) != 1 {
+		t.Fatalf("Expected 1 section with custom markers, got %d", len(sections))
+	}
+
+	result, err := ReplaceSections(content, sections, false, customBegin, customEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(result, "CONTENT") {
+		t.Error("Result should contain new content with custom markers")
+	}
+
+	if !strings.Contains(result, "[[ START mysection") {
+		t.Error("Result should preserve custom BEGIN marker")
+	}
+
+	if !strings.Contains(result, "[[ STOP mysection ]]") {
+		t.Error("Result should preserve custom END marker")
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test HasMarkers pre-filter
+// /////////////////////////////////////////////////////////////////////////////
+func TestHasMarkers(t *testing.T) {
+	customBegin, _ := MakeRegex("[[ START", "[[ STOP")
+
+	tests := []struct {
+		name    string
+		reBegin *regexp.Regexp
+		content string
+		want    bool
+	}{
+		{
+			name:    "Default marker present",
+			reBegin: reBegin,
+			content: "text\n<!-- BEGIN SECTION a file=a.txt -->\n",
+			want:    true,
+		},
+		{
+			name:    "No marker",
+			reBegin: reBegin,
+			content: "just some text\nwithout sections\n",
+			want:    false,
+		},
+		{
+			name:    "Custom marker present",
+			reBegin: customBegin,
+			content: "[[ START a ]]",
+			want:    true,
+		},
+		{
+			name:    "Custom marker absent",
+			reBegin: customBegin,
+			content: "<!-- BEGIN SECTION a -->",
+			want:    false,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasMarkers([]byte(tt.content), tt.reBegin); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test FilterSections function
+// /////////////////////////////////////////////////////////////////////////////
+func TestFilterSections(t *testing.T) {
+	sections := []Section{{Name: "install"}, {Name: "api-users"}, {Name: "api-groups"}, {Name: "footer"}}
+
+	tests := []struct {
+		name      string
+		patterns  []string
+		wantNames []string
+		wantError bool
+	}{
+		{
+			name:      "No pattern keeps all",
+			wantNames: []string{"install", "api-users", "api-groups", "footer"},
+		},
+		{
+			name:      "Exact names",
+			patterns:  []string{"install", "footer"},
+			wantNames: []string{"install", "footer"},
+		},
+		{
+			name:      "Glob pattern",
+			patterns:  []string{"api-*"},
+			wantNames: []string{"api-users", "api-groups"},
+		},
+		{
+			name:      "Invalid pattern",
+			patterns:  []string{"["},
+			wantError: true,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			kept, err := FilterSections(sections, tt.patterns)
+			if tt.wantError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			var names []string
+			for _, s := range kept {
+				names = append(names, s.Name)
+			}
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("Expected %v, got %v", tt.wantNames, names)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test line endings of the target are preserved
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceLineEndings(t *testing.T) {
+	tmpDir := t.TempDir()
+	lfSource := filepath.Join(tmpDir, "lf.txt")
+	crlfSource := filepath.Join(tmpDir, "crlf.txt")
+
+	err := os.WriteFile(lfSource, []byte("line 1\nline 2\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(crlfSource, []byte("line 1\r\nline 2\r\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		source string
+		eol    string
+	}{
+		{name: "LF source into CRLF target", source: lfSource, eol: "\r\n"},
+		{name: "CRLF source into CRLF target", source: crlfSource, eol: "\r\n"},
+		{name: "CRLF source into LF target", source: crlfSource, eol: "\n"},
+		{name: "LF source into LF target", source: lfSource, eol: "\n"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := strings.ReplaceAll("# Title\n<!-- BEGIN SECTION s file="+tt.source+" -->\nold\n<!-- END SECTION s -->\nFooter\n", "\n", tt.eol)
+			sections, err := FindSections(content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := ReplaceSections(content, sections, false, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			want := strings.ReplaceAll("# Title\n<!-- BEGIN SECTION s file="+tt.source+" -->\n\nline 1\nline 2\n\n<!-- END SECTION s -->\nFooter\n", "\n", tt.eol)
+			if result != want {
+				t.Errorf("Expected %q, got %q", want, result)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test failed sections are left unchanged with KeepGoing
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceKeepGoing(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "source.txt")
+	if err := os.WriteFile(source, []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "<!-- BEGIN SECTION a file=missing.txt -->\nold a\n<!-- END SECTION a -->\n" +
+		"<!-- BEGIN SECTION b file=" + source + " -->\nold b\n<!-- END SECTION b -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := Replace([]byte(content), sections, Options{}); err == nil {
+		t.Error("Expected error, got nil")
+	}
+
+	result, err := Replace([]byte(content), sections, Options{KeepGoing: true})
+	var sectionErrs SectionErrors
+	if !errors.As(err, &sectionErrs) || len(sectionErrs) != 1 {
+		t.Fatalf("Expected one section error, got %v", err)
+	}
+	want := "<!-- BEGIN SECTION a file=missing.txt -->\nold a\n<!-- END SECTION a -->\n" +
+		"<!-- BEGIN SECTION b file=" + source + " -->\n\ngenerated\n\n<!-- END SECTION b -->\n"
+	if string(result) != want {
+		t.Errorf("Expected %q, got %q", want, result)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test frozen sections are never rewritten
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceFrozen(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "source.txt")
+	if err := os.WriteFile(source, []byte("generated\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "<!-- BEGIN SECTION a file=" + source + " skip=true -->\nhand tweaked\n<!-- END SECTION a -->\n" +
+		"<!-- BEGIN SECTION b file=missing.txt frozen=true -->\npinned\n<!-- END SECTION b -->\n" +
+		"<!-- BEGIN SECTION c file=" + source + " frozen=false -->\nold\n<!-- END SECTION c -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 3 {
+		t.Fatalf("Expected frozen sections to be found, got %d sections", len(sections))
+	}
+
+	result, err := Replace([]byte(content), sections, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Replace(content, "frozen=false -->\nold\n", "frozen=false -->\n\ngenerated\n\n", 1)
+	if string(result) != want {
+		t.Errorf("Expected %q, got %q", want, result)
+	}
+}
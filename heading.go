@@ -0,0 +1,103 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// sections under Markdown headings, for documents without markers
+// /////////////////////////////////////////////////////////////////////////////
+
+// HeadingSection returns the name of the section holding the content under
+// heading, such as usage for "## Usage": the anchor of the heading
+func HeadingSection(heading string) (string, error) {
+	m := reHeading.FindStringSubmatch(strings.TrimSpace(heading))
+	if m == nil {
+		return "", fmt.Errorf("invalid heading %q (expected a Markdown heading such as \"## Usage\")", heading)
+	}
+	name := headingSlug(m[2])
+	if !reSectionName.MatchString(name) {
+		return "", fmt.Errorf("heading %q has no valid section name", heading)
+	}
+
+	return name, nil
+}
+
+// InsertUnderHeading wraps the content under the Markdown heading of
+// content, such as "## Usage", up to the next heading of the same or a
+// higher level, between the BEGIN and END lines of a new section. The
+// heading matches the first heading line of the same level and text, outside
+// fenced code blocks.
+func InsertUnderHeading(content []byte, heading, beginLine, endLine string) ([]byte, error) {
+	want := reHeading.FindStringSubmatch(strings.TrimSpace(heading))
+	if want == nil {
+		return nil, fmt.Errorf("invalid heading %q (expected a Markdown heading such as \"## Usage\")", heading)
+	}
+	level := len(want[1])
+
+	// offsets of the line after the heading and of the next heading
+	start, stop := -1, len(content)
+	fence := ""
+	offset := 0
+	for line := range strings.Lines(string(content)) {
+		lineStart := offset
+		offset += len(line)
+		line = strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimLeft(line, " ")
+
+		// skip fenced code blocks
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+			continue
+		}
+
+		m := reHeading.FindStringSubmatch(line)
+		switch {
+		case m == nil:
+		case start == -1 && len(m[1]) == level && m[2] == want[2]:
+			start = offset
+		case start != -1 && len(m[1]) <= level:
+			stop = lineStart
+		}
+		if stop != len(content) {
+			break
+		}
+	}
+	if start == -1 {
+		return nil, fmt.Errorf("heading %q not found", strings.TrimSpace(heading))
+	}
+
+	// the current content, without its surrounding blank lines, becomes the
+	// body of the section
+	nl := "\n"
+	if usesCRLF(content) {
+		nl = "\r\n"
+	}
+	body := bytes.Trim(content[start:stop], "\r\n")
+
+	var out bytes.Buffer
+	out.Write(content[:start])
+	if start > 0 && content[start-1] != '\n' {
+		out.WriteString(nl)
+	}
+	out.WriteString(nl + beginLine + nl)
+	if len(body) > 0 {
+		out.Write(body)
+		out.WriteString(nl)
+	}
+	out.WriteString(endLine + nl)
+	if stop < len(content) {
+		out.WriteString(nl)
+	}
+	out.Write(content[stop:])
+
+	return out.Bytes(), nil
+}
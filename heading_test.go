@@ -0,0 +1,81 @@
+package gosect
+
+import (
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test inserting markers under Markdown headings
+// /////////////////////////////////////////////////////////////////////////////
+func TestInsertUnderHeading(t *testing.T) {
+	const begin, end = "<!-- BEGIN SECTION usage file=usage.txt -->", "<!-- END SECTION usage -->"
+
+	tests := []struct {
+		name    string
+		content string
+		heading string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "Up to the next heading",
+			content: "# Tool\n\n## Usage\n\nold usage\n\n## License\n\nMIT\n",
+			heading: "## Usage",
+			want:    "# Tool\n\n## Usage\n\n" + begin + "\nold usage\n" + end + "\n\n## License\n\nMIT\n",
+		},
+		{
+			name:    "Up to the end of the document, over deeper headings",
+			content: "# Tool\n\n## Usage\n\n### Flags\n\n-v\n",
+			heading: "## Usage",
+			want:    "# Tool\n\n## Usage\n\n" + begin + "\n### Flags\n\n-v\n" + end + "\n",
+		},
+		{
+			name:    "Empty content",
+			content: "## Usage\n## License\n",
+			heading: "## Usage",
+			want:    "## Usage\n\n" + begin + "\n" + end + "\n\n## License\n",
+		},
+		{
+			name:    "Headings in code blocks",
+			content: "```\n## Usage\n```\n## Usage\nusage\n```\n# not a heading\n```\n# Next\n",
+			heading: "## Usage",
+			want:    "```\n## Usage\n```\n## Usage\n\n" + begin + "\nusage\n```\n# not a heading\n```\n" + end + "\n\n# Next\n",
+		},
+		{
+			name:    "Heading of another level",
+			content: "# Usage\n\nusage\n",
+			heading: "## Usage",
+			wantErr: `heading "## Usage" not found`,
+		},
+		{
+			name:    "Not a heading",
+			content: "usage\n",
+			heading: "Usage",
+			wantErr: `invalid heading "Usage" (expected a Markdown heading such as "## Usage")`,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := InsertUnderHeading([]byte(tt.content), tt.heading, begin, end)
+
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	if name, err := HeadingSection("## Getting Started"); err != nil || name != "getting-started" {
+		t.Errorf("Expected section getting-started, got %q %v", name, err)
+	}
+}
@@ -0,0 +1,83 @@
+package gosect
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// DefaultCacheDir is the default directory caching downloaded url= sources
+const DefaultCacheDir = ".gosect/cache"
+
+// cacheEntry describes a cached url= source: the validators revalidating it
+// and the media type and checksum of its body
+type cacheEntry struct {
+	URL          string `json:"url"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+	MediaType    string `json:"mediaType"`
+	SHA256       string `json:"sha256"`
+}
+
+// readCache returns the cached copy of url from the cache directory dir, if
+// any. Copies which do not match their checksum are ignored.
+func readCache(dir, url string) (cacheEntry, []byte, bool) {
+	var entry cacheEntry
+	if dir == "" {
+		return entry, nil, false
+	}
+
+	file := filepath.Join(dir, vendorFile(url))
+	data, err := os.ReadFile(file + ".json")
+	if err != nil || json.Unmarshal(data, &entry) != nil || entry.URL != url {
+		return entry, nil, false
+	}
+	body, err := os.ReadFile(file)
+	if err != nil || sha256Hex(body) != entry.SHA256 {
+		return entry, nil, false
+	}
+
+	return entry, body, true
+}
+
+// writeCache records body as the cached copy of entry.URL in the cache
+// directory dir
+func writeCache(dir string, entry cacheEntry, body []byte) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	entry.SHA256 = sha256Hex(body)
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Join(dir, vendorFile(entry.URL))
+	if err := writeFileAtomic(file, body); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(file+".json", append(data, '\n'))
+}
+
+// writeFileAtomic writes data to a temporary file renamed to path, so that
+// concurrent readers never see a partial file
+func writeFileAtomic(path string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+
+	return err
+}
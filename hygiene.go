@@ -0,0 +1,110 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"unicode/utf8"
+)
+
+// MarkerIssue is a marker hygiene problem reported by CheckMarkers, located
+// at a 1-based line and column of the content
+type MarkerIssue struct {
+	Line, Column int
+	Message      string
+}
+
+// Error formats the issue as line:column: message
+func (i MarkerIssue) Error() string {
+	return fmt.Sprintf("%d:%d: %s", i.Line, i.Column, i.Message)
+}
+
+// lineColumn returns the 1-based line and column, in characters, of offset
+// in content
+func lineColumn(content []byte, offset int) (int, int) {
+	start := bytes.LastIndexByte(content[:offset], '\n') + 1
+
+	return bytes.Count(content[:offset], []byte("\n")) + 1, utf8.RuneCount(content[start:offset]) + 1
+}
+
+// marker is a BEGIN or END marker found in content
+type marker struct {
+	offset int
+	name   string
+	begin  bool
+}
+
+// CheckMarkers reports every marker hygiene problem of content, including
+// those FindSections tolerates: END markers without BEGIN marker or preceding
+// it, BEGIN markers without END marker, duplicate section names, and sections
+// beginning inside another section.
+func CheckMarkers(content []byte, reBegin, reEnd *regexp.Regexp) []MarkerIssue {
+	var markers []marker
+	for _, loc := range reBegin.FindAllSubmatchIndex(content, -1) {
+		loc = markerLoc(reBegin, loc)
+		markers = append(markers, marker{offset: loc[0], name: string(content[loc[2]:loc[3]]), begin: true})
+	}
+	for _, loc := range reEnd.FindAllSubmatchIndex(content, -1) {
+		loc = markerLoc(reEnd, loc)
+		markers = append(markers, marker{offset: loc[0], name: string(content[loc[2]:loc[3]])})
+	}
+	slices.SortFunc(markers, func(a, b marker) int { return a.offset - b.offset })
+
+	var issues []MarkerIssue
+	report := func(offset int, format string, args ...any) {
+		line, column := lineColumn(content, offset)
+		issues = append(issues, MarkerIssue{Line: line, Column: column, Message: fmt.Sprintf(format, args...)})
+	}
+	lineOf := func(offset int) int {
+		line, _ := lineColumn(content, offset)
+		return line
+	}
+
+	// later reports whether a BEGIN marker of name follows offset
+	later := func(offset int, name string) bool {
+		return slices.ContainsFunc(markers, func(m marker) bool {
+			return m.begin && m.name == name && m.offset > offset
+		})
+	}
+
+	firstBegin := map[string]int{}
+	var open []marker
+	for _, m := range markers {
+		if m.begin {
+			if first, ok := firstBegin[m.name]; ok {
+				report(m.offset, "duplicate section %s (first BEGIN at line %d)", m.name, lineOf(first))
+			} else {
+				firstBegin[m.name] = m.offset
+			}
+			if len(open) > 0 {
+				outer := open[len(open)-1]
+				report(m.offset, "section %s begins inside section %s (BEGIN at line %d)", m.name, outer.name, lineOf(outer.offset))
+			}
+			open = append(open, m)
+			continue
+		}
+
+		i := slices.IndexFunc(open, func(o marker) bool { return o.name == m.name })
+		switch {
+		case i != -1:
+			open = slices.Delete(open, i, i+1)
+		case later(m.offset, m.name):
+			report(m.offset, "END SECTION %s before its BEGIN", m.name)
+		default:
+			report(m.offset, "orphaned END SECTION %s", m.name)
+		}
+	}
+
+	for _, m := range open {
+		report(m.offset, "no END SECTION for %s", m.name)
+	}
+	slices.SortStableFunc(issues, func(a, b MarkerIssue) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+
+	return issues
+}
@@ -0,0 +1,58 @@
+package gosect
+
+import (
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test marker hygiene checks
+// /////////////////////////////////////////////////////////////////////////////
+func TestCheckMarkers(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    []string
+	}{
+		{
+			name:    "clean",
+			content: "BEGIN SECTION a file=x\nEND SECTION a\nBEGIN SECTION b file=x\nEND SECTION b\n",
+		},
+		{
+			name:    "orphaned END",
+			content: "BEGIN SECTION a file=x\nEND SECTION a\n  END SECTION b\n",
+			want:    []string{"3:3: orphaned END SECTION b"},
+		},
+		{
+			name:    "END before BEGIN",
+			content: "END SECTION a\nBEGIN SECTION a file=x\nEND SECTION a\n",
+			want:    []string{"1:1: END SECTION a before its BEGIN"},
+		},
+		{
+			name:    "duplicate",
+			content: "BEGIN SECTION a file=x\nEND SECTION a\nBEGIN SECTION a file=y\nEND SECTION a\n",
+			want:    []string{"3:1: duplicate section a (first BEGIN at line 1)"},
+		},
+		{
+			name:    "overlap",
+			content: "BEGIN SECTION a file=x\nBEGIN SECTION b file=x\nEND SECTION a\nEND SECTION b\n",
+			want:    []string{"2:1: section b begins inside section a (BEGIN at line 1)"},
+		},
+		{
+			name:    "missing END",
+			content: "<!-- BEGIN SECTION a file=x -->\n",
+			want:    []string{"1:6: no END SECTION for a"},
+		},
+	}
+
+	// Run tests
+	for _, test := range tests {
+		var got []string
+		for _, issue := range CheckMarkers([]byte(test.content), reBegin, reEnd) {
+			got = append(got, issue.Error())
+		}
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%s: expected %q, got %q", test.name, test.want, got)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// markerIndent returns the leading whitespace of the line containing pos
+func markerIndent(content []byte, pos int) string {
+	lineStart := bytes.LastIndexByte(content[:pos], '\n') + 1
+	line := content[lineStart:pos]
+
+	return string(line[:len(line)-len(bytes.TrimLeft(line, " \t"))])
+}
+
+// sectionIndent returns the indentation to apply to the inserted content of
+// a section. The indent= attribute overrides the marker indentation:
+// a number of spaces, "tab", or "none"/"false" to disable re-indentation.
+func sectionIndent(s Section, marker string) (string, error) {
+	value, ok := s.Attrs["indent"]
+	if !ok {
+		return marker, nil
+	}
+
+	switch value {
+	case "none", "false":
+		return "", nil
+	case "true":
+		return marker, nil
+	case "tab":
+		return "\t", nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return "", fmt.Errorf("section %s has invalid indent=%s", s.Name, value)
+	}
+
+	return strings.Repeat(" ", n), nil
+}
+
+// writeIndented writes content to out, prefixing every non-blank line with
+// indent
+func writeIndented(out *bytes.Buffer, content []byte, indent string) {
+	if indent == "" {
+		out.Write(content)
+		return
+	}
+
+	for len(content) > 0 {
+		line := content
+		next := bytes.IndexByte(content, '\n')
+		if next != -1 {
+			line = content[:next+1]
+		}
+		content = content[len(line):]
+
+		if len(bytes.TrimSpace(line)) > 0 {
+			out.WriteString(indent)
+		}
+		out.Write(line)
+	}
+}
@@ -0,0 +1,86 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test marker indentation applied to inserted content
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceSectionsIndent(t *testing.T) {
+	tmpDir := t.TempDir()
+	sourceFile := filepath.Join(tmpDir, "source.yaml")
+	err := os.WriteFile(sourceFile, []byte("key: value\n\nother: 1\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		marker  string
+		attrs   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "Marker indentation",
+			marker: "    ",
+			want:   "    key: value\n\n    other: 1\n",
+		},
+		{
+			name:   "Explicit spaces",
+			marker: "  ",
+			attrs:  " indent=4",
+			want:   "    key: value\n\n    other: 1\n",
+		},
+		{
+			name:  "Tab indentation",
+			attrs: " indent=tab",
+			want:  "\tkey: value\n\n\tother: 1\n",
+		},
+		{
+			name:   "Indentation disabled",
+			marker: "  ",
+			attrs:  " indent=none",
+			want:   "key: value\n\nother: 1\n",
+		},
+		{
+			name:    "Invalid indent",
+			attrs:   " indent=abc",
+			wantErr: true,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "list:\n" +
+				tt.marker + "# BEGIN SECTION cfg file=" + sourceFile + tt.attrs + "\n" +
+				tt.marker + "old: true\n" +
+				tt.marker + "# END SECTION cfg\n"
+
+			sections, err := FindSections(content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := ReplaceSections(content, sections, false, reBegin, reEnd)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !strings.Contains(result, "\n"+tt.want+"\n"+tt.marker+"# END SECTION cfg") {
+				t.Errorf("Expected indented content %q, got:\n%s", tt.want, result)
+			}
+		})
+	}
+}
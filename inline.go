@@ -0,0 +1,67 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"slices"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// inline sections: BEGIN and END markers sharing a line
+// /////////////////////////////////////////////////////////////////////////////
+
+// inlineSection reports whether the BEGIN and END markers of s share a line,
+// as in Version <!-- BEGIN SECTION v file=VERSION -->1.2<!-- END SECTION v -->
+func inlineSection(content []byte, s Section) bool {
+	return bytes.IndexByte(content[s.StartIdx:s.EndIdx], '\n') == -1
+}
+
+// inlineSpan returns the span of the body of the inline section s, between
+// the comment holding its BEGIN marker and the comment holding its END marker
+func inlineSpan(content []byte, s Section) (int, int, error) {
+	lineStart := bytes.LastIndexByte(content[:s.StartIdx], '\n') + 1
+	attrsEnd := s.attrsEnd
+	if attrsEnd <= 0 {
+		attrsEnd = s.Pos.Begin.End.Offset
+	}
+
+	for _, lang := range slices.Sorted(maps.Keys(commentStyles)) {
+		style := commentStyles[lang]
+		if style.Suffix == "" || !bytes.Contains(content[lineStart:attrsEnd], []byte(style.Prefix)) {
+			continue
+		}
+		// the END marker match starts at the comment with some markers
+		between := content[attrsEnd:min(s.EndIdx+len(style.Prefix), len(content))]
+		start := bytes.Index(between, []byte(style.Suffix))
+		end := bytes.LastIndex(between, []byte(style.Prefix))
+		if start == -1 || end < start+len(style.Suffix) {
+			continue
+		}
+		return attrsEnd + start + len(style.Suffix), attrsEnd + end, nil
+	}
+
+	return 0, 0, fmt.Errorf("section %s: inline markers need comments closed on their line, such as <!-- -->", s.Name)
+}
+
+// renderInline renders the inline section s of content, returning its body
+// and the span it replaces. The content of inline sections must be a single
+// line, written without surrounding whitespace.
+func (opts Options) renderInline(content []byte, s Section, chain []string) ([]byte, int, int, error) {
+	start, end, err := inlineSpan(content, s)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	src, err := opts.sectionContent(s, content, chain)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	body := bytes.TrimSpace(src)
+	if n := bytes.Count(body, []byte("\n")); n > 0 {
+		return nil, 0, 0, fmt.Errorf("section %s: inline sections need a single line of content, got %d lines", s.Name, n+1)
+	}
+	opts.logger().Debug("inline section rendered", "section", s.Name, "source", sourceName(s), "bytes", len(body))
+
+	return body, start, end, nil
+}
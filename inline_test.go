@@ -0,0 +1,104 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test sections whose BEGIN and END markers share a line
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceInline(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"VERSION": "1.4.2\n",
+		"GO":      "1.25",
+		"NOTES":   "first\nsecond\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "Value mid-sentence",
+			content: "Install version <!-- BEGIN SECTION v file=VERSION -->0.9<!-- END SECTION v --> now.\n",
+			want:    "Install version <!-- BEGIN SECTION v file=VERSION -->1.4.2<!-- END SECTION v --> now.\n",
+		},
+		{
+			name:    "Empty body",
+			content: "Version <!-- BEGIN SECTION v file=VERSION --><!-- END SECTION v -->\n",
+			want:    "Version <!-- BEGIN SECTION v file=VERSION -->1.4.2<!-- END SECTION v -->\n",
+		},
+		{
+			name:    "Several sections on a line",
+			content: "gosect <!-- BEGIN SECTION v file=VERSION -->?<!-- END SECTION v --> needs Go <!-- BEGIN SECTION go file=GO -->?<!-- END SECTION go -->.\n",
+			want:    "gosect <!-- BEGIN SECTION v file=VERSION -->1.4.2<!-- END SECTION v --> needs Go <!-- BEGIN SECTION go file=GO -->1.25<!-- END SECTION go -->.\n",
+		},
+		{
+			name:    "Next to a block section",
+			content: "<!-- BEGIN SECTION notes file=NOTES -->\n<!-- END SECTION notes -->\n/* BEGIN SECTION v file=VERSION */0.9/* END SECTION v */\n",
+			want:    "<!-- BEGIN SECTION notes file=NOTES -->\n\nfirst\nsecond\n\n<!-- END SECTION notes -->\n/* BEGIN SECTION v file=VERSION */1.4.2/* END SECTION v */\n",
+		},
+		{
+			name:    "Frozen",
+			content: "Version <!-- BEGIN SECTION v file=VERSION frozen=true -->0.9<!-- END SECTION v -->\n",
+			want:    "Version <!-- BEGIN SECTION v file=VERSION frozen=true -->0.9<!-- END SECTION v -->\n",
+		},
+		{
+			name:    "Several lines of content",
+			content: "Notes: <!-- BEGIN SECTION notes file=NOTES --><!-- END SECTION notes -->\n",
+			wantErr: "1:13: section notes: inline sections need a single line of content, got 2 lines",
+		},
+		{
+			name:    "Line comments",
+			content: "# BEGIN SECTION v file=VERSION # END SECTION v\n",
+			wantErr: "1:3: section v: inline markers need comments closed on their line, such as <!-- -->",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := FindSections(tt.content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Replace([]byte(tt.content), sections, Options{BaseDir: tmpDir})
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	// Markers of the Markdown format match from the opening of the comment
+	content := "Version <!-- BEGIN SECTION v file=VERSION --><!-- END SECTION v -->\n"
+	b, e := MarkersFor("doc.md", DefaultBegin, DefaultEnd)
+	sections, err := FindSections(content, b, e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Replace([]byte(content), sections, Options{BaseDir: tmpDir, ReBegin: b, ReEnd: e})
+	if want := "Version <!-- BEGIN SECTION v file=VERSION -->1.4.2<!-- END SECTION v -->\n"; err != nil || string(got) != want {
+		t.Errorf("Expected %q, got %q (%v)", want, got, err)
+	}
+}
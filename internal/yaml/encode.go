@@ -0,0 +1,107 @@
+package yaml
+
+import (
+	"strconv"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// encoding
+// /////////////////////////////////////////////////////////////////////////////
+
+// Encode renders the node as block YAML, keeping the order of mapping keys.
+// Comments and the original quoting style are not preserved.
+func (n *Node) Encode() []byte {
+	var b strings.Builder
+	if n != nil {
+		if n.Kind == ScalarNode {
+			b.WriteString(encodeScalar(n, 0))
+			b.WriteByte('\n')
+		} else {
+			n.encodeBlock(&b, 0)
+		}
+	}
+
+	return []byte(b.String())
+}
+
+// encodeBlock writes a mapping or sequence node at indent
+func (n *Node) encodeBlock(b *strings.Builder, indent int) {
+	pad := strings.Repeat(" ", indent)
+	if n.Kind == MappingNode {
+		for i, key := range n.Keys {
+			b.WriteString(pad + encodeKey(key) + ":")
+			n.Values[i].encodeValue(b, indent+2)
+		}
+		return
+	}
+
+	for _, item := range n.Values {
+		b.WriteString(pad + "-")
+		if item.Kind == MappingNode && len(item.Keys) > 0 {
+			// the first key of a mapping item follows the dash
+			var nested strings.Builder
+			item.encodeBlock(&nested, indent+2)
+			b.WriteString(" " + strings.TrimPrefix(nested.String(), pad+"  "))
+			continue
+		}
+		item.encodeValue(b, indent+2)
+	}
+}
+
+// encodeValue writes the node following a mapping key or a sequence dash,
+// on the same line for scalars and empty collections
+func (n *Node) encodeValue(b *strings.Builder, indent int) {
+	switch {
+	case n.Kind == MappingNode && len(n.Keys) == 0:
+		b.WriteString(" {}\n")
+	case n.Kind == SequenceNode && len(n.Values) == 0:
+		b.WriteString(" []\n")
+	case n.Kind != ScalarNode:
+		b.WriteByte('\n')
+		n.encodeBlock(b, indent)
+	case n.Value == "" && !n.Quoted:
+		b.WriteByte('\n')
+	default:
+		b.WriteString(" " + encodeScalar(n, indent) + "\n")
+	}
+}
+
+// encodeKey returns a mapping key, quoted when it is not a plain scalar
+func encodeKey(key string) string {
+	return encodeScalar(&Node{Kind: ScalarNode, Value: key, Quoted: true}, 0)
+}
+
+// encodeScalar returns a scalar as a plain, double quoted or literal block
+// scalar at indent. Quoted strings which would read as another type, or
+// which hold YAML syntax, are quoted.
+func encodeScalar(n *Node, indent int) string {
+	value := n.Value
+	if strings.Contains(strings.TrimSuffix(value, "\n"), "\n") {
+		header := "|"
+		if !strings.HasSuffix(value, "\n") {
+			header = "|-"
+		}
+		pad := strings.Repeat(" ", indent)
+		lines := strings.Split(strings.TrimSuffix(value, "\n"), "\n")
+		for i, line := range lines {
+			if line != "" {
+				lines[i] = pad + line
+			}
+		}
+		return header + "\n" + strings.Join(lines, "\n")
+	}
+
+	plain := value != "" && value == strings.TrimSpace(value) &&
+		!strings.ContainsAny(value[:1], "-?:,[]{}#&*!|>'\"%@`") &&
+		!strings.Contains(value, ": ") && !strings.Contains(value, " #") &&
+		!strings.HasSuffix(value, ":") && !strings.ContainsAny(value, "\n\t")
+	if _, typed := resolve(value).(string); n.Quoted && !typed {
+		plain = false
+	}
+	if plain || (!n.Quoted && value != "") {
+		return value
+	}
+
+	return strconv.Quote(value)
+}
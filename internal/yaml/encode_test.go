@@ -0,0 +1,66 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test encoding nodes back to YAML
+// /////////////////////////////////////////////////////////////////////////////
+func TestEncode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{
+			name:  "Nested mappings keep their key order",
+			input: "name: api # comment\nspec:\n  replicas: 3\n  paused: false\n",
+			want:  "name: api\nspec:\n  replicas: 3\n  paused: false\n",
+		},
+		{
+			name:  "Sequences of mappings",
+			input: "containers:\n- name: api\n  ports: [80, 443]\n  env: []\n- name: sidecar\n",
+			want:  "containers:\n  - name: api\n    ports:\n      - 80\n      - 443\n    env: []\n  - name: sidecar\n",
+		},
+		{
+			name:  "Quoted scalars",
+			input: "version: \"1.0\"\nflag: 'true'\nmessage: \"a: b\"\nempty: \"\"\n\"key: x\": y\n",
+			want:  "version: \"1.0\"\nflag: \"true\"\nmessage: \"a: b\"\nempty: \"\"\n\"key: x\": y\n",
+		},
+		{
+			name:  "Block scalars",
+			input: "script: |\n  echo one\n  echo two\nnothing:\n",
+			want:  "script: |\n  echo one\n  echo two\nnothing:\n",
+		},
+		{
+			name:  "Scalar document",
+			input: "hello\n",
+			want:  "hello\n",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := docs[0].Encode()
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+
+			// the encoded document decodes to the same values
+			again, err := Parse(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(again[0].Decode(), docs[0].Decode()) {
+				t.Errorf("Expected %v after a round trip, got %v", docs[0].Decode(), again[0].Decode())
+			}
+		})
+	}
+}
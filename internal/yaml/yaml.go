@@ -0,0 +1,961 @@
+// Package yaml implements the subset of YAML used by gosect manifests and
+// source extractors: block mappings and sequences, flow collections, plain
+// and quoted scalars, block scalars and multi-document streams. Nodes keep
+// the line span they were parsed from so callers can extract the original
+// text of a fragment. Anchors are ignored, and the tags of the core schema
+// (!!str, !!int...) are honored. Constructs outside the subset fail rather
+// than returning wrong data: aliases (*name), merge keys (<<), other tags and
+// tab indentation. So do invalid documents: unknown escapes, duplicate keys
+// and nested mappings starting on the line of their key.
+package yaml
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Kind is the kind of a YAML node
+type Kind int
+
+// Node kinds
+const (
+	ScalarNode Kind = iota
+	MappingNode
+	SequenceNode
+)
+
+// Node is a parsed YAML node
+type Node struct {
+	Kind   Kind
+	Value  string   // scalar value, unquoted
+	Quoted bool     // scalar was quoted, so it is always a string
+	Keys   []string // mapping keys, in document order
+	Values []*Node  // mapping values or sequence items
+
+	Line, EndLine int // 1-based span of the node in the source
+}
+
+// line is a source line split into indentation and text
+type line struct {
+	num    int
+	indent int
+	text   string // text after the indentation
+}
+
+// blank reports whether the line holds no YAML content
+func (l line) blank() bool {
+	return l.text == "" || strings.HasPrefix(l.text, "#")
+}
+
+// indentError reports the content lines indented with tabs, which YAML
+// forbids
+func (l line) indentError() error {
+	if strings.HasPrefix(l.text, "\t") {
+		return fmt.Errorf("yaml: line %d: tabs are not allowed in indentation", l.num)
+	}
+
+	return nil
+}
+
+// parser parses the lines of a single document
+type parser struct {
+	lines []line
+	pos   int
+	last  int // number of the last content line consumed
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// public API
+// /////////////////////////////////////////////////////////////////////////////
+
+// Parse parses every document of a YAML stream. Documents holding no content
+// are skipped.
+func Parse(data []byte) ([]*Node, error) {
+	var docs []*Node
+	var current []line
+
+	flush := func() error {
+		p := &parser{lines: current}
+		if p.skip() == -1 {
+			current = nil
+			return nil
+		}
+		n, err := p.parseBlock(0)
+		if err != nil {
+			return err
+		}
+		if i := p.skip(); i != -1 {
+			return fmt.Errorf("yaml: line %d: unexpected content", p.lines[i].num)
+		}
+		docs = append(docs, n)
+		current = nil
+		return nil
+	}
+
+	text := strings.ReplaceAll(string(data), "\r\n", "\n")
+	text = strings.TrimPrefix(text, "\uFEFF")
+	for i, raw := range strings.Split(text, "\n") {
+		if raw == "---" || strings.HasPrefix(raw, "--- ") || raw == "..." {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if strings.HasPrefix(raw, "%") && len(current) == 0 {
+			continue // directive
+		}
+
+		trimmed := strings.TrimLeft(raw, " ")
+		current = append(current, line{
+			num:    i + 1,
+			indent: len(raw) - len(trimmed),
+			text:   strings.TrimRight(trimmed, " \t"),
+		})
+	}
+
+	if err := flush(); err != nil {
+		return nil, err
+	}
+
+	return docs, nil
+}
+
+// Unmarshal decodes the first document of data into v, following the
+// encoding/json rules for v
+func Unmarshal(data []byte, v any) error {
+	docs, err := Parse(data)
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return nil
+	}
+
+	return docs[0].DecodeInto(v)
+}
+
+// DecodeInto decodes the node into v, following the encoding/json rules for v
+func (n *Node) DecodeInto(v any) error {
+	b, err := json.Marshal(n.Decode())
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// Decode converts the node to plain Go values: map[string]any, []any,
+// string, int64, float64, bool or nil
+func (n *Node) Decode() any {
+	if n == nil {
+		return nil
+	}
+
+	switch n.Kind {
+	case MappingNode:
+		m := make(map[string]any, len(n.Keys))
+		for i, key := range n.Keys {
+			m[key] = n.Values[i].Decode()
+		}
+		return m
+	case SequenceNode:
+		items := make([]any, len(n.Values))
+		for i, item := range n.Values {
+			items[i] = item.Decode()
+		}
+		return items
+	}
+
+	if n.Quoted {
+		return n.Value
+	}
+
+	return resolve(n.Value)
+}
+
+// Get returns the value of key in a mapping node, or nil
+func (n *Node) Get(key string) *Node {
+	if n == nil || n.Kind != MappingNode {
+		return nil
+	}
+
+	for i, k := range n.Keys {
+		if k == key {
+			return n.Values[i]
+		}
+	}
+
+	return nil
+}
+
+// String returns the value of a scalar node, or "" for other nodes
+func (n *Node) String() string {
+	if n == nil || n.Kind != ScalarNode {
+		return ""
+	}
+
+	return n.Value
+}
+
+// resolve converts a plain scalar to its typed value
+func resolve(s string) any {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+	if strings.HasPrefix(s, "0x") || strings.HasPrefix(s, "0o") {
+		if i, err := strconv.ParseInt(s, 0, 64); err == nil {
+			return i
+		}
+	}
+	if f, err := strconv.ParseFloat(s, 64); err == nil && strings.ContainsAny(s, "0123456789") {
+		return f
+	}
+
+	return s
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// block parsing
+// /////////////////////////////////////////////////////////////////////////////
+
+// skip moves to the next content line and returns its index, or -1
+func (p *parser) skip() int {
+	for p.pos < len(p.lines) && p.lines[p.pos].blank() {
+		p.pos++
+	}
+	if p.pos == len(p.lines) {
+		return -1
+	}
+
+	return p.pos
+}
+
+// consume marks line i as read
+func (p *parser) consume(i int) {
+	p.pos = i + 1
+	if p.lines[i].num > p.last {
+		p.last = p.lines[i].num
+	}
+}
+
+// parseBlock parses the node starting at the next content line
+func (p *parser) parseBlock(minIndent int) (*Node, error) {
+	i := p.skip()
+	if i == -1 || p.lines[i].indent < minIndent {
+		return &Node{Kind: ScalarNode, Line: p.last, EndLine: p.last}, nil
+	}
+
+	l := p.lines[i]
+	if err := l.indentError(); err != nil {
+		return nil, err
+	}
+	if isSeqItem(l.text) {
+		return p.parseSequence(l.indent)
+	}
+	if _, _, ok := splitKey(l.text); ok {
+		return p.parseMapping(l.indent)
+	}
+
+	p.consume(i)
+	n, err := parseInline(p.quotedLines(l.text, l.num), l.num)
+	if err != nil {
+		return nil, err
+	}
+	if n.Kind == ScalarNode && !n.Quoted {
+		p.continuation(n, l.indent-1)
+	}
+	n.EndLine = max(n.EndLine, p.last)
+
+	return n, nil
+}
+
+// parseMapping parses a block mapping whose keys are at indent
+func (p *parser) parseMapping(indent int) (*Node, error) {
+	n := &Node{Kind: MappingNode, Line: p.lines[p.pos].num}
+
+	for {
+		i := p.skip()
+		if i == -1 {
+			break
+		}
+		l := p.lines[i]
+		if err := l.indentError(); err != nil {
+			return nil, err
+		}
+		if l.indent < indent {
+			break
+		}
+		if l.indent > indent {
+			return nil, fmt.Errorf("yaml: line %d: unexpected indentation", l.num)
+		}
+		if isSeqItem(l.text) {
+			break
+		}
+
+		key, rest, ok := splitKey(l.text)
+		if !ok {
+			return nil, fmt.Errorf("yaml: line %d: expected a mapping key", l.num)
+		}
+		if key == "<<" && !strings.HasPrefix(l.text, `"`) && !strings.HasPrefix(l.text, "'") {
+			return nil, fmt.Errorf("yaml: line %d: merge keys (<<) are not supported", l.num)
+		}
+		if slices.Contains(n.Keys, key) {
+			return nil, fmt.Errorf("yaml: line %d: duplicate key %q", l.num, key)
+		}
+		p.consume(i)
+
+		value, err := p.parseValue(rest, indent, l.num, true)
+		if err != nil {
+			return nil, err
+		}
+
+		n.Keys = append(n.Keys, key)
+		n.Values = append(n.Values, value)
+	}
+	n.EndLine = p.last
+
+	return n, nil
+}
+
+// parseSequence parses a block sequence whose items are at indent
+func (p *parser) parseSequence(indent int) (*Node, error) {
+	n := &Node{Kind: SequenceNode, Line: p.lines[p.pos].num}
+
+	for {
+		i := p.skip()
+		if i == -1 {
+			break
+		}
+		l := p.lines[i]
+		if err := l.indentError(); err != nil {
+			return nil, err
+		}
+		if l.indent != indent || !isSeqItem(l.text) {
+			if l.indent > indent {
+				return nil, fmt.Errorf("yaml: line %d: unexpected indentation", l.num)
+			}
+			break
+		}
+
+		rest := strings.TrimLeft(l.text[1:], " ")
+		var item *Node
+		var err error
+		if isSeqItem(rest) || isKeyLine(rest) {
+			// compact nested collection: re-read the item as an indented line
+			p.lines[i] = line{num: l.num, indent: indent + len(l.text) - len(rest), text: rest}
+			item, err = p.parseBlock(indent + 1)
+		} else {
+			p.consume(i)
+			item, err = p.parseValue(rest, indent, l.num, false)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		n.Values = append(n.Values, item)
+	}
+	n.EndLine = p.last
+
+	return n, nil
+}
+
+// parseValue parses the value following a mapping key or sequence dash
+// found on line num of a collection at indent
+func (p *parser) parseValue(rest string, indent, num int, inMapping bool) (*Node, error) {
+	rest, tag, err := properties(rest, num)
+	if err != nil {
+		return nil, err
+	}
+	n, err := p.parseUntagged(rest, indent, num, inMapping)
+	if err != nil {
+		return nil, err
+	}
+
+	return n, applyTag(n, tag, num)
+}
+
+// parseUntagged parses the value following a mapping key or sequence dash,
+// without its properties
+func (p *parser) parseUntagged(rest string, indent, num int, inMapping bool) (*Node, error) {
+	if isBlockScalarHeader(rest) {
+		return p.parseBlockScalar(rest, indent, num), nil
+	}
+
+	if stripComment(rest) == "" {
+		j := p.skip()
+		if j != -1 {
+			next := p.lines[j]
+			if next.indent > indent || (inMapping && next.indent == indent && isSeqItem(next.text)) {
+				return p.parseBlock(next.indent)
+			}
+		}
+		return &Node{Kind: ScalarNode, Line: num, EndLine: num}, nil
+	}
+
+	// flow collections may span several lines
+	if strings.HasPrefix(rest, "[") || strings.HasPrefix(rest, "{") {
+		text := rest
+		for !flowBalanced(text) {
+			j := p.skip()
+			if j == -1 {
+				return nil, fmt.Errorf("yaml: line %d: unterminated flow collection", num)
+			}
+			p.consume(j)
+			text += " " + p.lines[j].text
+		}
+		n, err := parseInline(text, num)
+		if err != nil {
+			return nil, err
+		}
+		n.EndLine = p.last
+		return n, nil
+	}
+
+	n, err := parseInline(p.quotedLines(rest, num), num)
+	if err != nil {
+		return nil, err
+	}
+	if n.Kind == ScalarNode && !n.Quoted {
+		p.continuation(n, indent)
+	}
+	n.EndLine = max(n.EndLine, p.last)
+
+	return n, nil
+}
+
+// quotedLines returns text, the start of a value found on line num, with the
+// next lines of the quoted scalar it opens, if any, joined by newlines
+func (p *parser) quotedLines(text string, num int) string {
+	rest, _, err := properties(text, num)
+	if err != nil || (!strings.HasPrefix(rest, `"`) && !strings.HasPrefix(rest, "'")) {
+		return text
+	}
+
+	for p.pos < len(p.lines) {
+		f := &flow{text: rest, num: num}
+		if _, err := f.parseQuoted(); !errors.Is(err, errUnterminated) {
+			break
+		}
+		next := p.lines[p.pos]
+		p.consume(p.pos)
+		text += "\n" + next.text
+		rest += "\n" + next.text
+	}
+
+	return text
+}
+
+// continuation appends the continuation lines of a multi-line plain scalar
+func (p *parser) continuation(n *Node, indent int) {
+	for {
+		j := p.skip()
+		if j == -1 {
+			return
+		}
+		next := p.lines[j]
+		if next.indent <= indent || isKeyLine(next.text) || isSeqItem(next.text) {
+			return
+		}
+		p.consume(j)
+		n.Value += " " + stripComment(next.text)
+		n.EndLine = next.num
+	}
+}
+
+// parseBlockScalar parses a literal (|) or folded (>) block scalar
+func (p *parser) parseBlockScalar(header string, indent, num int) *Node {
+	header = stripComment(header)
+	folded := header[0] == '>'
+	chomp := byte(0)
+	if strings.Contains(header, "-") {
+		chomp = '-'
+	} else if strings.Contains(header, "+") {
+		chomp = '+'
+	}
+
+	var lines []string
+	blockIndent := -1
+	end := num
+	for p.pos < len(p.lines) {
+		l := p.lines[p.pos]
+		if l.text == "" {
+			lines = append(lines, "")
+			p.pos++
+			continue
+		}
+		if l.indent <= indent {
+			break
+		}
+		if blockIndent == -1 {
+			blockIndent = l.indent
+		}
+		if l.indent < blockIndent {
+			break
+		}
+		lines = append(lines, strings.Repeat(" ", l.indent-blockIndent)+l.text)
+		end = l.num
+		p.pos++
+	}
+	if end > p.last {
+		p.last = end
+	}
+
+	// trailing blank lines only matter for the keep chomping indicator
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+
+	var value string
+	if folded {
+		var b strings.Builder
+		for i, l := range lines {
+			switch {
+			case i == 0:
+			case l == "" || lines[i-1] == "" || strings.HasPrefix(l, " "):
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(' ')
+			}
+			b.WriteString(l)
+		}
+		value = b.String()
+	} else {
+		value = strings.Join(lines, "\n")
+	}
+
+	switch {
+	case len(lines) == 0:
+	case chomp == '-':
+	case chomp == '+':
+		value += strings.Repeat("\n", trailing+1)
+	default:
+		value += "\n"
+	}
+
+	return &Node{Kind: ScalarNode, Value: value, Quoted: true, Line: num, EndLine: end}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// inline values
+// /////////////////////////////////////////////////////////////////////////////
+
+// parseInline parses a scalar or flow collection written on a single line
+func parseInline(text string, num int) (*Node, error) {
+	text, tag, err := properties(text, num)
+	if err != nil {
+		return nil, err
+	}
+	f := &flow{text: text, num: num}
+	n, err := f.parse()
+	if err != nil {
+		return nil, err
+	}
+
+	f.skipSpaces()
+	if f.pos < len(f.text) && !strings.HasPrefix(f.text[f.pos:], "#") {
+		// not a flow value: the whole text is a plain scalar
+		if n.Kind != ScalarNode || n.Quoted {
+			return nil, fmt.Errorf("yaml: line %d: unexpected %q", num, f.text[f.pos:])
+		}
+		value := stripComment(text)
+		if strings.Contains(value, ": ") || strings.HasSuffix(value, ":") {
+			return nil, fmt.Errorf("yaml: line %d: nested mappings cannot start on the line of their key", num)
+		}
+		n = &Node{Kind: ScalarNode, Value: value, Line: num, EndLine: num}
+	}
+
+	return n, applyTag(n, tag, num)
+}
+
+// flow parses flow collections and scalars
+type flow struct {
+	text string
+	pos  int
+	num  int
+}
+
+func (f *flow) skipSpaces() {
+	for f.pos < len(f.text) && (f.text[f.pos] == ' ' || f.text[f.pos] == '\t') {
+		f.pos++
+	}
+}
+
+func (f *flow) errorf(format string, args ...any) error {
+	return fmt.Errorf("yaml: line %d: "+format, append([]any{f.num}, args...)...)
+}
+
+// parse parses the value at the current position, with its properties
+func (f *flow) parse() (*Node, error) {
+	rest, tag, err := properties(f.text[f.pos:], f.num)
+	if err != nil {
+		return nil, err
+	}
+	f.pos = len(f.text) - len(rest)
+
+	n, err := f.parseNode()
+	if err != nil {
+		return nil, err
+	}
+
+	return n, applyTag(n, tag, f.num)
+}
+
+// parseNode parses the value at the current position
+func (f *flow) parseNode() (*Node, error) {
+	f.skipSpaces()
+	if f.pos == len(f.text) {
+		return &Node{Kind: ScalarNode, Line: f.num, EndLine: f.num}, nil
+	}
+
+	switch f.text[f.pos] {
+	case '[':
+		return f.parseSequence()
+	case '{':
+		return f.parseMapping()
+	case '"', '\'':
+		value, err := f.parseQuoted()
+		if err != nil {
+			return nil, err
+		}
+		return &Node{Kind: ScalarNode, Value: value, Quoted: true, Line: f.num, EndLine: f.num}, nil
+	}
+
+	start := f.pos
+	for f.pos < len(f.text) {
+		c := f.text[f.pos]
+		if c == ',' || c == ']' || c == '}' {
+			break
+		}
+		if c == ':' && (f.pos+1 == len(f.text) || f.text[f.pos+1] == ' ') {
+			break
+		}
+		if c == '#' && f.pos > start && f.text[f.pos-1] == ' ' {
+			break
+		}
+		f.pos++
+	}
+
+	return &Node{Kind: ScalarNode, Value: strings.TrimSpace(f.text[start:f.pos]), Line: f.num, EndLine: f.num}, nil
+}
+
+func (f *flow) parseSequence() (*Node, error) {
+	n := &Node{Kind: SequenceNode, Line: f.num, EndLine: f.num}
+	f.pos++ // [
+
+	for {
+		f.skipSpaces()
+		if f.pos == len(f.text) {
+			return nil, f.errorf("unterminated flow sequence")
+		}
+		if f.text[f.pos] == ']' {
+			f.pos++
+			return n, nil
+		}
+
+		item, err := f.parse()
+		if err != nil {
+			return nil, err
+		}
+		n.Values = append(n.Values, item)
+
+		f.skipSpaces()
+		if f.pos < len(f.text) && f.text[f.pos] == ',' {
+			f.pos++
+		}
+	}
+}
+
+func (f *flow) parseMapping() (*Node, error) {
+	n := &Node{Kind: MappingNode, Line: f.num, EndLine: f.num}
+	f.pos++ // {
+
+	for {
+		f.skipSpaces()
+		if f.pos == len(f.text) {
+			return nil, f.errorf("unterminated flow mapping")
+		}
+		if f.text[f.pos] == '}' {
+			f.pos++
+			return n, nil
+		}
+
+		key, err := f.parse()
+		if err != nil {
+			return nil, err
+		}
+		if slices.Contains(n.Keys, key.Value) {
+			return nil, f.errorf("duplicate key %q", key.Value)
+		}
+		f.skipSpaces()
+
+		value := &Node{Kind: ScalarNode, Line: f.num, EndLine: f.num}
+		if f.pos < len(f.text) && f.text[f.pos] == ':' {
+			f.pos++
+			if value, err = f.parse(); err != nil {
+				return nil, err
+			}
+		}
+		n.Keys = append(n.Keys, key.Value)
+		n.Values = append(n.Values, value)
+
+		f.skipSpaces()
+		if f.pos < len(f.text) && f.text[f.pos] == ',' {
+			f.pos++
+		}
+	}
+}
+
+// errUnterminated reports a quoted scalar without closing quote
+var errUnterminated = errors.New("unterminated quoted scalar")
+
+// escapes maps the escapes of double quoted scalars to their character
+var escapes = map[byte]string{
+	'0': "\x00", 'a': "\a", 'b': "\b", 't': "\t", '\t': "\t", 'n': "\n", 'v': "\v",
+	'f': "\f", 'r': "\r", 'e': "\x1b", ' ': " ", '"': `"`, '/': "/", '\\': `\`,
+	'N': "\u0085", '_': "\u00a0", 'L': "\u2028", 'P': "\u2029",
+}
+
+// hexEscapes maps the escapes of double quoted scalars followed by the code
+// point of a character to its number of hexadecimal digits
+var hexEscapes = map[byte]int{'x': 2, 'u': 4, 'U': 8}
+
+// parseQuoted parses a single or double quoted scalar. Line breaks inside
+// the scalar fold to a space, or to newlines when followed by empty lines.
+func (f *flow) parseQuoted() (string, error) {
+	quote := f.text[f.pos]
+	var b []byte
+	kept := 0 // end of the escaped characters, kept when folding lines
+
+	for i := f.pos + 1; i < len(f.text); i++ {
+		c := f.text[i]
+		switch {
+		case quote == '\'' && c == '\'':
+			if i+1 < len(f.text) && f.text[i+1] == '\'' {
+				b = append(b, '\'')
+				i++
+				continue
+			}
+			f.pos = i + 1
+			return string(b), nil
+		case quote == '"' && c == '"':
+			f.pos = i + 1
+			return string(b), nil
+		case c == '\n':
+			for len(b) > kept && (b[len(b)-1] == ' ' || b[len(b)-1] == '\t') {
+				b = b[:len(b)-1]
+			}
+			breaks := 0
+			for i+1 < len(f.text) && strings.IndexByte(" \t\n", f.text[i+1]) != -1 {
+				if f.text[i+1] == '\n' {
+					breaks++
+				}
+				i++
+			}
+			if breaks == 0 {
+				b = append(b, ' ')
+			}
+			b = append(b, strings.Repeat("\n", breaks)...)
+		case quote == '"' && c == '\\' && i+1 < len(f.text):
+			i++
+			e := f.text[i]
+			switch n, hex := hexEscapes[e]; {
+			case e == '\n':
+				// escaped line break: the lines join without space
+				for i+1 < len(f.text) && (f.text[i+1] == ' ' || f.text[i+1] == '\t') {
+					i++
+				}
+			case escapes[e] != "":
+				b = append(b, escapes[e]...)
+			case hex:
+				digits := f.text[i+1 : min(i+1+n, len(f.text))]
+				r, err := strconv.ParseUint(digits, 16, 32)
+				if len(digits) != n || err != nil || !utf8.ValidRune(rune(r)) {
+					return "", f.errorf("invalid escape \\%c%s in double quoted scalar", e, digits)
+				}
+				b = utf8.AppendRune(b, rune(r))
+				i += n
+			default:
+				return "", f.errorf("invalid escape \\%c in double quoted scalar", e)
+			}
+			kept = len(b)
+		default:
+			b = append(b, c)
+		}
+	}
+
+	return "", f.errorf("%w", errUnterminated)
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// helpers
+// /////////////////////////////////////////////////////////////////////////////
+
+// isSeqItem reports whether text starts a block sequence item
+func isSeqItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// isKeyLine reports whether text starts with a mapping key
+func isKeyLine(text string) bool {
+	_, _, ok := splitKey(text)
+	return ok
+}
+
+// splitKey splits a "key: value" line
+func splitKey(text string) (string, string, bool) {
+	if text == "" || strings.HasPrefix(text, "[") || strings.HasPrefix(text, "{") || strings.HasPrefix(text, "#") {
+		return "", "", false
+	}
+
+	// quoted key
+	if text[0] == '"' || text[0] == '\'' {
+		f := &flow{text: text}
+		key, err := f.parseQuoted()
+		if err != nil {
+			return "", "", false
+		}
+		rest := strings.TrimLeft(text[f.pos:], " ")
+		if rest == ":" || strings.HasPrefix(rest, ": ") {
+			return key, strings.TrimSpace(rest[1:]), true
+		}
+		return "", "", false
+	}
+
+	for i := 0; i < len(text); i++ {
+		if text[i] == '#' && i > 0 && text[i-1] == ' ' {
+			return "", "", false
+		}
+		if text[i] == ':' && (i+1 == len(text) || text[i+1] == ' ') {
+			return strings.TrimSpace(text[:i]), strings.TrimSpace(text[i+1:]), true
+		}
+	}
+
+	return "", "", false
+}
+
+// stripComment removes a trailing comment from a plain scalar
+func stripComment(text string) string {
+	if strings.HasPrefix(text, "#") {
+		return ""
+	}
+	if i := strings.Index(text, " #"); i != -1 {
+		text = text[:i]
+	}
+
+	return strings.TrimSpace(text)
+}
+
+// properties splits the leading anchor (&name) and tag (!tag) of text off,
+// returning the rest of text and the tag. Aliases (*name) are not supported.
+func properties(text string, num int) (string, string, error) {
+	tag := ""
+	for {
+		text = strings.TrimLeft(text, " \t")
+		switch {
+		case strings.HasPrefix(text, "*"):
+			return "", "", fmt.Errorf("yaml: line %d: aliases (%s) are not supported", num, propertyToken(text))
+		case strings.HasPrefix(text, "&"), strings.HasPrefix(text, "!"):
+			token := propertyToken(text)
+			if token[0] == '!' {
+				tag = token
+			}
+			text = text[len(token):]
+		default:
+			return text, tag, nil
+		}
+	}
+}
+
+// propertyToken returns the anchor, alias or tag starting text
+func propertyToken(text string) string {
+	if i := strings.IndexAny(text, " \t,]}"); i != -1 {
+		return text[:i]
+	}
+
+	return text
+}
+
+// coreTags are the tags of the YAML core schema, with the kind of their nodes
+var coreTags = map[string]Kind{
+	"!!str":   ScalarNode,
+	"!!int":   ScalarNode,
+	"!!float": ScalarNode,
+	"!!bool":  ScalarNode,
+	"!!null":  ScalarNode,
+	"!!map":   MappingNode,
+	"!!seq":   SequenceNode,
+}
+
+// applyTag applies the tag of node n found on line num: !!str and the
+// non-specific ! make scalars strings, the other core tags are resolved from
+// the value, and other tags are not supported
+func applyTag(n *Node, tag string, num int) error {
+	if tag == "" {
+		return nil
+	}
+	if tag == "!" {
+		tag = "!!str"
+	}
+
+	kind, ok := coreTags[tag]
+	if !ok {
+		return fmt.Errorf("yaml: line %d: tag %s is not supported", num, tag)
+	}
+	if kind != n.Kind {
+		return fmt.Errorf("yaml: line %d: tag %s does not match its value", num, tag)
+	}
+	if tag == "!!str" {
+		n.Quoted = true
+	}
+
+	return nil
+}
+
+// isBlockScalarHeader reports whether text is a | or > block scalar header
+func isBlockScalarHeader(text string) bool {
+	text = stripComment(text)
+	if text == "" || (text[0] != '|' && text[0] != '>') {
+		return false
+	}
+
+	return strings.Trim(text[1:], "+-0123456789") == ""
+}
+
+// flowBalanced reports whether every bracket opened in text is closed
+func flowBalanced(text string) bool {
+	depth := 0
+	var quote byte
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '[' || c == '{':
+			depth++
+		case c == ']' || c == '}':
+			depth--
+		}
+	}
+
+	return depth <= 0
+}
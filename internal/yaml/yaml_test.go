@@ -0,0 +1,222 @@
+package yaml
+
+import (
+	"reflect"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test decoding documents to Go values
+// /////////////////////////////////////////////////////////////////////////////
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  any
+	}{
+		{
+			name:  "Scalars",
+			input: "name: gosect\nport: 8080\nratio: 1.5\nenabled: true\nnothing: ~\nquoted: \"42\"\nsingle: 'it''s'\n",
+			want: map[string]any{
+				"name": "gosect", "port": int64(8080), "ratio": 1.5, "enabled": true,
+				"nothing": nil, "quoted": "42", "single": "it's",
+			},
+		},
+		{
+			name:  "Nested mappings and comments",
+			input: "# config\nserver:\n  host: localhost # inline\n  tls:\n    enabled: false\n",
+			want: map[string]any{
+				"server": map[string]any{"host": "localhost", "tls": map[string]any{"enabled": false}},
+			},
+		},
+		{
+			name:  "Sequences",
+			input: "items:\n  - a\n  - b\nsame:\n- c\n",
+			want:  map[string]any{"items": []any{"a", "b"}, "same": []any{"c"}},
+		},
+		{
+			name:  "Sequence of mappings",
+			input: "sections:\n  - name: intro\n    file: intro.md\n  - name: usage\n    file: usage.md\n",
+			want: map[string]any{"sections": []any{
+				map[string]any{"name": "intro", "file": "intro.md"},
+				map[string]any{"name": "usage", "file": "usage.md"},
+			}},
+		},
+		{
+			name:  "Flow collections",
+			input: "ports: [80, 443]\nlabels: {app: web, tier: \"front\"}\n",
+			want: map[string]any{
+				"ports":  []any{int64(80), int64(443)},
+				"labels": map[string]any{"app": "web", "tier": "front"},
+			},
+		},
+		{
+			name:  "Block scalars",
+			input: "literal: |\n  line 1\n  line 2\nfolded: >-\n  a\n  b\nafter: x\n",
+			want:  map[string]any{"literal": "line 1\nline 2\n", "folded": "a b", "after": "x"},
+		},
+		{
+			name:  "Plain values with colons",
+			input: "url: http://example.com:8080/path\ncmd: echo a, b\n",
+			want:  map[string]any{"url": "http://example.com:8080/path", "cmd": "echo a, b"},
+		},
+		{
+			name:  "Multi-line plain scalar",
+			input: "description: a long\n  description text\nnext: 1\n",
+			want:  map[string]any{"description": "a long description text", "next": int64(1)},
+		},
+		{
+			name:  "Double quoted escapes",
+			input: `text: "caf\u00e9 \x41\U0001F600 \"q\" \\ \t\/\_"` + "\n",
+			want:  map[string]any{"text": "café A\U0001F600 \"q\" \\ \t/\u00a0"},
+		},
+		{
+			name:  "Multi-line double quoted scalar",
+			input: "text: \"first\n  second\n\n  third \\\n  fourth\"\nnext: 1\n",
+			want:  map[string]any{"text": "first second\nthird fourth", "next": int64(1)},
+		},
+		{
+			name:  "Multi-line single quoted scalar",
+			input: "- 'it''s\n  # not a comment'\n",
+			want:  []any{"it's # not a comment"},
+		},
+		{
+			name:  "Tags and anchors",
+			input: "version: !!str 1.0\nport: !!int 80\nplain: ! true\nlist: !!seq [!!str 1, 2]\ndefaults: &defaults\n  a: 1\n",
+			want: map[string]any{
+				"version": "1.0", "port": int64(80), "plain": "true",
+				"list": []any{"1", int64(2)}, "defaults": map[string]any{"a": int64(1)},
+			},
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			docs, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(docs) != 1 {
+				t.Fatalf("Expected 1 document, got %d", len(docs))
+			}
+			if got := docs[0].Decode(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expected %#v, got %#v", tt.want, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test multi-document streams and line spans
+// /////////////////////////////////////////////////////////////////////////////
+func TestParseDocuments(t *testing.T) {
+	input := `---
+kind: Service
+metadata:
+  name: api
+---
+# only a comment
+---
+kind: Deployment
+spec:
+  replicas: 2
+`
+	docs, err := Parse([]byte(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("Expected 2 documents, got %d", len(docs))
+	}
+
+	if docs[1].Get("kind").String() != "Deployment" {
+		t.Errorf("Expected Deployment, got %q", docs[1].Get("kind").String())
+	}
+
+	meta := docs[0].Get("metadata")
+	if meta.Line != 4 || meta.EndLine != 4 {
+		t.Errorf("Expected metadata span 4-4, got %d-%d", meta.Line, meta.EndLine)
+	}
+
+	if docs[1].Line != 8 || docs[1].EndLine != 10 {
+		t.Errorf("Expected document span 8-10, got %d-%d", docs[1].Line, docs[1].EndLine)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test Unmarshal into structs
+// /////////////////////////////////////////////////////////////////////////////
+func TestUnmarshal(t *testing.T) {
+	var manifest struct {
+		Target   string `json:"target"`
+		Sections []struct {
+			Name string `json:"name"`
+			File string `json:"file"`
+		} `json:"sections"`
+	}
+
+	err := Unmarshal([]byte("target: README.md\nsections:\n  - name: intro\n    file: intro.md\n"), &manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if manifest.Target != "README.md" || len(manifest.Sections) != 1 || manifest.Sections[0].File != "intro.md" {
+		t.Errorf("Unexpected manifest: %+v", manifest)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test parse errors
+// /////////////////////////////////////////////////////////////////////////////
+func TestParseErrors(t *testing.T) {
+	inputs := []string{
+		"a: 1\n    b: 2\n",
+		"list: [1, 2\n",
+		"name: \"unterminated\n",
+		"a: &x 1\nb: *x\n",
+		"list:\n  - *x\n",
+		"flow: [1, *x]\n",
+		"base:\n  <<: {a: 1}\n",
+		"date: !!timestamp 2024-01-01\n",
+		"custom: !Ref name\n",
+		"port: !!map 80\n",
+		"a:\n\tb: 1\n",
+		"list:\n\t- a\n",
+	}
+
+	for _, input := range inputs {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Errorf("Expected error for %q", input)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test invalid documents are reported
+// /////////////////////////////////////////////////////////////////////////////
+func TestParseInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "Unknown escape", input: `a: "\q"`, wantErr: `yaml: line 1: invalid escape \q in double quoted scalar`},
+		{name: "Short hex escape", input: `a: "\u00e"`, wantErr: `yaml: line 1: invalid escape \u00e" in double quoted scalar`},
+		{name: "Invalid code point", input: `a: "\UFFFFFFFF"`, wantErr: `yaml: line 1: invalid escape \UFFFFFFFF in double quoted scalar`},
+		{name: "Duplicate key", input: "a: 1\nb: 2\na: 3\n", wantErr: `yaml: line 3: duplicate key "a"`},
+		{name: "Duplicate flow key", input: "m: {a: 1, a: 2}\n", wantErr: `yaml: line 1: duplicate key "a"`},
+		{name: "Nested mapping on the key line", input: "a: b: c\n", wantErr: "yaml: line 1: nested mappings cannot start on the line of their key"},
+		{name: "Unterminated multi-line scalar", input: "a: \"b\n  c\n", wantErr: "yaml: line 1: unterminated quoted scalar"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			if err == nil || err.Error() != tt.wantErr {
+				t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
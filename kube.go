@@ -0,0 +1,139 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/badele/gosect/internal/yaml"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// kind=, name= and query= attributes: objects of YAML manifests
+// /////////////////////////////////////////////////////////////////////////////
+
+// reQueryStep matches a step of a query= path: .key, ["key"], [index] or
+// [key=value], selecting the first sequence item whose key has value
+var reQueryStep = regexp.MustCompile(`^(?:\.([^.\[\]]+)|\["([^"]*)"\]|\[(\d+)\]|\[([^=\]]+)=([^\]]*)\])`)
+
+// objectSelected reports whether s selects an object of a YAML source
+func objectSelected(s Section) bool {
+	for _, attr := range []string{"kind", "name", "namespace", "query"} {
+		if _, ok := s.Attrs[attr]; ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selectObject returns the document of the multi-document YAML src whose
+// kind and metadata match the kind=, name= and namespace= attributes of s,
+// or the fragment of it selected by the query= path, such as
+// .spec.template.spec.containers[0]. Whole documents keep their comments.
+func selectObject(s Section, src []byte) ([]byte, error) {
+	if !objectSelected(s) {
+		return src, nil
+	}
+
+	docs, err := yaml.Parse(src)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %s: %w", s.Name, sourceName(s), err)
+	}
+
+	var matches []*yaml.Node
+	for _, doc := range docs {
+		metadata := doc.Get("metadata")
+		if value, ok := s.Attrs["kind"]; ok && doc.Get("kind").String() != value {
+			continue
+		}
+		if value, ok := s.Attrs["name"]; ok && metadata.Get("name").String() != value {
+			continue
+		}
+		if value, ok := s.Attrs["namespace"]; ok && metadata.Get("namespace").String() != value {
+			continue
+		}
+		matches = append(matches, doc)
+	}
+	switch {
+	case len(matches) == 0:
+		return nil, fmt.Errorf("section %s: no matching object in %s", s.Name, sourceName(s))
+	case len(matches) > 1:
+		return nil, fmt.Errorf("section %s: %d objects match in %s (use kind=, name= or namespace=)", s.Name, len(matches), sourceName(s))
+	}
+	doc := matches[0]
+
+	query := strings.TrimPrefix(s.Attrs["query"], ".")
+	if query == "" {
+		return documentText(src, doc), nil
+	}
+
+	node, err := queryNode(doc, "."+query)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: query=%s: %w", s.Name, s.Attrs["query"], err)
+	}
+
+	return node.Encode(), nil
+}
+
+// queryNode follows the steps of query from node
+func queryNode(node *yaml.Node, query string) (*yaml.Node, error) {
+	for query != "" {
+		m := reQueryStep.FindStringSubmatch(query)
+		if m == nil {
+			return nil, fmt.Errorf("invalid step %s", query)
+		}
+		step := m[0]
+		query = query[len(m[0]):]
+
+		switch {
+		case m[1] != "" || strings.HasPrefix(step, `["`):
+			node = node.Get(m[1] + m[2])
+		case m[3] != "":
+			index, _ := strconv.Atoi(m[3])
+			if node == nil || node.Kind != yaml.SequenceNode || index >= len(node.Values) {
+				return nil, fmt.Errorf("no item %s", step)
+			}
+			node = node.Values[index]
+		default:
+			var found *yaml.Node
+			if node != nil && node.Kind == yaml.SequenceNode {
+				for _, item := range node.Values {
+					if item.Get(m[4]).String() == m[5] {
+						found = item
+						break
+					}
+				}
+			}
+			node = found
+		}
+		if node == nil {
+			return nil, fmt.Errorf("no value at %s", step)
+		}
+	}
+
+	return node, nil
+}
+
+// documentText returns the lines of doc in src, with the comments above it
+func documentText(src []byte, doc *yaml.Node) []byte {
+	start, end := 0, len(src)
+	line := 1
+	for i, c := range src {
+		if c != '\n' {
+			continue
+		}
+		line++
+		if line == doc.Line {
+			start = i + 1
+		}
+		if line == doc.EndLine+1 {
+			end = i + 1
+			break
+		}
+	}
+
+	return append(bytes.TrimRight(src[commentStart(src, start, "#"):end], " \t\r\n"), '\n')
+}
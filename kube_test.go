@@ -0,0 +1,119 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// deployManifest is the multi-document manifest of the kind= and query= tests
+const deployManifest = `# API deployment
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: prod
+spec:
+  template:
+    spec:
+      containers:
+        - name: api
+          image: example/api:1.2
+          ports: [8080]
+        - name: proxy
+          image: envoy
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+  labels:
+    app.kubernetes.io/name: api
+spec:
+  ports:
+    - port: 80
+`
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test selecting objects and fragments of YAML manifests
+// /////////////////////////////////////////////////////////////////////////////
+func TestSelectObject(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "deploy.yaml"), []byte(deployManifest), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		attrs   string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "Whole object with its comments",
+			attrs: "kind=Deployment name=api",
+			want:  "# API deployment\napiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: api\n  namespace: prod\nspec:\n  template:\n    spec:\n      containers:\n        - name: api\n          image: example/api:1.2\n          ports: [8080]\n        - name: proxy\n          image: envoy\n",
+		},
+		{
+			name:  "Sequence item",
+			attrs: "kind=Deployment name=api query=.spec.template.spec.containers[0]",
+			want:  "name: api\nimage: example/api:1.2\nports:\n  - 8080\n",
+		},
+		{
+			name:  "Item selected by key",
+			attrs: "kind=Deployment query=.spec.template.spec.containers[name=proxy].image",
+			want:  "envoy\n",
+		},
+		{
+			name:  "Quoted key",
+			attrs: `kind=Service query='.metadata.labels["app.kubernetes.io/name"]'`,
+			want:  "api\n",
+		},
+		{
+			name:  "Namespace",
+			attrs: "namespace=prod query=.metadata",
+			want:  "name: api\nnamespace: prod\n",
+		},
+		{
+			name:    "Ambiguous objects",
+			attrs:   "name=api",
+			wantErr: "2 objects match in deploy.yaml",
+		},
+		{
+			name:    "No object",
+			attrs:   "kind=ConfigMap",
+			wantErr: "no matching object in deploy.yaml",
+		},
+		{
+			name:    "Missing value",
+			attrs:   "kind=Service query=.spec.ports[3]",
+			wantErr: "query=.spec.ports[3]: no item [3]",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "<!-- BEGIN SECTION s file=deploy.yaml " + tt.attrs + " -->\n<!-- END SECTION s -->\n"
+			sections, err := FindSections(content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Replace([]byte(content), sections, Options{BaseDir: tmpDir})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "<!-- BEGIN SECTION s file=deploy.yaml " + tt.attrs + " -->\n\n" + tt.want + "\n<!-- END SECTION s -->\n"
+			if string(got) != want {
+				t.Errorf("Expected %q, got %q", want, got)
+			}
+		})
+	}
+}
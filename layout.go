@@ -0,0 +1,78 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// bodySlot is the slot filled by the content of a source outside its define
+// blocks
+const bodySlot = "body"
+
+// renderLayout renders the layout= attribute of s, a text/template shared by
+// many sections whose {{ slot "name" }} calls are filled by the source src:
+// each {{ define "name" }} block of the source fills the slot of that name,
+// and the rest of the source fills the "body" slot unless defined.
+// {{ hasSlot "name" }} reports whether the source fills a slot.
+func (opts Options) renderLayout(s Section, layout string, src []byte) ([]byte, error) {
+	path, err := opts.SourcePath(Section{Name: s.Name, SrcFile: layout})
+	if err != nil {
+		return nil, err
+	}
+	skeleton, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: layout: %w", s.Name, err)
+	}
+
+	data := opts.templateData(s)
+
+	fills, err := template.New(sourceName(s)).Option("missingkey=error").Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %w", s.Name, err)
+	}
+
+	// lookup returns the template filling a slot, or nil
+	lookup := func(name string) *template.Template {
+		if fill := fills.Lookup(name); fill != nil && fill.Tree != nil {
+			return fill
+		}
+		if name == bodySlot && strings.TrimSpace(fills.Tree.Root.String()) != "" {
+			return fills
+		}
+		return nil
+	}
+
+	slot := func(name string) (string, error) {
+		fill := lookup(name)
+		if fill == nil {
+			return "", fmt.Errorf("slot %q is not filled by %s", name, sourceName(s))
+		}
+
+		var out bytes.Buffer
+		if err := fill.Execute(&out, data); err != nil {
+			return "", err
+		}
+		return strings.TrimSpace(out.String()), nil
+	}
+	hasSlot := func(name string) bool {
+		return lookup(name) != nil
+	}
+
+	tmpl, err := template.New(layout).Option("missingkey=error").Funcs(template.FuncMap{
+		"slot":    slot,
+		"hasSlot": hasSlot,
+	}).Parse(string(skeleton))
+	if err != nil {
+		return nil, fmt.Errorf("section %s: layout: %w", s.Name, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("section %s: layout: %w", s.Name, err)
+	}
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,60 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test layout snippets filled by sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestLayout(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"page.md":    "# {{ slot \"title\" }}\n\n{{ slot \"body\" }}\n{{ if hasSlot \"footer\" }}\n{{ slot \"footer\" }}\n{{ end }}---\nSection {{ .Section }}\n",
+		"install.md": "{{ define \"title\" }}Install{{ end }}\nRun `make install`.\n",
+		"usage.md":   "{{ define \"title\" }}Usage{{ end }}{{ define \"body\" }}Run `gosect`.{{ end }}{{ define \"footer\" }}See also: install{{ end }}",
+		"broken.md":  "Body without title\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		source  string
+		want    string
+		wantErr string
+	}{
+		{"install.md", "# Install\n\nRun `make install`.\n---\nSection s", ""},
+		{"usage.md", "# Usage\n\nRun `gosect`.\n\nSee also: install\n---\nSection s", ""},
+		{"broken.md", "", `slot "title" is not filled by broken.md`},
+	}
+
+	// Run tests
+	for _, test := range tests {
+		content := "<!-- BEGIN SECTION s file=" + test.source + " layout=page.md -->\n<!-- END SECTION s -->\n"
+		sections, err := FindSections(content, reBegin, reEnd)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := Replace([]byte(content), sections, Options{BaseDir: tmpDir})
+		if test.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), test.wantErr) {
+				t.Errorf("%s: expected error containing %q, got %v", test.source, test.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", test.source, err)
+			continue
+		}
+		if !strings.Contains(string(result), "\n"+test.want+"\n") {
+			t.Errorf("%s: expected %q, got %q", test.source, test.want, result)
+		}
+	}
+}
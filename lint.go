@@ -0,0 +1,150 @@
+package gosect
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+)
+
+// LintSeverity is the severity of the issues reported by a lint rule
+type LintSeverity string
+
+// Lint rule severities. Errors fail gosect validate, warnings are only
+// reported and off disables a rule.
+const (
+	SeverityError   LintSeverity = "error"
+	SeverityWarning LintSeverity = "warning"
+	SeverityOff     LintSeverity = "off"
+)
+
+// LintRule configures a lint rule. Max is used by maxSections and Attrs by
+// requiredAttrs. The severity defaults to error.
+type LintRule struct {
+	Severity LintSeverity `json:"severity"`
+	Max      int          `json:"max"`
+	Attrs    []string     `json:"attrs"`
+}
+
+// configured reports whether the rule is set
+func (r LintRule) configured() bool {
+	return r.Severity != "" || r.Max > 0 || len(r.Attrs) > 0
+}
+
+// severity returns the severity of the rule, or off when it is not set
+func (r LintRule) severity() LintSeverity {
+	switch {
+	case !r.configured():
+		return SeverityOff
+	case r.Severity == "":
+		return SeverityError
+	default:
+		return r.Severity
+	}
+}
+
+// LintRules are the marker policies checked by gosect validate, beyond the
+// marker pairing problems reported by CheckMarkers
+type LintRules struct {
+	MaxSections   LintRule `json:"maxSections"`   // at most Max sections per file
+	RequiredAttrs LintRule `json:"requiredAttrs"` // every section sets Attrs
+	AbsolutePaths LintRule `json:"absolutePaths"` // file= sources are relative
+}
+
+// validate checks the severities and values of the rules
+func (rules LintRules) validate() error {
+	for name, r := range map[string]LintRule{
+		"maxSections":   rules.MaxSections,
+		"requiredAttrs": rules.RequiredAttrs,
+		"absolutePaths": rules.AbsolutePaths,
+	} {
+		switch r.Severity {
+		case "", SeverityError, SeverityWarning, SeverityOff:
+		default:
+			return fmt.Errorf("lint rule %s has unknown severity %s (expected error, warning or off)", name, r.Severity)
+		}
+	}
+	if rules.MaxSections.severity() != SeverityOff && rules.MaxSections.Max < 1 {
+		return errors.New("lint rule maxSections requires max")
+	}
+	if rules.RequiredAttrs.severity() != SeverityOff && len(rules.RequiredAttrs.Attrs) == 0 {
+		return errors.New("lint rule requiredAttrs requires attrs")
+	}
+
+	return nil
+}
+
+// merge overrides the rules of rules set in other
+func (rules *LintRules) merge(other LintRules) {
+	for _, r := range []struct {
+		dst *LintRule
+		src LintRule
+	}{
+		{&rules.MaxSections, other.MaxSections},
+		{&rules.RequiredAttrs, other.RequiredAttrs},
+		{&rules.AbsolutePaths, other.AbsolutePaths},
+	} {
+		if r.src.configured() {
+			*r.dst = r.src
+		}
+	}
+}
+
+// LintIssue is a problem reported by a lint rule
+type LintIssue struct {
+	MarkerIssue
+	Rule     string
+	Severity LintSeverity
+}
+
+// Error formats the issue as line:column: severity: message (rule)
+func (i LintIssue) Error() string {
+	return fmt.Sprintf("%d:%d: %s: %s (%s)", i.Line, i.Column, i.Severity, i.Message, i.Rule)
+}
+
+// reDrivePath matches Windows absolute paths, checked on every platform
+var reDrivePath = regexp.MustCompile(`^(?:[A-Za-z]:)?[\\/]`)
+
+// Lint checks the sections found by FindSections against rules, locating
+// issues with the positions of their markers
+func Lint(sections []Section, rules LintRules) []LintIssue {
+	var issues []LintIssue
+	report := func(rule string, severity LintSeverity, at Position, format string, args ...any) {
+		issues = append(issues, LintIssue{
+			MarkerIssue: MarkerIssue{Line: at.Line, Column: at.Column, Message: fmt.Sprintf(format, args...)},
+			Rule:        rule,
+			Severity:    severity,
+		})
+	}
+
+	if severity := rules.MaxSections.severity(); severity != SeverityOff && len(sections) > rules.MaxSections.Max {
+		s := sections[rules.MaxSections.Max]
+		report("maxSections", severity, s.Pos.Begin.Start, "%d sections, more than the maximum of %d", len(sections), rules.MaxSections.Max)
+	}
+
+	for _, s := range sections {
+		if severity := rules.RequiredAttrs.severity(); severity != SeverityOff {
+			for _, key := range rules.RequiredAttrs.Attrs {
+				if _, ok := s.Attrs[key]; !ok {
+					report("requiredAttrs", severity, s.Pos.Begin.Start, "section %s has no %s= attribute", s.Name, key)
+				}
+			}
+		}
+
+		if severity := rules.AbsolutePaths.severity(); severity != SeverityOff && s.SrcFile != "" {
+			if filepath.IsAbs(s.SrcFile) || reDrivePath.MatchString(s.SrcFile) {
+				report("absolutePaths", severity, s.Pos.Attrs["file"].Value.Start, "section %s has absolute file=%s", s.Name, s.SrcFile)
+			}
+		}
+	}
+
+	slices.SortStableFunc(issues, func(a, b LintIssue) int {
+		if a.Line != b.Line {
+			return a.Line - b.Line
+		}
+		return a.Column - b.Column
+	})
+
+	return issues
+}
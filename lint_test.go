@@ -0,0 +1,73 @@
+package gosect
+
+import (
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test lint rules
+// /////////////////////////////////////////////////////////////////////////////
+func TestLint(t *testing.T) {
+	content := "BEGIN SECTION a file=/etc/hosts owner=docs\nEND SECTION a\n" +
+		"BEGIN SECTION b file=C:\\notes.txt\nEND SECTION b\n" +
+		"BEGIN SECTION c file=c.txt lang=go owner=docs\nEND SECTION c\n"
+
+	tests := []struct {
+		name  string
+		rules LintRules
+		want  []string
+	}{
+		{
+			name: "no rule",
+		},
+		{
+			name:  "max sections",
+			rules: LintRules{MaxSections: LintRule{Max: 2, Severity: SeverityWarning}},
+			want:  []string{"5:1: warning: 3 sections, more than the maximum of 2 (maxSections)"},
+		},
+		{
+			name:  "required attributes",
+			rules: LintRules{RequiredAttrs: LintRule{Attrs: []string{"owner"}}},
+			want:  []string{"3:1: error: section b has no owner= attribute (requiredAttrs)"},
+		},
+		{
+			name:  "absolute paths",
+			rules: LintRules{AbsolutePaths: LintRule{Severity: SeverityError}},
+			want: []string{
+				"1:22: error: section a has absolute file=/etc/hosts (absolutePaths)",
+				"3:22: error: section b has absolute file=C:\\notes.txt (absolutePaths)",
+			},
+		},
+		{
+			name:  "rule off",
+			rules: LintRules{RequiredAttrs: LintRule{Attrs: []string{"lang"}, Severity: SeverityOff}},
+		},
+	}
+
+	sections, err := FindSectionsBytes([]byte(content), reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Run tests
+	for _, test := range tests {
+		var got []string
+		for _, issue := range Lint(sections, test.rules) {
+			got = append(got, issue.Error())
+		}
+		if strings.Join(got, "\n") != strings.Join(test.want, "\n") {
+			t.Errorf("%s: expected %q, got %q", test.name, test.want, got)
+		}
+	}
+
+	for _, rules := range []LintRules{
+		{MaxSections: LintRule{Severity: "fatal", Max: 1}},
+		{MaxSections: LintRule{Severity: SeverityWarning}},
+		{RequiredAttrs: LintRule{Severity: SeverityError}},
+	} {
+		if err := rules.validate(); err == nil {
+			t.Errorf("Expected an error for rules %+v", rules)
+		}
+	}
+}
@@ -0,0 +1,83 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// makefile= sources: targets and their help comments
+// /////////////////////////////////////////////////////////////////////////////
+
+// reMakeTarget matches a rule of a Makefile, capturing its targets and the
+// ## help comment following its prerequisites
+var reMakeTarget = regexp.MustCompile(`^([^\s:#=][^:#=]*?)[ \t]*::?(?:[^:=]|$)(?:[^#]*?##[ \t]*(.*))?`)
+
+// makeGroup is the list of documented targets following a ##@ heading
+type makeGroup struct {
+	title string
+	rows  [][]string
+}
+
+// extractMakefile renders the documented targets of the makefile= source of
+// s as a Markdown table. Targets are documented by a ## comment following
+// their prerequisites or on the line above them, and grouped by ##@ headings;
+// the group= attribute of s only renders one group.
+func extractMakefile(s Section, data []byte) ([]byte, error) {
+	groups := []makeGroup{{}}
+	help := ""
+	for line := range bytes.Lines(data) {
+		text := strings.TrimRight(string(line), " \t\r\n")
+		if title, ok := strings.CutPrefix(text, "##@"); ok {
+			groups = append(groups, makeGroup{title: strings.TrimSpace(title)})
+			help = ""
+			continue
+		}
+		if comment, ok := strings.CutPrefix(text, "##"); ok {
+			help = strings.TrimSpace(comment)
+			continue
+		}
+
+		m := reMakeTarget.FindStringSubmatch(text)
+		if m == nil || strings.HasPrefix(text, "\t") {
+			help = ""
+			continue
+		}
+		if m[2] != "" {
+			help = strings.TrimSpace(m[2])
+		}
+		for _, target := range strings.Fields(m[1]) {
+			// special targets such as .PHONY are not documented
+			if help != "" && !strings.HasPrefix(target, ".") {
+				group := &groups[len(groups)-1]
+				group.rows = append(group.rows, []string{tableCell("`" + target + "`"), tableCell(help)})
+			}
+		}
+		help = ""
+	}
+
+	name, filtered := s.Attrs["group"]
+	var out bytes.Buffer
+	for _, group := range groups {
+		if len(group.rows) == 0 || filtered && group.title != name {
+			continue
+		}
+		if out.Len() > 0 {
+			out.WriteString("\n")
+		}
+		if group.title != "" && !filtered {
+			fmt.Fprintf(&out, "**%s**\n\n", group.title)
+		}
+		out.Write(formatTable([]string{"Target", "Description"}, group.rows))
+	}
+	if out.Len() == 0 {
+		if filtered {
+			return nil, fmt.Errorf("section %s: no documented target in group %s of %s", s.Name, name, s.Attrs["makefile"])
+		}
+		return nil, fmt.Errorf("section %s: no documented target in %s", s.Name, s.Attrs["makefile"])
+	}
+
+	return out.Bytes(), nil
+}
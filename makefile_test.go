@@ -0,0 +1,108 @@
+package gosect
+
+import (
+	"strings"
+	"testing"
+)
+
+// makefile is the Makefile of the makefile= tests
+const makefile = `.PHONY: build test lint
+VERSION := 1.0
+FLAGS ::= -v
+
+##@ Development
+
+build: deps ## Build the binary
+	go build ./...
+
+## Run the tests
+test:
+	go test ./...
+
+lint fmt: ## Check | format the code
+
+##@ Release
+
+release: build ## Publish a release
+	goreleaser release
+
+clean:
+	rm -rf dist
+`
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test rendering the documented targets of makefile= sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestExtractMakefile(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		attrs   map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "Grouped targets",
+			data:  makefile,
+			attrs: map[string]string{"makefile": "Makefile"},
+			want: "**Development**\n\n" +
+				"| Target  | Description              |\n" +
+				"| ------- | ------------------------ |\n" +
+				"| `build` | Build the binary         |\n" +
+				"| `test`  | Run the tests            |\n" +
+				"| `lint`  | Check \\| format the code |\n" +
+				"| `fmt`   | Check \\| format the code |\n" +
+				"\n**Release**\n\n" +
+				"| Target    | Description       |\n" +
+				"| --------- | ----------------- |\n" +
+				"| `release` | Publish a release |\n",
+		},
+		{
+			name:  "Single group",
+			data:  makefile,
+			attrs: map[string]string{"makefile": "Makefile", "group": "Release"},
+			want: "| Target    | Description       |\n" +
+				"| --------- | ----------------- |\n" +
+				"| `release` | Publish a release |\n",
+		},
+		{
+			name:  "Ungrouped targets",
+			data:  "all: ## Build everything\n\techo\n",
+			attrs: map[string]string{"makefile": "Makefile"},
+			want: "| Target | Description      |\n" +
+				"| ------ | ---------------- |\n" +
+				"| `all`  | Build everything |\n",
+		},
+		{
+			name:    "Unknown group",
+			data:    makefile,
+			attrs:   map[string]string{"makefile": "Makefile", "group": "Docs"},
+			wantErr: "no documented target in group Docs of Makefile",
+		},
+		{
+			name:    "Undocumented targets",
+			data:    "all:\n\techo\n",
+			attrs:   map[string]string{"makefile": "Makefile"},
+			wantErr: "no documented target in Makefile",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := extractMakefile(Section{Name: "targets", Attrs: tt.attrs}, []byte(tt.data))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
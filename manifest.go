@@ -0,0 +1,153 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/badele/gosect/internal/yaml"
+)
+
+// Default marker layout of assembled documents
+const (
+	DefaultManifestBegin  = "<!-- " + DefaultBegin
+	DefaultManifestEnd    = "<!-- " + DefaultEnd
+	DefaultManifestSuffix = " -->"
+)
+
+// reSectionName matches valid section names
+var reSectionName = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Manifest describes a document generated from an ordered list of sections
+type Manifest struct {
+	Target   string          `json:"target"`   // generated document path
+	Begin    string          `json:"begin"`    // BEGIN marker prefix
+	End      string          `json:"end"`      // END marker prefix
+	Suffix   string          `json:"suffix"`   // text closing marker lines
+	Sections []ManifestEntry `json:"sections"` // document content, in order
+}
+
+// ManifestEntry is a section of a manifest, or static text when Text is set
+type ManifestEntry struct {
+	Name  string         `json:"name"`
+	File  string         `json:"file"`
+	Attrs map[string]any `json:"attrs"`
+	Text  string         `json:"text"`
+}
+
+// LoadManifest reads a YAML (or JSON) manifest
+func LoadManifest(path string) (*Manifest, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manifest{}
+	if err := yaml.Unmarshal(b, m); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if m.Begin == "" && m.End == "" && m.Suffix == "" {
+		m.Begin, m.End, m.Suffix = DefaultManifestBegin, DefaultManifestEnd, DefaultManifestSuffix
+	}
+	if m.Begin == "" {
+		m.Begin = DefaultBegin
+	}
+	if m.End == "" {
+		m.End = DefaultEnd
+	}
+
+	return m, nil
+}
+
+// Markers returns the BEGIN and END regexes matching the manifest markers
+func (m *Manifest) Markers() (*regexp.Regexp, *regexp.Regexp) {
+	return MakeRegex(m.Begin, m.End)
+}
+
+// Skeleton returns the document with empty sections, markers included
+func (m *Manifest) Skeleton() ([]byte, error) {
+	var out bytes.Buffer
+
+	for i, entry := range m.Sections {
+		if i > 0 {
+			out.WriteByte('\n')
+		}
+
+		if entry.Name == "" {
+			out.WriteString(strings.TrimRight(entry.Text, "\n") + "\n")
+			continue
+		}
+
+		if !reSectionName.MatchString(entry.Name) {
+			return nil, fmt.Errorf("invalid section name %q", entry.Name)
+		}
+		if entry.File == "" {
+			return nil, fmt.Errorf("section %s has no file", entry.Name)
+		}
+
+		attrs, err := entry.attrs()
+		if err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(&out, "%s %s file=%s%s%s\n", m.Begin, entry.Name, quoteAttr(entry.File), attrs, m.Suffix)
+		fmt.Fprintf(&out, "%s %s%s\n", m.End, entry.Name, m.Suffix)
+	}
+
+	return out.Bytes(), nil
+}
+
+// reAttrKey matches valid attribute keys
+var reAttrKey = regexp.MustCompile(`^` + attrKeyPattern + `$`)
+
+// attrs returns the extra marker attributes of the entry, sorted by key.
+// Values must be strings, numbers or booleans.
+func (entry ManifestEntry) attrs() (string, error) {
+	keys := make([]string, 0, len(entry.Attrs))
+	for key := range entry.Attrs {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, key := range keys {
+		if !reAttrKey.MatchString(key) {
+			return "", fmt.Errorf("section %s: invalid attribute name %q", entry.Name, key)
+		}
+
+		var value string
+		switch v := entry.Attrs[key].(type) {
+		case string:
+			value = v
+		case bool:
+			value = strconv.FormatBool(v)
+		case float64:
+			value = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return "", fmt.Errorf("section %s: attribute %s must be a string, a number or a boolean", entry.Name, key)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, quoteAttr(value))
+	}
+
+	return b.String(), nil
+}
+
+// Assemble generates the whole document described by the manifest
+func Assemble(m *Manifest, opts Options) ([]byte, error) {
+	skeleton, err := m.Skeleton()
+	if err != nil {
+		return nil, err
+	}
+
+	opts.ReBegin, opts.ReEnd = m.Markers()
+	sections, err := FindSectionsBytes(skeleton, opts.ReBegin, opts.ReEnd)
+	if err != nil {
+		return nil, err
+	}
+
+	return Replace(skeleton, sections, opts)
+}
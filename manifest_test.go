@@ -0,0 +1,120 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test manifest-driven document assembly
+// /////////////////////////////////////////////////////////////////////////////
+func TestAssemble(t *testing.T) {
+	tmpDir := t.TempDir()
+	intro := filepath.Join(tmpDir, "intro.md")
+	usage := filepath.Join(tmpDir, "usage.sh")
+	manifestFile := filepath.Join(tmpDir, "manifest.yaml")
+
+	err := os.WriteFile(intro, []byte("# My Project\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(usage, []byte("my-project --help\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(manifestFile, []byte(`target: README.md
+sections:
+  - name: intro
+    file: `+intro+`
+  - text: "## Usage"
+  - name: usage
+    file: `+usage+`
+    attrs:
+      fence: true
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, err := LoadManifest(manifestFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Target != "README.md" || len(m.Sections) != 3 {
+		t.Fatalf("Unexpected manifest: %+v", m)
+	}
+
+	result, err := Assemble(m, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "<!-- BEGIN SECTION intro file=" + intro + " -->\n\n# My Project\n\n<!-- END SECTION intro -->\n\n" +
+		"## Usage\n\n" +
+		"<!-- BEGIN SECTION usage file=" + usage + " fence=true -->\n\n```bash\nmy-project --help\n```\n\n<!-- END SECTION usage -->\n"
+	if string(result) != want {
+		t.Errorf("Unexpected document:\n%s\nwant:\n%s", result, want)
+	}
+
+	// the assembled document can be updated in place
+	sections, err := FindSectionsBytes(result, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := Replace(result, sections, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(again) != string(result) {
+		t.Errorf("Expected assembled document to be stable, got:\n%s", again)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test manifest attributes rendering
+// /////////////////////////////////////////////////////////////////////////////
+func TestManifestSkeletonAttrs(t *testing.T) {
+	m := &Manifest{Begin: DefaultBegin, End: DefaultEnd, Sections: []ManifestEntry{{
+		Name:  "a",
+		File:  "my docs/a.md",
+		Attrs: map[string]any{"fence": true, "width": 1e6, "title": "Getting started"},
+	}}}
+
+	got, err := m.Skeleton()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "BEGIN SECTION a file=\"my docs/a.md\" fence=true title=\"Getting started\" width=1000000\nEND SECTION a\n"
+	if string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test manifest validation
+// /////////////////////////////////////////////////////////////////////////////
+func TestManifestSkeletonErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		entry   ManifestEntry
+		wantErr string
+	}{
+		{name: "Invalid name", entry: ManifestEntry{Name: "bad name", File: "a.md"}, wantErr: "invalid section name"},
+		{name: "Missing file", entry: ManifestEntry{Name: "a"}, wantErr: "has no file"},
+		{name: "Nested attribute", entry: ManifestEntry{Name: "a", File: "a.md", Attrs: map[string]any{"lines": []any{1.0, 2.0}}}, wantErr: "attribute lines must be a string, a number or a boolean"},
+		{name: "Invalid attribute name", entry: ManifestEntry{Name: "a", File: "a.md", Attrs: map[string]any{"bad key": "x"}}, wantErr: `invalid attribute name "bad key"`},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manifest{Begin: DefaultBegin, End: DefaultEnd, Sections: []ManifestEntry{tt.entry}}
+			_, err := m.Skeleton()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package gosect
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// DefaultSnapshotDir is the directory where the CLI records generated bodies
+// for three-way merges
+const DefaultSnapshotDir = ".gosect/snapshots"
+
+// hunk replaces the base lines start to end (exclusive) with lines
+type hunk struct {
+	start, end int
+	lines      []string
+}
+
+// splitBody splits body into lines, keeping their line endings
+func splitBody(body string) []string {
+	lines := strings.SplitAfter(body, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// lineHunks returns the hunks turning the lines of base into the lines of
+// other, computed from their longest common subsequence
+func lineHunks(base, other []string) []hunk {
+	lcs := make([][]int, len(base)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(other)+1)
+	}
+	for i := len(base) - 1; i >= 0; i-- {
+		for j := len(other) - 1; j >= 0; j-- {
+			if base[i] == other[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var hunks []hunk
+	var h *hunk
+	i, j := 0, 0
+	for i < len(base) || j < len(other) {
+		if i < len(base) && j < len(other) && base[i] == other[j] {
+			h = nil
+			i++
+			j++
+			continue
+		}
+
+		if h == nil {
+			hunks = append(hunks, hunk{start: i, end: i})
+			h = &hunks[len(hunks)-1]
+		}
+		if i < len(base) && (j == len(other) || lcs[i+1][j] >= lcs[i][j+1]) {
+			i++
+			h.end = i
+		} else {
+			h.lines = append(h.lines, other[j])
+			j++
+		}
+	}
+
+	return hunks
+}
+
+// applyHunks returns the base lines start to end with hunks applied
+func applyHunks(base []string, start, end int, hunks []hunk) []string {
+	var out []string
+	pos := start
+	for _, h := range hunks {
+		out = append(out, base[pos:h.start]...)
+		out = append(out, h.lines...)
+		pos = h.end
+	}
+
+	return append(out, base[pos:end]...)
+}
+
+// errMergeConflict reports changes of both sides of a merge to the same lines
+var errMergeConflict = errors.New("conflicting changes")
+
+// merge3 merges the changes made to base by ours and by theirs. Changes of
+// both sides to the same or adjacent lines conflict, unless they are equal.
+func merge3(base, ours, theirs string) (string, error) {
+	baseLines := splitBody(base)
+	a := lineHunks(baseLines, splitBody(ours))
+	b := lineHunks(baseLines, splitBody(theirs))
+
+	var out []string
+	pos := 0
+	for len(a) > 0 || len(b) > 0 {
+		// gather the overlapping hunks of both sides, from the first one
+		start := len(baseLines)
+		if len(a) > 0 {
+			start = a[0].start
+		}
+		if len(b) > 0 {
+			start = min(start, b[0].start)
+		}
+		end := start
+		var ha, hb []hunk
+		for {
+			if len(a) > 0 && a[0].start <= end {
+				end = max(end, a[0].end)
+				ha, a = append(ha, a[0]), a[1:]
+				continue
+			}
+			if len(b) > 0 && b[0].start <= end {
+				end = max(end, b[0].end)
+				hb, b = append(hb, b[0]), b[1:]
+				continue
+			}
+			break
+		}
+
+		out = append(out, baseLines[pos:start]...)
+		oursRegion := applyHunks(baseLines, start, end, ha)
+		theirsRegion := applyHunks(baseLines, start, end, hb)
+		switch {
+		case len(hb) == 0:
+			out = append(out, oursRegion...)
+		case len(ha) == 0, slices.Equal(oursRegion, theirsRegion):
+			out = append(out, theirsRegion...)
+		default:
+			return "", errMergeConflict
+		}
+		pos = end
+	}
+	out = append(out, baseLines[pos:]...)
+
+	return strings.Join(out, ""), nil
+}
+
+// loadSnapshot returns the body recorded in the snapshot directory with the
+// checksum sum
+func (opts Options) loadSnapshot(sum string) ([]byte, bool, error) {
+	data, err := os.ReadFile(filepath.Join(opts.SnapshotDir, sum))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+
+	return data, err == nil, err
+}
+
+// saveSnapshot records body in the snapshot directory, named after its
+// checksum. Snapshots are renamed into place, as concurrent updates may
+// record the same body.
+func (opts Options) saveSnapshot(body []byte) error {
+	path := filepath.Join(opts.SnapshotDir, BodyChecksum(body))
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(opts.SnapshotDir, 0755); err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, body)
+}
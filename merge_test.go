@@ -0,0 +1,100 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test three-way merge of section bodies
+// /////////////////////////////////////////////////////////////////////////////
+func TestMerge3(t *testing.T) {
+	base := "a\nb\nc\nd\ne\n"
+
+	tests := []struct {
+		name         string
+		ours, theirs string
+		want         string
+		wantConflict bool
+	}{
+		{name: "Unchanged", ours: base, theirs: base, want: base},
+		{name: "Ours only", ours: "a\nB\nc\nd\ne\n", theirs: base, want: "a\nB\nc\nd\ne\n"},
+		{name: "Theirs only", ours: base, theirs: "a\nb\nc\nd\nE\n", want: "a\nb\nc\nd\nE\n"},
+		{name: "Separate changes", ours: "A\nb\nc\nd\ne\n", theirs: "a\nb\nc\nd\nE\n", want: "A\nb\nc\nd\nE\n"},
+		{name: "Insertion and deletion", ours: "a\nb\nnote\nc\nd\ne\n", theirs: "a\nb\nc\ne\n", want: "a\nb\nnote\nc\ne\n"},
+		{name: "Same change", ours: "a\nX\nc\nd\ne\n", theirs: "a\nX\nc\nd\ne\n", want: "a\nX\nc\nd\ne\n"},
+		{name: "Conflict", ours: "a\nX\nc\nd\ne\n", theirs: "a\nY\nc\nd\ne\n", wantConflict: true},
+		{name: "Adjacent changes", ours: "a\nX\nc\nd\ne\n", theirs: "a\nb\nY\nd\ne\n", wantConflict: true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := merge3(base, tt.ours, tt.theirs)
+			if (err != nil) != tt.wantConflict {
+				t.Fatalf("Expected conflict %v, got %v", tt.wantConflict, err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test hand edits merged with source changes from snapshots
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceMergeEdits(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "source.txt")
+	if err := os.WriteFile(source, []byte("one\ntwo\nthree\nfour\nfive\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	opts := Options{Checksum: true, SnapshotDir: filepath.Join(tmpDir, "snapshots")}
+
+	content := "<!-- BEGIN SECTION gen file=" + source + " -->\n<!-- END SECTION gen -->\n"
+	generated := replaceAll(t, content, opts)
+
+	// edit the first line by hand and the last line in the source
+	edited := strings.Replace(generated, "\none\n", "\nONE\n", 1)
+	if err := os.WriteFile(source, []byte("one\ntwo\nthree\nfour\nFIVE\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	merged := replaceAll(t, edited, opts)
+	if !strings.Contains(merged, "\nONE\ntwo\nthree\nfour\nFIVE\n") {
+		t.Errorf("Expected hand edit and source change to be merged, got %q", merged)
+	}
+
+	// a merged document stays stable
+	if again := replaceAll(t, merged, opts); again != merged {
+		t.Errorf("Expected a stable result, got %q", again)
+	}
+
+	// read-only runs merge from the snapshots without recording new ones
+	snapshots, _ := os.ReadDir(opts.SnapshotDir)
+	readOnly := opts
+	readOnly.ReadOnlySnapshots = true
+	if err := os.WriteFile(source, []byte("one\ntwo\nthree\nfour\nFIVE\nsix\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := replaceAll(t, merged, readOnly); !strings.Contains(got, "\nONE\ntwo\nthree\nfour\nFIVE\nsix\n") {
+		t.Errorf("Expected a read-only run to merge, got %q", got)
+	}
+	if after, _ := os.ReadDir(opts.SnapshotDir); len(after) != len(snapshots) {
+		t.Errorf("Expected %d snapshots after a read-only run, got %d", len(snapshots), len(after))
+	}
+
+	// conflicting changes fail
+	if err := os.WriteFile(source, []byte("uno\ntwo\nthree\nfour\nFIVE\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sections, err := FindSections(merged, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Replace([]byte(merged), sections, opts); err == nil || !strings.Contains(err.Error(), "conflicts") {
+		t.Errorf("Expected conflict error, got %v", err)
+	}
+}
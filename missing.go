@@ -0,0 +1,64 @@
+package gosect
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MissingPolicy is the handling of sections whose file= source is missing
+type MissingPolicy string
+
+// Missing source policies
+const (
+	MissingError MissingPolicy = "error" // fail (default)
+	MissingWarn  MissingPolicy = "warn"  // keep the current body and log a warning
+	MissingSkip  MissingPolicy = "skip"  // keep the current body silently
+)
+
+// ParseMissingPolicy parses a missing source policy: error, warn or skip
+func ParseMissingPolicy(value string) (MissingPolicy, error) {
+	switch policy := MissingPolicy(value); policy {
+	case MissingError, MissingWarn, MissingSkip:
+		return policy, nil
+	}
+
+	return "", fmt.Errorf("invalid missing source policy %q: expected error, warn or skip", value)
+}
+
+// missingSourceError is the failure to find the file= source of a section,
+// rather than a file the source refers to
+type missingSourceError struct {
+	err error
+}
+
+// Error returns the message of the error
+func (e missingSourceError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e missingSourceError) Unwrap() error {
+	return e.err
+}
+
+// tolerateMissing reports whether the failure err of section s is its
+// missing source that Options.OnMissing allows to leave the section
+// unchanged. Other missing files, such as layouts or the sources of included
+// documents, still fail.
+func (opts Options) tolerateMissing(s Section, err error) bool {
+	var missing missingSourceError
+	var include *IncludeError
+	if !errors.As(err, &missing) || errors.As(err, &include) {
+		return false
+	}
+
+	switch opts.OnMissing {
+	case MissingWarn:
+		opts.logger().Warn("missing source, keeping the current body", "section", s.Name, "source", sourceName(s), "error", err)
+		return true
+	case MissingSkip:
+		return true
+	}
+
+	return false
+}
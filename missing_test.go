@@ -0,0 +1,70 @@
+package gosect
+
+import (
+	"bytes"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test missing source policies
+// /////////////////////////////////////////////////////////////////////////////
+func TestOnMissing(t *testing.T) {
+	content := "<!-- BEGIN SECTION opt file=missing.txt -->\nold body\n<!-- END SECTION opt -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		policy  string
+		wantErr bool
+		wantLog bool
+	}{
+		{"error", true, false},
+		{"warn", false, true},
+		{"skip", false, false},
+	}
+
+	// Run tests
+	for _, test := range tests {
+		policy, err := ParseMissingPolicy(test.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var logs bytes.Buffer
+		opts := Options{OnMissing: policy, Logger: slog.New(slog.NewTextHandler(&logs, nil))}
+		result, err := Replace([]byte(content), sections, opts)
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: expected error %v, got %v", test.policy, test.wantErr, err)
+		}
+		if !test.wantErr && string(result) != content {
+			t.Errorf("%s: expected body to be kept, got %q", test.policy, result)
+		}
+		if got := strings.Contains(logs.String(), "missing source"); got != test.wantLog {
+			t.Errorf("%s: expected warning %v, got %q", test.policy, test.wantLog, logs.String())
+		}
+	}
+
+	// other missing files fail, whatever the policy
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "source.txt"), []byte("body\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content = "<!-- BEGIN SECTION opt file=source.txt layout=missing.tmpl -->\n<!-- END SECTION opt -->\n"
+	sections, err = FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Replace([]byte(content), sections, Options{BaseDir: tmpDir, OnMissing: MissingSkip}); err == nil || !strings.Contains(err.Error(), "layout") {
+		t.Errorf("Expected a missing layout to fail, got %v", err)
+	}
+
+	if _, err := ParseMissingPolicy("ignore"); err == nil {
+		t.Error("Expected error for invalid policy")
+	}
+}
@@ -0,0 +1,18 @@
+//go:build !unix
+
+package gosect
+
+import (
+	"errors"
+	"os"
+)
+
+// mmapFile is not supported on this platform, sources are always read
+func mmapFile(f *os.File, size int64) ([]byte, error) {
+	return nil, errors.ErrUnsupported
+}
+
+// munmap is a no-op on this platform
+func munmap(data []byte) error {
+	return nil
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package gosect
+
+import (
+	"os"
+	"syscall"
+)
+
+// mmapFile maps size bytes of f read-only into memory
+func mmapFile(f *os.File, size int64) ([]byte, error) {
+	return syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+}
+
+// munmap releases a mapping created by mmapFile
+func munmap(data []byte) error {
+	return syscall.Munmap(data)
+}
@@ -0,0 +1,305 @@
+package gosect
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/badele/gosect/internal/yaml"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// openapi= sources: operations of an OpenAPI document
+// /////////////////////////////////////////////////////////////////////////////
+
+// parseOpenAPI parses a JSON or YAML OpenAPI document
+func parseOpenAPI(data []byte) (map[string]any, error) {
+	var doc any
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		if err := json.Unmarshal(data, &doc); err != nil {
+			return nil, err
+		}
+	} else {
+		docs, err := yaml.Parse(data)
+		if err != nil {
+			return nil, err
+		}
+		if len(docs) > 0 {
+			doc = docs[0].Decode()
+		}
+	}
+
+	spec, ok := doc.(map[string]any)
+	if !ok {
+		return nil, errors.New("not an OpenAPI document")
+	}
+
+	return spec, nil
+}
+
+// openAPIRef follows the $ref of the object v to spec, and returns the object
+// and the name of the last reference followed
+func openAPIRef(spec map[string]any, v any) (map[string]any, string) {
+	name := ""
+	for range DefaultMaxDepth {
+		obj, _ := v.(map[string]any)
+		ref, ok := obj["$ref"].(string)
+		if !ok {
+			return obj, name
+		}
+
+		pointer, ok := strings.CutPrefix(ref, "#/")
+		if !ok {
+			return nil, name
+		}
+		v = spec
+		for _, key := range strings.Split(pointer, "/") {
+			parent, _ := v.(map[string]any)
+			v = parent[strings.NewReplacer("~1", "/", "~0", "~").Replace(key)]
+		}
+		name = path.Base(ref)
+	}
+
+	return nil, name
+}
+
+// openAPIString returns the string value of key in obj, or ""
+func openAPIString(obj map[string]any, key string) string {
+	value, _ := obj[key].(string)
+	return value
+}
+
+// extractOpenAPI renders the part= of the operation of the openapi= source
+// of s selected by its path= and method= attributes: summary (the default),
+// schema or example
+func extractOpenAPI(s Section, data []byte) ([]byte, error) {
+	spec, err := parseOpenAPI(data)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %s: %w", s.Name, s.Attrs["openapi"], err)
+	}
+
+	route, ok := s.Attrs["path"]
+	if !ok {
+		return nil, fmt.Errorf("section %s has openapi= but no path= attribute", s.Name)
+	}
+	paths, _ := spec["paths"].(map[string]any)
+	item, _ := openAPIRef(spec, paths[route])
+	if item == nil {
+		return nil, fmt.Errorf("section %s: no path %s in %s", s.Name, route, s.Attrs["openapi"])
+	}
+	method := strings.ToLower(cmp.Or(s.Attrs["method"], "get"))
+	op, _ := item[method].(map[string]any)
+	if op == nil {
+		return nil, fmt.Errorf("section %s: no %s %s operation in %s", s.Name, strings.ToUpper(method), route, s.Attrs["openapi"])
+	}
+
+	switch part := cmp.Or(s.Attrs["part"], "summary"); part {
+	case "summary":
+		return openAPISummary(spec, item, op, method, route), nil
+	case "schema", "example":
+		media, err := openAPIMedia(s, spec, op)
+		if err != nil {
+			return nil, err
+		}
+		if part == "schema" {
+			return openAPISchema(spec, media["schema"]), nil
+		}
+		return openAPIExample(s, spec, media)
+	default:
+		return nil, fmt.Errorf("section %s has unknown part=%s (expected summary, schema or example)", s.Name, part)
+	}
+}
+
+// openAPISummary renders the summary, description, parameters and responses
+// of an operation
+func openAPISummary(spec, item, op map[string]any, method, route string) []byte {
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "**%s %s**", strings.ToUpper(method), route)
+	if summary := openAPIString(op, "summary"); summary != "" {
+		out.WriteString(": " + strings.TrimSpace(summary))
+	}
+	out.WriteString("\n")
+	if description := openAPIString(op, "description"); description != "" {
+		out.WriteString("\n" + strings.TrimSpace(description) + "\n")
+	}
+
+	// operation parameters override the path parameters of the same name
+	var params []map[string]any
+	seen := map[string]bool{}
+	for _, list := range []any{op["parameters"], item["parameters"]} {
+		items, _ := list.([]any)
+		for _, v := range items {
+			param, _ := openAPIRef(spec, v)
+			key := openAPIString(param, "in") + ":" + openAPIString(param, "name")
+			if param == nil || seen[key] {
+				continue
+			}
+			seen[key] = true
+			params = append(params, param)
+		}
+	}
+	if len(params) > 0 {
+		var rows [][]string
+		for _, param := range params {
+			required := ""
+			if param["required"] == true {
+				required = "yes"
+			}
+			rows = append(rows, []string{
+				tableCell(openAPIString(param, "name")),
+				tableCell(openAPIString(param, "in")),
+				tableCell(openAPIType(spec, param["schema"])),
+				required,
+				tableCell(openAPIString(param, "description")),
+			})
+		}
+		out.WriteString("\n")
+		out.Write(formatTable([]string{"Parameter", "In", "Type", "Required", "Description"}, rows))
+	}
+
+	if responses, _ := op["responses"].(map[string]any); len(responses) > 0 {
+		var rows [][]string
+		for _, status := range slices.Sorted(maps.Keys(responses)) {
+			response, _ := openAPIRef(spec, responses[status])
+			rows = append(rows, []string{tableCell(status), tableCell(openAPIString(response, "description"))})
+		}
+		out.WriteString("\n")
+		out.Write(formatTable([]string{"Status", "Description"}, rows))
+	}
+
+	return out.Bytes()
+}
+
+// openAPIMedia returns the JSON (or first) media type of the response
+// selected by the status= attribute of s; without status=, of the request
+// body of op, else of its first successful response
+func openAPIMedia(s Section, spec, op map[string]any) (map[string]any, error) {
+	responses, _ := op["responses"].(map[string]any)
+	var target any
+	if status, ok := s.Attrs["status"]; ok {
+		if target = responses[status]; target == nil {
+			return nil, fmt.Errorf("section %s: no %s response", s.Name, status)
+		}
+	} else if target = op["requestBody"]; target == nil {
+		for _, status := range slices.Sorted(maps.Keys(responses)) {
+			if strings.HasPrefix(status, "2") {
+				target = responses[status]
+				break
+			}
+		}
+	}
+
+	obj, _ := openAPIRef(spec, target)
+	content, _ := obj["content"].(map[string]any)
+	if len(content) == 0 {
+		return nil, fmt.Errorf("section %s: no content to render", s.Name)
+	}
+	media, ok := content["application/json"]
+	if !ok {
+		media = content[slices.Min(slices.Collect(maps.Keys(content)))]
+	}
+	m, _ := media.(map[string]any)
+
+	return m, nil
+}
+
+// openAPIType describes the type of a schema: the name of its reference, or
+// its type and format
+func openAPIType(spec map[string]any, v any) string {
+	schema, name := openAPIRef(spec, v)
+	if name != "" {
+		return name
+	}
+
+	typ, _ := schema["type"].(string)
+	switch format, _ := schema["format"].(string); {
+	case typ == "array":
+		return "[]" + openAPIType(spec, schema["items"])
+	case typ == "" && schema["properties"] != nil:
+		return "object"
+	case format != "":
+		return typ + " (" + format + ")"
+	default:
+		return typ
+	}
+}
+
+// openAPISchema renders the properties of a schema, or of the items of an
+// array schema, as a Markdown table sorted by name
+func openAPISchema(spec map[string]any, v any) []byte {
+	schema, name := openAPIRef(spec, v)
+	title := name
+	if schema["type"] == "array" {
+		title = "[]" + openAPIType(spec, schema["items"])
+		schema, _ = openAPIRef(spec, schema["items"])
+	}
+
+	properties, _ := schema["properties"].(map[string]any)
+	if len(properties) == 0 {
+		return []byte(cmp.Or(title, openAPIType(spec, v)) + "\n")
+	}
+
+	required := map[string]bool{}
+	list, _ := schema["required"].([]any)
+	for _, key := range list {
+		required[fmt.Sprint(key)] = true
+	}
+
+	var rows [][]string
+	for _, key := range slices.Sorted(maps.Keys(properties)) {
+		property, _ := openAPIRef(spec, properties[key])
+		mark := ""
+		if required[key] {
+			mark = "yes"
+		}
+		rows = append(rows, []string{
+			tableCell(key),
+			tableCell(openAPIType(spec, properties[key])),
+			mark,
+			tableCell(openAPIString(property, "description")),
+		})
+	}
+
+	var out bytes.Buffer
+	if title != "" {
+		fmt.Fprintf(&out, "**%s**\n\n", title)
+	}
+	out.Write(formatTable([]string{"Property", "Type", "Required", "Description"}, rows))
+
+	return out.Bytes()
+}
+
+// openAPIExample renders the example of a media type as indented JSON: its
+// example, the examples entry named by the example= attribute of s (the
+// first by default), or the example of its schema
+func openAPIExample(s Section, spec, media map[string]any) ([]byte, error) {
+	value, ok := media["example"]
+	if examples, _ := media["examples"].(map[string]any); !ok && len(examples) > 0 {
+		name, named := s.Attrs["example"]
+		if !named {
+			name = slices.Min(slices.Collect(maps.Keys(examples)))
+		}
+		example, _ := openAPIRef(spec, examples[name])
+		value, ok = example["value"]
+	}
+	if !ok {
+		schema, _ := openAPIRef(spec, media["schema"])
+		value, ok = schema["example"]
+	}
+	if !ok {
+		return nil, fmt.Errorf("section %s: no example to render", s.Name)
+	}
+
+	out, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %w", s.Name, err)
+	}
+
+	return append(out, '\n'), nil
+}
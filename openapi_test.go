@@ -0,0 +1,148 @@
+package gosect
+
+import (
+	"strings"
+	"testing"
+)
+
+// openAPISpec is the OpenAPI document of the openapi= tests
+const openAPISpec = `openapi: 3.0.3
+info:
+  title: Users
+  version: 1.0.0
+paths:
+  /users/{id}:
+    parameters:
+      - $ref: '#/components/parameters/id'
+    get:
+      summary: Get a user
+      description: Returns a single user.
+      parameters:
+        - name: fields
+          in: query
+          description: fields to return
+          schema:
+            type: array
+            items:
+              type: string
+      responses:
+        '200':
+          description: The user
+          content:
+            application/json:
+              schema:
+                $ref: '#/components/schemas/User'
+              example:
+                id: 42
+                name: Ada
+        '404':
+          description: Not found
+    put:
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/User'
+      responses:
+        '204':
+          description: Updated
+components:
+  parameters:
+    id:
+      name: id
+      in: path
+      required: true
+      schema:
+        type: integer
+        format: int64
+  schemas:
+    User:
+      type: object
+      required: [id]
+      properties:
+        id:
+          type: integer
+          description: unique id
+        name:
+          type: string
+`
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test rendering the operations of openapi= sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestExtractOpenAPI(t *testing.T) {
+	tests := []struct {
+		name    string
+		attrs   map[string]string
+		src     string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "Summary",
+			attrs: map[string]string{"path": "/users/{id}"},
+			want: "**GET /users/{id}**: Get a user\n\nReturns a single user.\n\n" +
+				"| Parameter | In    | Type            | Required | Description      |\n" +
+				"| --------- | ----- | --------------- | -------- | ---------------- |\n" +
+				"| fields    | query | []string        |          | fields to return |\n" +
+				"| id        | path  | integer (int64) | yes      |                  |\n" +
+				"\n| Status | Description |\n| -----: | ----------- |\n|    200 | The user    |\n|    404 | Not found   |\n",
+		},
+		{
+			name:  "Response schema",
+			attrs: map[string]string{"path": "/users/{id}", "part": "schema"},
+			want:  "**User**\n\n| Property | Type    | Required | Description |\n| -------- | ------- | -------- | ----------- |\n| id       | integer | yes      | unique id   |\n| name     | string  |          |             |\n",
+		},
+		{
+			name:  "Example",
+			attrs: map[string]string{"path": "/users/{id}", "part": "example", "status": "200"},
+			want:  "{\n  \"id\": 42,\n  \"name\": \"Ada\"\n}\n",
+		},
+		{
+			name:  "Request body of a JSON document",
+			attrs: map[string]string{"path": "/users/{id}", "method": "PUT", "part": "schema"},
+			src:   `{"paths": {"/users/{id}": {"put": {"requestBody": {"content": {"application/json": {"schema": {"type": "string"}}}}}}}}`,
+			want:  "string\n",
+		},
+		{
+			name:    "Unknown operation",
+			attrs:   map[string]string{"path": "/users/{id}", "method": "delete"},
+			wantErr: "no DELETE /users/{id} operation in spec.yaml",
+		},
+		{
+			name:    "No example",
+			attrs:   map[string]string{"path": "/users/{id}", "method": "put", "part": "example"},
+			wantErr: "no example to render",
+		},
+		{
+			name:    "Unknown part",
+			attrs:   map[string]string{"path": "/users/{id}", "part": "code"},
+			wantErr: "unknown part=code",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.attrs["openapi"] = "spec.yaml"
+			src := tt.src
+			if src == "" {
+				src = openAPISpec
+			}
+
+			got, err := extractOpenAPI(Section{Name: "api", Attrs: tt.attrs}, []byte(src))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
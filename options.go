@@ -0,0 +1,294 @@
+package gosect
+
+import (
+	"log/slog"
+	"maps"
+	"net/http"
+	"os"
+	"regexp"
+	"time"
+)
+
+// DefaultMaxDepth is the default nesting limit of recursive section expansion
+const DefaultMaxDepth = 10
+
+// Default region markers used by the region= attribute
+const (
+	DefaultRegionBegin = "#region"
+	DefaultRegionEnd   = "#endregion"
+)
+
+// Options controls how sections are rendered. The zero value renders file=
+// sources with the default markers. Options can be set directly or built
+// with NewOptions and functional options; both stay supported in v1, and new
+// settings are only added as fields whose zero value keeps the current
+// behavior.
+type Options struct {
+	// Verbose logs details about processed sections to stderr, when Logger
+	// is nil
+	Verbose bool
+
+	// Logger receives the log records of processed sections; when nil,
+	// warnings (and debug records with Verbose) are written to stderr as text
+	Logger *slog.Logger
+
+	// RenderTemplates runs every source through text/template, as if each
+	// section had the template=true attribute
+	RenderTemplates bool
+
+	// Values is exposed to templates as .Values
+	Values map[string]any
+
+	// Vars replaces the {{NAME}} placeholders of sources, before templates
+	// are rendered. Placeholders of undefined variables are kept.
+	Vars map[string]string
+
+	// ReBegin and ReEnd are the marker regexes used to expand sections found
+	// in included sources; the default markers are used when nil
+	ReBegin, ReEnd *regexp.Regexp
+
+	// MaxDepth limits recursive expansion of sections found in included
+	// sources; DefaultMaxDepth is used when 0
+	MaxDepth int
+
+	// MmapThreshold is the source size in bytes above which sources are
+	// memory-mapped; DefaultMmapThreshold is used when 0, a negative value
+	// disables memory mapping
+	MmapThreshold int64
+
+	// MaxSourceSize limits the size in bytes of file= and url= sources,
+	// which are handled by OnOversize when they are larger; 0 disables the
+	// limit
+	MaxSourceSize int64
+
+	// OnOversize is the handling of file= and url= sources larger than
+	// MaxSourceSize; OversizeError is used when empty
+	OnOversize OversizePolicy
+
+	// Language is the language of the target document, such as fr for
+	// README.fr.md, replacing the {{.lang}} placeholders of source
+	// attributes
+	Language string
+
+	// EditorConfig holds the .editorconfig settings of the target (see
+	// LoadEditorConfig): its end_of_line sets the line endings of inserted
+	// content, and its indent_style the indentation of indent= attributes
+	EditorConfig EditorConfig
+
+	// ValidateDiagrams checks the syntax of Mermaid and PlantUML sections,
+	// as validate=true does for a single section
+	ValidateDiagrams bool
+
+	// RegionBegin and RegionEnd are the markers delimiting named regions in
+	// source files; DefaultRegionBegin and DefaultRegionEnd are used when
+	// empty
+	RegionBegin, RegionEnd string
+
+	// Checksum records a sha= checksum of the generated body on every BEGIN
+	// marker. Markers which already carry one are always updated.
+	Checksum bool
+
+	// Force overwrites sections whose body was edited by hand since it was
+	// generated, instead of failing
+	Force bool
+
+	// SnapshotDir records the body generated for every section with a sha=
+	// checksum, named after the checksum. Bodies edited by hand since they
+	// were generated are merged with the new content from their snapshot,
+	// and only fail on conflicting changes.
+	SnapshotDir string
+
+	// ReadOnlySnapshots loads the snapshots of SnapshotDir for merges without
+	// recording new ones, for runs which do not write their targets
+	ReadOnlySnapshots bool
+
+	// AllowCommands enables cmd= sources, which run a shell command and
+	// insert its output
+	AllowCommands bool
+
+	// RetryDelay is the delay before retrying a failed cmd= source;
+	// DefaultRetryDelay is used when 0
+	RetryDelay time.Duration
+
+	// CommandTimeout kills the cmd= sources and resolver plugins running
+	// longer, when positive
+	CommandTimeout time.Duration
+
+	// HTTPClient downloads url= sources; a client with DefaultHTTPTimeout is
+	// used when nil
+	HTTPClient *http.Client
+
+	// CacheDir caches the url= sources downloaded with an ETag or
+	// Last-Modified header; later downloads are conditional requests, served
+	// from the cache when the source was not modified
+	CacheDir string
+
+	// VendorDir is a vendor directory created by Vendor; url= sources
+	// vendored there are read from it instead of being downloaded
+	VendorDir string
+
+	// Roots maps workspace names to directories for file=@name/path
+	// sources (see Config)
+	Roots map[string]string
+
+	// BaseDir is the directory relative file= sources are resolved from; the
+	// current directory is used when empty. Sources of nested sections are
+	// resolved from the directory of the file including them.
+	BaseDir string
+
+	// ExpandEnv expands $VAR and ${VAR} references in the file=, url= and
+	// sql= attributes; cmd= commands are expanded by the shell
+	ExpandEnv bool
+
+	// KeepGoing leaves the sections which cannot be rendered unchanged and
+	// replaces the others, instead of stopping at the first failure
+	KeepGoing bool
+
+	// Reviewer stamps the END marker of every rendered section with
+	// reviewed-by=Reviewer and the review-hash of its body. When empty,
+	// sections whose body no longer matches their review-hash fail.
+	Reviewer string
+
+	// OnMissing is the handling of sections whose file= source is missing;
+	// MissingError is used when empty
+	OnMissing MissingPolicy
+
+	// Format splices rendered content into the target (see FormatFor);
+	// PlainFormat is used when nil. Nested sections of sources always use
+	// PlainFormat.
+	Format TargetFormat
+
+	// Resolvers read the file=scheme:ref sources of custom schemes, by
+	// scheme (see WithResolver)
+	Resolvers map[string]Resolver
+
+	// Plugins resolves the file=scheme:ref sources of schemes without a
+	// registered resolver with the gosect-resolver-<scheme> executable found
+	// in PATH (see PluginRequest)
+	Plugins bool
+
+	// Transforms are custom transform= functions, by name. They take
+	// precedence over the builtin transforms of the same name.
+	Transforms map[string]func([]byte) []byte
+}
+
+// Option sets a field of Options
+type Option func(*Options)
+
+// NewOptions returns the options set by options, in order
+func NewOptions(options ...Option) Options {
+	var opts Options
+	for _, option := range options {
+		option(&opts)
+	}
+
+	return opts
+}
+
+// WithMarkers sets the BEGIN and END marker prefixes of nested sections
+func WithMarkers(begin, end string) Option {
+	return func(opts *Options) {
+		opts.ReBegin, opts.ReEnd = MakeRegex(begin, end)
+	}
+}
+
+// WithResolver reads the file=scheme:ref sources of scheme with resolve
+func WithResolver(scheme string, resolve Resolver) Option {
+	return func(opts *Options) {
+		opts.Resolvers = maps.Clone(opts.Resolvers)
+		if opts.Resolvers == nil {
+			opts.Resolvers = map[string]Resolver{}
+		}
+		opts.Resolvers[scheme] = resolve
+	}
+}
+
+// WithTransforms adds custom transform= functions, by name
+func WithTransforms(transforms map[string]func([]byte) []byte) Option {
+	return func(opts *Options) {
+		opts.Transforms = maps.Clone(opts.Transforms)
+		if opts.Transforms == nil {
+			opts.Transforms = map[string]func([]byte) []byte{}
+		}
+		maps.Copy(opts.Transforms, transforms)
+	}
+}
+
+// WithLogger sets the logger of processed sections
+func WithLogger(logger *slog.Logger) Option {
+	return func(opts *Options) {
+		opts.Logger = logger
+	}
+}
+
+// logger returns the logger of processed sections
+func (opts Options) logger() *slog.Logger {
+	if opts.Logger != nil {
+		return opts.Logger
+	}
+
+	level := slog.LevelWarn
+	if opts.Verbose {
+		level = slog.LevelDebug
+	}
+
+	return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+}
+
+// format returns the format of the target
+func (opts Options) format() TargetFormat {
+	if opts.Format == nil {
+		return PlainFormat{}
+	}
+
+	return opts.Format
+}
+
+// markers returns the marker regexes to use for nested sections
+func (opts Options) markers() (*regexp.Regexp, *regexp.Regexp) {
+	if opts.ReBegin == nil || opts.ReEnd == nil {
+		return reBegin, reEnd
+	}
+
+	return opts.ReBegin, opts.ReEnd
+}
+
+// maxDepth returns the effective recursive expansion limit
+func (opts Options) maxDepth() int {
+	if opts.MaxDepth <= 0 {
+		return DefaultMaxDepth
+	}
+
+	return opts.MaxDepth
+}
+
+// mmapThreshold returns the effective memory mapping threshold
+func (opts Options) mmapThreshold() int64 {
+	if opts.MmapThreshold == 0 {
+		return DefaultMmapThreshold
+	}
+
+	return opts.MmapThreshold
+}
+
+// regionMarkers returns the effective region markers
+func (opts Options) regionMarkers() (string, string) {
+	begin, end := opts.RegionBegin, opts.RegionEnd
+	if begin == "" {
+		begin = DefaultRegionBegin
+	}
+	if end == "" {
+		end = DefaultRegionEnd
+	}
+
+	return begin, end
+}
+
+// retryDelay returns the effective delay before the first command retry
+func (opts Options) retryDelay() time.Duration {
+	if opts.RetryDelay <= 0 {
+		return DefaultRetryDelay
+	}
+
+	return opts.RetryDelay
+}
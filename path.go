@@ -0,0 +1,39 @@
+package gosect
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// reWindowsAbs matches the absolute Windows paths: drive letter paths such as
+// C:\docs\usage.md and UNC paths such as \\server\share\usage.md
+var reWindowsAbs = regexp.MustCompile(`^(?:[A-Za-z]:[\\/]|\\\\[^\\/]+[\\/])`)
+
+// localPath converts the separators of the source path of s to those of the
+// platform goos, so that documents written on Windows with backslashes
+// resolve elsewhere. Absolute Windows paths are only valid on Windows.
+func localPath(s Section, path, goos string) (string, error) {
+	if goos == "windows" {
+		return path, nil
+	}
+	if reWindowsAbs.MatchString(path) {
+		return "", fmt.Errorf("section %s: Windows path %s is not supported on %s", s.Name, path, goos)
+	}
+
+	return strings.ReplaceAll(path, `\`, "/"), nil
+}
+
+// cutRoot splits the workspace path name/rel, separated by a slash or, on
+// Windows, a backslash
+func cutRoot(path, goos string) (string, string) {
+	seps := "/"
+	if goos == "windows" {
+		seps = `/\`
+	}
+	if i := strings.IndexAny(path, seps); i != -1 {
+		return path[:i], path[i+1:]
+	}
+
+	return path, ""
+}
@@ -0,0 +1,90 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test converting the separators of Windows style source paths
+// /////////////////////////////////////////////////////////////////////////////
+func TestLocalPath(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		goos    string
+		want    string
+		wantErr string
+	}{
+		{"Relative backslash path", `docs\usage.md`, "linux", "docs/usage.md", ""},
+		{"Slash path", "docs/usage.md", "linux", "docs/usage.md", ""},
+		{"Workspace backslash path", `@shared\intro.md`, "darwin", "@shared/intro.md", ""},
+		{"Drive letter path", `C:\docs\usage.md`, "linux", "", `Windows path C:\docs\usage.md is not supported on linux`},
+		{"Drive letter slash path", "c:/docs/usage.md", "linux", "", "not supported"},
+		{"UNC path", `\\server\share\usage.md`, "linux", "", "not supported"},
+		{"Windows keeps paths", `C:\docs\usage.md`, "windows", `C:\docs\usage.md`, ""},
+		{"Windows keeps UNC paths", `\\server\share\usage.md`, "windows", `\\server\share\usage.md`, ""},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := localPath(Section{Name: "a"}, tt.path, tt.goos)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	for _, tt := range []struct{ path, goos, name, rel string }{
+		{"shared/intro.md", "linux", "shared", "intro.md"},
+		{`shared\intro.md`, "windows", "shared", "intro.md"},
+		{"shared", "windows", "shared", ""},
+	} {
+		if name, rel := cutRoot(tt.path, tt.goos); name != tt.name || rel != tt.rel {
+			t.Errorf("Expected %s to split as %s and %s on %s, got %s and %s", tt.path, tt.name, tt.rel, tt.goos, name, rel)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test backslash separated file= sources resolve on every platform
+// /////////////////////////////////////////////////////////////////////////////
+func TestBackslashSource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("backslashes are separators on Windows")
+	}
+
+	tmpDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(tmpDir, "docs"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(tmpDir, "docs", "usage.md"), []byte("usage\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "<!-- BEGIN SECTION a file=docs\\usage.md -->\n<!-- END SECTION a -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := Replace([]byte(content), sections, Options{BaseDir: tmpDir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "\nusage\n") {
+		t.Errorf("Expected the source to be inserted, got %q", got)
+	}
+}
@@ -0,0 +1,128 @@
+package gosect
+
+import "unicode/utf8"
+
+// Position is a location in content: a byte offset and its 1-based line and
+// column, in characters
+type Position struct {
+	Offset int `json:"offset"`
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Span is the range of content from Start to End, exclusive
+type Span struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// AttrSpan locates an attribute of a marker. The value span includes the
+// quotes of quoted values.
+type AttrSpan struct {
+	Key   Span `json:"key"`
+	Value Span `json:"value"`
+}
+
+// Positions locates the parts of a section in the content it was found in,
+// for tools highlighting or editing exact ranges
+type Positions struct {
+	Begin    Span                `json:"begin"`    // BEGIN marker, from its prefix to its last attribute
+	Name     Span                `json:"name"`     // section name of the BEGIN marker
+	Attrs    map[string]AttrSpan `json:"attrs"`    // attributes of the BEGIN marker
+	Body     Span                `json:"body"`     // lines between the BEGIN and END marker lines
+	End      Span                `json:"end"`      // END marker, from its prefix to its last attribute
+	EndAttrs map[string]AttrSpan `json:"endAttrs"` // attributes of the END marker
+}
+
+// markerLine is a line of a document, holding markers
+type markerLine struct {
+	text   []byte
+	start  int // offset of the line in the document
+	number int // 1-based line number
+}
+
+// position returns the position of the byte i of the line
+func (l markerLine) position(i int) Position {
+	return Position{Offset: l.start + i, Line: l.number, Column: utf8.RuneCount(l.text[:i]) + 1}
+}
+
+// span returns the span of the bytes start to end of the line
+func (l markerLine) span(start, end int) Span {
+	return Span{Start: l.position(start), End: l.position(end)}
+}
+
+// next returns the position of the start of the next line
+func (l markerLine) next() Position {
+	return Position{Offset: l.start + len(l.text), Line: l.number + 1, Column: 1}
+}
+
+// markerPositions locates the parts of the section delimited by the BEGIN
+// marker b and the END marker e
+func markerPositions(b, e foundMarker) Positions {
+	// the body starts on the line after the BEGIN marker and stops at the
+	// start of the END marker line, or lies between markers sharing a line
+	body := Span{Start: b.line.next(), End: e.line.position(0)}
+	if b.line.number == e.line.number {
+		body = b.line.span(b.loc[1], e.loc[0])
+	}
+
+	return Positions{
+		Begin:    b.span(0),
+		Name:     b.span(2),
+		Attrs:    attrPositions(b),
+		Body:     body,
+		End:      e.span(0),
+		EndAttrs: attrPositions(e),
+	}
+}
+
+// attrPositions locates the attributes of the attribute list captured by the
+// marker m
+func attrPositions(m foundMarker) map[string]AttrSpan {
+	attrs := map[string]AttrSpan{}
+	if len(m.loc) < 6 || m.loc[4] < 0 {
+		return attrs
+	}
+	start, end := m.loc[4], m.loc[5]
+
+	for _, a := range reAttr.FindAllSubmatchIndex(m.line.text[start:end], -1) {
+		key := string(m.line.text[start+a[2] : start+a[3]])
+		attrs[key] = AttrSpan{
+			Key:   m.line.span(start+a[2], start+a[3]),
+			Value: m.line.span(start+a[4], start+a[5]),
+		}
+	}
+
+	return attrs
+}
+
+// moved returns the position moved by offset bytes and lines lines
+func (p Position) moved(offset, lines int) Position {
+	return Position{Offset: p.Offset + offset, Line: p.Line + lines, Column: p.Column}
+}
+
+// moved returns the span moved by offset bytes and lines lines
+func (s Span) moved(offset, lines int) Span {
+	return Span{Start: s.Start.moved(offset, lines), End: s.End.moved(offset, lines)}
+}
+
+// moved returns the positions moved by offset bytes and lines lines, such as
+// when the section is located in another part of its document
+func (p Positions) moved(offset, lines int) Positions {
+	moveAttrs := func(attrs map[string]AttrSpan) map[string]AttrSpan {
+		moved := make(map[string]AttrSpan, len(attrs))
+		for key, a := range attrs {
+			moved[key] = AttrSpan{Key: a.Key.moved(offset, lines), Value: a.Value.moved(offset, lines)}
+		}
+		return moved
+	}
+
+	return Positions{
+		Begin:    p.Begin.moved(offset, lines),
+		Name:     p.Name.moved(offset, lines),
+		Attrs:    moveAttrs(p.Attrs),
+		Body:     p.Body.moved(offset, lines),
+		End:      p.End.moved(offset, lines),
+		EndAttrs: moveAttrs(p.EndAttrs),
+	}
+}
@@ -0,0 +1,51 @@
+package gosect
+
+import "testing"
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the positions of section parts
+// /////////////////////////////////////////////////////////////////////////////
+func TestSectionPositions(t *testing.T) {
+	content := "# Title\n<!-- BEGIN SECTION intro file=a.md prefix=\"> \" -->\nold\n<!-- END SECTION intro reviewed-by=me -->\n" +
+		"é <!-- BEGIN SECTION b --> x <!-- END SECTION b -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 2 {
+		t.Fatalf("Expected 2 sections, got %d", len(sections))
+	}
+
+	// text returns the content covered by a span
+	text := func(s Span) string {
+		return content[s.Start.Offset:s.End.Offset]
+	}
+
+	tests := []struct {
+		name string
+		span Span
+		want string
+		line int
+		col  int
+	}{
+		{"BEGIN marker", sections[0].Pos.Begin, "BEGIN SECTION intro file=a.md prefix=\"> \"", 2, 6},
+		{"Name", sections[0].Pos.Name, "intro", 2, 20},
+		{"Attribute key", sections[0].Pos.Attrs["prefix"].Key, "prefix", 2, 36},
+		{"Quoted value", sections[0].Pos.Attrs["prefix"].Value, "\"> \"", 2, 43},
+		{"Body", sections[0].Pos.Body, "old\n", 3, 1},
+		{"END marker", sections[0].Pos.End, "END SECTION intro reviewed-by=me", 4, 6},
+		{"END attribute", sections[0].Pos.EndAttrs["reviewed-by"].Value, "me", 4, 36},
+		{"Inline body", sections[1].Pos.Body, " --> x <!-- ", 5, 23},
+		{"Column in characters", sections[1].Pos.Begin, "BEGIN SECTION b", 5, 8},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		if got := text(tt.span); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+		if tt.span.Start.Line != tt.line || tt.span.Start.Column != tt.col {
+			t.Errorf("%s: expected %d:%d, got %d:%d", tt.name, tt.line, tt.col, tt.span.Start.Line, tt.span.Start.Column)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// proto= sources: definitions of Protocol Buffers files
+// /////////////////////////////////////////////////////////////////////////////
+
+// protoKinds are the definition attributes of proto= sources
+var protoKinds = []string{"message", "service", "enum"}
+
+// extractProto returns the message=, service= or enum= definition of the
+// proto= source of s with its leading comments. Nested definitions are named
+// with dots, as in message=Outer.Inner.
+func extractProto(s Section, data []byte) ([]byte, error) {
+	var kind, name string
+	for _, k := range protoKinds {
+		if value, ok := s.Attrs[k]; ok {
+			if kind != "" {
+				return nil, fmt.Errorf("section %s has both %s= and %s=", s.Name, kind, k)
+			}
+			kind, name = k, value
+		}
+	}
+	if kind == "" {
+		return nil, fmt.Errorf("section %s has proto= but no message=, service= or enum= attribute", s.Name)
+	}
+
+	// look for each part of a nested name in the body of its parent
+	from, to := 0, len(data)
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		k := "message"
+		if i == len(parts)-1 {
+			k = kind
+		}
+		re := regexp.MustCompile(`(?m)^[ \t]*` + k + `[ \t]+` + regexp.QuoteMeta(part) + `[ \t]*\{`)
+		loc := re.FindIndex(data[from:to])
+		if loc == nil {
+			return nil, fmt.Errorf("section %s: no %s %s in %s", s.Name, kind, name, s.Attrs["proto"])
+		}
+		end, ok := protoBlockEnd(data, from+loc[1]-1)
+		if !ok {
+			return nil, fmt.Errorf("section %s: %s %s in %s is not terminated", s.Name, kind, name, s.Attrs["proto"])
+		}
+		from, to = from+loc[0], end
+	}
+
+	out := dedent(data[commentStart(data, from, "//"):to])
+	return append(out, '\n'), nil
+}
+
+// protoBlockEnd returns the offset following the brace closing the block
+// opened at data[open], skipping strings and comments
+func protoBlockEnd(data []byte, open int) (int, bool) {
+	depth := 0
+	for i := open; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth--; depth == 0 {
+				return i + 1, true
+			}
+		case c == '"' || c == '\'':
+			for i++; i < len(data) && data[i] != c && data[i] != '\n'; i++ {
+				if data[i] == '\\' {
+					i++
+				}
+			}
+		case bytes.HasPrefix(data[i:], []byte("//")):
+			n := bytes.IndexByte(data[i:], '\n')
+			if n == -1 {
+				return 0, false
+			}
+			i += n
+		case bytes.HasPrefix(data[i:], []byte("/*")):
+			n := bytes.Index(data[i+2:], []byte("*/"))
+			if n == -1 {
+				return 0, false
+			}
+			i += n + 3
+		}
+	}
+
+	return 0, false
+}
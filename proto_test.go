@@ -0,0 +1,105 @@
+package gosect
+
+import (
+	"strings"
+	"testing"
+)
+
+// protoFile is the Protocol Buffers file of the proto= tests
+const protoFile = `syntax = "proto3";
+
+package users.v1;
+
+// User is a registered user.
+// Users are never deleted.
+message User {
+  int64 id = 1; // unique id
+  string name = 2 [json_name = "name}"];
+
+  /* Address is a postal address */
+  message Address {
+    string city = 1;
+  }
+}
+
+/*
+ * Users manages users.
+ */
+service Users {
+  rpc GetUser(GetUserRequest) returns (User) {
+    option (google.api.http) = { get: "/v1/users/{id}" };
+  }
+}
+
+enum Role {
+  ROLE_UNSPECIFIED = 0;
+}
+`
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test extracting definitions of proto= sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestExtractProto(t *testing.T) {
+	tests := []struct {
+		name    string
+		attrs   map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "Message with comments",
+			attrs: map[string]string{"message": "User"},
+			want:  "// User is a registered user.\n// Users are never deleted.\nmessage User {\n  int64 id = 1; // unique id\n  string name = 2 [json_name = \"name}\"];\n\n  /* Address is a postal address */\n  message Address {\n    string city = 1;\n  }\n}\n",
+		},
+		{
+			name:  "Nested message",
+			attrs: map[string]string{"message": "User.Address"},
+			want:  "/* Address is a postal address */\nmessage Address {\n  string city = 1;\n}\n",
+		},
+		{
+			name:  "Service with block comment",
+			attrs: map[string]string{"service": "Users"},
+			want:  "/*\n * Users manages users.\n */\nservice Users {\n  rpc GetUser(GetUserRequest) returns (User) {\n    option (google.api.http) = { get: \"/v1/users/{id}\" };\n  }\n}\n",
+		},
+		{
+			name:  "Enum",
+			attrs: map[string]string{"enum": "Role"},
+			want:  "enum Role {\n  ROLE_UNSPECIFIED = 0;\n}\n",
+		},
+		{
+			name:    "Unknown message",
+			attrs:   map[string]string{"message": "Group"},
+			wantErr: "no message Group in users.proto",
+		},
+		{
+			name:    "No definition",
+			attrs:   map[string]string{},
+			wantErr: "no message=, service= or enum= attribute",
+		},
+		{
+			name:    "Several definitions",
+			attrs:   map[string]string{"message": "User", "enum": "Role"},
+			wantErr: "has both message= and enum=",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.attrs["proto"] = "users.proto"
+			got, err := extractProto(Section{Name: "idl", Attrs: tt.attrs}, []byte(protoFile))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package gosect
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// raw marker regexes, for the markers of other tools
+// /////////////////////////////////////////////////////////////////////////////
+
+// RawMarkers compiles the BEGIN and END marker regexes begin and end, given
+// in full rather than as prefixes, so the markers of other tools such as
+// <!-- BEGIN_TF_DOCS --> delimit sections. Both need a (?P<name>...) group
+// capturing the section name, which pairs BEGIN and END markers. The
+// optional (?P<attrs>...) group captures a list of key=value attributes, and
+// the other named groups of the BEGIN regex, such as (?P<file>...), set the
+// attribute of their name. The regexes match within a line, in multi-line
+// mode: ^ and $ match at line boundaries.
+func RawMarkers(begin, end string) (*regexp.Regexp, *regexp.Regexp, error) {
+	b, err := rawMarker("BEGIN", begin)
+	if err != nil {
+		return nil, nil, err
+	}
+	e, err := rawMarker("END", end)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b, e, nil
+}
+
+// rawMarker compiles the raw regex expr of the kind (BEGIN or END) markers
+func rawMarker(kind, expr string) (*regexp.Regexp, error) {
+	re, err := regexp.Compile("(?m)" + expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s marker regex: %w", kind, err)
+	}
+	if re.SubexpIndex("name") == -1 {
+		return nil, fmt.Errorf("%s marker regex %q needs a (?P<name>...) group capturing the section name", kind, expr)
+	}
+
+	return re, nil
+}
+
+// markerLoc returns the submatch indexes loc of the marker regex re in the
+// layout of MakeRegex: the match, then the section name and the attribute
+// list, -1 when re has no attrs group or it did not match. The named groups
+// of raw marker regexes other than name and attrs follow, in the order of
+// re.SubexpNames.
+func markerLoc(re *regexp.Regexp, loc []int) []int {
+	name := re.SubexpIndex("name")
+	if loc == nil || name == -1 {
+		return loc
+	}
+
+	out := []int{loc[0], loc[1], loc[2*name], loc[2*name+1]}
+	if out[2] == -1 {
+		out[2], out[3] = loc[0], loc[0]
+	}
+	// without an attribute list, attributes such as sha= cannot be written
+	if attrs := re.SubexpIndex("attrs"); attrs != -1 {
+		out = append(out, loc[2*attrs], loc[2*attrs+1])
+	} else {
+		out = append(out, -1, -1)
+	}
+	for i, group := range re.SubexpNames() {
+		if group != "" && group != "name" && group != "attrs" {
+			out = append(out, loc[2*i], loc[2*i+1])
+		}
+	}
+
+	return out
+}
+
+// groupAttrs sets the attributes of s captured by the named groups of the
+// raw BEGIN marker regex re, in the marker match loc of text laid out by
+// markerLoc. The attribute list of the marker takes precedence.
+func groupAttrs[T string | []byte](s *Section, re *regexp.Regexp, text T, loc []int) {
+	if re.SubexpIndex("name") == -1 {
+		return
+	}
+
+	n := 3
+	for _, group := range re.SubexpNames() {
+		if group == "" || group == "name" || group == "attrs" {
+			continue
+		}
+		if _, ok := s.Attrs[group]; !ok && loc[2*n] != -1 {
+			s.Attrs[group] = string(submatch(text, loc, n))
+		}
+		n++
+	}
+	s.SrcFile = s.Attrs["file"]
+}
@@ -0,0 +1,156 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test sections delimited by raw marker regexes
+// /////////////////////////////////////////////////////////////////////////////
+func TestRawMarkers(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "inputs.md"), []byte("| name | type |\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		begin   string
+		end     string
+		content string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "Name and attributes",
+			begin:   `<!-- BEGIN_(?P<name>TF_DOCS)(?P<attrs>(?:[ \t]+\w+=\S+)*) -->`,
+			end:     `<!-- END_(?P<name>TF_DOCS) -->`,
+			content: "<!-- BEGIN_TF_DOCS file=inputs.md fence=false -->\nold\n<!-- END_TF_DOCS -->\n",
+			want:    "<!-- BEGIN_TF_DOCS file=inputs.md fence=false -->\n\n| name | type |\n\n<!-- END_TF_DOCS -->\n",
+		},
+		{
+			name:    "File group before the name",
+			begin:   `<!-- include (?P<file>\S+) as (?P<name>\w+) -->`,
+			end:     `<!-- end (?P<name>\w+) -->`,
+			content: "<!-- include inputs.md as inputs -->\n<!-- end inputs -->\n",
+			want:    "<!-- include inputs.md as inputs -->\n\n| name | type |\n\n<!-- end inputs -->\n",
+		},
+		{
+			name:    "Attribute list over groups",
+			begin:   `<!-- include (?P<file>\S+)(?P<attrs>(?:[ \t]+\w+=\S+)*)(?P<name>) -->`,
+			end:     `<!-- /include(?P<name>) -->`,
+			content: "<!-- include missing.md file=inputs.md -->\n<!-- /include -->\n",
+			want:    "<!-- include missing.md file=inputs.md -->\n\n| name | type |\n\n<!-- /include -->\n",
+		},
+		{
+			name:    "Without name group",
+			begin:   `<!-- BEGIN_TF_DOCS -->`,
+			end:     `<!-- END_(?P<name>TF_DOCS) -->`,
+			wantErr: `BEGIN marker regex "<!-- BEGIN_TF_DOCS -->" needs a (?P<name>...) group capturing the section name`,
+		},
+		{
+			name:    "Invalid regex",
+			begin:   `<!-- (?P<name>\w+ -->`,
+			end:     `<!-- END_(?P<name>TF_DOCS) -->`,
+			wantErr: "invalid BEGIN marker regex",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, e, err := RawMarkers(tt.begin, tt.end)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			sections, err := FindSections(tt.content, b, e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Replace([]byte(tt.content), sections, Options{BaseDir: tmpDir, ReBegin: b, ReEnd: e})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+
+			// Scanning finds the same sections
+			for s, err := range ScanMarkers(strings.NewReader(tt.content), b, e) {
+				if err != nil || s.SrcFile != sections[0].SrcFile || s.Name != sections[0].Name {
+					t.Errorf("Expected scanned section %s from %s, got %s from %s (%v)", sections[0].Name, sections[0].SrcFile, s.Name, s.SrcFile, err)
+				}
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test attributes written to raw markers
+// /////////////////////////////////////////////////////////////////////////////
+func TestRawMarkersWriteAttrs(t *testing.T) {
+	tmpDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(tmpDir, "inputs.md"), []byte("| name | type |\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	sha := BodyChecksum([]byte("\n| name | type |\n\n"))
+
+	tests := []struct {
+		name    string
+		begin   string
+		content string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "Attribute list",
+			begin:   `<!-- include (?P<file>\S+) as (?P<name>\w+)(?P<attrs>(?:[ \t]+\w+=\S+)*) -->`,
+			content: "<!-- include inputs.md as inputs -->\n<!-- end inputs -->\n",
+			want:    "<!-- include inputs.md as inputs sha=" + sha + " -->\n\n| name | type |\n\n<!-- end inputs -->\n",
+		},
+		{
+			name:    "Without attribute list",
+			begin:   `<!-- include (?P<file>\S+) as (?P<name>\w+) -->`,
+			content: "<!-- include inputs.md as inputs -->\n<!-- end inputs -->\n",
+			wantErr: "needs a (?P<attrs>...) group",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, e, err := RawMarkers(tt.begin, `<!-- end (?P<name>\w+) -->`)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sections, err := FindSections(tt.content, b, e)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Replace([]byte(tt.content), sections, Options{BaseDir: tmpDir, ReBegin: b, ReEnd: e, Checksum: true})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
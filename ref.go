@@ -0,0 +1,70 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// ref= sources: the body of a section of another document
+// /////////////////////////////////////////////////////////////////////////////
+
+// RefSource returns the document path and the section name of the
+// ref=other.md#name source of s, when it has one
+func RefSource(s Section) (string, string, bool) {
+	ref, ok := s.Attrs["ref"]
+	if !ok {
+		return "", "", false
+	}
+	path, name, _ := strings.Cut(ref, "#")
+
+	return path, name, true
+}
+
+// readRef returns the current body of the section name of the document at
+// path, without the blank lines around it
+func (opts Options) readRef(s Section, path, name string) ([]byte, error) {
+	if path == "" || name == "" {
+		return nil, fmt.Errorf("section %s has invalid ref=%s (expected ref=file#section)", s.Name, s.Attrs["ref"])
+	}
+	resolved, err := opts.SourcePath(Section{Name: s.Name, SrcFile: path})
+	if err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(resolved)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %w", s.Name, err)
+	}
+	content, _, err := DecodeText(raw)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %s: %w", s.Name, path, err)
+	}
+
+	reBegin, reEnd := opts.markers()
+	sections, err := FindSectionsBytes(content, reBegin, reEnd)
+	if err != nil {
+		return nil, &IncludeError{Section: s.Name, Source: path, Err: FileErrors(path, err)}
+	}
+	for _, ref := range sections {
+		if ref.Name == name {
+			body := content[ref.Pos.Body.Start.Offset:ref.Pos.Body.End.Offset]
+			return append(bytes.Trim(body, "\r\n"), '\n'), nil
+		}
+	}
+
+	return nil, fmt.Errorf("section %s: no section %s in %s", s.Name, name, path)
+}
+
+// refPath returns the absolute path of the document of the ref= source of s
+func (opts Options) refPath(s Section) (string, error) {
+	path, _, _ := RefSource(s)
+	resolved, err := opts.SourcePath(Section{Name: s.Name, SrcFile: path})
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Abs(resolved)
+}
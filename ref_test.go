@@ -0,0 +1,99 @@
+package gosect
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test ref= sources embedding the body of a section of another document
+// /////////////////////////////////////////////////////////////////////////////
+func TestRefSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	files := map[string]string{
+		"docs/shared.md": "# Shared\n\n<!-- BEGIN SECTION install -->\n\ngo install ./cmd/gosect\n\n<!-- END SECTION install -->\n" +
+			"<!-- BEGIN SECTION usage -->\n<!-- BEGIN SECTION flags file=flags.txt -->\n<!-- END SECTION flags -->\n<!-- END SECTION usage -->\n",
+		"docs/flags.txt": "-stdout\n",
+		"broken.md":      "<!-- BEGIN SECTION install -->\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(tmpDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		ref     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "Section body",
+			ref:  "docs/shared.md#install",
+			want: "\ngo install ./cmd/gosect\n",
+		},
+		{
+			name: "Nested sections resolve from the referenced document",
+			ref:  "docs/shared.md#usage",
+			want: "\n<!-- BEGIN SECTION flags file=flags.txt -->\n\n-stdout\n\n<!-- END SECTION flags -->\n",
+		},
+		{
+			name:    "Unknown section",
+			ref:     "docs/shared.md#missing",
+			wantErr: "no section missing in docs/shared.md",
+		},
+		{
+			name:    "No section name",
+			ref:     "docs/shared.md",
+			wantErr: "expected ref=file#section",
+		},
+		{
+			name:    "Broken document",
+			ref:     "broken.md#install",
+			wantErr: "section doc -> broken.md:1:6: no END SECTION for install",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "<!-- BEGIN SECTION doc ref=" + tt.ref + " -->\n<!-- END SECTION doc -->\n"
+			sections, err := FindSections(content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Replace([]byte(content), sections, Options{BaseDir: tmpDir})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := "<!-- BEGIN SECTION doc ref=" + tt.ref + " -->\n" + tt.want + "\n<!-- END SECTION doc -->\n"
+			if string(got) != want {
+				t.Errorf("Expected %q, got %q", want, got)
+			}
+		})
+	}
+
+	// the failures of the referenced document are include errors
+	content := "<!-- BEGIN SECTION doc ref=broken.md#install -->\n<!-- END SECTION doc -->\n"
+	sections, _ := FindSections(content, reBegin, reEnd)
+	_, err := Replace([]byte(content), sections, Options{BaseDir: tmpDir})
+	var include *IncludeError
+	if !errors.As(err, &include) || include.Source != "broken.md" {
+		t.Errorf("Expected an include error of broken.md, got %v", err)
+	}
+}
@@ -0,0 +1,151 @@
+package gosect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Resolver returns the content of a file=scheme:ref source, given the section
+// and the ref part of its source
+type Resolver func(s Section, ref string) ([]byte, error)
+
+// reScheme matches the scheme of a file= source. Schemes have at least two
+// characters, so that Windows drive letters remain paths.
+var reScheme = regexp.MustCompile(`^([A-Za-z][A-Za-z0-9+.-]+):(.*)$`)
+
+// sourceScheme returns the scheme and ref of the file= source of s, when it
+// has a scheme
+func sourceScheme(s Section) (string, string, bool) {
+	m := reScheme.FindStringSubmatch(s.SrcFile)
+	if m == nil {
+		return "", "", false
+	}
+
+	return m[1], m[2], true
+}
+
+// PluginPrefix prefixes the name of the executables resolving the sources of
+// a scheme: file=confluence:42 is read by gosect-resolver-confluence
+const PluginPrefix = "gosect-resolver-"
+
+// PluginRequest is the JSON document a resolver plugin reads on its standard
+// input. The plugin writes the content of the source on its standard output.
+type PluginRequest struct {
+	Section string            `json:"section"`
+	Scheme  string            `json:"scheme"`
+	Ref     string            `json:"ref"`
+	Attrs   map[string]string `json:"attrs"`
+}
+
+// PluginResolver returns the resolver running the plugin executable at path
+func PluginResolver(path string) Resolver {
+	return Options{}.pluginResolver(path)
+}
+
+// pluginResolver returns the resolver running the plugin executable at path,
+// killed after CommandTimeout
+func (opts Options) pluginResolver(path string) Resolver {
+	return func(s Section, ref string) ([]byte, error) {
+		scheme, _, _ := sourceScheme(s)
+		request, err := json.Marshal(PluginRequest{Section: s.Name, Scheme: scheme, Ref: ref, Attrs: s.Attrs})
+		if err != nil {
+			return nil, err
+		}
+
+		var stdout, stderr bytes.Buffer
+		ctx, cancel := opts.commandContext()
+		defer cancel()
+		cmd := exec.CommandContext(ctx, path)
+		cmd.Stdin = bytes.NewReader(request)
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := opts.runTimed(ctx, cmd); err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				err = fmt.Errorf("%w: %s", err, msg)
+			}
+			return nil, fmt.Errorf("plugin %s: %w", path, err)
+		}
+
+		return stdout.Bytes(), nil
+	}
+}
+
+// resolver returns the resolver of scheme: a registered resolver, else with
+// Plugins the gosect-resolver-<scheme> executable found in PATH
+func (opts Options) resolver(scheme string) (Resolver, bool) {
+	if resolve, ok := opts.Resolvers[scheme]; ok {
+		return resolve, true
+	}
+	if !opts.Plugins || scheme == "git" {
+		return nil, false
+	}
+	path, ok := lookPlugin(scheme)
+	if !ok {
+		return nil, false
+	}
+
+	return opts.pluginResolver(path), true
+}
+
+// pluginPaths caches the paths of plugin executables, empty when missing, by
+// name and PATH: watch mode looks the sources of every target up on each poll
+var pluginPaths sync.Map
+
+// lookPlugin returns the path of the plugin executable of scheme in PATH
+func lookPlugin(scheme string) (string, bool) {
+	name := PluginPrefix + scheme
+	key := name + "\x00" + os.Getenv("PATH")
+	if cached, ok := pluginPaths.Load(key); ok {
+		path := cached.(string)
+		return path, path != ""
+	}
+
+	path, err := exec.LookPath(name)
+	if err != nil {
+		path = ""
+	}
+	pluginPaths.Store(key, path)
+
+	return path, path != ""
+}
+
+// SchemeSource reports whether the file= source of s is read from git or by a
+// resolver, rather than from a local path
+func (opts Options) SchemeSource(s Section) bool {
+	scheme, _, ok := sourceScheme(s)
+	if !ok {
+		return false
+	}
+	if scheme == "git" {
+		return true
+	}
+	_, ok = opts.resolver(scheme)
+
+	return ok
+}
+
+// resolve reads the source of s with the resolver of its scheme. It reports
+// false when no resolver handles the source.
+func (opts Options) resolve(s Section) ([]byte, bool, error) {
+	scheme, ref, ok := sourceScheme(s)
+	if !ok {
+		return nil, false, nil
+	}
+	resolve, ok := opts.resolver(scheme)
+	if !ok {
+		return nil, false, nil
+	}
+
+	data, err := resolve(s, ref)
+	if err != nil {
+		return nil, true, fmt.Errorf("section %s: %s: %w", s.Name, s.SrcFile, err)
+	}
+
+	return data, true, nil
+}
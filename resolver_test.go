@@ -0,0 +1,189 @@
+package gosect
+
+import (
+	"bytes"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test functional options and custom resolvers
+// /////////////////////////////////////////////////////////////////////////////
+func TestFunctionalOptions(t *testing.T) {
+	wiki := func(s Section, ref string) ([]byte, error) {
+		if ref == "missing" {
+			return nil, errors.New("page not found")
+		}
+		return []byte("page " + ref + " of " + s.Name), nil
+	}
+	reverse := func(src []byte) []byte {
+		out := []byte(string(src))
+		for i, j := 0, len(out)-1; i < j; i, j = i+1, j-1 {
+			out[i], out[j] = out[j], out[i]
+		}
+		return out
+	}
+
+	var logs bytes.Buffer
+	opts := NewOptions(
+		WithMarkers("BEGIN SNIPPET", "END SNIPPET"),
+		WithResolver("wiki", wiki),
+		WithTransforms(map[string]func([]byte) []byte{"reverse": reverse}),
+		WithLogger(slog.New(slog.NewTextHandler(&logs, nil))),
+	)
+	if opts.ReBegin.String() == reBegin.String() || opts.Logger == nil {
+		t.Fatalf("Expected markers and logger to be set, got %+v", opts)
+	}
+
+	tests := []struct {
+		name    string
+		content string
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "Resolver",
+			content: "BEGIN SECTION a file=wiki:42\nEND SECTION a\n",
+			want:    "BEGIN SECTION a file=wiki:42\n\npage 42 of a\n\nEND SECTION a\n",
+		},
+		{
+			name:    "Custom transform",
+			content: "BEGIN SECTION a file=wiki:42 transform=reverse,upper\nEND SECTION a\n",
+			want:    "BEGIN SECTION a file=wiki:42 transform=reverse,upper\n\nA FO 24 EGAP\n\nEND SECTION a\n",
+		},
+		{
+			name:    "Resolver error",
+			content: "BEGIN SECTION a file=wiki:missing\nEND SECTION a\n",
+			wantErr: "section a: wiki:missing: page not found",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := FindSections(tt.content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Replace([]byte(tt.content), sections, opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	// Options built from opts do not change it
+	other := NewOptions(WithResolver("wiki", wiki))
+	WithResolver("docs", wiki)(&other)
+	if len(opts.Resolvers) != 1 || len(other.Resolvers) != 2 {
+		t.Errorf("Expected 1 and 2 resolvers, got %d and %d", len(opts.Resolvers), len(other.Resolvers))
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test resolver plugins found in PATH
+// /////////////////////////////////////////////////////////////////////////////
+func TestPluginResolver(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts not supported")
+	}
+
+	tmpDir := t.TempDir()
+	plugin := "#!/bin/sh\ncat\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, PluginPrefix+"echo"), []byte(plugin), 0755); err != nil {
+		t.Fatal(err)
+	}
+	failing := "#!/bin/sh\necho 'page not found' >&2\nexit 1\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, PluginPrefix+"fail"), []byte(failing), 0755); err != nil {
+		t.Fatal(err)
+	}
+	slow := "#!/bin/sh\nsleep 10\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, PluginPrefix+"slow"), []byte(slow), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", tmpDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	tests := []struct {
+		name    string
+		content string
+		opts    Options
+		want    string
+		wantErr string
+	}{
+		{
+			name:    "Plugin",
+			content: "BEGIN SECTION a file=echo:42 lang=txt\nEND SECTION a\n",
+			opts:    Options{Plugins: true},
+			want:    `{"section":"a","scheme":"echo","ref":"42","attrs":{"file":"echo:42","lang":"txt"}}`,
+		},
+		{
+			name:    "Registered resolver first",
+			content: "BEGIN SECTION a file=echo:42\nEND SECTION a\n",
+			opts:    NewOptions(WithResolver("echo", func(Section, string) ([]byte, error) { return []byte("registered"), nil }), func(opts *Options) { opts.Plugins = true }),
+			want:    "registered",
+		},
+		{
+			name:    "Plugin error",
+			content: "BEGIN SECTION a file=fail:42\nEND SECTION a\n",
+			opts:    Options{Plugins: true},
+			wantErr: "page not found",
+		},
+		{
+			name:    "Plugin timeout",
+			content: "BEGIN SECTION a file=slow:42\nEND SECTION a\n",
+			opts:    Options{Plugins: true, CommandTimeout: 100 * time.Millisecond},
+			wantErr: "timed out after 100ms",
+		},
+		{
+			name:    "Plugins disabled",
+			content: "BEGIN SECTION a file=echo:42\nEND SECTION a\n",
+			wantErr: "echo:42: no such file or directory",
+		},
+		{
+			name:    "No plugin",
+			content: "BEGIN SECTION a file=other:42\nEND SECTION a\n",
+			opts:    Options{Plugins: true},
+			wantErr: "other:42: no such file or directory",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := FindSections(tt.content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := Replace([]byte(tt.content), sections, tt.opts)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !strings.Contains(string(got), "\n"+tt.want+"\n") {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Review annotations recorded on END markers by Options.Reviewer
+const (
+	ReviewedByAttr = "reviewed-by"
+	ReviewHashAttr = "review-hash"
+)
+
+// reviewEnd verifies that the new body of s matches the review hash recorded
+// on its END marker, so that reviewed sections cannot change unnoticed. With
+// Options.Reviewer, it instead returns the END marker attributes stamped with
+// the reviewer and the review hash of body, and their span in content.
+func (opts Options) reviewEnd(content []byte, s Section, body []byte) ([]byte, int, int, error) {
+	hash := BodyChecksum(body)
+
+	if opts.Reviewer == "" {
+		recorded, ok := s.EndAttrs[ReviewHashAttr]
+		if ok && recorded != hash {
+			return nil, 0, 0, fmt.Errorf("section %s changed since it was reviewed by %s (review it with gosect review)", s.Name, s.EndAttrs[ReviewedByAttr])
+		}
+		return nil, 0, 0, nil
+	}
+
+	start, end, err := opts.endMarkerAttrs(content, s)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+
+	attrs := setAttr(content[start:end], ReviewedByAttr, opts.Reviewer)
+	attrs = setAttr(attrs, ReviewHashAttr, hash)
+
+	return attrs, start, end, nil
+}
+
+// endMarkerAttrs returns the span in content of the attribute list of the END
+// marker of s, as matched by the END regex the section was found with
+func (opts Options) endMarkerAttrs(content []byte, s Section) (int, int, error) {
+	if s.endAttrsEnd > 0 && s.endAttrsStart >= s.EndIdx && s.endAttrsEnd <= len(content) {
+		return s.endAttrsStart, s.endAttrsEnd, nil
+	}
+
+	// section built by the caller, find the marker again
+	_, reEnd := opts.markers()
+	loc := markerLoc(reEnd, reEnd.FindSubmatchIndex(content[s.EndIdx:]))
+	if loc == nil || bytes.IndexByte(content[s.EndIdx:s.EndIdx+loc[0]], '\n') != -1 {
+		return 0, 0, fmt.Errorf("malformed END line for section %s", s.Name)
+	}
+	if loc[4] == -1 {
+		return 0, 0, fmt.Errorf("section %s: the END marker has no attribute list to record the review in (the marker regex needs a (?P<attrs>...) group)", s.Name)
+	}
+
+	return s.EndIdx + loc[4], s.EndIdx + loc[5], nil
+}
@@ -0,0 +1,69 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test review annotations on END markers
+// /////////////////////////////////////////////////////////////////////////////
+func TestReview(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "legal.txt")
+	if err := os.WriteFile(source, []byte("Terms v1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "<!-- BEGIN SECTION legal file=" + source + " -->\n<!-- END SECTION legal -->\n"
+	reviewed := replaceAll(t, content, Options{Reviewer: "alice"})
+	hash := BodyChecksum([]byte("\nTerms v1\n\n"))
+	want := "<!-- END SECTION legal reviewed-by=alice review-hash=" + hash + " -->\n"
+	if !strings.HasSuffix(reviewed, want) {
+		t.Fatalf("Expected END marker %q, got %q", want, reviewed)
+	}
+
+	sections, err := FindSections(reviewed, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sections[0].EndAttrs[ReviewedByAttr] != "alice" || sections[0].EndAttrs[ReviewHashAttr] != hash {
+		t.Errorf("Expected review annotations, got %v", sections[0].EndAttrs)
+	}
+
+	// unchanged content passes the review check
+	if result := replaceAll(t, reviewed, Options{}); result != reviewed {
+		t.Errorf("Expected %q, got %q", reviewed, result)
+	}
+
+	// changed content requires a new review
+	if err := os.WriteFile(source, []byte("Terms v2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Replace([]byte(reviewed), sections, Options{}); err == nil || !strings.Contains(err.Error(), "reviewed by alice") {
+		t.Errorf("Expected review error, got %v", err)
+	}
+
+	rereviewed := replaceAll(t, reviewed, Options{Reviewer: "bob"})
+	want = "<!-- END SECTION legal reviewed-by=bob review-hash=" + BodyChecksum([]byte("\nTerms v2\n\n")) + " -->\n"
+	if !strings.HasSuffix(rereviewed, want) {
+		t.Errorf("Expected END marker %q, got %q", want, rereviewed)
+	}
+
+	// the END marker is matched with the regex the section was found with
+	content = "# START legal file=" + source + "\n# STOP legal\n"
+	begin, end := MakeRegex("# START", "# STOP")
+	sections, err = FindSections(content, begin, end)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := Replace([]byte(content), sections, Options{Reviewer: "carol"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "# STOP legal reviewed-by=carol review-hash=" + BodyChecksum([]byte("\nTerms v2\n\n")) + "\n"
+	if !strings.HasSuffix(string(result), want) {
+		t.Errorf("Expected END marker %q, got %q", want, result)
+	}
+}
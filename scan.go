@@ -0,0 +1,412 @@
+package gosect
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// foundMarker is a BEGIN or END marker found on a line
+type foundMarker struct {
+	begin bool
+	name  string
+	loc   []int // submatch indexes in the line, laid out by markerLoc
+	line  markerLine
+}
+
+// at returns the offset in the document of the submatch index i of the
+// marker, or -1 when its group did not match
+func (m foundMarker) at(i int) int {
+	if m.loc[i] == -1 {
+		return -1
+	}
+
+	return m.line.start + m.loc[i]
+}
+
+// span returns the span of the submatch indexes i and i+1 of the marker
+func (m foundMarker) span(i int) Span {
+	return m.line.span(m.loc[i], m.loc[i+1])
+}
+
+// errorf returns an error located at the marker
+func (m foundMarker) errorf(format string, args ...any) *PositionError {
+	p := m.line.position(m.loc[0])
+
+	return &PositionError{Line: p.Line, Column: p.Column, Err: fmt.Errorf(format, args...)}
+}
+
+// lineMarkers returns the BEGIN and END markers of line in order of
+// appearance
+func lineMarkers(line markerLine, reBegin, reEnd *regexp.Regexp) []foundMarker {
+	var markers []foundMarker
+	for _, loc := range reBegin.FindAllSubmatchIndex(line.text, -1) {
+		loc = markerLoc(reBegin, loc)
+		markers = append(markers, foundMarker{begin: true, name: string(line.text[loc[2]:loc[3]]), loc: loc, line: line})
+	}
+	for _, loc := range reEnd.FindAllSubmatchIndex(line.text, -1) {
+		loc = markerLoc(reEnd, loc)
+		markers = append(markers, foundMarker{begin: false, name: string(line.text[loc[2]:loc[3]]), loc: loc, line: line})
+	}
+	slices.SortStableFunc(markers, func(a, b foundMarker) int { return a.loc[0] - b.loc[0] })
+
+	return markers
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// pair BEGIN and END markers
+// /////////////////////////////////////////////////////////////////////////////
+
+// pairer pairs the markers of a document, read in order, like brackets: an
+// END marker closes the innermost open section of its name. END markers
+// without an open section are left to CheckMarkers.
+type pairer struct {
+	reBegin *regexp.Regexp
+	open    []foundMarker
+	crossed []crossing
+	errs    []error
+}
+
+// crossing is a section still open when the END marker end of a section
+// enclosing it was read: both overlap when its own END marker follows, else
+// it has none
+type crossing struct {
+	inner, end foundMarker
+}
+
+// add pairs the marker m and returns the section it ends, if any
+func (p *pairer) add(m foundMarker) (Section, bool) {
+	if m.begin {
+		if j := slices.IndexFunc(p.open, func(o foundMarker) bool { return o.name == m.name }); j != -1 {
+			p.errs = append(p.errs, m.errorf("repeated section %s begins inside section %s (BEGIN at line %d)", m.name, m.name, p.open[j].line.number))
+		}
+		p.open = append(p.open, m)
+		return Section{}, false
+	}
+
+	p.crossed = slices.DeleteFunc(p.crossed, func(c crossing) bool {
+		if c.inner.name != m.name {
+			return false
+		}
+		p.errs = append(p.errs, c.end.errorf("sections %s and %s overlap: END SECTION %s comes before END SECTION %s (BEGIN at line %d)", c.end.name, c.inner.name, c.end.name, c.inner.name, c.inner.line.number))
+		return true
+	})
+
+	j := len(p.open) - 1
+	for j >= 0 && p.open[j].name != m.name {
+		j--
+	}
+	if j == -1 {
+		return Section{}, false
+	}
+
+	// the sections opened after the ending one overlap it, or are not
+	// closed at all
+	for _, inner := range p.open[j+1:] {
+		p.crossed = append(p.crossed, crossing{inner: inner, end: m})
+	}
+	b := p.open[j]
+	p.open = p.open[:j]
+
+	return p.section(b, m), true
+}
+
+// failed reports whether the markers read so far are in error
+func (p *pairer) failed() bool {
+	return len(p.errs) > 0 || len(p.crossed) > 0
+}
+
+// finish returns the errors of the markers once all of them are read, in
+// document order
+func (p *pairer) finish() error {
+	for _, c := range p.crossed {
+		p.errs = append(p.errs, c.inner.errorf("no END SECTION for %s", c.inner.name))
+	}
+	for _, o := range p.open {
+		p.errs = append(p.errs, o.errorf("no END SECTION for %s", o.name))
+	}
+
+	slices.SortStableFunc(p.errs, func(a, b error) int {
+		pa, pb := a.(*PositionError), b.(*PositionError)
+		return cmp.Or(pa.Line-pb.Line, pa.Column-pb.Column)
+	})
+
+	return errors.Join(p.errs...)
+}
+
+// section returns the section delimited by the BEGIN marker b and the END
+// marker e
+func (p *pairer) section(b, e foundMarker) Section {
+	s := newSection(e.name, string(submatch(b.line.text, b.loc, 2)))
+	groupAttrs(&s, p.reBegin, b.line.text, b.loc)
+	s.StartIdx = b.at(0)
+	s.EndIdx = e.at(0)
+	s.EndAttrs = parseAttrs(string(submatch(e.line.text, e.loc, 2)))
+	s.attrsStart, s.attrsEnd = b.at(4), b.at(5)
+	s.endAttrsStart, s.endAttrsEnd = e.at(4), e.at(5)
+	s.Pos = markerPositions(b, e)
+
+	return s
+}
+
+// lineScanner finds and pairs the markers of a document read line by line
+type lineScanner struct {
+	pairer
+	reEnd                  *regexp.Regexp
+	beginPrefix, endPrefix []byte
+	line                   markerLine // last line read
+}
+
+// newLineScanner returns a scanner of the markers matched by reBegin and
+// reEnd, which match within a line
+func newLineScanner(reBegin, reEnd *regexp.Regexp) *lineScanner {
+	beginPrefix, _ := reBegin.LiteralPrefix()
+	endPrefix, _ := reEnd.LiteralPrefix()
+
+	return &lineScanner{
+		pairer:      pairer{reBegin: reBegin},
+		reEnd:       reEnd,
+		beginPrefix: []byte(beginPrefix),
+		endPrefix:   []byte(endPrefix),
+	}
+}
+
+// scan reads the next line text of the document and returns the sections
+// its markers end, none once the markers are in error
+func (sc *lineScanner) scan(text []byte) []Section {
+	sc.line = markerLine{text: text, start: sc.line.start + len(sc.line.text), number: sc.line.number + 1}
+
+	// skip the regexes on lines without marker prefix, and END markers when
+	// no section is open or crossed
+	waiting := len(sc.open) > 0 || len(sc.crossed) > 0
+	if !bytes.Contains(text, sc.beginPrefix) && (!waiting || !bytes.Contains(text, sc.endPrefix)) {
+		return nil
+	}
+
+	var ended []Section
+	for _, m := range lineMarkers(sc.line, sc.reBegin, sc.reEnd) {
+		if s, ok := sc.add(m); ok && !sc.failed() {
+			ended = append(ended, s)
+		}
+	}
+
+	return ended
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// stream sections from a reader
+// /////////////////////////////////////////////////////////////////////////////
+
+// Scan reads r line by line and lazily yields each section delimited by the
+// default markers as soon as its END marker is read. Nested sections are
+// yielded before the section enclosing them. Content holds the lines between
+// the BEGIN and END marker lines. Markers pair as in FindSections: once a
+// marker is in error, no more section is yielded and the errors, located at
+// the offending markers, are yielded at the end of r.
+func Scan(r io.Reader) iter.Seq2[Section, error] {
+	return ScanMarkers(r, reBegin, reEnd)
+}
+
+// ScanMarkers is like Scan but uses the given BEGIN and END regexes, as built
+// by MakeRegex
+func ScanMarkers(r io.Reader, reBegin, reEnd *regexp.Regexp) iter.Seq2[Section, error] {
+	return func(yield func(Section, error) bool) {
+		br := bufio.NewReader(r)
+		sc := newLineScanner(reBegin, reEnd)
+		bodies := map[int]*strings.Builder{} // by BEGIN offset of open sections
+
+		for {
+			text, err := br.ReadBytes('\n')
+			if len(text) > 0 {
+				for _, s := range sc.scan(text) {
+					if body := bodies[s.StartIdx]; body != nil {
+						s.Content = body.String()
+						delete(bodies, s.StartIdx)
+					}
+					if !yield(s, nil) {
+						return
+					}
+				}
+
+				// accumulate the line in every section still open after it
+				if !sc.failed() {
+					for _, o := range sc.open {
+						if o.line.number < sc.line.number {
+							if bodies[o.at(0)] == nil {
+								bodies[o.at(0)] = &strings.Builder{}
+							}
+							bodies[o.at(0)].Write(text)
+						}
+					}
+				}
+			}
+
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				yield(Section{}, err)
+				return
+			}
+		}
+
+		if err := sc.finish(); err != nil {
+			yield(Section{}, err)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// replace sections of a stream
+// /////////////////////////////////////////////////////////////////////////////
+
+// ReplaceStream is like Replace on the sections found by FindSections, but
+// reads the document from r line by line and writes the result to w as it
+// goes: only the lines of the section being read are held in memory, which
+// bounds the memory used by the largest section rather than the document.
+// Each section is rendered by Replace, using the line endings of its lines.
+// Sections with a src= pseudo-source, generated from the whole document,
+// fail. On error, w holds the document up to the failed section; with
+// Options.KeepGoing failed sections are copied unchanged and their
+// SectionErrors returned.
+func ReplaceStream(r io.Reader, w io.Writer, reBegin, reEnd *regexp.Regexp, opts Options) error {
+	br := bufio.NewReader(r)
+	bw := bufio.NewWriter(w)
+	sc := newLineScanner(reBegin, reEnd)
+	var held bytes.Buffer // lines of the sections being read
+	var heldLine markerLine
+	var sections []Section
+	var failed SectionErrors
+
+	for {
+		text, err := br.ReadBytes('\n')
+		if len(text) > 0 {
+			wasOpen := len(sc.open) > 0
+			ended := sc.scan(text)
+			switch {
+			case sc.failed():
+				// keep reading to report every marker error
+			case !wasOpen && len(sc.open) == 0 && len(ended) == 0:
+				if _, err := bw.Write(text); err != nil {
+					return err
+				}
+			default:
+				if held.Len() == 0 {
+					heldLine = sc.line
+				}
+				held.Write(text)
+				sections = append(sections, ended...)
+				if len(sc.open) > 0 {
+					break
+				}
+
+				// render the sections once the outermost one ends
+				out, err := opts.replaceHeld(held.Bytes(), sections, heldLine)
+				if errs, ok := err.(SectionErrors); ok && opts.KeepGoing {
+					failed = append(failed, errs...)
+				} else if err != nil {
+					return cmp.Or(bw.Flush(), err)
+				}
+				if _, err := bw.Write(out); err != nil {
+					return err
+				}
+				held.Reset()
+				sections = sections[:0]
+			}
+		}
+
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if err := sc.finish(); err != nil {
+		return cmp.Or(bw.Flush(), err)
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	if len(failed) > 0 {
+		return failed
+	}
+
+	return nil
+}
+
+// replaceHeld renders the sections of content, the lines of the document
+// from the line first, whose offsets are located in the document. The
+// sections and errors are moved to content and back.
+func (opts Options) replaceHeld(content []byte, sections []Section, first markerLine) ([]byte, error) {
+	var local []Section
+	var errs SectionErrors
+	for _, s := range sections {
+		if builtinSource(s) && !Frozen(s) {
+			p := s.Pos.Begin.Start
+			errs = append(errs, &PositionError{Line: p.Line, Column: p.Column, Err: fmt.Errorf("section %s: src=%s needs the whole document", s.Name, s.Attrs["src"])})
+			continue
+		}
+		local = append(local, s.moved(-first.start, 1-first.number))
+	}
+	slices.SortFunc(local, func(a, b Section) int { return a.StartIdx - b.StartIdx })
+
+	out, err := opts.replace(content, local, nil)
+	err = shiftLines(err, first.number-1)
+	if len(errs) == 0 {
+		return out, err
+	}
+
+	// src= sections fail like the sections Replace cannot render
+	if !opts.KeepGoing {
+		return nil, errors.Join(append(errs, err)...)
+	}
+	if e, ok := err.(SectionErrors); ok {
+		errs = append(errs, e...)
+	} else if err != nil {
+		return nil, err
+	}
+	if out == nil {
+		out = content
+	}
+
+	return out, errs
+}
+
+// shiftLines moves the position errors of err, which may join several
+// errors, down by lines. Errors located in other files are left unchanged.
+func shiftLines(err error, lines int) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *PositionError:
+		if e.File != "" {
+			return e
+		}
+		moved := *e
+		moved.Line += lines
+		return &moved
+	case SectionErrors:
+		errs := make(SectionErrors, len(e))
+		for i, err := range e {
+			errs[i] = shiftLines(err, lines)
+		}
+		return errs
+	case interface{ Unwrap() []error }:
+		var errs []error
+		for _, err := range e.Unwrap() {
+			errs = append(errs, shiftLines(err, lines))
+		}
+		return errors.Join(errs...)
+	}
+
+	return err
+}
@@ -0,0 +1,370 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test Scan function
+// /////////////////////////////////////////////////////////////////////////////
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name         string
+		content      string
+		wantNames    []string
+		wantContents []string
+		wantError    string
+	}{
+		{
+			name: "Single section",
+			content: `Header
+<!-- BEGIN SECTION test file=test.txt -->
+content here
+<!-- END SECTION test -->
+Footer`,
+			wantNames:    []string{"test"},
+			wantContents: []string{"content here\n"},
+		},
+		{
+			name: "Multiple sections",
+			content: `<!-- BEGIN SECTION first file=a.txt -->
+content1
+<!-- END SECTION first -->
+<!-- BEGIN SECTION second file=b.txt -->
+content2
+<!-- END SECTION second -->
+`,
+			wantNames:    []string{"first", "second"},
+			wantContents: []string{"content1\n", "content2\n"},
+		},
+		{
+			name: "Nested sections yield inner first",
+			content: `<!-- BEGIN SECTION outer -->
+a
+<!-- BEGIN SECTION inner -->
+b
+<!-- END SECTION inner -->
+<!-- END SECTION outer -->`,
+			wantNames:    []string{"inner", "outer"},
+			wantContents: []string{"b\n", "a\n<!-- BEGIN SECTION inner -->\nb\n<!-- END SECTION inner -->\n"},
+		},
+		{
+			name: "No END marker",
+			content: `<!-- BEGIN SECTION test file=test.txt -->
+content here`,
+			wantError: "1:6: no END SECTION for test",
+		},
+		{
+			name:      "Every unclosed section",
+			content:   "BEGIN SECTION a\nBEGIN SECTION b\nx\n",
+			wantError: "1:1: no END SECTION for a\n2:1: no END SECTION for b",
+		},
+		{
+			name:      "Overlapping sections",
+			content:   "BEGIN SECTION a\nBEGIN SECTION b\nEND SECTION a\nEND SECTION b\n",
+			wantError: "3:1: sections a and b overlap: END SECTION a comes before END SECTION b (BEGIN at line 2)",
+		},
+		{
+			name:      "Repeated name inside itself",
+			content:   "BEGIN SECTION a\nBEGIN SECTION a\nEND SECTION a\nEND SECTION a\n",
+			wantError: "2:1: repeated section a begins inside section a (BEGIN at line 1)",
+		},
+		{
+			name:         "Sections before an error",
+			content:      "BEGIN SECTION a\n1\nEND SECTION a\nBEGIN SECTION b\nBEGIN SECTION c\nEND SECTION b\nEND SECTION c\n",
+			wantNames:    []string{"a"},
+			wantContents: []string{"1\n"},
+			wantError:    "6:1: sections b and c overlap: END SECTION b comes before END SECTION c (BEGIN at line 5)",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names, contents []string
+			var scanErr error
+			for s, err := range Scan(strings.NewReader(tt.content)) {
+				if err != nil {
+					scanErr = err
+					continue
+				}
+				names = append(names, s.Name)
+				contents = append(contents, s.Content)
+			}
+
+			if tt.wantError != "" {
+				if scanErr == nil || scanErr.Error() != tt.wantError {
+					t.Errorf("Expected error %q, got %v", tt.wantError, scanErr)
+				}
+			} else if scanErr != nil {
+				t.Fatalf("Unexpected error: %v", scanErr)
+			}
+
+			if strings.Join(names, ",") != strings.Join(tt.wantNames, ",") {
+				t.Errorf("Expected sections %v, got %v", tt.wantNames, names)
+			}
+
+			for i := range contents {
+				if i < len(tt.wantContents) && contents[i] != tt.wantContents[i] {
+					t.Errorf("Section %s: expected content %q, got %q", names[i], tt.wantContents[i], contents[i])
+				}
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test Scan offsets and positions match FindSections
+// /////////////////////////////////////////////////////////////////////////////
+func TestScanOffsets(t *testing.T) {
+	content := `# Doc
+<!-- BEGIN SECTION a file=a.txt -->
+old
+<!-- END SECTION a reviewed=2024-01-02 -->
+text
+<!-- BEGIN SECTION b file=b.txt -->
+<!-- END SECTION b -->
+`
+	want, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := 0
+	for s, err := range Scan(strings.NewReader(content)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := want[i]
+		if s.Name != w.Name || s.StartIdx != w.StartIdx || s.EndIdx != w.EndIdx || s.SrcFile != w.SrcFile {
+			t.Errorf("Section %d: expected %+v, got %+v", i, w, s)
+		}
+		if !reflect.DeepEqual(s.Pos, w.Pos) || !reflect.DeepEqual(s.EndAttrs, w.EndAttrs) {
+			t.Errorf("Section %d: expected positions %+v and END attributes %v, got %+v and %v", i, w.Pos, w.EndAttrs, s.Pos, s.EndAttrs)
+		}
+		i++
+	}
+
+	if i != len(want) {
+		t.Errorf("Expected %d sections, got %d", len(want), i)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test Scan stops when the consumer breaks
+// /////////////////////////////////////////////////////////////////////////////
+func TestScanEarlyBreak(t *testing.T) {
+	content := strings.Repeat("<!-- BEGIN SECTION s -->\nx\n<!-- END SECTION s -->\n", 10)
+
+	count := 0
+	for _, err := range Scan(strings.NewReader(content)) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		count++
+		if count == 3 {
+			break
+		}
+	}
+
+	if count != 3 {
+		t.Errorf("Expected to stop after 3 sections, got %d", count)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test ReplaceStream renders like Replace
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceStream(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("new a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		content   string
+		keepGoing bool
+		want      string
+		wantError string
+	}{
+		{
+			name:    "Sections and text",
+			content: "# Doc\nBEGIN SECTION a file=a.txt\nold\nEND SECTION a\ntext\nBEGIN SECTION b file=a.txt indent=2\nEND SECTION b\n",
+			want:    "# Doc\nBEGIN SECTION a file=a.txt\n\nnew a\n\nEND SECTION a\ntext\nBEGIN SECTION b file=a.txt indent=2\n\n  new a\n\nEND SECTION b\n",
+		},
+		{
+			name:    "Inline section",
+			content: "a <!-- BEGIN SECTION a file=a.txt -->old<!-- END SECTION a --> b\n",
+			want:    "a <!-- BEGIN SECTION a file=a.txt -->new a<!-- END SECTION a --> b\n",
+		},
+		{
+			name:    "Orphaned END marker",
+			content: "END SECTION a\nBEGIN SECTION a file=a.txt\nEND SECTION a",
+			want:    "END SECTION a\nBEGIN SECTION a file=a.txt\n\nnew a\n\nEND SECTION a",
+		},
+		{
+			name:      "Error located in the document",
+			content:   "1\n2\nBEGIN SECTION a file=a.txt\nEND SECTION a\nBEGIN SECTION b file=missing.txt\nEND SECTION b\n",
+			want:      "1\n2\nBEGIN SECTION a file=a.txt\n\nnew a\n\nEND SECTION a\n",
+			wantError: "5:1: section b: ",
+		},
+		{
+			name:      "Keep going",
+			content:   "1\nBEGIN SECTION b file=missing.txt\nold\nEND SECTION b\nBEGIN SECTION a file=a.txt\nEND SECTION a\n",
+			keepGoing: true,
+			want:      "1\nBEGIN SECTION b file=missing.txt\nold\nEND SECTION b\nBEGIN SECTION a file=a.txt\n\nnew a\n\nEND SECTION a\n",
+			wantError: "2:1: section b: ",
+		},
+		{
+			name:      "Whole document source",
+			content:   "# Doc\n\nBEGIN SECTION toc src=toc\nEND SECTION toc\n",
+			wantError: "3:1: section toc: src=toc needs the whole document",
+		},
+		{
+			name:      "Overlapping sections",
+			content:   "BEGIN SECTION a\nBEGIN SECTION b\nEND SECTION a\nEND SECTION b\n",
+			wantError: "3:1: sections a and b overlap",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := Options{BaseDir: dir, KeepGoing: tt.keepGoing}
+			var out strings.Builder
+			err := ReplaceStream(strings.NewReader(tt.content), &out, reBegin, reEnd, opts)
+
+			if tt.wantError != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.wantError) {
+					t.Errorf("Expected error %q, got %v", tt.wantError, err)
+				}
+			} else if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if tt.want == "" {
+				return
+			}
+			if out.String() != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, out.String())
+			}
+
+			// the same as Replace on the sections of the whole document
+			if err != nil && !tt.keepGoing {
+				return
+			}
+			sections, err := FindSections(tt.content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, _ := Replace([]byte(tt.content), sections, opts)
+			if string(result) != out.String() {
+				t.Errorf("Expected the result of Replace %q, got %q", result, out.String())
+			}
+		})
+	}
+}
+
+// largeDocument returns a document of n sections of file=a.txt, each
+// followed by a paragraph
+func largeDocument(n int) string {
+	var doc strings.Builder
+	for i := range n {
+		fmt.Fprintf(&doc, "## Part %d\n\nBEGIN SECTION s%d file=a.txt\nold\nEND SECTION s%d\n\n%s\n", i, i, i, strings.Repeat("text ", 40))
+	}
+
+	return doc.String()
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test finding and replacing the sections of large documents
+// /////////////////////////////////////////////////////////////////////////////
+func TestLargeDocument(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	content := largeDocument(5000)
+
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 5000 {
+		t.Fatalf("Expected 5000 sections, got %d", len(sections))
+	}
+	last := sections[len(sections)-1]
+	if last.Pos.Begin.Start.Line != 5000*7-4 {
+		t.Errorf("Expected the last section on line %d, got %d", 5000*7-4, last.Pos.Begin.Start.Line)
+	}
+
+	opts := Options{BaseDir: dir}
+	want, err := Replace([]byte(content), sections, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := ReplaceStream(strings.NewReader(content), &out, reBegin, reEnd, opts); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(out.Bytes(), want) {
+		t.Error("Expected ReplaceStream to render like Replace")
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test ReplaceStream writes rendered sections before the end of its input
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceStreamWritesAsItReads(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "a.txt"), []byte("new\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		err := ReplaceStream(inR, outW, reBegin, reEnd, Options{BaseDir: dir})
+		outW.CloseWithError(err)
+		done <- err
+	}()
+
+	// the first half of the document is rendered while the rest is unread
+	go func() {
+		_, _ = io.WriteString(inW, largeDocument(1000))
+	}()
+	first := make([]byte, 64<<10)
+	read := make(chan error, 1)
+	go func() {
+		_, err := io.ReadFull(outR, first)
+		read <- err
+	}()
+	select {
+	case err := <-read:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Expected output before the end of the input")
+	}
+	if !strings.Contains(string(first), "BEGIN SECTION s0 file=a.txt\n\nnew\n\nEND SECTION s0\n") {
+		t.Errorf("Expected the first section rendered, got %q", first[:200])
+	}
+
+	inW.Close()
+	if _, err := io.Copy(io.Discard, outR); err != nil {
+		t.Fatal(err)
+	}
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
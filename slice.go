@@ -0,0 +1,256 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// extract a part of the source
+// /////////////////////////////////////////////////////////////////////////////
+
+// parseLineRange parses a lines= attribute value: "N", "N-M", "N-" or "-M",
+// with 1-based inclusive line numbers. An end of 0 means the end of file.
+func parseLineRange(spec string) (int, int, error) {
+	from, to, isRange := strings.Cut(spec, "-")
+
+	start, end := 1, 0
+	var err error
+	if from != "" {
+		if start, err = strconv.Atoi(from); err != nil || start < 1 {
+			return 0, 0, fmt.Errorf("invalid lines=%s", spec)
+		}
+	}
+
+	switch {
+	case !isRange:
+		end = start
+	case to != "":
+		if end, err = strconv.Atoi(to); err != nil || end < start {
+			return 0, 0, fmt.Errorf("invalid lines=%s", spec)
+		}
+	}
+
+	return start, end, nil
+}
+
+// sliceLines returns lines start to end (1-based, inclusive, 0 for the end of
+// data) as a sub-slice of data
+func sliceLines(data []byte, start, end int) ([]byte, error) {
+	pos := 0
+	for line := 1; line < start; line++ {
+		next := bytes.IndexByte(data[pos:], '\n')
+		if next == -1 {
+			return nil, fmt.Errorf("line %d is beyond the end of the source (%d lines)", start, line)
+		}
+		pos += next + 1
+	}
+	if pos >= len(data) && start > 1 {
+		return nil, fmt.Errorf("line %d is beyond the end of the source (%d lines)", start, start-1)
+	}
+
+	if end == 0 {
+		return data[pos:], nil
+	}
+
+	stop := pos
+	for line := start; line <= end; line++ {
+		next := bytes.IndexByte(data[stop:], '\n')
+		if next == -1 {
+			return data[pos:], nil
+		}
+		stop += next + 1
+	}
+
+	return data[pos:stop], nil
+}
+
+// selectLines applies the lines= attribute of s to src
+func selectLines(s Section, src []byte) ([]byte, error) {
+	spec, ok := s.Attrs["lines"]
+	if !ok {
+		return src, nil
+	}
+
+	start, end, err := parseLineRange(spec)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %w", s.Name, err)
+	}
+
+	out, err := sliceLines(src, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %s: %w", s.Name, s.SrcFile, err)
+	}
+
+	return out, nil
+}
+
+// lineCount parses the head= or tail= attribute key of s, a positive number
+// of lines
+func lineCount(s Section, key string) (int, bool, error) {
+	value, ok := s.Attrs[key]
+	if !ok {
+		return 0, false, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, false, fmt.Errorf("section %s: invalid %s=%s", s.Name, key, value)
+	}
+
+	return n, true, nil
+}
+
+// selectHeadTail applies the head= and tail= attributes of s to src, keeping
+// only its first and last lines. The truncated= attribute inserts an
+// indicator in place of the removed lines: truncated=true for the default
+// "… N lines truncated", or its own text.
+func selectHeadTail(s Section, src []byte) ([]byte, error) {
+	head, hasHead, err := lineCount(s, "head")
+	if err != nil {
+		return nil, err
+	}
+	tail, hasTail, err := lineCount(s, "tail")
+	if err != nil {
+		return nil, err
+	}
+	if !hasHead && !hasTail {
+		return src, nil
+	}
+
+	lines := bytes.SplitAfter(src, []byte("\n"))
+	if len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+	if head+tail >= len(lines) {
+		return src, nil
+	}
+
+	var out bytes.Buffer
+	for _, line := range lines[:head] {
+		out.Write(line)
+	}
+	switch indicator := s.Attrs["truncated"]; indicator {
+	case "", "false":
+	case "true":
+		fmt.Fprintf(&out, "… %d lines truncated\n", len(lines)-head-tail)
+	default:
+		out.WriteString(indicator + "\n")
+	}
+	for _, line := range lines[len(lines)-tail:] {
+		out.Write(line)
+	}
+
+	return out.Bytes(), nil
+}
+
+// regionMarker reports whether line holds the marker followed by name, or
+// any name when name is empty
+func regionMarker(line []byte, marker, name string) bool {
+	idx := bytes.Index(line, []byte(marker))
+	if idx == -1 {
+		return false
+	}
+	if name == "" {
+		return true
+	}
+
+	fields := bytes.Fields(line[idx+len(marker):])
+
+	return len(fields) > 0 && string(fields[0]) == name
+}
+
+// extractRegion returns the lines between the begin and end region markers
+// named name. Region markers of nested regions are removed from the result.
+func extractRegion(data []byte, name, begin, end string) ([]byte, error) {
+	var out bytes.Buffer
+	inside := false
+	depth := 0
+
+	for len(data) > 0 {
+		line := data
+		if next := bytes.IndexByte(data, '\n'); next != -1 {
+			line = data[:next+1]
+		}
+		data = data[len(line):]
+
+		if !inside {
+			inside = regionMarker(line, begin, name)
+			continue
+		}
+
+		switch {
+		case regionMarker(line, end, ""):
+			if depth == 0 {
+				return out.Bytes(), nil
+			}
+			depth--
+		case regionMarker(line, begin, ""):
+			depth++
+		default:
+			out.Write(line)
+		}
+	}
+
+	if inside {
+		return nil, fmt.Errorf("region %s has no %s marker", name, end)
+	}
+
+	return nil, fmt.Errorf("region %s not found", name)
+}
+
+// selectRegion applies the region= attribute of s to src
+func (opts Options) selectRegion(s Section, src []byte) ([]byte, error) {
+	name, ok := s.Attrs["region"]
+	if !ok {
+		return src, nil
+	}
+
+	begin, end := opts.regionMarkers()
+	out, err := extractRegion(src, name, begin, end)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %s: %w", s.Name, s.SrcFile, err)
+	}
+
+	return out, nil
+}
+
+// stripFrontMatter removes a leading YAML front matter block delimited by
+// --- lines
+func stripFrontMatter(data []byte) []byte {
+	rest, ok := bytes.CutPrefix(data, []byte("---\n"))
+	if !ok {
+		return data
+	}
+
+	for pos := 0; pos < len(rest); {
+		line := rest[pos:]
+		next := bytes.IndexByte(line, '\n')
+		if next != -1 {
+			line = line[:next]
+		}
+		if string(bytes.TrimRight(line, "\r")) == "---" {
+			if next == -1 {
+				return rest[len(rest):]
+			}
+			return rest[pos+next+1:]
+		}
+		if next == -1 {
+			break
+		}
+		pos += next + 1
+	}
+
+	return data
+}
+
+// selectFrontMatter applies the frontmatter=strip attribute of s to src
+func selectFrontMatter(s Section, src []byte) []byte {
+	if s.Attrs["frontmatter"] != "strip" {
+		return src
+	}
+
+	return stripFrontMatter(src)
+}
@@ -0,0 +1,223 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test lines= attribute
+// /////////////////////////////////////////////////////////////////////////////
+func TestSelectLines(t *testing.T) {
+	src := []byte("one\ntwo\nthree\nfour\nfive\n")
+
+	tests := []struct {
+		name      string
+		spec      string
+		want      string
+		wantError bool
+	}{
+		{name: "Range", spec: "2-4", want: "two\nthree\nfour\n"},
+		{name: "Single line", spec: "3", want: "three\n"},
+		{name: "Open end", spec: "4-", want: "four\nfive\n"},
+		{name: "Open start", spec: "-2", want: "one\ntwo\n"},
+		{name: "End beyond file", spec: "4-10", want: "four\nfive\n"},
+		{name: "Start beyond file", spec: "6-7", wantError: true},
+		{name: "Reversed range", spec: "4-2", wantError: true},
+		{name: "Not a number", spec: "a-b", wantError: true},
+		{name: "Zero line", spec: "0-2", wantError: true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Section{Name: "test", Attrs: map[string]string{"lines": tt.spec}}
+			got, err := selectLines(s, src)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("Expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test head= and tail= attributes
+// /////////////////////////////////////////////////////////////////////////////
+func TestSelectHeadTail(t *testing.T) {
+	src := []byte("one\ntwo\nthree\nfour\nfive\n")
+
+	tests := []struct {
+		name      string
+		attrs     map[string]string
+		want      string
+		wantError bool
+	}{
+		{name: "Head", attrs: map[string]string{"head": "2"}, want: "one\ntwo\n"},
+		{name: "Tail", attrs: map[string]string{"tail": "2"}, want: "four\nfive\n"},
+		{name: "Head and tail", attrs: map[string]string{"head": "1", "tail": "1"}, want: "one\nfive\n"},
+		{name: "Longer than source", attrs: map[string]string{"head": "3", "tail": "2"}, want: "one\ntwo\nthree\nfour\nfive\n"},
+		{name: "Indicator", attrs: map[string]string{"head": "2", "truncated": "true"}, want: "one\ntwo\n… 3 lines truncated\n"},
+		{name: "Custom indicator", attrs: map[string]string{"head": "1", "tail": "1", "truncated": "[...]"}, want: "one\n[...]\nfive\n"},
+		{name: "No indicator without truncation", attrs: map[string]string{"tail": "5", "truncated": "true"}, want: "one\ntwo\nthree\nfour\nfive\n"},
+		{name: "Zero lines", attrs: map[string]string{"head": "0"}, wantError: true},
+		{name: "Not a number", attrs: map[string]string{"tail": "all"}, wantError: true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Section{Name: "test", Attrs: tt.attrs}
+			got, err := selectHeadTail(s, src)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("Expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test lines= attribute end-to-end
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceLines(t *testing.T) {
+	tmpDir := t.TempDir()
+	sourceFile := filepath.Join(tmpDir, "main.go")
+	err := os.WriteFile(sourceFile, []byte("package main\n\nfunc main() {\n\tprintln(\"hi\")\n}\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := "<!-- BEGIN SECTION fn file=" + sourceFile + " lines=3-5 -->\n<!-- END SECTION fn -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := Replace([]byte(content), sections, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(string(result), "func main() {") || strings.Contains(string(result), "package main") {
+		t.Errorf("Expected only lines 3-5, got:\n%s", result)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test region= attribute
+// /////////////////////////////////////////////////////////////////////////////
+func TestSelectRegion(t *testing.T) {
+	src := []byte(`package main
+
+// #region imports
+import "fmt"
+// #endregion
+
+// #region main
+func main() {
+	// #region body
+	fmt.Println("hi")
+	// #endregion body
+}
+// #endregion main
+
+// #region broken
+`)
+
+	tests := []struct {
+		name      string
+		region    string
+		opts      Options
+		want      string
+		wantError bool
+	}{
+		{name: "Simple region", region: "imports", want: "import \"fmt\"\n"},
+		{name: "Nested markers removed", region: "main", want: "func main() {\n\tfmt.Println(\"hi\")\n}\n"},
+		{name: "Inner region", region: "body", want: "\tfmt.Println(\"hi\")\n"},
+		{name: "Missing region", region: "unknown", wantError: true},
+		{name: "Unterminated region", region: "broken", wantError: true},
+		{name: "Custom markers", region: "imports", opts: Options{RegionBegin: "@start", RegionEnd: "@stop"}, wantError: true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Section{Name: "test", Attrs: map[string]string{"region": tt.region}}
+			got, err := tt.opts.selectRegion(s, src)
+			if tt.wantError {
+				if err == nil {
+					t.Errorf("Expected error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test region= attribute with custom markers
+// /////////////////////////////////////////////////////////////////////////////
+func TestSelectRegionCustomMarkers(t *testing.T) {
+	src := []byte("# @start config\nport: 8080\n# @stop\n")
+	opts := Options{RegionBegin: "@start", RegionEnd: "@stop"}
+
+	got, err := opts.selectRegion(Section{Name: "test", Attrs: map[string]string{"region": "config"}}, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "port: 8080") {
+		t.Errorf("Expected region content, got %q", got)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test frontmatter=strip attribute
+// /////////////////////////////////////////////////////////////////////////////
+func TestSelectFrontMatter(t *testing.T) {
+	tests := []struct {
+		name  string
+		attrs map[string]string
+		src   string
+		want  string
+	}{
+		{name: "Stripped", attrs: map[string]string{"frontmatter": "strip"}, src: "---\nowner: me\n---\nbody\n", want: "body\n"},
+		{name: "Kept without attribute", src: "---\nowner: me\n---\nbody\n", want: "---\nowner: me\n---\nbody\n"},
+		{name: "No front matter", attrs: map[string]string{"frontmatter": "strip"}, src: "body\n", want: "body\n"},
+		{name: "Unterminated", attrs: map[string]string{"frontmatter": "strip"}, src: "---\nkind: x\n", want: "---\nkind: x\n"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectFrontMatter(Section{Attrs: tt.attrs}, []byte(tt.src))
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
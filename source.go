@@ -0,0 +1,121 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DefaultMmapThreshold is the default source size above which sources are
+// memory-mapped instead of read into memory
+const DefaultMmapThreshold = 64 << 20
+
+// OversizePolicy is the handling of file= and url= sources larger than
+// Options.MaxSourceSize
+type OversizePolicy string
+
+// Oversized source policies
+const (
+	OversizeError    OversizePolicy = "error"    // fail (default)
+	OversizeTruncate OversizePolicy = "truncate" // keep the lines within the limit and log a warning
+)
+
+// ParseOversizePolicy parses an oversized source policy: error or truncate
+func ParseOversizePolicy(value string) (OversizePolicy, error) {
+	switch policy := OversizePolicy(value); policy {
+	case OversizeError, OversizeTruncate:
+		return policy, nil
+	}
+
+	return "", fmt.Errorf("invalid oversized source policy %q: expected error or truncate", value)
+}
+
+// source is the raw content of a section source. Large files are
+// memory-mapped so that slicing an excerpt only touches the pages it needs.
+type source struct {
+	data      []byte
+	mapped    bool
+	truncated bool // the file is larger than the size limit
+}
+
+// openSource reads the file at path, memory-mapping it when its size is
+// above threshold. Files larger than limit, when positive, fail unless
+// truncate is set: their lines within limit are then read in chunks,
+// without loading the rest of the file.
+func openSource(path string, threshold, limit int64, truncate bool) (*source, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	if limit > 0 && (info.Size() > limit || !info.Mode().IsRegular()) {
+		data, truncated, err := readLimited(f, limit)
+		if err != nil {
+			return nil, err
+		}
+		if truncated && !truncate {
+			return nil, fmt.Errorf("%s: source larger than %d bytes", path, limit)
+		}
+		return &source{data: data, truncated: truncated}, nil
+	}
+
+	if threshold > 0 && info.Size() >= threshold && info.Mode().IsRegular() {
+		data, err := mmapFile(f, info.Size())
+		if err == nil {
+			return &source{data: data, mapped: true}, nil
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &source{data: data}, nil
+}
+
+// readLimited reads at most limit bytes of r and reports whether r is
+// longer, in which case the content is cut after its last complete line
+func readLimited(r io.Reader, limit int64) ([]byte, bool, error) {
+	var buf bytes.Buffer
+	n, err := io.CopyN(&buf, r, limit+1)
+	if err != nil && err != io.EOF {
+		return nil, false, err
+	}
+	if n <= limit {
+		return buf.Bytes(), false, nil
+	}
+
+	data := buf.Bytes()[:limit]
+	if i := bytes.LastIndexByte(data, '\n'); i >= 0 {
+		data = data[:i+1]
+	}
+
+	return data, true, nil
+}
+
+// detach returns b as a slice that stays valid after the source is closed
+func (src *source) detach(b []byte) []byte {
+	if !src.mapped {
+		return b
+	}
+
+	return bytes.Clone(b)
+}
+
+// close releases the memory mapping, if any
+func (src *source) close() error {
+	if !src.mapped {
+		return nil
+	}
+	src.mapped = false
+
+	return munmap(src.data)
+}
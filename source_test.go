@@ -0,0 +1,108 @@
+package gosect
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test openSource with and without memory mapping
+// /////////////////////////////////////////////////////////////////////////////
+func TestOpenSource(t *testing.T) {
+	tmpDir := t.TempDir()
+	sourceFile := filepath.Join(tmpDir, "large.log")
+	content := bytes.Repeat([]byte("log line\n"), 1000)
+	err := os.WriteFile(sourceFile, content, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		threshold int64
+	}{
+		{name: "Read", threshold: -1},
+		{name: "Below threshold", threshold: int64(len(content)) + 1},
+		{name: "Memory mapped", threshold: 1},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := openSource(sourceFile, tt.threshold, 0, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			excerpt := src.detach(src.data[:9])
+			if !bytes.Equal(src.data, content) {
+				t.Error("Source content mismatch")
+			}
+
+			if err := src.close(); err != nil {
+				t.Fatal(err)
+			}
+
+			if string(excerpt) != "log line\n" {
+				t.Errorf("Expected detached excerpt to survive close, got %q", excerpt)
+			}
+		})
+	}
+
+	if _, err := openSource(filepath.Join(tmpDir, "missing"), 1, 0, false); err == nil {
+		t.Error("Expected error for missing source")
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test openSource size limit
+// /////////////////////////////////////////////////////////////////////////////
+func TestOpenSourceLimit(t *testing.T) {
+	sourceFile := filepath.Join(t.TempDir(), "large.log")
+	content := bytes.Repeat([]byte("log line\n"), 1000)
+	err := os.WriteFile(sourceFile, content, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		limit         int64
+		truncate      bool
+		want          []byte
+		wantTruncated bool
+		wantErr       bool
+	}{
+		{name: "Within limit", limit: int64(len(content)), want: content},
+		{name: "Error above limit", limit: 100, wantErr: true},
+		{name: "Truncated to complete lines", limit: 100, truncate: true, want: content[:99], wantTruncated: true},
+		{name: "No limit", limit: 0, want: content},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src, err := openSource(sourceFile, 1, tt.limit, tt.truncate)
+			if tt.wantErr {
+				if err == nil || !strings.Contains(err.Error(), "source larger than 100 bytes") {
+					t.Fatalf("Expected size error, got %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer src.close()
+
+			if !bytes.Equal(src.data, tt.want) {
+				t.Errorf("Expected %d bytes, got %d", len(tt.want), len(src.data))
+			}
+			if src.truncated != tt.wantTruncated {
+				t.Errorf("Expected truncated %v, got %v", tt.wantTruncated, src.truncated)
+			}
+		})
+	}
+}
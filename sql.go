@@ -0,0 +1,198 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// sql= sources: table and view definitions of SQL schemas
+// /////////////////////////////////////////////////////////////////////////////
+
+// reDatabaseURL matches the database URLs of sql= sources, whose schema is
+// dumped with the client tools of the database
+var reDatabaseURL = regexp.MustCompile(`^(postgres|postgresql|mysql|sqlite)://`)
+
+// DatabaseURL reports whether the sql= source spec is a database URL rather
+// than a schema file
+func DatabaseURL(spec string) bool {
+	return reDatabaseURL.MatchString(spec)
+}
+
+// sqlKinds are the definition attributes of sql= sources
+var sqlKinds = []string{"table", "view"}
+
+// sqlName returns a pattern matching the SQL identifier name, bare or quoted
+func sqlName(name string) string {
+	quoted := regexp.QuoteMeta(name)
+	return `(?:"` + quoted + `"|` + "`" + quoted + "`" + `|\[` + quoted + `\]|` + quoted + `\b)`
+}
+
+// extractSQL returns the CREATE statement of the table= or view= of the sql=
+// source of s with its leading comments. Names may be qualified with their
+// schema, as in table=public.users.
+func extractSQL(s Section, data []byte) ([]byte, error) {
+	var kind, name string
+	for _, k := range sqlKinds {
+		if value, ok := s.Attrs[k]; ok {
+			if kind != "" {
+				return nil, fmt.Errorf("section %s has both %s= and %s=", s.Name, kind, k)
+			}
+			kind, name = k, value
+		}
+	}
+	if kind == "" {
+		return nil, fmt.Errorf("section %s has sql= but no table= or view= attribute", s.Name)
+	}
+
+	qualified := `(?:[^\s.(]+\.)?` + sqlName(name)
+	if schema, table, ok := strings.Cut(name, "."); ok {
+		qualified = sqlName(schema) + `\.` + sqlName(table)
+	}
+	re := regexp.MustCompile(`(?im)^[ \t]*CREATE\s+(?:OR\s+REPLACE\s+)?(?:(?:GLOBAL|LOCAL)\s+)?(?:TEMP(?:ORARY)?\s+|UNLOGGED\s+|MATERIALIZED\s+)?` +
+		kind + `\s+(?:IF\s+NOT\s+EXISTS\s+)?` + qualified)
+	loc := re.FindIndex(data)
+	if loc == nil {
+		return nil, fmt.Errorf("section %s: no %s %s in %s", s.Name, kind, name, redactDatabaseURL(s.Attrs["sql"]))
+	}
+
+	end := sqlStatementEnd(data, loc[1])
+	out := dedent(bytes.TrimRight(data[commentStart(data, loc[0], "--"):end], " \t\r\n"))
+	return append(out, '\n'), nil
+}
+
+// reDollarQuote matches the opening tag of a dollar-quoted PostgreSQL string
+var reDollarQuote = regexp.MustCompile(`^\$[A-Za-z_0-9]*\$`)
+
+// sqlStatementEnd returns the offset following the semicolon ending the
+// statement at data[from:], skipping quoted identifiers and strings, comments
+// and dollar-quoted bodies, or the end of data
+func sqlStatementEnd(data []byte, from int) int {
+	for i := from; i < len(data); i++ {
+		switch c := data[i]; {
+		case c == ';':
+			return i + 1
+		case c == '\'' || c == '"' || c == '`':
+			// doubled quotes escape themselves and read as two strings
+			if n := bytes.IndexByte(data[i+1:], c); n != -1 {
+				i += n + 1
+			} else {
+				return len(data)
+			}
+		case bytes.HasPrefix(data[i:], []byte("--")):
+			n := bytes.IndexByte(data[i:], '\n')
+			if n == -1 {
+				return len(data)
+			}
+			i += n
+		case bytes.HasPrefix(data[i:], []byte("/*")):
+			n := bytes.Index(data[i+2:], []byte("*/"))
+			if n == -1 {
+				return len(data)
+			}
+			i += n + 3
+		case c == '$':
+			tag := reDollarQuote.Find(data[i:])
+			if tag == nil {
+				continue
+			}
+			n := bytes.Index(data[i+len(tag):], tag)
+			if n == -1 {
+				return len(data)
+			}
+			i += len(tag) + n + len(tag) - 1
+		}
+	}
+
+	return len(data)
+}
+
+// passwordEnv returns the environment of a database client reading the
+// password of the database URL u from the variable name, rather than from
+// its arguments, which other users of the system can list. It is nil, the
+// environment of gosect, without a password.
+func passwordEnv(name string, u *url.URL) []string {
+	if u.User == nil {
+		return nil
+	}
+	password, ok := u.User.Password()
+	if !ok {
+		return nil
+	}
+
+	return append(os.Environ(), name+"="+password)
+}
+
+// redactDatabaseURL hides the password of a database URL in messages
+func redactDatabaseURL(spec string) string {
+	if u, err := url.Parse(spec); err == nil && DatabaseURL(spec) {
+		return u.Redacted()
+	}
+
+	return spec
+}
+
+// dumpSchema returns the schema of the database at the sql= URL of s, dumped
+// read-only with pg_dump, mysqldump or sqlite3
+func (opts Options) dumpSchema(s Section, dsn string) ([]byte, error) {
+	if !opts.AllowCommands {
+		return nil, fmt.Errorf("section %s: database sql= sources are disabled (use -allow-cmd)", s.Name)
+	}
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: invalid database URL %s", s.Name, redactDatabaseURL(dsn))
+	}
+
+	// database names follow --, so that they are never read as options
+	var cmd *exec.Cmd
+	switch u.Scheme {
+	case "postgres", "postgresql":
+		conn := *u
+		if u.User != nil {
+			conn.User = url.User(u.User.Username())
+		}
+		cmd = exec.Command("pg_dump", "--schema-only", "--no-owner", "--no-privileges", "--", conn.String())
+		cmd.Env = passwordEnv("PGPASSWORD", u)
+	case "mysql":
+		args := []string{"--no-data", "--skip-comments"}
+		if host := u.Hostname(); host != "" {
+			args = append(args, "--host", host)
+		}
+		if port := u.Port(); port != "" {
+			args = append(args, "--port", port)
+		}
+		if u.User != nil {
+			args = append(args, "--user", u.User.Username())
+		}
+		cmd = exec.Command("mysqldump", append(args, "--", strings.TrimPrefix(u.Path, "/"))...)
+		cmd.Env = passwordEnv("MYSQL_PWD", u)
+	case "sqlite":
+		path, err := opts.SourcePath(Section{Name: s.Name, SrcFile: u.Host + u.Path})
+		if err != nil {
+			return nil, err
+		}
+		// sqlite3 has no --: relative paths starting with a dash are made explicit
+		if strings.HasPrefix(path, "-") {
+			path = "." + string(filepath.Separator) + path
+		}
+		cmd = exec.Command("sqlite3", "-readonly", path, ".schema")
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			err = fmt.Errorf("%w: %s", err, msg)
+		}
+		return nil, fmt.Errorf("section %s: %s: %s: %w", s.Name, redactDatabaseURL(dsn), cmd.Args[0], err)
+	}
+
+	return stdout.Bytes(), nil
+}
@@ -0,0 +1,154 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// sqlSchema is the schema dump of the sql= tests
+const sqlSchema = `-- Users of the application
+-- (one row per account)
+CREATE TABLE IF NOT EXISTS public."users" (
+    id bigint PRIMARY KEY,
+    name text NOT NULL DEFAULT 'it''s; fine' -- not the end;
+);
+
+CREATE INDEX users_name ON public.users (name);
+
+CREATE FUNCTION touch() RETURNS trigger AS $body$
+BEGIN
+    NEW.updated := now();
+    RETURN NEW;
+END;
+$body$ LANGUAGE plpgsql;
+
+/* Active users */
+CREATE OR REPLACE VIEW active_users AS
+    SELECT * FROM users WHERE name <> '';
+
+CREATE TABLE audit.users (id bigint);
+`
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test extracting definitions of sql= sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestExtractSQL(t *testing.T) {
+	tests := []struct {
+		name    string
+		attrs   map[string]string
+		want    string
+		wantErr string
+	}{
+		{
+			name:  "Table with comments",
+			attrs: map[string]string{"table": "users"},
+			want:  "-- Users of the application\n-- (one row per account)\nCREATE TABLE IF NOT EXISTS public.\"users\" (\n    id bigint PRIMARY KEY,\n    name text NOT NULL DEFAULT 'it''s; fine' -- not the end;\n);\n",
+		},
+		{
+			name:  "Qualified table",
+			attrs: map[string]string{"table": "audit.users"},
+			want:  "CREATE TABLE audit.users (id bigint);\n",
+		},
+		{
+			name:  "View",
+			attrs: map[string]string{"view": "active_users"},
+			want:  "/* Active users */\nCREATE OR REPLACE VIEW active_users AS\n    SELECT * FROM users WHERE name <> '';\n",
+		},
+		{
+			name:    "Prefix of another name",
+			attrs:   map[string]string{"table": "user"},
+			wantErr: "no table user in schema.sql",
+		},
+		{
+			name:    "No definition",
+			attrs:   map[string]string{},
+			wantErr: "no table= or view= attribute",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.attrs["sql"] = "schema.sql"
+			got, err := extractSQL(Section{Name: "ddl", Attrs: tt.attrs}, []byte(sqlSchema))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	if end := sqlStatementEnd([]byte(sqlSchema), strings.Index(sqlSchema, "CREATE FUNCTION")); !strings.HasSuffix(sqlSchema[:end], "LANGUAGE plpgsql;") {
+		t.Errorf("Expected dollar-quoted bodies to be skipped, got %q", sqlSchema[:end])
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test reading sql= sources from a database
+// /////////////////////////////////////////////////////////////////////////////
+func TestDatabaseSource(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("shell scripts not supported")
+	}
+
+	// a fake sqlite3 client printing the schema of read-only databases
+	tmpDir := t.TempDir()
+	client := "#!/bin/sh\n[ \"$1\" = -readonly ] && [ \"$3\" = .schema ] || exit 1\necho 'CREATE TABLE users (id INTEGER);'\n"
+	if err := os.WriteFile(filepath.Join(tmpDir, "sqlite3"), []byte(client), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", tmpDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	content := "BEGIN SECTION ddl sql=sqlite://app.db table=users\nEND SECTION ddl\n"
+	result := replaceAll(t, content, Options{AllowCommands: true})
+	if !strings.Contains(result, "\nCREATE TABLE users (id INTEGER);\n") {
+		t.Errorf("Expected the table definition, got %q", result)
+	}
+
+	// passwords are passed in the environment, and database names after --
+	for name, password := range map[string]string{"pg_dump": "PGPASSWORD", "mysqldump": "MYSQL_PWD"} {
+		dump := "#!/bin/sh\necho \"CREATE TABLE users (args '$*' password '$" + password + "');\"\n"
+		if err := os.WriteFile(filepath.Join(tmpDir, name), []byte(dump), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"postgres://app:secret@db/app", "args '--schema-only --no-owner --no-privileges -- postgres://app@db/app' password 'secret'"},
+		{"mysql://app:secret@db:3306/-app", "args '--no-data --skip-comments --host db --port 3306 --user app -- -app' password 'secret'"},
+		{"mysql://db/app", "args '--no-data --skip-comments --host db -- app' password ''"},
+	}
+	for _, tt := range tests {
+		content := "BEGIN SECTION ddl sql=" + tt.url + " table=users\nEND SECTION ddl\n"
+		if result := replaceAll(t, content, Options{AllowCommands: true}); !strings.Contains(result, tt.want) {
+			t.Errorf("Expected %s for %s, got %q", tt.want, tt.url, result)
+		}
+	}
+
+	// database clients are commands
+	content = "BEGIN SECTION ddl sql=postgres://app:secret@db/app table=users\nEND SECTION ddl\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = Replace([]byte(content), sections, Options{})
+	if err == nil || !strings.Contains(err.Error(), "disabled") {
+		t.Errorf("Expected database sources to be disabled, got %v", err)
+	}
+	if got := redactDatabaseURL("postgres://app:secret@db/app"); got != "postgres://app:xxxxx@db/app" {
+		t.Errorf("Expected the password to be redacted, got %q", got)
+	}
+}
@@ -0,0 +1,171 @@
+package gosect
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// renderFormat applies the format= attribute of s to src, such as
+// format=md-table rendering a CSV or TSV source as a Markdown table
+func renderFormat(s Section, src []byte) ([]byte, error) {
+	switch format, ok := s.Attrs["format"]; {
+	case !ok:
+		return src, nil
+	case format == "md-table":
+		return markdownTable(s, src)
+	default:
+		return nil, fmt.Errorf("section %s has unknown format=%s", s.Name, format)
+	}
+}
+
+// tableDelimiter returns the field delimiter of the CSV or TSV src: the
+// delimiter= attribute (a character or "tab"), the .tsv extension of the
+// source, or a tab when the first line has tabs but no commas
+func tableDelimiter(s Section, src []byte) (rune, error) {
+	switch value, ok := s.Attrs["delimiter"]; {
+	case value == "tab":
+		return '\t', nil
+	case ok && len([]rune(value)) == 1:
+		return []rune(value)[0], nil
+	case ok:
+		return 0, fmt.Errorf("section %s has invalid delimiter=%s", s.Name, value)
+	}
+
+	switch strings.ToLower(filepath.Ext(s.SrcFile)) {
+	case ".tsv", ".tab":
+		return '\t', nil
+	case ".csv":
+		return ',', nil
+	}
+
+	first, _, _ := bytes.Cut(src, []byte("\n"))
+	if bytes.ContainsRune(first, '\t') && !bytes.ContainsRune(first, ',') {
+		return '\t', nil
+	}
+
+	return ',', nil
+}
+
+// isNumber reports whether a table cell holds a number
+func isNumber(cell string) bool {
+	cell = strings.TrimSuffix(strings.TrimSpace(cell), "%")
+	_, err := strconv.ParseFloat(strings.ReplaceAll(cell, ",", ""), 64)
+
+	return err == nil
+}
+
+// markdownTable renders the CSV or TSV src as a Markdown table. The first row
+// is the header with header=true, data with header=false, and by default a
+// header unless one of its cells is a number. Numeric columns are right
+// aligned, and cells are padded so that columns line up.
+func markdownTable(s Section, src []byte) ([]byte, error) {
+	delimiter, err := tableDelimiter(s, src)
+	if err != nil {
+		return nil, err
+	}
+
+	r := csv.NewReader(bytes.NewReader(src))
+	r.Comma = delimiter
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %s: %w", s.Name, s.SrcFile, err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+
+	for _, row := range rows {
+		for j, cell := range row {
+			row[j] = tableCell(cell)
+		}
+	}
+
+	var header []string
+	switch s.Attrs["header"] {
+	case "true":
+		header, rows = rows[0], rows[1:]
+	case "false":
+	default:
+		if !slices.ContainsFunc(rows[0], isNumber) {
+			header, rows = rows[0], rows[1:]
+		}
+	}
+
+	return formatTable(header, rows), nil
+}
+
+// tableCell escapes text for a Markdown table cell
+func tableCell(text string) string {
+	text = strings.ReplaceAll(strings.TrimSpace(text), "|", `\|`)
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "<br>"), "\n", "<br>")
+}
+
+// formatTable renders escaped cells as a Markdown table. Numeric columns are
+// right aligned, and cells are padded so that columns line up.
+func formatTable(header []string, rows [][]string) []byte {
+	columns := len(header)
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+
+	// pad rows to the same number of columns
+	header = append(header, make([]string, columns-len(header))...)
+	for i := range rows {
+		rows[i] = append(rows[i], make([]string, columns-len(rows[i]))...)
+	}
+
+	// right align the columns holding numbers only
+	widths := make([]int, columns)
+	numeric := make([]bool, columns)
+	for j := range columns {
+		widths[j] = max(StringWidth(header[j]), 3)
+		numbers, texts := 0, 0
+		for _, row := range rows {
+			widths[j] = max(widths[j], StringWidth(row[j]))
+			switch {
+			case isNumber(row[j]):
+				numbers++
+			case row[j] != "":
+				texts++
+			}
+		}
+		numeric[j] = numbers > 0 && texts == 0
+	}
+
+	var out bytes.Buffer
+	writeRow := func(cells []string) {
+		out.WriteString("|")
+		for j, cell := range cells {
+			pad := strings.Repeat(" ", widths[j]-StringWidth(cell))
+			if numeric[j] {
+				out.WriteString(" " + pad + cell + " |")
+			} else {
+				out.WriteString(" " + cell + pad + " |")
+			}
+		}
+		out.WriteString("\n")
+	}
+
+	writeRow(header)
+	out.WriteString("|")
+	for j := range columns {
+		if numeric[j] {
+			out.WriteString(" " + strings.Repeat("-", widths[j]-1) + ": |")
+		} else {
+			out.WriteString(" " + strings.Repeat("-", widths[j]) + " |")
+		}
+	}
+	out.WriteString("\n")
+	for _, row := range rows {
+		writeRow(row)
+	}
+
+	return out.Bytes()
+}
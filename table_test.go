@@ -0,0 +1,68 @@
+package gosect
+
+import "testing"
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test format=md-table rendering of CSV and TSV sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestMarkdownTable(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		attrs   map[string]string
+		src     string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "CSV with header",
+			file: "prices.csv",
+			src:  "Item,Price\napple,1.5\n\"kiwi, gold\",12\n",
+			want: "| Item       | Price |\n| ---------- | ----: |\n| apple      |   1.5 |\n| kiwi, gold |    12 |\n",
+		},
+		{
+			name: "TSV without header",
+			file: "data.tsv",
+			src:  "a\t1\nb\t2\n",
+			want: "|     |     |\n| --- | --: |\n| a   |   1 |\n| b   |   2 |\n",
+		},
+		{
+			name:  "Forced header and ragged rows",
+			file:  "data.txt",
+			attrs: map[string]string{"header": "true", "delimiter": ";"},
+			src:   "2024;2025\nx|y\n",
+			want:  "| 2024 | 2025 |\n| ---- | ---- |\n| x\\|y |      |\n",
+		},
+		{
+			name: "Sniffed tabs and wide characters",
+			file: "data",
+			src:  "Name\tCity\n山田\tTōkyō\n",
+			want: "| Name | City  |\n| ---- | ----- |\n| 山田 | Tōkyō |\n",
+		},
+		{
+			name:    "Invalid delimiter",
+			file:    "data.csv",
+			attrs:   map[string]string{"delimiter": "ab"},
+			src:     "a,b\n",
+			wantErr: true,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attrs := map[string]string{"format": "md-table"}
+			for key, value := range tt.attrs {
+				attrs[key] = value
+			}
+			s := Section{Name: "table", SrcFile: tt.file, Attrs: attrs}
+			got, err := renderFormat(s, []byte(tt.src))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package gosect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// TemplateData is the data context passed to source templates
+type TemplateData struct {
+	Section string            // section name
+	Attrs   map[string]string // BEGIN marker attributes
+	Values  map[string]any    // values loaded with LoadValues
+	Env     map[string]string // process environment
+}
+
+// templateEnabled reports whether the source of s must be rendered as a
+// template. The template= attribute overrides Options.RenderTemplates.
+func (opts Options) templateEnabled(s Section) bool {
+	switch s.Attrs["template"] {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+
+	return opts.RenderTemplates
+}
+
+// renderTemplate executes src as a text/template
+func (opts Options) renderTemplate(s Section, src []byte) ([]byte, error) {
+	tmpl, err := template.New(s.SrcFile).Option("missingkey=error").Parse(string(src))
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %w", s.Name, err)
+	}
+
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, opts.templateData(s)); err != nil {
+		return nil, fmt.Errorf("section %s: %w", s.Name, err)
+	}
+
+	return out.Bytes(), nil
+}
+
+// templateData returns the data context of the templates of s
+func (opts Options) templateData(s Section) TemplateData {
+	return TemplateData{
+		Section: s.Name,
+		Attrs:   s.Attrs,
+		Values:  opts.Values,
+		Env:     environ(),
+	}
+}
+
+// environ returns the process environment as a map
+func environ() map[string]string {
+	env := map[string]string{}
+	for _, kv := range os.Environ() {
+		key, value, _ := strings.Cut(kv, "=")
+		env[key] = value
+	}
+
+	return env
+}
+
+// LoadValues reads a JSON values file exposed to templates as .Values
+func LoadValues(path string) (map[string]any, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var values map[string]any
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return values, nil
+}
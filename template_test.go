@@ -0,0 +1,108 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test template rendering of sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceTemplate(t *testing.T) {
+	tmpDir := t.TempDir()
+	sourceFile := filepath.Join(tmpDir, "source.tmpl")
+	err := os.WriteFile(sourceFile, []byte(`{{ .Section }} {{ .Values.version }} {{ .Env.GOSECT_TEST_USER }}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOSECT_TEST_USER", "alice")
+
+	tests := []struct {
+		name    string
+		attrs   string
+		opts    Options
+		want    string
+		wantErr bool
+	}{
+		{
+			name:  "template attribute",
+			attrs: " template=true",
+			opts:  Options{Values: map[string]any{"version": "1.2.3"}},
+			want:  "demo 1.2.3 alice",
+		},
+		{
+			name: "render all templates",
+			opts: Options{RenderTemplates: true, Values: map[string]any{"version": "2.0"}},
+			want: "demo 2.0 alice",
+		},
+		{
+			name:  "template disabled by attribute",
+			attrs: " template=false",
+			opts:  Options{RenderTemplates: true},
+			want:  "{{ .Section }}",
+		},
+		{
+			name:  "Not rendered by default",
+			attrs: "",
+			want:  "{{ .Section }}",
+		},
+		{
+			name:    "Missing value",
+			attrs:   " template=true",
+			wantErr: true,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content := "<!-- BEGIN SECTION demo file=" + sourceFile + tt.attrs + " -->\n<!-- END SECTION demo -->\n"
+			sections, err := FindSections(content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			result, err := Replace([]byte(content), sections, tt.opts)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+
+			if !strings.Contains(string(result), tt.want) {
+				t.Errorf("Expected result to contain %q, got:\n%s", tt.want, result)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test LoadValues function
+// /////////////////////////////////////////////////////////////////////////////
+func TestLoadValues(t *testing.T) {
+	tmpDir := t.TempDir()
+	valuesFile := filepath.Join(tmpDir, "values.json")
+	err := os.WriteFile(valuesFile, []byte(`{"name": "gosect", "port": 8080}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values, err := LoadValues(valuesFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if values["name"] != "gosect" {
+		t.Errorf("Expected name=gosect, got %v", values["name"])
+	}
+
+	if _, err := LoadValues(filepath.Join(tmpDir, "missing.json")); err == nil {
+		t.Error("Expected error for missing values file")
+	}
+}
@@ -0,0 +1,127 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// DefaultTOCDepth is the deepest heading level listed by src=toc sections
+// without depth= attribute
+const DefaultTOCDepth = 3
+
+// builtinSources maps the src= pseudo-sources to the functions generating
+// their content from the target document
+var builtinSources = map[string]func(s Section, doc []byte) ([]byte, error){
+	"toc": tableOfContents,
+}
+
+// builtinSource reports whether s has a src= pseudo-source, rendered from the
+// final target document once the other sections are rendered
+func builtinSource(s Section) bool {
+	_, ok := s.Attrs["src"]
+	return ok && s.SrcFile == ""
+}
+
+// readBuiltin generates the content of the src= pseudo-source of s from the
+// target document doc
+func readBuiltin(s Section, doc []byte) ([]byte, error) {
+	name := s.Attrs["src"]
+	generate, ok := builtinSources[name]
+	if !ok {
+		return nil, fmt.Errorf("section %s has unknown src=%s", s.Name, name)
+	}
+
+	return generate(s, doc)
+}
+
+// reHeading matches an ATX Markdown heading, capturing its level and text
+var reHeading = regexp.MustCompile(`^(#{1,6})[ \t]+(.*?)(?:[ \t]+#+)?[ \t]*$`)
+
+// tableOfContents returns a Markdown list linking the headings of doc whose
+// level is at most the depth= attribute of s. Headings inside fenced code
+// blocks are ignored, and the list is indented from the shallowest level.
+func tableOfContents(s Section, doc []byte) ([]byte, error) {
+	depth := DefaultTOCDepth
+	if value, ok := s.Attrs["depth"]; ok {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > 6 {
+			return nil, fmt.Errorf("section %s has invalid depth=%s (expected 1 to 6)", s.Name, value)
+		}
+		depth = n
+	}
+
+	type heading struct {
+		level      int
+		text, slug string
+	}
+
+	var headings []heading
+	slugs := map[string]int{}
+	fence := ""
+	for line := range strings.Lines(string(doc)) {
+		line = strings.TrimRight(line, "\r\n")
+		trimmed := strings.TrimLeft(line, " ")
+
+		// skip fenced code blocks
+		if fence != "" {
+			if strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "" {
+				fence = ""
+			}
+			continue
+		}
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			fence = trimmed[:len(trimmed)-len(strings.TrimLeft(trimmed, trimmed[:1]))]
+			continue
+		}
+
+		m := reHeading.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+
+		// every heading gets a unique anchor, even when it is not listed
+		slug := headingSlug(m[2])
+		if n := slugs[slug]; n > 0 {
+			slugs[slug] = n + 1
+			slug = fmt.Sprintf("%s-%d", slug, n)
+		} else {
+			slugs[slug] = 1
+		}
+
+		if len(m[1]) <= depth {
+			headings = append(headings, heading{level: len(m[1]), text: m[2], slug: slug})
+		}
+	}
+
+	top := 6
+	for _, h := range headings {
+		top = min(top, h.level)
+	}
+
+	var out bytes.Buffer
+	for _, h := range headings {
+		fmt.Fprintf(&out, "%s- [%s](#%s)\n", strings.Repeat("  ", h.level-top), h.text, h.slug)
+	}
+
+	return out.Bytes(), nil
+}
+
+// headingSlug returns the anchor GitHub generates for a heading: lower case
+// letters, digits, hyphens and underscores, with spaces turned into hyphens
+func headingSlug(text string) string {
+	var slug strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == ' ':
+			slug.WriteByte('-')
+		case r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r):
+			slug.WriteRune(r)
+		}
+	}
+
+	return slug.String()
+}
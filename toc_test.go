@@ -0,0 +1,80 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test src=toc table of contents
+// /////////////////////////////////////////////////////////////////////////////
+func TestTableOfContents(t *testing.T) {
+	doc := "# Title\n\n## Install\n\n```sh\n# not a heading\n```\n\n### From source ###\n\n#### Deep\n\n## Usage\n\n## Usage\n\n## C'est l'été!\n"
+
+	tests := []struct {
+		name    string
+		depth   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "Default depth",
+			want: "- [Title](#title)\n  - [Install](#install)\n    - [From source](#from-source)\n  - [Usage](#usage)\n  - [Usage](#usage-1)\n  - [C'est l'été!](#cest-lété)\n",
+		},
+		{
+			name:  "Depth 2",
+			depth: "2",
+			want:  "- [Title](#title)\n  - [Install](#install)\n  - [Usage](#usage)\n  - [Usage](#usage-1)\n  - [C'est l'été!](#cest-lété)\n",
+		},
+		{
+			name:    "Invalid depth",
+			depth:   "7",
+			wantErr: true,
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Section{Name: "toc", Attrs: map[string]string{"src": "toc"}}
+			if tt.depth != "" {
+				s.Attrs["depth"] = tt.depth
+			}
+			got, err := tableOfContents(s, []byte(doc))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test src=toc lists the headings inserted by other sections
+// /////////////////////////////////////////////////////////////////////////////
+func TestReplaceTableOfContents(t *testing.T) {
+	source := filepath.Join(t.TempDir(), "usage.md")
+	if err := os.WriteFile(source, []byte("## Usage\n\nRun it.\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "# Title\n<!-- BEGIN SECTION toc src=toc depth=2 -->\n<!-- END SECTION toc -->\n" +
+		"<!-- BEGIN SECTION usage file=" + source + " -->\n<!-- END SECTION usage -->\n"
+	result := replaceAll(t, content, Options{})
+
+	if !strings.Contains(result, "\n- [Title](#title)\n  - [Usage](#usage)\n") {
+		t.Errorf("Expected the table of contents to list the inserted heading, got %q", result)
+	}
+	if !strings.Contains(result, "\n## Usage\n") {
+		t.Errorf("Expected the usage section to be rendered, got %q", result)
+	}
+
+	// an up to date document is unchanged
+	if again := replaceAll(t, result, Options{}); again != result {
+		t.Errorf("Expected a stable result, got %q", again)
+	}
+}
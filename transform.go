@@ -0,0 +1,145 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// tabWidth is the width of tab stops of the tab-expand transform
+const tabWidth = 4
+
+// transforms maps the names accepted by the transform= attribute to the
+// functions normalizing source content
+var transforms = map[string]func([]byte) []byte{
+	"trim":                mapLines(func(line []byte) []byte { return bytes.TrimSpace(line) }),
+	"trim-right":          mapLines(func(line []byte) []byte { return bytes.TrimRight(line, " \t\r") }),
+	"dedent":              dedent,
+	"squeeze-blank-lines": squeezeBlankLines,
+	"tab-expand":          mapLines(expandTabs),
+	"upper":               bytes.ToUpper,
+	"lower":               bytes.ToLower,
+}
+
+// applyTransforms applies the comma separated transforms of the transform=
+// attribute of s to src, in order
+func (opts Options) applyTransforms(s Section, src []byte) ([]byte, error) {
+	value, ok := s.Attrs["transform"]
+	if !ok {
+		return src, nil
+	}
+
+	for _, name := range strings.Split(value, ",") {
+		transform, ok := opts.Transforms[name]
+		if !ok {
+			transform, ok = transforms[name]
+		}
+		if !ok {
+			return nil, fmt.Errorf("section %s has unknown transform %q", s.Name, name)
+		}
+		src = transform(src)
+	}
+
+	return src, nil
+}
+
+// mapLines returns a transform applying fn to every line
+func mapLines(fn func([]byte) []byte) func([]byte) []byte {
+	return func(src []byte) []byte {
+		lines := bytes.Split(src, []byte("\n"))
+		for i, line := range lines {
+			lines[i] = fn(line)
+		}
+		return bytes.Join(lines, []byte("\n"))
+	}
+}
+
+// isBlank reports whether line only contains whitespace
+func isBlank(line []byte) bool {
+	return len(bytes.TrimSpace(line)) == 0
+}
+
+// dedent removes the leading whitespace common to every non blank line
+func dedent(src []byte) []byte {
+	lines := bytes.Split(src, []byte("\n"))
+
+	var common []byte
+	first := true
+	for _, line := range lines {
+		if isBlank(line) {
+			continue
+		}
+		indent := line[:len(line)-len(bytes.TrimLeft(line, " \t"))]
+		if first {
+			common, first = indent, false
+			continue
+		}
+		n := 0
+		for n < len(common) && n < len(indent) && common[n] == indent[n] {
+			n++
+		}
+		common = common[:n]
+	}
+
+	for i, line := range lines {
+		lines[i] = bytes.TrimPrefix(line, common)
+	}
+
+	return bytes.Join(lines, []byte("\n"))
+}
+
+// squeezeBlankLines collapses runs of blank lines into a single empty line
+func squeezeBlankLines(src []byte) []byte {
+	var out [][]byte
+	for _, line := range bytes.Split(src, []byte("\n")) {
+		if isBlank(line) {
+			if len(out) > 0 && len(out[len(out)-1]) == 0 {
+				continue
+			}
+			line = nil
+		}
+		out = append(out, line)
+	}
+
+	return bytes.Join(out, []byte("\n"))
+}
+
+// expandTabs replaces the tabs of line with spaces up to the next tab stop
+func expandTabs(line []byte) []byte {
+	if bytes.IndexByte(line, '\t') == -1 {
+		return line
+	}
+
+	var out bytes.Buffer
+	column := 0
+	for _, r := range string(line) {
+		if r == '\t' {
+			n := tabWidth - column%tabWidth
+			out.WriteString(strings.Repeat(" ", n))
+			column += n
+			continue
+		}
+		out.WriteRune(r)
+		column += RuneWidth(r)
+	}
+
+	return out.Bytes()
+}
+
+// applyPrefix prepends the prefix= attribute of s to every line of src, such
+// as "// " to turn it into comments or "> " into a blockquote. Blank lines get
+// the prefix without its trailing spaces.
+func applyPrefix(s Section, src []byte) []byte {
+	prefix, ok := s.Attrs["prefix"]
+	if !ok || prefix == "" {
+		return src
+	}
+
+	blank := []byte(strings.TrimRight(prefix, " \t"))
+	return mapLines(func(line []byte) []byte {
+		if isBlank(line) {
+			return blank
+		}
+		return append([]byte(prefix), line...)
+	})(src)
+}
@@ -0,0 +1,91 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the transform= pipeline
+// /////////////////////////////////////////////////////////////////////////////
+func TestApplyTransforms(t *testing.T) {
+	tests := []struct {
+		transform string
+		src       string
+		want      string
+		wantErr   bool
+	}{
+		{"trim", "  a  \n\tb\t", "a\nb", false},
+		{"trim-right", "  a  \n\tb\t", "  a\n\tb", false},
+		{"dedent", "    func() {\n      return\n\n    }", "func() {\n  return\n\n}", false},
+		{"dedent", "\ta\n  b", "\ta\n  b", false},
+		{"squeeze-blank-lines", "a\n\n \n\nb\n\nc", "a\n\nb\n\nc", false},
+		{"tab-expand", "a\tb\n\tc\n日\td", "a   b\n    c\n日  d", false},
+		{"upper", "Hello", "HELLO", false},
+		{"lower", "Hello", "hello", false},
+		{"dedent,upper", "  a\n    b", "A\n  B", false},
+		{"trim,squeeze-blank-lines", "a\n  \n\t\nb", "a\n\nb", false},
+		{"reverse", "a", "", true},
+	}
+
+	// Run tests
+	for _, test := range tests {
+		s := Section{Name: "s", Attrs: map[string]string{"transform": test.transform}}
+		got, err := Options{}.applyTransforms(s, []byte(test.src))
+		if (err != nil) != test.wantErr {
+			t.Errorf("%s: expected error %v, got %v", test.transform, test.wantErr, err)
+			continue
+		}
+		if !test.wantErr && string(got) != test.want {
+			t.Errorf("%s: expected %q, got %q", test.transform, test.want, got)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the prefix= attribute
+// /////////////////////////////////////////////////////////////////////////////
+func TestApplyPrefix(t *testing.T) {
+	tests := []struct {
+		prefix string
+		src    string
+		want   string
+	}{
+		{"// ", "a\nb", "// a\n// b"},
+		{"> ", "a\n\nb", "> a\n>\n> b"},
+		{"# ", "a\n  \nb", "# a\n#\n# b"},
+		{"", "a\nb", "a\nb"},
+	}
+
+	// Run tests
+	for _, test := range tests {
+		s := Section{Name: "s", Attrs: map[string]string{"prefix": test.prefix}}
+		if got := applyPrefix(s, []byte(test.src)); string(got) != test.want {
+			t.Errorf("%q: expected %q, got %q", test.prefix, test.want, got)
+		}
+	}
+
+	source := filepath.Join(t.TempDir(), "a.txt")
+	if err := os.WriteFile(source, []byte("hello\n\nworld\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	content := `<!-- BEGIN SECTION a file=` + source + ` prefix="> " -->` + "\nold\n<!-- END SECTION a -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sections) != 1 || sections[0].Attrs["prefix"] != "> " {
+		t.Fatalf("Expected section a with prefix \"> \", got %v", sections)
+	}
+
+	result, err := Replace([]byte(content), sections, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `<!-- BEGIN SECTION a file=` + source + ` prefix="> " -->` + "\n\n> hello\n>\n> world\n\n<!-- END SECTION a -->\n"
+	if string(result) != want {
+		t.Errorf("Expected %q, got %q", want, result)
+	}
+}
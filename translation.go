@@ -0,0 +1,170 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// translated documents: README.md and README.fr.md
+// /////////////////////////////////////////////////////////////////////////////
+
+// reLanguage matches the language code of translated document names, such
+// as fr or pt-BR
+var reLanguage = regexp.MustCompile(`^[a-z]{2,3}(?:[-_][A-Z]{2})?$`)
+
+// DocumentLanguage returns the language of a translated document, fr for
+// README.fr.md, or an empty string for documents without a language
+func DocumentLanguage(path string) string {
+	ext := filepath.Ext(path)
+	lang := filepath.Ext(strings.TrimSuffix(path, ext))
+	if ext == "" || !reLanguage.MatchString(strings.TrimPrefix(lang, ".")) {
+		return ""
+	}
+
+	return lang[1:]
+}
+
+// OriginalDocument returns the path of the document translated by path,
+// README.md for README.fr.md, or path itself when it has no language
+func OriginalDocument(path string) string {
+	lang := DocumentLanguage(path)
+	if lang == "" {
+		return path
+	}
+	ext := filepath.Ext(path)
+
+	return strings.TrimSuffix(path, "."+lang+ext) + ext
+}
+
+// Translations returns the translations of the document at path, such as
+// README.fr.md and README.de.md for README.md, found in its directory
+func Translations(path string) ([]string, error) {
+	ext := filepath.Ext(path)
+	matches, err := filepath.Glob(strings.TrimSuffix(path, ext) + ".*" + ext)
+	if err != nil {
+		return nil, err
+	}
+
+	var translations []string
+	for _, match := range matches {
+		if DocumentLanguage(match) != "" && OriginalDocument(match) == path {
+			translations = append(translations, match)
+		}
+	}
+
+	return translations, nil
+}
+
+// DefaultLanguage is the language of documents without a language code in
+// their name, such as README.md
+const DefaultLanguage = "en"
+
+// langPlaceholder is replaced by the language of the target document in the
+// source attributes of its sections
+const langPlaceholder = "{{.lang}}"
+
+// ExpandLanguage returns s with the {{.lang}} placeholders of its source
+// attributes replaced by lang, the language of the target document, so that
+// file=snippets/{{.lang}}/install.md reads the snippet of each translation
+func ExpandLanguage(s Section, lang string) Section {
+	if !translatedSource(s) {
+		return s
+	}
+
+	s.Attrs = maps.Clone(s.Attrs)
+	for _, key := range langAttrs {
+		if value, ok := s.Attrs[key]; ok {
+			s.Attrs[key] = strings.ReplaceAll(value, langPlaceholder, lang)
+		}
+	}
+	s.SrcFile = s.Attrs["file"]
+
+	return s
+}
+
+// expandLanguage replaces the {{.lang}} placeholders of the source
+// attributes of s by Options.Language
+func (opts Options) expandLanguage(s Section) (Section, error) {
+	if opts.Language == "" && translatedSource(s) {
+		return s, fmt.Errorf("section %s: %s needs the language of the target document", s.Name, langPlaceholder)
+	}
+
+	return ExpandLanguage(s, opts.Language), nil
+}
+
+// translatedSource reports whether a source attribute of s has a {{.lang}}
+// placeholder
+func translatedSource(s Section) bool {
+	return slices.ContainsFunc(langAttrs, func(key string) bool {
+		return strings.Contains(s.Attrs[key], langPlaceholder)
+	})
+}
+
+// CompareTranslation compares the sections of a document with those of its
+// translation and returns the issues of each: sections missing in the
+// other document, and sections generated from the same source whose bodies
+// differ, one of the documents being stale. Sections are compared by name,
+// and their sha= checksums are ignored.
+func CompareTranslation(doc, translation []Section, docContent, translationContent []byte) (docIssues, translationIssues []MarkerIssue) {
+	byName := func(sections []Section) map[string]Section {
+		m := map[string]Section{}
+		for _, s := range sections {
+			if _, ok := m[s.Name]; !ok {
+				m[s.Name] = s
+			}
+		}
+		return m
+	}
+	docSections, translated := byName(doc), byName(translation)
+
+	issue := func(s Section, format string, args ...any) MarkerIssue {
+		at := s.Pos.Name.Start
+		return MarkerIssue{Line: at.Line, Column: at.Column, Message: fmt.Sprintf(format, args...)}
+	}
+
+	for _, s := range doc {
+		t, ok := translated[s.Name]
+		switch {
+		case docSections[s.Name].StartIdx != s.StartIdx:
+			// compare repeated sections once
+		case !ok:
+			docIssues = append(docIssues, issue(s, "section %s is missing in the translation", s.Name))
+		case !sameSource(s, t):
+			// translated sources generate different bodies
+		case !bytes.Equal(sectionBody(s, docContent), sectionBody(t, translationContent)):
+			translationIssues = append(translationIssues, issue(t, "section %s differs from the original document, generated from the same source: one of them is stale", s.Name))
+		}
+	}
+	for _, t := range translation {
+		if _, ok := docSections[t.Name]; !ok && translated[t.Name].StartIdx == t.StartIdx {
+			translationIssues = append(translationIssues, issue(t, "section %s is missing in the original document", t.Name))
+		}
+	}
+
+	return docIssues, translationIssues
+}
+
+// sameSource reports whether the sections a and b have the same attributes,
+// their sha= checksums apart, and no translated source
+func sameSource(a, b Section) bool {
+	if translatedSource(a) {
+		return false
+	}
+
+	attrsA, attrsB := maps.Clone(a.Attrs), maps.Clone(b.Attrs)
+	delete(attrsA, "sha")
+	delete(attrsB, "sha")
+
+	return maps.Equal(attrsA, attrsB)
+}
+
+// sectionBody returns the body of s found in content
+func sectionBody(s Section, content []byte) []byte {
+	return content[s.Pos.Body.Start.Offset:s.Pos.Body.End.Offset]
+}
@@ -0,0 +1,169 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the language of translated document names
+// /////////////////////////////////////////////////////////////////////////////
+func TestDocumentLanguage(t *testing.T) {
+	tests := []struct {
+		path     string
+		lang     string
+		original string
+	}{
+		{"README.md", "", "README.md"},
+		{"README.fr.md", "fr", "README.md"},
+		{"docs/install.pt-BR.md", "pt-BR", "docs/install.md"},
+		{"docs/v1.2.md", "", "docs/v1.2.md"},
+		{"CHANGELOG.draft.md", "", "CHANGELOG.draft.md"},
+		{"fr", "", "fr"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := DocumentLanguage(tt.path); got != tt.lang {
+				t.Errorf("Expected language %q, got %q", tt.lang, got)
+			}
+			if got := OriginalDocument(tt.path); got != tt.original {
+				t.Errorf("Expected original %q, got %q", tt.original, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the translations found next to a document
+// /////////////////////////////////////////////////////////////////////////////
+func TestTranslations(t *testing.T) {
+	tmpDir := t.TempDir()
+	for _, name := range []string{"README.md", "README.fr.md", "README.de.md", "README.draft.md", "GUIDE.fr.md"} {
+		if err := os.WriteFile(filepath.Join(tmpDir, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err := Translations(filepath.Join(tmpDir, "README.md"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(tmpDir, "README.de.md"), filepath.Join(tmpDir, "README.fr.md")}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test the comparison of the sections of translated documents
+// /////////////////////////////////////////////////////////////////////////////
+func TestCompareTranslation(t *testing.T) {
+	doc := "# Doc\n" +
+		"<!-- BEGIN SECTION usage file=usage.txt sha=aaaaaaaaaaaa -->\nnew usage\n<!-- END SECTION usage -->\n" +
+		"<!-- BEGIN SECTION install file=snippets/en/install.md -->\nInstall\n<!-- END SECTION install -->\n" +
+		"<!-- BEGIN SECTION flags file=flags.txt -->\n-v\n<!-- END SECTION flags -->\n" +
+		"<!-- BEGIN SECTION license file=LICENSE -->\nMIT\n<!-- END SECTION license -->\n"
+	translation := "# Doc\n" +
+		"<!-- BEGIN SECTION usage file=usage.txt sha=bbbbbbbbbbbb -->\nold usage\n<!-- END SECTION usage -->\n" +
+		"<!-- BEGIN SECTION install file=snippets/fr/install.md -->\nInstallation\n<!-- END SECTION install -->\n" +
+		"<!-- BEGIN SECTION license file=LICENSE -->\nMIT\n<!-- END SECTION license -->\n" +
+		"<!-- BEGIN SECTION faq file=faq.fr.md -->\nFAQ\n<!-- END SECTION faq -->\n"
+
+	docSections, err := FindSections(doc, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	translated, err := FindSections(translation, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	docIssues, translationIssues := CompareTranslation(docSections, translated, []byte(doc), []byte(translation))
+	want := []MarkerIssue{{Line: 8, Column: 20, Message: "section flags is missing in the translation"}}
+	if !slices.Equal(docIssues, want) {
+		t.Errorf("Expected %v, got %v", want, docIssues)
+	}
+	want = []MarkerIssue{
+		{Line: 2, Column: 20, Message: "section usage differs from the original document, generated from the same source: one of them is stale"},
+		{Line: 11, Column: 20, Message: "section faq is missing in the original document"},
+	}
+	if !slices.Equal(translationIssues, want) {
+		t.Errorf("Expected %v, got %v", want, translationIssues)
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test {{.lang}} placeholders of source attributes
+// /////////////////////////////////////////////////////////////////////////////
+func TestExpandLanguage(t *testing.T) {
+	tmpDir := t.TempDir()
+	for lang, content := range map[string]string{"en": "Install\n", "fr": "Installation\n"} {
+		if err := os.MkdirAll(filepath.Join(tmpDir, lang), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(tmpDir, lang, "install.md"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	content := "<!-- BEGIN SECTION install file={{.lang}}/install.md -->\n<!-- END SECTION install -->\n"
+
+	tests := []struct {
+		name     string
+		language string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "Original document",
+			language: "en",
+			want:     "<!-- BEGIN SECTION install file={{.lang}}/install.md -->\n\nInstall\n\n<!-- END SECTION install -->\n",
+		},
+		{
+			name:     "Translation",
+			language: "fr",
+			want:     "<!-- BEGIN SECTION install file={{.lang}}/install.md -->\n\nInstallation\n\n<!-- END SECTION install -->\n",
+		},
+		{
+			name:    "No language",
+			wantErr: "section install: {{.lang}} needs the language of the target document",
+		},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sections, err := FindSections(content, reBegin, reEnd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Replace([]byte(content), sections, Options{BaseDir: tmpDir, Language: tt.language})
+
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Expected error %q, got %v", tt.wantErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	// Translated sources are not compared between languages
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sameSource(sections[0], sections[0]) {
+		t.Errorf("Expected a translated source not to be compared")
+	}
+}
@@ -0,0 +1,160 @@
+package gosect
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultHTTPTimeout is the timeout of url= source requests made with the
+// default HTTP client
+const DefaultHTTPTimeout = 30 * time.Second
+
+// textTypes lists the non text/* media types accepted from url= sources
+var textTypes = map[string]bool{
+	"application/javascript": true,
+	"application/json":       true,
+	"application/toml":       true,
+	"application/x-sh":       true,
+	"application/xml":        true,
+	"application/yaml":       true,
+	"application/x-yaml":     true,
+	"image/svg+xml":          true,
+}
+
+// isTextType reports whether the media type can be inserted in a document
+func isTextType(mediaType string) bool {
+	return strings.HasPrefix(mediaType, "text/") ||
+		strings.HasSuffix(mediaType, "+json") ||
+		strings.HasSuffix(mediaType, "+xml") ||
+		textTypes[mediaType]
+}
+
+// isJSONType reports whether the media type is JSON
+func isJSONType(mediaType string) bool {
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
+
+// httpClient returns the client used for url= sources
+func (opts Options) httpClient() *http.Client {
+	if opts.HTTPClient == nil {
+		return &http.Client{Timeout: DefaultHTTPTimeout}
+	}
+
+	return opts.HTTPClient
+}
+
+// download fetches url and returns its body and media type, sniffed from
+// the body when the response has no Content-Type. With CacheDir, copies
+// cached by earlier downloads are revalidated with a conditional request.
+func (opts Options) download(url string) ([]byte, string, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	cached, cachedBody, isCached := readCache(opts.CacheDir, url)
+	if isCached {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := opts.httpClient().Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	if isCached && resp.StatusCode == http.StatusNotModified {
+		opts.logger().Debug("url= source not modified", "url", url)
+		return cachedBody, cached.MediaType, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("%s: %s", url, resp.Status)
+	}
+
+	body, truncated, err := opts.readBody(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: %w", url, err)
+	}
+	if truncated && opts.OnOversize != OversizeTruncate {
+		return nil, "", fmt.Errorf("%s: source larger than %d bytes", url, opts.MaxSourceSize)
+	}
+	if truncated {
+		opts.logger().Warn("source truncated", "source", url, "bytes", len(body))
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, "", fmt.Errorf("%s: invalid content type %q", url, contentType)
+	}
+
+	// only complete responses with validators can be revalidated
+	entry := cacheEntry{URL: url, ETag: resp.Header.Get("ETag"), LastModified: resp.Header.Get("Last-Modified"), MediaType: mediaType}
+	if opts.CacheDir != "" && !truncated && (entry.ETag != "" || entry.LastModified != "") {
+		if err := writeCache(opts.CacheDir, entry, body); err != nil {
+			opts.logger().Warn("cannot cache url= source", "url", url, "error", err)
+		}
+	}
+
+	return body, mediaType, nil
+}
+
+// readBody reads the body of a url= response, at most MaxSourceSize bytes
+// when it is set, and reports whether the body is longer
+func (opts Options) readBody(r io.Reader) ([]byte, bool, error) {
+	if opts.MaxSourceSize > 0 {
+		return readLimited(r, opts.MaxSourceSize)
+	}
+	body, err := io.ReadAll(r)
+
+	return body, false, err
+}
+
+// urlContent applies the default transform of the media type of a url=
+// source: JSON is pretty-printed unless pretty=false, text passes through
+// and binary types are rejected
+func urlContent(s Section, body []byte, mediaType string) ([]byte, error) {
+	switch {
+	case isJSONType(mediaType) && s.Attrs["pretty"] != "false":
+		var out bytes.Buffer
+		if err := json.Indent(&out, body, "", "  "); err != nil {
+			return nil, fmt.Errorf("section %s: %s: %w", s.Name, s.Attrs["url"], err)
+		}
+		return out.Bytes(), nil
+	case isTextType(mediaType):
+		return body, nil
+	}
+
+	return nil, fmt.Errorf("section %s: %s: unexpected content type %s", s.Name, s.Attrs["url"], mediaType)
+}
+
+// fetchURL returns the content of the url= source of a section, read from
+// the vendor directory when it was vendored
+func (opts Options) fetchURL(s Section, url string) ([]byte, error) {
+	body, mediaType, ok, err := readVendored(opts.VendorDir, url)
+	if err != nil {
+		return nil, fmt.Errorf("section %s: %w", s.Name, err)
+	}
+
+	if !ok {
+		body, mediaType, err = opts.download(url)
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", s.Name, err)
+		}
+	}
+
+	return urlContent(s, body, mediaType)
+}
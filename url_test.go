@@ -0,0 +1,152 @@
+package gosect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test url= sources and content type handling
+// /////////////////////////////////////////////////////////////////////////////
+func TestFetchURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/data.json":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write([]byte(`{"name":"gosect","tags":["a"]}`))
+		case "/notes.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte(`{"kept":"as is"}`))
+		case "/sniffed":
+			w.Header()["Content-Type"] = nil
+			w.Write([]byte("plain text\n"))
+		case "/log.txt":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("line 1\nline 2\nline 3\n"))
+		case "/logo.png":
+			w.Header().Set("Content-Type", "image/png")
+			w.Write([]byte("\x89PNG"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		path    string
+		attrs   map[string]string
+		opts    Options
+		want    string
+		wantErr bool
+	}{
+		{name: "JSON is pretty-printed", path: "/data.json", want: "{\n  \"name\": \"gosect\",\n  \"tags\": [\n    \"a\"\n  ]\n}"},
+		{name: "JSON kept with pretty=false", path: "/data.json", attrs: map[string]string{"pretty": "false"}, want: `{"name":"gosect","tags":["a"]}`},
+		{name: "Text passes through", path: "/notes.txt", want: `{"kept":"as is"}`},
+		{name: "Sniffed content type", path: "/sniffed", want: "plain text\n"},
+		{name: "Binary rejected", path: "/logo.png", wantErr: true},
+		{name: "Not found", path: "/missing", wantErr: true},
+		{name: "Within the size limit", path: "/log.txt", opts: Options{MaxSourceSize: 21}, want: "line 1\nline 2\nline 3\n"},
+		{name: "Oversized", path: "/log.txt", opts: Options{MaxSourceSize: 10}, wantErr: true},
+		{name: "Oversized truncated", path: "/log.txt", opts: Options{MaxSourceSize: 16, OnOversize: OversizeTruncate}, want: "line 1\nline 2\n"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			url := server.URL + tt.path
+			s := Section{Name: "remote", Attrs: map[string]string{"url": url}}
+			for k, v := range tt.attrs {
+				s.Attrs[k] = v
+			}
+
+			opts := tt.opts
+			opts.HTTPClient = server.Client()
+			got, err := opts.fetchURL(s, url)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test revalidating cached url= sources
+// /////////////////////////////////////////////////////////////////////////////
+func TestHTTPCache(t *testing.T) {
+	body, etag := "v1\n", `"1"`
+	var requests, notModified int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.URL.Path == "/plain.txt" {
+			w.Write([]byte(body))
+			return
+		}
+		if r.Header.Get("If-None-Match") == etag {
+			notModified++
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	opts := Options{HTTPClient: server.Client(), CacheDir: t.TempDir()}
+	fetch := func(path string) string {
+		t.Helper()
+		url := server.URL + path
+		got, err := opts.fetchURL(Section{Name: "remote", Attrs: map[string]string{"url": url}}, url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(got)
+	}
+
+	tests := []struct {
+		name        string
+		path        string
+		change      bool // change the body and ETag before fetching
+		want        string
+		notModified int
+	}{
+		{name: "First download", path: "/data.txt", want: "v1\n"},
+		{name: "Not modified", path: "/data.txt", want: "v1\n", notModified: 1},
+		{name: "Modified", path: "/data.txt", change: true, want: "v2\n", notModified: 1},
+		{name: "Cached modified copy", path: "/data.txt", want: "v2\n", notModified: 2},
+		{name: "No validators", path: "/plain.txt", want: "v2\n", notModified: 2},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.change {
+				body, etag = "v2\n", `"2"`
+			}
+			if got := fetch(tt.path); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+			if notModified != tt.notModified {
+				t.Errorf("Expected %d not modified responses, got %d", tt.notModified, notModified)
+			}
+		})
+	}
+
+	if requests != len(tests) {
+		t.Errorf("Expected %d requests, got %d", len(tests), requests)
+	}
+	if _, _, ok := readCache(opts.CacheDir, server.URL+"/plain.txt"); ok {
+		t.Error("Expected responses without validators not to be cached")
+	}
+}
@@ -0,0 +1,79 @@
+package gosect
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// validate sections
+// /////////////////////////////////////////////////////////////////////////////
+
+// CheckOrder verifies that the sections listed in order appear in that
+// order. When exact is set, the document must contain exactly the listed
+// sections, so missing and unlisted sections are reported too.
+func CheckOrder(sections []Section, order []string, exact bool) error {
+	var errs []error
+
+	rank := map[string]int{}
+	for i, name := range order {
+		rank[name] = i
+	}
+
+	last, lastName := -1, ""
+	for _, s := range sections {
+		r, ok := rank[s.Name]
+		if !ok {
+			if exact {
+				errs = append(errs, fmt.Errorf("section %s is not listed in the expected order", s.Name))
+			}
+			continue
+		}
+
+		if r < last {
+			errs = append(errs, fmt.Errorf("section %s must appear before section %s", s.Name, lastName))
+			continue
+		}
+		last, lastName = r, s.Name
+	}
+
+	if exact {
+		for _, name := range order {
+			if !slices.ContainsFunc(sections, func(s Section) bool { return s.Name == name }) {
+				errs = append(errs, fmt.Errorf("section %s is missing", name))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// LoadOrder reads an ordered list of section names, one per line. Blank
+// lines and lines starting with # are ignored.
+func LoadOrder(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var order []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		order = append(order, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return order, nil
+}
@@ -0,0 +1,64 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test CheckOrder function
+// /////////////////////////////////////////////////////////////////////////////
+func TestCheckOrder(t *testing.T) {
+	sections := []Section{{Name: "intro"}, {Name: "install"}, {Name: "extra"}, {Name: "usage"}}
+
+	tests := []struct {
+		name      string
+		order     []string
+		exact     bool
+		wantError string
+	}{
+		{name: "Matching order", order: []string{"intro", "install", "usage"}},
+		{name: "Wrong order", order: []string{"install", "intro", "usage"}, wantError: "section install must appear before section intro"},
+		{name: "Exact with unlisted section", order: []string{"intro", "install", "usage"}, exact: true, wantError: "section extra is not listed"},
+		{name: "Exact with missing section", order: []string{"intro", "install", "extra", "usage", "faq"}, exact: true, wantError: "section faq is missing"},
+		{name: "Exact match", order: []string{"intro", "install", "extra", "usage"}, exact: true},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckOrder(sections, tt.order, tt.exact)
+			if tt.wantError == "" {
+				if err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+				t.Errorf("Expected error containing %q, got %v", tt.wantError, err)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test LoadOrder function
+// /////////////////////////////////////////////////////////////////////////////
+func TestLoadOrder(t *testing.T) {
+	orderFile := filepath.Join(t.TempDir(), "order.txt")
+	err := os.WriteFile(orderFile, []byte("# document layout\nintro\n\n  install\nusage\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	order, err := LoadOrder(orderFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if strings.Join(order, ",") != "intro,install,usage" {
+		t.Errorf("Expected intro,install,usage, got %v", order)
+	}
+}
@@ -0,0 +1,73 @@
+package gosect
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// reVar matches a {{NAME}} placeholder, capturing the variable name
+var reVar = regexp.MustCompile(`\{\{\s*([A-Za-z_][A-Za-z0-9_]*)\s*\}\}`)
+
+// reVarName matches a variable name
+var reVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// substituteVars replaces the {{NAME}} placeholders of src with the values of
+// opts.Vars. Placeholders of undefined variables are left unchanged, as are
+// the sources of sections with vars=false.
+func (opts Options) substituteVars(s Section, src []byte) []byte {
+	if len(opts.Vars) == 0 || s.Attrs["vars"] == "false" {
+		return src
+	}
+
+	return reVar.ReplaceAllFunc(src, func(placeholder []byte) []byte {
+		name := reVar.FindSubmatch(placeholder)[1]
+		if value, ok := opts.Vars[string(name)]; ok {
+			return []byte(value)
+		}
+		return placeholder
+	})
+}
+
+// ParseVar parses a NAME=value variable assignment
+func ParseVar(assignment string) (string, string, error) {
+	name, value, ok := strings.Cut(assignment, "=")
+	name = strings.TrimSpace(name)
+	if !ok || !reVarName.MatchString(name) {
+		return "", "", fmt.Errorf("invalid variable %q (expected NAME=value)", assignment)
+	}
+
+	return name, value, nil
+}
+
+// LoadVars reads a file of NAME=value lines. Blank lines and lines starting
+// with # are ignored.
+func LoadVars(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	vars := map[string]string{}
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		name, value, err := ParseVar(line)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, n, err)
+		}
+		vars[name] = strings.TrimSpace(value)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return vars, nil
+}
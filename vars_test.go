@@ -0,0 +1,63 @@
+package gosect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test {{NAME}} variable substitution
+// /////////////////////////////////////////////////////////////////////////////
+func TestSubstituteVars(t *testing.T) {
+	opts := Options{Vars: map[string]string{"PROJECT": "gosect", "VERSION": "1.2.0"}}
+
+	tests := []struct {
+		name  string
+		attrs map[string]string
+		src   string
+		want  string
+	}{
+		{"Placeholders", nil, "go install {{PROJECT}}@v{{ VERSION }}", "go install gosect@v1.2.0"},
+		{"Undefined variable", nil, "{{PROJECT}} {{OWNER}} {{ .Values.x }}", "gosect {{OWNER}} {{ .Values.x }}"},
+		{"Disabled", map[string]string{"vars": "false"}, "{{PROJECT}}", "{{PROJECT}}"},
+	}
+
+	// Run tests
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := opts.substituteVars(Section{Name: "s", Attrs: tt.attrs}, []byte(tt.src))
+			if string(got) != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test reading variables files
+// /////////////////////////////////////////////////////////////////////////////
+func TestLoadVars(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "vars.env")
+	if err := os.WriteFile(path, []byte("# release\nVERSION = 1.2.0\n\nURL=https://example.com/?a=b\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	vars, err := LoadVars(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vars["VERSION"] != "1.2.0" || vars["URL"] != "https://example.com/?a=b" || len(vars) != 2 {
+		t.Errorf("Unexpected variables %v", vars)
+	}
+
+	if err := os.WriteFile(path, []byte("VERSION\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadVars(path); err == nil {
+		t.Error("Expected an error for a line without =")
+	}
+	if _, _, err := ParseVar("1X=a"); err == nil {
+		t.Error("Expected an error for an invalid name")
+	}
+}
@@ -0,0 +1,151 @@
+package gosect
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// Default vendor directory and the name of its lockfile
+const (
+	DefaultVendorDir = ".gosect/vendor"
+	VendorLockFile   = "gosect.lock"
+)
+
+// VendorLock records the vendored copies of url= sources, indexed by URL
+type VendorLock struct {
+	Sources map[string]VendorEntry `json:"sources"`
+}
+
+// VendorEntry is a vendored url= source: its file in the vendor directory,
+// media type and SHA-256 checksum
+type VendorEntry struct {
+	File      string `json:"file"`
+	MediaType string `json:"mediaType"`
+	SHA256    string `json:"sha256"`
+}
+
+// sha256Hex returns the hex encoded SHA-256 checksum of data
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// vendorFile returns the name of the vendored copy of rawURL in the vendor
+// directory
+func vendorFile(rawURL string) string {
+	ext := ""
+	if u, err := url.Parse(rawURL); err == nil {
+		ext = path.Ext(u.Path)
+	}
+
+	return sha256Hex([]byte(rawURL))[:16] + ext
+}
+
+// LoadVendorLock reads the lockfile of the vendor directory dir. A missing
+// lockfile yields an empty lock.
+func LoadVendorLock(dir string) (*VendorLock, error) {
+	lock := &VendorLock{Sources: map[string]VendorEntry{}}
+
+	data, err := os.ReadFile(filepath.Join(dir, VendorLockFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return lock, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, lock); err != nil {
+		return nil, fmt.Errorf("%s: %w", filepath.Join(dir, VendorLockFile), err)
+	}
+	if lock.Sources == nil {
+		lock.Sources = map[string]VendorEntry{}
+	}
+
+	return lock, nil
+}
+
+// Save writes the lockfile to the vendor directory dir
+func (lock *VendorLock) Save(dir string) error {
+	data, err := json.MarshalIndent(lock, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, VendorLockFile), append(data, '\n'), 0644)
+}
+
+// Vendor downloads the url= sources of sections into the vendor directory
+// dir and records their checksums in its lockfile. Sources already vendored
+// are refreshed.
+func Vendor(sections []Section, dir string, opts Options) (*VendorLock, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	lock, err := LoadVendorLock(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range sections {
+		url, ok := s.Attrs["url"]
+		if !ok {
+			continue
+		}
+
+		body, mediaType, err := opts.download(url)
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", s.Name, err)
+		}
+
+		sum := sha256Hex(body)
+		file := vendorFile(url)
+		if err := os.WriteFile(filepath.Join(dir, file), body, 0644); err != nil {
+			return nil, err
+		}
+		lock.Sources[url] = VendorEntry{File: file, MediaType: mediaType, SHA256: sum}
+	}
+
+	if err := lock.Save(dir); err != nil {
+		return nil, err
+	}
+
+	return lock, nil
+}
+
+// readVendored returns the vendored copy of url from the vendor directory dir,
+// if any, after checking it against the lockfile checksum
+func readVendored(dir, url string) ([]byte, string, bool, error) {
+	if dir == "" {
+		return nil, "", false, nil
+	}
+
+	lock, err := LoadVendorLock(dir)
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	entry, ok := lock.Sources[url]
+	if !ok {
+		return nil, "", false, nil
+	}
+
+	body, err := os.ReadFile(filepath.Join(dir, entry.File))
+	if err != nil {
+		return nil, "", false, err
+	}
+
+	if sum := sha256Hex(body); sum != entry.SHA256 {
+		return nil, "", false, fmt.Errorf("vendored copy of %s does not match its lockfile checksum (expected %s, got %s)", url, entry.SHA256, sum)
+	}
+
+	return body, entry.MediaType, true, nil
+}
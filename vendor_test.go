@@ -0,0 +1,52 @@
+package gosect
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test vendoring url= sources and reading vendored copies
+// /////////////////////////////////////////////////////////////////////////////
+func TestVendor(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.Write([]byte("remote content\n"))
+	}))
+
+	dir := filepath.Join(t.TempDir(), "vendor")
+	url := server.URL + "/notes.txt?raw=1"
+	content := "<!-- BEGIN SECTION notes url=" + url + " -->\n<!-- END SECTION notes -->\n"
+	sections, err := FindSections(content, reBegin, reEnd)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lock, err := Vendor(sections, dir, Options{HTTPClient: server.Client()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry, ok := lock.Sources[url]
+	if !ok || !strings.HasSuffix(entry.File, ".txt") || entry.MediaType != "text/plain" {
+		t.Fatalf("Unexpected lock entry: %+v", lock.Sources)
+	}
+
+	// vendored copies are used without network access
+	server.Close()
+	result := replaceAll(t, content, Options{VendorDir: dir})
+	if !strings.Contains(result, "\nremote content\n") {
+		t.Errorf("Expected vendored content, got %q", result)
+	}
+
+	// tampered copies are rejected
+	if err := os.WriteFile(filepath.Join(dir, entry.File), []byte("tampered\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Replace([]byte(content), sections, Options{VendorDir: dir}); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("Expected checksum error, got %v", err)
+	}
+}
@@ -0,0 +1,216 @@
+package gosect
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Ellipsis marks truncated lines
+const Ellipsis = "…"
+
+// wideRanges lists the East Asian Wide and Fullwidth code point ranges,
+// displayed on two terminal columns
+var wideRanges = [][2]rune{
+	{0x1100, 0x115F}, {0x231A, 0x231B}, {0x2329, 0x232A}, {0x23E9, 0x23EC},
+	{0x23F0, 0x23F0}, {0x23F3, 0x23F3}, {0x25FD, 0x25FE}, {0x2614, 0x2615},
+	{0x2648, 0x2653}, {0x267F, 0x267F}, {0x2693, 0x2693}, {0x26A1, 0x26A1},
+	{0x26AA, 0x26AB}, {0x26BD, 0x26BE}, {0x26C4, 0x26C5}, {0x26CE, 0x26CE},
+	{0x26D4, 0x26D4}, {0x26EA, 0x26EA}, {0x26F2, 0x26F3}, {0x26F5, 0x26F5},
+	{0x26FA, 0x26FA}, {0x26FD, 0x26FD}, {0x2705, 0x2705}, {0x270A, 0x270B},
+	{0x2728, 0x2728}, {0x274C, 0x274C}, {0x274E, 0x274E}, {0x2753, 0x2755},
+	{0x2757, 0x2757}, {0x2795, 0x2797}, {0x27B0, 0x27B0}, {0x27BF, 0x27BF},
+	{0x2B1B, 0x2B1C}, {0x2B50, 0x2B50}, {0x2B55, 0x2B55}, {0x2E80, 0x303E},
+	{0x3041, 0x33FF}, {0x3400, 0x4DBF}, {0x4E00, 0x9FFF}, {0xA000, 0xA4CF},
+	{0xA960, 0xA97F}, {0xAC00, 0xD7A3}, {0xF900, 0xFAFF}, {0xFE10, 0xFE19},
+	{0xFE30, 0xFE6F}, {0xFF00, 0xFF60}, {0xFFE0, 0xFFE6}, {0x16FE0, 0x16FE4},
+	{0x17000, 0x18CFF}, {0x1B000, 0x1B2FF}, {0x1F004, 0x1F004}, {0x1F0CF, 0x1F0CF},
+	{0x1F18E, 0x1F18E}, {0x1F191, 0x1F19A}, {0x1F200, 0x1F202}, {0x1F210, 0x1F23B},
+	{0x1F240, 0x1F248}, {0x1F250, 0x1F251}, {0x1F260, 0x1F265}, {0x1F300, 0x1F64F},
+	{0x1F680, 0x1F6FF}, {0x1F7E0, 0x1F7EB}, {0x1F90C, 0x1F9FF}, {0x1FA70, 0x1FAFF},
+	{0x20000, 0x2FFFD}, {0x30000, 0x3FFFD},
+}
+
+// RuneWidth returns the number of terminal columns used to display r:
+// 0 for control and combining characters, 2 for wide East Asian characters
+// and emoji, 1 otherwise
+func RuneWidth(r rune) int {
+	switch {
+	case r == utf8.RuneError:
+		return 1
+	case r < 0x20 || (r >= 0x7F && r < 0xA0):
+		return 0
+	case r < 0x1100:
+		if unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) {
+			return 0
+		}
+		return 1
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Cf) || (r >= 0xFE00 && r <= 0xFE0F):
+		return 0
+	}
+
+	for _, rng := range wideRanges {
+		if r < rng[0] {
+			break
+		}
+		if r <= rng[1] {
+			return 2
+		}
+	}
+
+	return 1
+}
+
+// StringWidth returns the number of terminal columns used to display s
+func StringWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += RuneWidth(r)
+	}
+
+	return width
+}
+
+// truncateWidth cuts s to at most max columns, ending it with tail when it
+// is cut. Runes are never split.
+func truncateWidth(s string, max int, tail string) string {
+	if StringWidth(s) <= max {
+		return s
+	}
+
+	limit := max - StringWidth(tail)
+	width := 0
+	for i, r := range s {
+		w := RuneWidth(r)
+		if width+w > limit {
+			return s[:i] + tail
+		}
+		width += w
+	}
+
+	return s
+}
+
+// wrapWidth wraps s on word boundaries so that no line exceeds max columns.
+// Words wider than max, such as CJK runs without spaces, are split between
+// runes.
+func wrapWidth(s string, max int) []string {
+	var lines []string
+	var line strings.Builder
+	lineWidth := 0
+
+	flush := func() {
+		lines = append(lines, strings.TrimRight(line.String(), " "))
+		line.Reset()
+		lineWidth = 0
+	}
+
+	for _, word := range strings.Fields(s) {
+		wordWidth := StringWidth(word)
+		if lineWidth > 0 && lineWidth+1+wordWidth > max {
+			flush()
+		}
+		if lineWidth > 0 {
+			line.WriteByte(' ')
+			lineWidth++
+		}
+
+		for wordWidth > max-lineWidth {
+			// split an over-long word between runes
+			width := 0
+			cut := 0
+			for i, r := range word {
+				if width+RuneWidth(r) > max-lineWidth {
+					cut = i
+					break
+				}
+				width += RuneWidth(r)
+			}
+			if cut == 0 && lineWidth == 0 {
+				_, cut = utf8.DecodeRuneInString(word)
+			}
+			line.WriteString(word[:cut])
+			flush()
+			word = word[cut:]
+			wordWidth = StringWidth(word)
+		}
+
+		line.WriteString(word)
+		lineWidth += wordWidth
+	}
+
+	if lineWidth > 0 || len(lines) == 0 {
+		flush()
+	}
+
+	return lines
+}
+
+// applyWidth applies the truncate= and wrap= attributes of s to every line
+// of src
+func applyWidth(s Section, src []byte) ([]byte, error) {
+	truncate, err := widthAttr(s, "truncate")
+	if err != nil {
+		return nil, err
+	}
+	wrap, err := widthAttr(s, "wrap")
+	if err != nil {
+		return nil, err
+	}
+	if truncate == 0 && wrap == 0 {
+		return src, nil
+	}
+
+	var out bytes.Buffer
+	for i, line := range strings.Split(string(src), "\n") {
+		if i > 0 {
+			out.WriteByte('\n')
+		}
+
+		eol := "\n"
+		if strings.HasSuffix(line, "\r") {
+			eol = "\r\n"
+		}
+		line = strings.TrimSuffix(line, "\r")
+
+		// truncate each line produced by wrap=
+		lines := []string{line}
+		if wrap > 0 && StringWidth(line) > wrap {
+			indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			lines = wrapWidth(line, max(wrap-StringWidth(indent), 1))
+			for j := range lines {
+				lines[j] = indent + lines[j]
+			}
+		}
+		if truncate > 0 {
+			for j := range lines {
+				lines[j] = truncateWidth(lines[j], truncate, Ellipsis)
+			}
+		}
+
+		out.WriteString(strings.Join(lines, eol))
+		if eol == "\r\n" {
+			out.WriteByte('\r')
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+// widthAttr parses a column count attribute, 0 when absent
+func widthAttr(s Section, key string) (int, error) {
+	value, ok := s.Attrs[key]
+	if !ok {
+		return 0, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("section %s has invalid %s=%s", s.Name, key, value)
+	}
+
+	return n, nil
+}
@@ -0,0 +1,131 @@
+package gosect
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test StringWidth function
+// /////////////////////////////////////////////////////////////////////////////
+func TestStringWidth(t *testing.T) {
+	tests := map[string]int{
+		"hello":     5,
+		"héllo":     5,
+		"é":        1, // combining acute accent
+		"日本語":       6,
+		"한국어":       6,
+		"ｆｕｌｌ":      8,
+		"🚀 go":      5,
+		"a‍b":       2, // zero width joiner
+		"tab\there": 7,
+	}
+
+	for s, want := range tests {
+		if got := StringWidth(s); got != want {
+			t.Errorf("StringWidth(%q): expected %d, got %d", s, want, got)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test truncateWidth function
+// /////////////////////////////////////////////////////////////////////////////
+func TestTruncateWidth(t *testing.T) {
+	tests := []struct {
+		input string
+		max   int
+		want  string
+	}{
+		{input: "short", max: 10, want: "short"},
+		{input: "hello world", max: 8, want: "hello w…"},
+		{input: "日本語のテキスト", max: 7, want: "日本語…"},
+		{input: "日本語のテキスト", max: 8, want: "日本語…"},
+		{input: "| 名前 | 説明 |", max: 9, want: "| 名前 |…"},
+	}
+
+	for _, tt := range tests {
+		got := truncateWidth(tt.input, tt.max, Ellipsis)
+		if got != tt.want {
+			t.Errorf("truncateWidth(%q, %d): expected %q, got %q", tt.input, tt.max, tt.want, got)
+		}
+		if !utf8.ValidString(got) {
+			t.Errorf("truncateWidth(%q, %d) produced invalid UTF-8", tt.input, tt.max)
+		}
+		if StringWidth(got) > tt.max {
+			t.Errorf("truncateWidth(%q, %d) is %d columns wide", tt.input, tt.max, StringWidth(got))
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test wrapWidth function
+// /////////////////////////////////////////////////////////////////////////////
+func TestWrapWidth(t *testing.T) {
+	tests := []struct {
+		input string
+		max   int
+		want  []string
+	}{
+		{input: "the quick brown fox jumps", max: 10, want: []string{"the quick", "brown fox", "jumps"}},
+		{input: "日本語のテキストです", max: 6, want: []string{"日本語", "のテキ", "ストで", "す"}},
+		{input: "abcdefghij", max: 4, want: []string{"abcd", "efgh", "ij"}},
+	}
+
+	for _, tt := range tests {
+		got := wrapWidth(tt.input, tt.max)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("wrapWidth(%q, %d): expected %q, got %q", tt.input, tt.max, tt.want, got)
+		}
+	}
+}
+
+// /////////////////////////////////////////////////////////////////////////////
+// Test truncate= and wrap= attributes
+// /////////////////////////////////////////////////////////////////////////////
+func TestApplyWidth(t *testing.T) {
+	src := []byte("first line is long\n  indented words here\n日本語のテキスト")
+
+	got, err := applyWidth(Section{Attrs: map[string]string{"truncate": "10"}}, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "first lin…\n  indente…\n日本語の…"
+	if string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	got, err = applyWidth(Section{Attrs: map[string]string{"wrap": "12"}}, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "first line\nis long\n  indented\n  words here\n日本語のテキ\nスト"
+	if string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// every wrapped line is truncated
+	got, err = applyWidth(Section{Attrs: map[string]string{"wrap": "12", "truncate": "8"}}, src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "first l…\nis long\n  inden…\n  words…\n日本語…\nスト"
+	if string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// wrapped CRLF lines keep their line ending
+	got, err = applyWidth(Section{Attrs: map[string]string{"wrap": "3"}}, []byte("a b c\r\nd\r\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "a b\r\nc\r\nd\r\n"
+	if string(got) != want {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	if _, err := applyWidth(Section{Name: "s", Attrs: map[string]string{"wrap": "0"}}, src); err == nil {
+		t.Error("Expected error for wrap=0")
+	}
+}